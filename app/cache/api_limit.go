@@ -0,0 +1,105 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : api_limit
+ * author : jarryliu
+ * date : 2026-10-19 11:30
+ * description : 商户接口限流及调用统计
+ * history :
+ */
+package cache
+
+import (
+	"fmt"
+	"github.com/garyburd/redigo/redis"
+	"github.com/jsix/gof/storage"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/infrastructure/ratelimit"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// 统计保留的天数,每日统计由守护进程写入数据库
+const apiUsageExpires = 3600 * 24 * 7
+
+func getRedisConn() redis.Conn {
+	return GetKVS().(storage.IRedisStorage).GetConn()
+}
+
+func apiUsageKey(date int) string {
+	return fmt.Sprintf("go2o:mch:api:usage:%d", date)
+}
+
+// 取出接口令牌,按接口编号及接口类别分别限流
+func TakeApiToken(a *merchant.ApiInfo, class string) *ratelimit.Result {
+	conn := getRedisConn()
+	defer conn.Close()
+	key := fmt.Sprintf("go2o:mch:api:rate:%s:%s", a.ApiId, class)
+	r, err := ratelimit.Take(conn, key, a.RateLimit(class))
+	if err != nil {
+		// 存储不可用时不限流
+		log.Println("[ Go2o][ API][ Limit]: take token failed:", err.Error())
+	}
+	return r
+}
+
+// 记录接口调用次数,date格式如:20261019
+func AddApiUsage(mchId int32, class string, date int, limited bool) {
+	conn := getRedisConn()
+	defer conn.Close()
+	key := apiUsageKey(date)
+	mchKey := fmt.Sprintf("%s:%d", key, mchId)
+	conn.Send("MULTI")
+	conn.Send("SADD", key, mchId)
+	conn.Send("HINCRBY", mchKey, class, 1)
+	if limited {
+		conn.Send("HINCRBY", mchKey, class+":limited", 1)
+	}
+	conn.Send("EXPIRE", key, apiUsageExpires)
+	conn.Send("EXPIRE", mchKey, apiUsageExpires)
+	if _, err := conn.Do("EXEC"); err != nil {
+		log.Println("[ Go2o][ API][ Usage]: add usage failed:", err.Error())
+	}
+}
+
+// 获取有调用记录的商户
+func GetApiUsageMerchants(date int) []int32 {
+	conn := getRedisConn()
+	defer conn.Close()
+	arr, _ := redis.Ints(conn.Do("SMEMBERS", apiUsageKey(date)))
+	list := make([]int32, len(arr))
+	for i, v := range arr {
+		list[i] = int32(v)
+	}
+	return list
+}
+
+// 获取商户某日的接口调用统计
+func GetApiUsages(mchId int32, date int) []*merchant.ApiUsage {
+	conn := getRedisConn()
+	defer conn.Close()
+	mp, _ := redis.StringMap(conn.Do("HGETALL",
+		fmt.Sprintf("%s:%d", apiUsageKey(date), mchId)))
+	usages := map[string]*merchant.ApiUsage{}
+	for k, v := range mp {
+		class := strings.TrimSuffix(k, ":limited")
+		u, ok := usages[class]
+		if !ok {
+			u = &merchant.ApiUsage{MchId: mchId, StatDate: date, ApiClass: class}
+			usages[class] = u
+		}
+		n, _ := strconv.ParseInt(v, 10, 64)
+		if class == k {
+			u.Requests = n
+		} else {
+			u.Limited = n
+		}
+	}
+	list := []*merchant.ApiUsage{}
+	for _, class := range []string{merchant.ApiClassRead, merchant.ApiClassWrite} {
+		if u, ok := usages[class]; ok {
+			list = append(list, u)
+		}
+	}
+	return list
+}
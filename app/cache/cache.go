@@ -12,6 +12,7 @@ import (
 	"errors"
 	"github.com/jsix/gof/log"
 	"github.com/jsix/gof/storage"
+	icache "go2o/core/infrastructure/cache"
 )
 
 /** this package is manage system cache. **/
@@ -19,6 +20,10 @@ import (
 var (
 	DefaultMaxSeconds int64 = 300 //默认存储300秒
 	kvCacheStorage    storage.Interface
+	// 与仓储共用名称的缓存,仓储中的实体变更后广播失效
+	mchCache   *icache.Cache
+	shopCache  *icache.Cache
+	levelCache *icache.Cache
 )
 
 // Get Key-value storage
@@ -41,6 +46,9 @@ func PrefixDel(prefix string) {
 func Initialize(kvStorage storage.Interface) {
 	if kvStorage.Driver() == storage.DriveRedisStorage {
 		kvCacheStorage = kvStorage
+		mchCache = icache.New("mch", kvStorage, DefaultMaxSeconds, icache.DefaultLocalSize)
+		shopCache = icache.New("shop", kvStorage, DefaultMaxSeconds, icache.DefaultLocalSize)
+		levelCache = icache.New("level", kvStorage, DefaultMaxSeconds, 0)
 	} else {
 		panic(errors.New("only support redis storage now."))
 	}
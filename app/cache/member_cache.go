@@ -9,32 +9,30 @@
 package cache
 
 import (
+	"database/sql"
 	"encoding/json"
 	"go2o/core/domain/interface/member"
 	"go2o/core/service/rsi"
 	"strconv"
 )
 
-// 获取最高等级
+// 获取最高等级,等级变更后失效
 func GetHighestLevel() *member.Level {
-	key := "go2o:rep:level:glob:max"
-	sto := GetKVS()
 	lv := member.Level{}
-	if sto.Get(key, &lv) != nil {
+	levelCache.Get("glob:max", &lv, func() error {
 		lv = rsi.MemberService.GetHighestLevel()
-		if lv.ID > 0 {
-			sto.SetExpire(key, lv, DefaultMaxSeconds)
+		if lv.ID <= 0 {
+			return sql.ErrNoRows
 		}
-	}
+		return nil
+	})
 	return &lv
 }
 
-// 获取等级JSON
+// 获取等级JSON,等级变更后失效
 func GetLevelMapJson() string {
-	key := "go2o:rep:level:mp-json"
-	sto := GetKVS()
-	str, err := sto.GetString(key)
-	if err != nil {
+	str := ""
+	levelCache.Get("mp-json", &str, func() error {
 		list := rsi.MemberService.GetMemberLevels()
 		mp := make(map[string]string, 0)
 		for _, v := range list {
@@ -42,9 +40,9 @@ func GetLevelMapJson() string {
 				mp[strconv.Itoa(int(v.ID))] = v.Name
 			}
 		}
-		data, _ := json.Marshal(mp)
+		data, err := json.Marshal(mp)
 		str = string(data)
-		sto.SetExpire(key, str, DefaultMaxSeconds)
-	}
+		return err
+	})
 	return str
 }
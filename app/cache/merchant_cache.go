@@ -10,46 +10,35 @@ package cache
 
 import (
 	"bytes"
+	"database/sql"
 	"fmt"
 	"github.com/jsix/gof/storage"
 	"go2o/core/domain/interface/express"
 	"go2o/core/domain/interface/merchant"
+	"go2o/core/infrastructure/domain"
 	"go2o/core/service/rsi"
 	"sort"
 	"strconv"
 	"strings"
 )
 
-// 获取商户信息缓存
+// 获取商户信息缓存,商户保存后失效
 func GetValueMerchantCache(mchId int32) *merchant.Merchant {
-	var v merchant.Merchant
-	var sto storage.Interface = GetKVS()
-	var key string = GetValueMerchantCacheCK(mchId)
-	if sto.Get(key, &v) != nil {
-		v2 := rsi.MerchantService.GetMerchant(mchId)
-		if v2 != nil {
-			sto.SetExpire(key, *v2, DefaultMaxSeconds)
-			return v2
+	v := &merchant.Merchant{}
+	mchCache.Get(fmt.Sprintf("%d:value", mchId), v, func() error {
+		if v2 := rsi.MerchantService.GetMerchant(mchId); v2 != nil {
+			*v = *v2
+			return nil
 		}
-	}
-	return &v
-
-}
-
-// 设置商户信息缓存
-func GetValueMerchantCacheCK(mchId int32) string {
-	return fmt.Sprintf("cache:partner:value:%d", mchId)
-}
-
-// 设置商户站点配置
-func GetMerchantSiteConfCK(mchId int32) string {
-	return fmt.Sprintf("cache:partner:siteconf:%d", mchId)
+		return sql.ErrNoRows
+	})
+	return v
 }
 
+// 清除商户的缓存
 func DelMerchantCache(mchId int32) {
-	kvs := GetKVS()
-	kvs.Del(GetValueMerchantCacheCK(mchId))
-	kvs.Del(GetMerchantSiteConfCK(mchId))
+	mchCache.Invalidate(fmt.Sprint(mchId))
+	mchCache.InvalidatePrefix(fmt.Sprintf("%d:", mchId))
 }
 
 // 根据主机头识别会员编号
@@ -82,18 +71,22 @@ func GetMerchantIdByApiId(apiId string) int32 {
 	return mchId
 }
 
-// 获取API 信息
+// 获取API 信息,由仓储缓存,密钥轮换或撤销后各实例立即失效
 func GetMerchantApiInfo(mchId int32) *merchant.ApiInfo {
-	var d *merchant.ApiInfo = new(merchant.ApiInfo)
-	kvs := GetKVS()
-	key := fmt.Sprintf("cache:partner:api:info-%d", mchId)
-	err := kvs.Get(key, &d)
-	if err != nil {
-		if d = rsi.MerchantService.GetApiInfo(mchId); d != nil {
-			kvs.Set(key, d)
-		}
+	return rsi.MerchantService.GetApiInfo(mchId)
+}
+
+// 检查接口请求的随机串是否未使用过,并记录到签名有效期结束,用于防止重放
+func CheckApiNonce(apiId string, nonce string) bool {
+	if len(nonce) < 8 || len(nonce) > 64 {
+		return false
 	}
-	return d
+	conn := GetKVS().(storage.IRedisStorage).GetConn()
+	defer conn.Close()
+	key := fmt.Sprintf("cache:partner:api:nonce-%s-%s", apiId, nonce)
+	// 使用SET NX保证并发请求时只有一个成功
+	r, err := conn.Do("SET", key, 1, "EX", domain.ApiSignMaxSkew*2, "NX")
+	return err == nil && r != nil
 }
 
 var (
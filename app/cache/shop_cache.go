@@ -15,24 +15,23 @@ import (
 	"go2o/core/service/rsi"
 )
 
-// 设置商户站点配置
-func GetShopDataKey(shopId int32) string {
-	return fmt.Sprintf("go2o:cache:online-shop:siteconf:%d", shopId)
+// 获取商店的数据到dst,不存在时调用load加载,商店保存后失效
+func GetShopData(shopId int32, dst interface{}, load func() error) error {
+	return shopCache.Get(fmt.Sprintf("%d:data", shopId), dst, load)
 }
 
 // 清除在线商店缓存
 func CleanShopData(shopId int32) {
 	if shopId > 0 {
-		key := GetShopDataKey(shopId)
-		GetKVS().Del(key)
+		shopCache.Invalidate(fmt.Sprint(shopId))
+		shopCache.InvalidatePrefix(fmt.Sprintf("%d:", shopId))
 	}
 }
 
-// 删除商铺缓存
+// 删除商户的商铺缓存
 func DelShopCache(mchId int32) {
-	kvs := GetKVS()
-	kvs.Del(GetValueMerchantCacheCK(mchId))
-	kvs.Del(GetMerchantSiteConfCK(mchId))
+	shopCache.InvalidatePrefix(fmt.Sprintf("mch%d:", mchId))
+	DelMerchantCache(mchId)
 }
 
 // 根据主机头识别商店编号
@@ -0,0 +1,45 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : ws_ticket
+ * author : jarryliu
+ * date : 2026-10-19 16:40
+ * description : WebSocket连接凭据,由商户接口获取后交给浏览器使用
+ * history :
+ */
+package cache
+
+import (
+	"github.com/garyburd/redigo/redis"
+	"go2o/core/infrastructure/domain"
+)
+
+// 凭据有效时间(秒)
+const wsTicketExpires = 60
+
+// 创建商户的连接凭据
+func CreateWsTicket(mchId int32) (string, error) {
+	conn := getRedisConn()
+	defer conn.Close()
+	ticket := domain.NewApiSecret()
+	_, err := conn.Do("SET", "go2o:ws:ticket:"+ticket, mchId, "EX", wsTicketExpires)
+	return ticket, err
+}
+
+// 使用连接凭据,凭据只能使用一次。返回商户编号,无效时返回0
+func TakeWsTicket(ticket string) int32 {
+	if len(ticket) != 32 {
+		return 0
+	}
+	conn := getRedisConn()
+	defer conn.Close()
+	key := "go2o:ws:ticket:" + ticket
+	conn.Send("MULTI")
+	conn.Send("GET", key)
+	conn.Send("DEL", key)
+	values, err := redis.Values(conn.Do("EXEC"))
+	if err != nil || values[0] == nil {
+		return 0
+	}
+	mchId, _ := redis.Int(values[0], nil)
+	return int32(mchId)
+}
@@ -0,0 +1,44 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : after_sales
+ * author : jarryliu
+ * date : 2026-10-18 15:50
+ * description :
+ * history :
+ */
+package daemon
+
+import (
+	"database/sql"
+	"go2o/core/domain/interface/after-sales"
+	"go2o/core/service/rsi"
+	"log"
+)
+
+// 处理超时的售后单:自动同意、自动取消、自动收货及自动申请调解
+func afterSalesTimeout() {
+	size := 50
+	var lastId int32
+	for {
+		idArr := []int32{}
+		appCtx.Db().Query(`SELECT id FROM sale_after_order WHERE
+			id>? AND state IN(?,?,?,?) ORDER BY id LIMIT ?`,
+			func(rs *sql.Rows) {
+				var id int32
+				for rs.Next() {
+					rs.Scan(&id)
+					idArr = append(idArr, id)
+				}
+			}, lastId, afterSales.StatAwaitingVendor, afterSales.StatDeclined,
+			afterSales.StatAwaitingReturnShip, afterSales.StatReturnShipped, size)
+		for _, id := range idArr {
+			if _, err := rsi.AfterSalesService.HandleTimeout(id); err != nil {
+				log.Println("[ AfterSales][ Timeout][ Error]:", id, err.Error())
+			}
+			lastId = id
+		}
+		if len(idArr) < size {
+			break
+		}
+	}
+}
@@ -0,0 +1,30 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : api_usage
+ * author : jarryliu
+ * date : 2026-10-19 11:50
+ * description :
+ * history :
+ */
+package daemon
+
+import (
+	"go2o/app/cache"
+	"go2o/core/service/rsi"
+	"log"
+	"strconv"
+	"time"
+)
+
+// 将前一日的商户接口调用统计写入数据库
+func apiUsageFlush() {
+	date, _ := strconv.Atoi(time.Now().AddDate(0, 0, -1).Format("20060102"))
+	for _, mchId := range cache.GetApiUsageMerchants(date) {
+		for _, v := range cache.GetApiUsages(mchId, date) {
+			if err := rsi.MerchantService.SaveApiUsage(mchId, v); err != nil {
+				log.Println("[ Go2o][ Daemon][ ApiUsage]: save usage failed:",
+					mchId, err.Error())
+			}
+		}
+	}
+}
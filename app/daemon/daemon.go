@@ -19,18 +19,28 @@ import (
 	"github.com/jsix/gof/util"
 	"github.com/robfig/cron"
 	"go2o/app"
+	"go2o/app/cache"
 	"go2o/core"
+	"go2o/core/domain/interface/events"
+	"go2o/core/domain/interface/merchant"
 	"go2o/core/domain/interface/mss"
 	"go2o/core/domain/interface/order"
 	"go2o/core/service/rsi"
 	"go2o/core/service/thrift/idl/gen-go/define"
 	"go2o/core/variable"
 	"log"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+// 售后单观察者,服务实现此接口后接收售后单状态变更
+type AfterSalesObserver interface {
+	// 处理售后单状态变更,返回布尔值,如果返回false,则不继续执行
+	AfterSalesObs(e *events.AfterSalesStateChanged) bool
+}
+
 // 守护进程执行的函数
 type Func func(gof.App)
 
@@ -94,6 +104,7 @@ func Start() {
 		cronTab.Stop()
 		ticker.Stop()
 	}()
+	registerJobs()
 	//运行自定义服务
 	for i, s := range services {
 		log.Println("** [ Go2o][ Daemon] - (", i, ")", s.Name(), "daemon running")
@@ -145,17 +156,29 @@ func SetLastUnix(key string, unix int64) {
 
 // 运行定时任务
 func startCronTab() {
-	//商户每日报表
-	cronTab.AddFunc("0 0 0 * * *", mchDayChart)
-	//个人金融结算,每天00:20更新数据
-	cronTab.AddFunc("0 20 0 * * *", personFinanceSettle)
-	//检查订单过期,1分钟检测一次
-	cronTab.AddFunc("0 * * * * *", detectOrderExpires)
-	//订单自动收货,2分钟检测一次
-	cronTab.AddFunc("0 */2 * * * *", orderAutoReceive)
+	go startJobTrigger()
 	cronTab.Start()
 }
 
+// 注册计划任务
+func registerJobs() {
+	if len(jobs) > 0 {
+		return
+	}
+	AddJob("mch_day_chart", "0 0 0 * * *", "商户每日报表", mchDayChart)
+	AddFencedJob("person_finance_settle", "0 20 0 * * *", "个人金融结算,每天00:20更新数据", personFinanceSettle)
+	AddJob("member_level_evaluate", "0 40 0 * * *", "会员等级评定,每天00:40执行", memberLevelEvaluate)
+	AddJob("commission_release", "0 */10 * * * *", "发放分销佣金,10分钟检测一次", commissionRelease)
+	AddJob("after_sales_timeout", "0 */5 * * * *", "售后单超时处理,5分钟检测一次", afterSalesTimeout)
+	AddJob("webhook_deliver", "30 * * * * *", "发送商户通知,1分钟检测一次", webhookDeliver)
+	AddJob("api_usage_flush", "0 10 0 * * *", "保存前一日的接口调用统计,每日00:10执行", apiUsageFlush)
+	AddJob("detect_order_expires", "0 * * * * *", "检查订单过期,1分钟检测一次", detectOrderExpires)
+	AddJob("order_auto_confirm", "30 * * * * *", "订单自动确认,1分钟检测一次", orderAutoConfirm)
+	AddJob("order_auto_receive", "0 */2 * * * *", "订单自动收货,2分钟检测一次", orderAutoReceive)
+	AddJob("event_outbox_purge", "0 30 3 * * *", "清除已发布的事件,每日03:30执行", outboxPurge)
+	AddJob("idempotent_key_purge", "0 40 3 * * *", "清除过期的幂等键,每日03:40执行", idempotentKeyPurge)
+}
+
 // 添加定时任务
 func AddCron(spec string, cmd func()) {
 	mux.Lock()
@@ -190,9 +213,11 @@ func (d *defaultService) Start(a gof.App) {
 	go superviseMemberUpdate(services)
 	go superviseOrder(services)
 	go supervisePaymentOrderFinish(services)
+	go superviseAfterSales(services)
+	go startOutboxRelay()
 	go startMailQueue(services)
-	go personFinanceSettle() //启动时结算
-	go mchDayChart()         //商户每日报表
+	go runJob(jobIndex["person_finance_settle"], TriggerStartup) //启动时结算
+	go runJob(jobIndex["mch_day_chart"], TriggerStartup)         //商户每日报表
 
 	//go func() {
 	//    time.Sleep(time.Second * 6)
@@ -209,10 +234,11 @@ func (d *defaultService) OrderObs(o *define.ComplexOrder) bool {
 	if d.app.Debug() {
 		d.app.Log().Println("-- 订单", o.OrderNo, "状态:", o.State)
 	}
+	publishOrderWebhook(o)
+	publishOrderPush(o)
 	if d.sOrder {
 		conn := core.GetRedisConn()
 		defer conn.Close()
-		defer Recover()
 
 		switch o.State {
 		//订单未支付，则超时自动取消
@@ -221,6 +247,9 @@ func (d *defaultService) OrderObs(o *define.ComplexOrder) bool {
 		//自动确认订单
 		case order.StatAwaitingConfirm:
 			d.orderAutoConfirm(conn, o)
+		//已确认或已取消,取消自动确认
+		case order.StatAwaitingPickup, order.StatCancelled:
+			d.cancelOrderConfirm(conn, o)
 		//订单自动收货
 		case order.StatShipped:
 			d.orderAutoReceive(conn, o)
@@ -235,7 +264,7 @@ func (d *defaultService) OrderObs(o *define.ComplexOrder) bool {
 // 监视会员修改,@create:是否为新注册会员
 // 返回布尔值,如果返回false,则不继续执行
 func (d *defaultService) MemberObs(m *define.Member, create bool) bool {
-	defer Recover()
+	publishMemberEvent(m, create)
 	if d.sMember {
 		//todo: 执行会员逻辑
 	}
@@ -250,6 +279,8 @@ func (d *defaultService) PaymentOrderObs(order *define.PaymentOrder) bool {
 	if d.app.Debug() {
 		d.app.Log().Println("---支付单", order.TradeNo, "支付完成")
 	}
+	publishPaymentWebhook(order)
+	publishPaymentEvent(order)
 	return true
 }
 
@@ -275,8 +306,8 @@ func (d *defaultService) batchDelKeys(conn redis.Conn, key string) {
 func (d *defaultService) updateOrderExpires(conn redis.Conn, o *define.ComplexOrder) {
 	//订单刚创建时,设置过期时间
 	if o.State == order.StatAwaitingPayment {
-		ss := rsi.FoundationService.GetGlobMchSaleConf()
-		unix := o.UpdateTime + int64(ss.OrderTimeOutMinute)*60
+		ot := d.getOrderTimeout(o)
+		unix := o.UpdateTime + int64(ot.TimeoutMinute)*60
 		t := time.Unix(unix, 0)
 		tk := getTick(t)
 		orderNo, sub := d.testSubId(o)
@@ -295,21 +326,62 @@ func (d *defaultService) cancelOrderExpires(conn redis.Conn, o *define.ComplexOr
 	d.batchDelKeys(conn, key)
 }
 
-// 确认订单
+// 获取订单的超时设置,未设置商户时使用全局设置
+func (d *defaultService) getOrderTimeout(o *define.ComplexOrder) merchant.OrderTimeout {
+	if o.VendorId > 0 {
+		if v := rsi.MerchantService.GetOrderTimeout(o.VendorId, o.OrderType); v != nil {
+			return *v
+		}
+	}
+	ss := rsi.FoundationService.GetGlobMchSaleConf()
+	return merchant.OrderTimeout{
+		OrderType:         o.OrderType,
+		TimeoutMinute:     ss.OrderTimeOutMinute,
+		ConfirmMinute:     ss.OrderConfirmAfterMinute,
+		ReceiveHour:       ss.OrderTimeOutReceiveHour,
+		ReceiveExtendHour: ss.OrderReceiveExtendHour,
+	}
+}
+
+// 确认订单,自动确认时间为0时立即确认,否则到时间后自动确认
 func (d *defaultService) orderAutoConfirm(conn redis.Conn, o *define.ComplexOrder) {
-	rsi.ShoppingService.ConfirmOrder(d.testSubId(o))
 	d.cancelOrderExpires(conn, o) //付款后取消自动取消
+	ot := d.getOrderTimeout(o)
+	if ot.ConfirmMinute <= 0 {
+		rsi.ShoppingService.ConfirmOrder(d.testSubId(o))
+		return
+	}
+	unix := o.UpdateTime + int64(ot.ConfirmMinute)*60
+	tk := getTick(time.Unix(unix, 0))
+	orderNo, sub := d.testSubId(o)
+	prefix := util.BoolExt.TString(sub, "sub!", "")
+	key := fmt.Sprintf("%s:%s%s:%s", variable.KvOrderAutoConfirm, prefix, orderNo, tk)
+	conn.Do("SET", key, unix)
 }
 
-// 订单自动收货
+// 取消订单自动确认
+func (d *defaultService) cancelOrderConfirm(conn redis.Conn, o *define.ComplexOrder) {
+	orderNo, sub := d.testSubId(o)
+	prefix := util.BoolExt.TString(sub, "sub!", "")
+	key := fmt.Sprintf("%s:%s%s:*", variable.KvOrderAutoConfirm, prefix, orderNo)
+	d.batchDelKeys(conn, key)
+}
+
+// 订单自动收货,买家已延长收货时同时推迟
 func (d *defaultService) orderAutoReceive(conn redis.Conn, o *define.ComplexOrder) {
 	if o.State == order.StatShipped {
-		ss := rsi.FoundationService.GetGlobMchSaleConf()
-		unix := o.UpdateTime + int64(ss.OrderTimeOutReceiveHour)*60*60
-		t := time.Unix(unix, 0)
-		tk := getTick(t)
+		ot := d.getOrderTimeout(o)
 		orderNo, sub := d.testSubId(o)
 		prefix := util.BoolExt.TString(sub, "sub!", "")
+		hours := ot.ReceiveHour
+		// 延长事件先于发货处理时,在此推迟
+		extend, _ := strconv.Atoi(o.Data[order.ComplexReceiveExtend])
+		if extend > 0 && markReceiveExtended(conn, prefix, orderNo) {
+			hours += extend
+		}
+		unix := o.UpdateTime + int64(hours)*60*60
+		t := time.Unix(unix, 0)
+		tk := getTick(t)
 		key := fmt.Sprintf("%s:%s%s:%s", variable.KvOrderAutoReceive, prefix, orderNo, tk)
 		//log.Println(" [Daemon][AutoReceive][ Key]:", key)
 		conn.Do("SET", key, unix)
@@ -329,7 +401,6 @@ func (d *defaultService) orderReceived(conn redis.Conn, o *define.ComplexOrder)
 // 处理邮件队列
 // 返回布尔值,如果返回false,则不继续执行
 func (d *defaultService) HandleMailQueue(list []*mss.MailTask) bool {
-	defer Recover()
 	if !d.sMail {
 		handleMailQueue(list)
 	}
@@ -345,6 +416,8 @@ func Run(ctx gof.App) {
 	}
 	_db = appCtx.Db()
 	_orm = _db.GetOrm()
+	initJobs()
+	cache.Initialize(appCtx.Storage())
 	sMail := appCtx.Config().GetString(variable.SystemMailQueueOff) != "1" //是否关闭系统邮件队列
 	//sMail := cnf.GetString(variable.)
 
@@ -365,19 +438,51 @@ func FlagRun() {
 	var service string
 	var serviceArr []string = []string{"mail", "order"}
 	var ch chan bool = make(chan bool)
+	var jobList bool
+	var jobRun, jobPause, jobResume, jobRuns string
+	var eventDead bool
+	var eventReplay, eventDrop string
 	flag.StringVar(&conf, "conf", "app.conf", "")
 	flag.BoolVar(&debug, "debug", true, "")
 	flag.BoolVar(&trace, "trace", true, "")
 	flag.StringVar(&service, "service", strings.Join(serviceArr, ","), "")
+	flag.BoolVar(&jobList, "job-list", false, "list jobs")
+	flag.StringVar(&jobRun, "job-run", "", "trigger job by name")
+	flag.StringVar(&jobPause, "job-pause", "", "pause job by name")
+	flag.StringVar(&jobResume, "job-resume", "", "resume job by name")
+	flag.StringVar(&jobRuns, "job-runs", "", "show job run history")
+	flag.BoolVar(&eventDead, "event-dead", false, "list dead letter events")
+	flag.StringVar(&eventReplay, "event-replay", "", "replay dead letter event by id, 'all' replay all")
+	flag.StringVar(&eventDrop, "event-drop", "", "delete dead letter event by id")
 
 	flag.Parse()
 
 	appCtx = core.NewApp(conf)
-	core.Init(appCtx, debug, trace)
+	if !core.Init(appCtx, debug, trace) {
+		log.Fatalln("[ Go2o][ Daemon]: init failed")
+	}
 	gof.CurrentApp = appCtx
 
 	_db = appCtx.Db()
 	_orm = _db.GetOrm()
+	initJobs()
+	cache.Initialize(appCtx.Storage())
+
+	// 管理计划任务后退出
+	if jobList || jobRun != "" || jobPause != "" || jobResume != "" || jobRuns != "" {
+		registerJobs()
+		if err := jobCommand(jobList, jobRun, jobPause, jobResume, jobRuns); err != nil {
+			log.Fatalln("[ Go2o][ Job]:", err.Error())
+		}
+		return
+	}
+	// 管理死信事件后退出
+	if eventDead || eventReplay != "" || eventDrop != "" {
+		if err := deadEventCommand(eventDead, eventReplay, eventDrop); err != nil {
+			log.Fatalln("[ Go2o][ Event]:", err.Error())
+		}
+		return
+	}
 
 	rsi.Init(appCtx, app.FlagDaemon)
 
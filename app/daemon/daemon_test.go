@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"github.com/robfig/cron"
 	"go2o/core/infrastructure/tool"
 	"testing"
 	"time"
@@ -14,3 +15,28 @@ func TestGenerateMchDayChart(t *testing.T) {
 		generateMchDayChart(st, et)
 	}
 }
+
+// 测试任务执行时panic转为错误
+func TestInvokeJob(t *testing.T) {
+	j := &Job{Name: "test", fn: func(*JobFence) { panic("oops") }}
+	if err := invokeJob(j, nil); err == nil {
+		t.Error("panic should be returned as error")
+	}
+	j.fn = func(*JobFence) {}
+	if err := invokeJob(j, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// 测试启动时使用最近一次计划执行时间,与计划执行使用相同的执行时间
+func TestPrevJobTime(t *testing.T) {
+	sc, _ := cron.Parse("0 0 3 * * *")
+	j := &Job{Name: "test", schedule: sc}
+	at := time.Date(2026, 10, 20, 3, 0, 0, 0, time.Local)
+	if v := prevJobTime(j, at.Add(7*time.Hour)); !v.Equal(at) {
+		t.Error("最近一次执行时间不正确:", v)
+	}
+	if v := prevJobTime(j, at); !v.Equal(j.schedule.Next(at.Add(-10 * time.Second))) {
+		t.Error("与计划执行的时间不一致:", v)
+	}
+}
@@ -0,0 +1,49 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : distribution
+ * author : jarryliu
+ * date : 2026-10-18 11:50
+ * description :
+ * history :
+ */
+package daemon
+
+import (
+	"database/sql"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/service/rsi"
+	"log"
+	"time"
+)
+
+// 发放售后期已结束的分销佣金
+func commissionRelease() {
+	unix := time.Now().Unix()
+	size := 50
+	var lastId int64
+	for {
+		idArr := []int64{}
+		mchArr := []int32{}
+		appCtx.Db().Query(`SELECT id,mch_id FROM mch_commission WHERE
+			id>? AND state=? AND unfreeze_time<=? ORDER BY id LIMIT ?`,
+			func(rs *sql.Rows) {
+				var id int64
+				var mchId int32
+				for rs.Next() {
+					rs.Scan(&id, &mchId)
+					idArr = append(idArr, id)
+					mchArr = append(mchArr, mchId)
+				}
+			}, lastId, merchant.CommissionFrozen, unix, size)
+		for i, id := range idArr {
+			err := rsi.MerchantService.ReleaseCommission(mchArr[i], id)
+			if err != nil {
+				log.Println("[ Distribution][ Release][ Error]:", id, err.Error())
+			}
+			lastId = id
+		}
+		if len(idArr) < size {
+			break
+		}
+	}
+}
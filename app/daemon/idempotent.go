@@ -0,0 +1,22 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : idempotent.go
+ * author : jarryliu
+ * date : 2026-10-20 00:50
+ * description :
+ * history :
+ */
+package daemon
+
+import (
+	"go2o/core/infrastructure/idempotent"
+	"log"
+	"time"
+)
+
+// 清除过期的幂等键
+func idempotentKeyPurge() {
+	if _, err := idempotent.Purge(_db, time.Now().Unix()); err != nil {
+		log.Println("[ Go2o][ Idempotent][ Purge][ Error]:", err.Error())
+	}
+}
@@ -0,0 +1,407 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : job.go
+ * author : jarryliu
+ * date : 2026-10-19 17:50
+ * description : 计划任务调度,多个守护进程同时运行时,通过分布式锁保证
+ *   同一时刻只有一个实例执行任务,并记录任务的执行历史
+ * history :
+ */
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"github.com/garyburd/redigo/redis"
+	"github.com/jsix/gof/db/orm"
+	"github.com/robfig/cron"
+	"go2o/core"
+	"go2o/core/infrastructure/locker"
+	"log"
+	"os"
+	"time"
+)
+
+// 任务触发方式
+const (
+	// 计划执行
+	TriggerCron = "cron"
+	// 手动执行
+	TriggerManual = "manual"
+	// 启动时执行
+	TriggerStartup = "startup"
+)
+
+// 任务执行状态
+const (
+	// 执行中
+	JobRunning = 1
+	// 执行成功
+	JobSuccess = 2
+	// 执行失败
+	JobFailed = 3
+)
+
+const (
+	// 任务锁的有效时间
+	jobLockTTL = 2 * time.Minute
+	// 续期任务锁的间隔
+	jobHeartbeat = 30 * time.Second
+	// 已暂停的任务
+	jobPausedKey = "go2o:daemon:job:paused"
+	// 手动执行的任务队列
+	jobTriggerKey = "go2o:daemon:job:trigger"
+)
+
+var (
+	jobs     []*Job
+	jobIndex = map[string]*Job{}
+	// 当前实例标识
+	instance = jobInstance()
+
+	ErrNoSuchJob = errors.New("no such job")
+)
+
+// 计划任务
+type Job struct {
+	// 任务名称
+	Name string
+	// 执行计划
+	Spec string
+	// 任务说明
+	Desc     string
+	fn       func(fence *JobFence)
+	schedule cron.Schedule
+}
+
+// 任务锁令牌,任务在每批写入前检查,锁已被其他实例获得时停止写入
+type JobFence struct {
+	key   string
+	fence int64
+}
+
+// 令牌是否仍为最新,未加锁执行时返回true
+func (f *JobFence) Valid() bool {
+	if f == nil {
+		return true
+	}
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	return locker.CheckFence(conn, f.key, f.fence)
+}
+
+// 任务执行记录
+type JobRun struct {
+	// 编号
+	Id int64 `db:"id" pk:"yes" auto:"yes"`
+	// 任务名称
+	JobName string `db:"job_name"`
+	// 触发方式
+	TriggerBy string `db:"trigger_by"`
+	// 执行的实例
+	Instance string `db:"instance"`
+	// 锁令牌
+	Fence int64 `db:"fence"`
+	// 开始时间
+	StartTime int64 `db:"start_time"`
+	// 结束时间
+	EndTime int64 `db:"end_time"`
+	// 状态
+	State int `db:"state"`
+	// 错误信息
+	Error string `db:"error"`
+}
+
+// 任务信息
+type JobInfo struct {
+	Name   string
+	Spec   string
+	Desc   string
+	Paused bool
+	// 最后一次执行
+	LastRun *JobRun
+}
+
+func jobInstance() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// 初始化任务记录的映射
+func initJobs() {
+	_orm.Mapping(JobRun{}, "sys_job_run")
+}
+
+// 添加计划任务,多个实例中同一执行时间只会执行一次
+func AddJob(name string, spec string, desc string, f func()) error {
+	return AddFencedJob(name, spec, desc, func(*JobFence) { f() })
+}
+
+// 添加写入前检查锁令牌的计划任务,任务执行时间超过锁的有效时间,
+// 锁被其他实例获得后,通过令牌停止后续的写入
+func AddFencedJob(name string, spec string, desc string, f func(fence *JobFence)) error {
+	mux.Lock()
+	defer mux.Unlock()
+	if _, ok := jobIndex[name]; ok {
+		return errors.New("job named " + name + " is registed")
+	}
+	sc, err := cron.Parse(spec)
+	if err != nil {
+		return err
+	}
+	j := &Job{Name: name, Spec: spec, Desc: desc, fn: f, schedule: sc}
+	jobs = append(jobs, j)
+	jobIndex[name] = j
+	return cronTab.AddFunc(spec, func() {
+		runJob(j, TriggerCron)
+	})
+}
+
+// 执行任务,返回是否在当前实例执行
+func runJob(j *Job, trigger string) bool {
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	switch trigger {
+	case TriggerCron:
+		// 暂停的任务不按计划执行
+		if paused, _ := redis.Bool(conn.Do("HEXISTS", jobPausedKey, j.Name)); paused {
+			return false
+		}
+		if !claimJobSlot(conn, j, j.schedule.Next(time.Now().Add(-10*time.Second))) {
+			return false
+		}
+	case TriggerStartup:
+		// 多个实例同时启动时仅执行一次,最近一次计划已执行时不再执行
+		if !claimJobSlot(conn, j, prevJobTime(j, time.Now())) {
+			return false
+		}
+	}
+	lockKey := "go2o:daemon:job:lock:" + j.Name
+	l, err := locker.Acquire(conn, lockKey, jobLockTTL)
+	if err != nil {
+		log.Println("[ Go2o][ Job]: acquire lock failed", j.Name, err.Error())
+		return false
+	}
+	if l == nil {
+		return false
+	}
+	run := &JobRun{
+		JobName:   j.Name,
+		TriggerBy: trigger,
+		Instance:  instance,
+		Fence:     l.Fence,
+		StartTime: time.Now().Unix(),
+		State:     JobRunning,
+	}
+	saveJobRun(run)
+
+	stop := make(chan bool)
+	go jobHeartbeatLoop(j, l, stop)
+	err = invokeJob(j, &JobFence{key: lockKey, fence: l.Fence})
+	close(stop)
+
+	run.EndTime = time.Now().Unix()
+	run.State = JobSuccess
+	// 执行后再检查令牌,记录执行期间锁是否被其他实例获得。仅用于事后审计,
+	// 不能阻止已发生的写入,写入前的检查由任务通过JobFence完成
+	if err == nil && !locker.CheckFence(conn, lockKey, l.Fence) {
+		err = fmt.Errorf("lock lost, fence: %d", l.Fence)
+	}
+	if err != nil {
+		run.State = JobFailed
+		run.Error = err.Error()
+		if len(run.Error) > 512 {
+			run.Error = run.Error[:512]
+		}
+		log.Println("[ Go2o][ Job]: job", j.Name, "failed:", err.Error())
+	}
+	saveJobRun(run)
+	l.Release(conn)
+	return true
+}
+
+// 占用任务的执行时间,同一执行时间仅由一个实例执行,
+// 避免锁释放后其他实例重复执行
+func claimJobSlot(conn redis.Conn, j *Job, t time.Time) bool {
+	key := fmt.Sprintf("go2o:daemon:job:slot:%s:%d", j.Name, t.Unix())
+	r, _ := conn.Do("SET", key, instance, "NX", "EX", 86400)
+	return r != nil
+}
+
+// 获取不晚于t的最近一次计划执行时间,两天内没有时返回下一次执行时间
+func prevJobTime(j *Job, t time.Time) time.Time {
+	prev := time.Time{}
+	for next := j.schedule.Next(t.Add(-48 * time.Hour)); !next.After(t); next = j.schedule.Next(next) {
+		prev = next
+	}
+	if prev.IsZero() {
+		return j.schedule.Next(t)
+	}
+	return prev
+}
+
+// 执行任务,将panic转为错误
+func invokeJob(j *Job, f *JobFence) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	j.fn(f)
+	return nil
+}
+
+// 任务执行期间定时续期任务锁
+func jobHeartbeatLoop(j *Job, l *locker.Lock, stop chan bool) {
+	t := time.NewTicker(jobHeartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			conn := core.GetRedisConn()
+			ok, err := l.Refresh(conn)
+			conn.Close()
+			if err == nil && !ok {
+				log.Println("[ Go2o][ Job]: job", j.Name, "lost lock, fence:", l.Fence)
+			}
+		}
+	}
+}
+
+func saveJobRun(r *JobRun) {
+	if _orm == nil {
+		return
+	}
+	id, err := orm.Save(_orm, r, int(r.Id))
+	if err != nil {
+		log.Println("[ Go2o][ Job]: save job run failed", err.Error())
+		return
+	}
+	r.Id = int64(id)
+}
+
+// 消费手动执行的任务,任一实例获取到后执行
+func startJobTrigger() {
+	for {
+		conn := core.GetRedisConn()
+		arr, err := redis.Strings(conn.Do("BLPOP", jobTriggerKey, 0))
+		conn.Close()
+		if err != nil {
+			time.Sleep(time.Second)
+			continue
+		}
+		if j, ok := jobIndex[arr[1]]; ok {
+			go runJob(j, TriggerManual)
+		}
+	}
+}
+
+// 获取任务列表
+func ListJobs() []*JobInfo {
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	paused, _ := redis.StringMap(conn.Do("HGETALL", jobPausedKey))
+	list := make([]*JobInfo, len(jobs))
+	for i, j := range jobs {
+		_, p := paused[j.Name]
+		list[i] = &JobInfo{
+			Name:   j.Name,
+			Spec:   j.Spec,
+			Desc:   j.Desc,
+			Paused: p,
+		}
+		if runs := GetJobRuns(j.Name, 1); len(runs) > 0 {
+			list[i].LastRun = runs[0]
+		}
+	}
+	return list
+}
+
+// 暂停任务的计划执行
+func PauseJob(name string) error {
+	if _, ok := jobIndex[name]; !ok {
+		return ErrNoSuchJob
+	}
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	_, err := conn.Do("HSET", jobPausedKey, name, time.Now().Unix())
+	return err
+}
+
+// 恢复任务的计划执行
+func ResumeJob(name string) error {
+	if _, ok := jobIndex[name]; !ok {
+		return ErrNoSuchJob
+	}
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	_, err := conn.Do("HDEL", jobPausedKey, name)
+	return err
+}
+
+// 手动执行任务,由任一运行中的守护进程执行
+func TriggerJob(name string) error {
+	if _, ok := jobIndex[name]; !ok {
+		return ErrNoSuchJob
+	}
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	_, err := conn.Do("RPUSH", jobTriggerKey, name)
+	return err
+}
+
+// 获取任务最近的执行记录
+func GetJobRuns(name string, size int) []*JobRun {
+	list := []*JobRun{}
+	if _orm != nil {
+		_orm.SelectByQuery(&list, "SELECT * FROM sys_job_run WHERE job_name=? ORDER BY id DESC LIMIT ?",
+			name, size)
+	}
+	return list
+}
+
+// 执行任务管理命令
+func jobCommand(list bool, run, pause, resume, runs string) error {
+	if list {
+		for _, j := range ListJobs() {
+			last := "-"
+			if j.LastRun != nil {
+				last = fmt.Sprintf("%s %d", time.Unix(j.LastRun.StartTime, 0).
+					Format("2006-01-02 15:04:05"), j.LastRun.State)
+			}
+			fmt.Printf("%-24s %-16s paused:%-5v last:%s  %s\n", j.Name, j.Spec,
+				j.Paused, last, j.Desc)
+		}
+	}
+	if run != "" {
+		if err := TriggerJob(run); err != nil {
+			return err
+		}
+		fmt.Println("job", run, "triggered")
+	}
+	if pause != "" {
+		if err := PauseJob(pause); err != nil {
+			return err
+		}
+		fmt.Println("job", pause, "paused")
+	}
+	if resume != "" {
+		if err := ResumeJob(resume); err != nil {
+			return err
+		}
+		fmt.Println("job", resume, "resumed")
+	}
+	if runs != "" {
+		if _, ok := jobIndex[runs]; !ok {
+			return ErrNoSuchJob
+		}
+		for _, r := range GetJobRuns(runs, 20) {
+			fmt.Printf("%-8d %-8s %-24s fence:%-6d %s %ds state:%d %s\n", r.Id,
+				r.TriggerBy, r.Instance, r.Fence, time.Unix(r.StartTime, 0).
+					Format("2006-01-02 15:04:05"), r.EndTime-r.StartTime, r.State, r.Error)
+		}
+	}
+	return nil
+}
@@ -43,6 +43,7 @@ func startMailQueue(ss []Service) {
 }
 
 func sendForWaitingQueue(ss []Service) {
+	defer Recover()
 	var list = []*mss.MailTask{}
 	err := appCtx.Db().GetOrm().Select(&list, "is_send = 0 OR is_failed = 1")
 	if err == nil && len(list) > 0 {
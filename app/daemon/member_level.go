@@ -0,0 +1,60 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : member_level
+ * author : jarryliu
+ * date : 2026-10-18 10:20
+ * description :
+ * history :
+ */
+package daemon
+
+import (
+	"database/sql"
+	"go2o/core/domain/interface/member"
+	"go2o/core/infrastructure/tool"
+	"go2o/core/service/rsi"
+	"log"
+	"time"
+)
+
+var (
+	mmLevelUnixKey string = "cron:go2o:d:mm:level-unix"
+)
+
+// 根据等级规则重新评定会员等级,每日执行一次
+func memberLevelEvaluate() {
+	unix := tool.GetStartDate(time.Now()).Unix()
+	if CompareLastUnix(mmLevelUnixKey, unix) {
+		log.Println("[ Member][ Level]: today level is evaluated!")
+		return
+	}
+	begin := 0
+	size := 50
+	changed := 0
+	var tmp int64
+	for {
+		idArr := []int64{}
+		appCtx.Db().Query(`SELECT id FROM mm_member WHERE state=?
+			ORDER BY id LIMIT ?,?`, func(rs *sql.Rows) {
+			for rs.Next() {
+				rs.Scan(&tmp)
+				idArr = append(idArr, tmp)
+			}
+		}, member.StateOk, begin, size)
+		for _, id := range idArr {
+			b, err := rsi.MemberService.ReEvaluateLevel(id)
+			if err != nil {
+				log.Println("[ Member][ Level][ Error]:", id, err.Error())
+			} else if b {
+				changed++
+			}
+		}
+		if l := len(idArr); l == size {
+			begin += l
+		} else {
+			break
+		}
+	}
+	log.Println("[ Member][ Level]: evaluate finished, changed", changed, "members")
+	signHandled(mmLevelUnixKey, unix)
+}
@@ -0,0 +1,107 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : member_push
+ * author : jarryliu
+ * date : 2026-10-19 15:30
+ * description : 推送订单、会员及支付单的变动
+ * history :
+ */
+package daemon
+
+import (
+	"go2o/core"
+	"go2o/core/infrastructure/push"
+	"go2o/core/service/thrift/idl/gen-go/define"
+	"log"
+)
+
+// 推送订单状态给买家,并发布实时事件给买家及商户
+func publishOrderPush(o *define.ComplexOrder) {
+	if o.BuyerId <= 0 {
+		return
+	}
+	data := map[string]interface{}{
+		"order_no":     o.OrderNo,
+		"order_id":     o.OrderId,
+		"sub_order_id": o.SubOrderId,
+		"order_type":   o.OrderType,
+		"final_amount": o.FinalAmount,
+		"state":        o.State,
+		"update_time":  o.UpdateTime,
+	}
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	_, err := push.Publish(conn, o.BuyerId, push.TopicOrder, data)
+	if err == nil {
+		err = push.PublishEvent(conn, &push.Event{
+			Type:     push.EventOrder,
+			MemberId: o.BuyerId,
+			Data:     data,
+		})
+	}
+	// 子订单通知商户
+	if err == nil && o.SubOrderId > 0 && o.VendorId > 0 {
+		err = push.PublishEvent(conn, &push.Event{
+			Type:  push.EventOrder,
+			MchId: o.VendorId,
+			Data:  data,
+		})
+	}
+	if err != nil {
+		log.Println("[ Push][ Order][ Error]:", o.OrderNo, err.Error())
+	}
+}
+
+// 发布会员变动事件
+func publishMemberEvent(m *define.Member, create bool) {
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	err := push.PublishEvent(conn, &push.Event{
+		Type:     push.EventMember,
+		MemberId: m.ID,
+		Data: map[string]interface{}{
+			"member_id":       m.ID,
+			"create":          create,
+			"level":           m.Level,
+			"exp":             m.Exp,
+			"premium_user":    m.PremiumUser,
+			"premium_expires": m.PremiumExpires,
+			"state":           m.State,
+			"update_time":     m.UpdateTime,
+		},
+	})
+	if err != nil {
+		log.Println("[ Push][ Member][ Error]:", m.ID, err.Error())
+	}
+}
+
+// 发布支付单事件给付款会员及商户
+func publishPaymentEvent(p *define.PaymentOrder) {
+	data := map[string]interface{}{
+		"trade_no":     p.TradeNo,
+		"order_id":     p.OrderId,
+		"final_amount": p.FinalAmount,
+		"state":        p.State,
+		"paid_time":    p.PaidTime,
+	}
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	var err error
+	if p.BuyUser > 0 {
+		err = push.PublishEvent(conn, &push.Event{
+			Type:     push.EventPayment,
+			MemberId: p.BuyUser,
+			Data:     data,
+		})
+	}
+	if err == nil && p.VendorId > 0 {
+		err = push.PublishEvent(conn, &push.Event{
+			Type:  push.EventPayment,
+			MchId: p.VendorId,
+			Data:  data,
+		})
+	}
+	if err != nil {
+		log.Println("[ Push][ Payment][ Error]:", p.TradeNo, err.Error())
+	}
+}
@@ -0,0 +1,65 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : outbox.go
+ * author : jarryliu
+ * date : 2026-10-19 21:05
+ * description : 发布发件箱中的领域事件
+ * history :
+ */
+package daemon
+
+import (
+	"go2o/core"
+	"go2o/core/infrastructure/locker"
+	"go2o/core/infrastructure/outbox"
+	"log"
+	"time"
+)
+
+const (
+	// 发布事件的锁,多个实例中仅持有锁的实例发布,保证事件的顺序
+	outboxLockKey = "go2o:daemon:outbox:lock"
+	outboxLockTTL = 30 * time.Second
+	// 每次发布的数量
+	outboxBatch = 100
+	// 没有事件时的检测间隔
+	outboxInterval = time.Second
+)
+
+// 持续发布发件箱中的事件
+func startOutboxRelay() {
+	var l *locker.Lock
+	for {
+		conn := core.GetRedisConn()
+		if l == nil {
+			l, _ = locker.Acquire(conn, outboxLockKey, outboxLockTTL)
+		} else if ok, err := l.Refresh(conn); err == nil && !ok {
+			l = nil
+		}
+		// 发布前检查令牌,锁已被其他实例获得时不再发布
+		if l != nil && !locker.CheckFence(conn, outboxLockKey, l.Fence) {
+			l = nil
+		}
+		n := 0
+		if l != nil {
+			var err error
+			n, err = outbox.Relay(_db, conn, outboxBatch)
+			if err != nil {
+				log.Println("[ Go2o][ Outbox][ Error]:", err.Error())
+			}
+		}
+		conn.Close()
+		// 未发布完时继续发布
+		if n < outboxBatch {
+			time.Sleep(outboxInterval)
+		}
+	}
+}
+
+// 清除7天前已发布的事件
+func outboxPurge() {
+	before := time.Now().Add(-7 * 24 * time.Hour).Unix()
+	if _, err := outbox.Purge(_db, before); err != nil {
+		log.Println("[ Go2o][ Outbox][ Purge][ Error]:", err.Error())
+	}
+}
@@ -23,7 +23,8 @@ var (
 	settleUnixKey string = "sys:go2o:d:pf:date"
 )
 
-func personFinanceSettle() {
+// 个人金融结算,每批写入前检查任务锁令牌,锁已被其他实例获得时停止结算
+func personFinanceSettle(f *JobFence) {
 	now := time.Now()
 	//invokeSettle(now.Add(time.Hour * -24))
 	unix := tool.GetStartDate(time.Now()).Unix()
@@ -32,32 +33,50 @@ func personFinanceSettle() {
 		log.Println("[ PersonFinance][ Settle][ Info]:Today is settled!")
 		return
 	}
-	invokeSettle(now)
-	// 保存最新结算日期
-	SetLastUnix(settleUnixKey, unix)
+	if invokeSettle(f, now) {
+		// 保存最新结算日期
+		SetLastUnix(settleUnixKey, unix)
+	}
 }
 
-// 执行结算,结算时间为当天,
+// 执行结算,结算时间为当天,返回是否完成结算。
 // 收益计算当天前一天收益,转入转出按当天计算
-func invokeSettle(t time.Time) {
+func invokeSettle(f *JobFence, t time.Time) bool {
 	b := time.Now()
-	confirmTransferIn(t)                   //今天确认T+?前的转入
-	settleRiseData(t.Add(time.Hour * -24)) //今天结算昨日的收益
+	//今天确认T+?前的转入,今天结算昨日的收益
+	if !confirmTransferIn(f, t) || !settleRiseData(f, t.Add(time.Hour*-24)) {
+		log.Println("[ PersonFinance][ Settle][ Stop]: job lock lost")
+		return false
+	}
 	log.Println("[ PersonFinance][ Settle][ Success]:Total used",
 		math.Floor(time.Now().Sub(b).Minutes()*100)/100, "minutes!")
+	return true
 }
 
-// 确认转入数据
+// 按理财计划确认转入数据,各计划使用自己的T+N;锁已被其他实例获得时返回false
+func confirmTransferIn(f *JobFence, t time.Time) bool {
+	plans := append([]*personfinance.FinancePlan{
+		rsi.PersonFinanceService.GetPlan(personfinance.DefaultPlanId)},
+		rsi.PersonFinanceService.GetPlans()...)
+	for _, p := range plans {
+		settleTime := t.AddDate(0, 0, -p.SettleTValue) // 倒推结算日
+		if !confirmPlanTransferIn(f, p.Id, tool.GetStartDate(settleTime).Unix()) {
+			return false
+		}
+	}
+	return true
+}
+
+// 确认理财计划的转入数据
 // 采用按ID分段,通过传入ID区间用多个gorouting进行处理.
-func confirmTransferIn(t time.Time) {
-	settleTime := t.AddDate(0, 0, -personfinance.RiseSettleTValue) // 倒推结算日
-	unixDate := tool.GetStartDate(settleTime).Unix()
+func confirmPlanTransferIn(f *JobFence, planId int32, unixDate int64) bool {
 	begin := 0
 	size := 20
 	for {
 		idArr := []int32{}
-		err := _db.Query(`SELECT id FROM pf_riselog WHERE
-		unix_date<=? AND type=? AND state=? LIMIT ?,?`,
+		err := _db.Query(`SELECT l.id FROM pf_riselog l INNER JOIN pf_riseinfo r
+		ON r.person_id=l.person_id WHERE r.plan_id=? AND l.unix_date<=? AND
+		l.type=? AND l.state=? LIMIT ?,?`,
 			func(rows *sql.Rows) {
 				var i int32
 				for rows.Next() {
@@ -66,20 +85,25 @@ func confirmTransferIn(t time.Time) {
 						idArr = append(idArr, i)
 					}
 				}
-			}, unixDate, personfinance.RiseTypeTransferIn,
+			}, planId, unixDate, personfinance.RiseTypeTransferIn,
 			personfinance.RiseStateDefault, 0, size)
 		if err != nil {
 			log.Println("[ Error][ Transfer-Confirm]:", err.Error())
 			break
 		}
+		// 每批写入前检查任务锁令牌
+		if !f.Valid() {
+			return false
+		}
 		// 将IdArr按指定size切片处理
 		//wg := sync.WaitGroup{}
 		for _, v := range idArr {
 			//wg.Add(1)
 			confirmTransferInByCursor(unixDate, v)
 		}
-		log.Println("[ PersonFinance][ RiseSettle][ Job]:begin:", begin,
-			"; size:", size, "; len:", len(idArr), "; unix date =", unixDate)
+		log.Println("[ PersonFinance][ RiseSettle][ Job]:plan:", planId,
+			"; begin:", begin, "; size:", size, "; len:", len(idArr),
+			"; unix date =", unixDate)
 		time.Sleep(time.Second / 4)
 		if l := len(idArr); l == size {
 			begin += l
@@ -87,6 +111,7 @@ func confirmTransferIn(t time.Time) {
 			break
 		}
 	}
+	return true
 }
 
 // 分组确认转入数据
@@ -110,9 +135,9 @@ func confirmTransferInByCursor(unixDate int64, logId int32) {
 	}
 }
 
-// 结算增利数据,t为结算日
+// 结算增利数据,t为结算日;锁已被其他实例获得时返回false
 // 采用按ID分段,通过传入ID区间用多个gorouting进行处理.
-func settleRiseData(settleDate time.Time) {
+func settleRiseData(f *JobFence, settleDate time.Time) bool {
 	settleUnix := tool.GetStartDate(settleDate).Unix() //结算日期
 	begin := 0
 	size := 20
@@ -133,6 +158,10 @@ func settleRiseData(settleDate time.Time) {
 			log.Println("[ Error][ Rise-Settle]:", err.Error())
 			break
 		}
+		// 每批写入前检查任务锁令牌
+		if !f.Valid() {
+			return false
+		}
 		wg := sync.WaitGroup{}
 		for _, personId := range idArr {
 			wg.Add(1)
@@ -148,12 +177,12 @@ func settleRiseData(settleDate time.Time) {
 			break
 		}
 	}
+	return true
 }
 
-// 结算每日数据
+// 结算每日数据,收益率由会员的理财计划决定
 func riseGroupSettle(wg *sync.WaitGroup, settleUnix int64, personId int64) {
-	err := rsi.PersonFinanceService.RiseSettleByDay(personId, settleUnix,
-		personfinance.RiseDayRatioProvider(personId))
+	err := rsi.PersonFinanceService.RiseSettleByDay(personId, settleUnix)
 	if err != nil {
 		log.Println("[ PersonFinance][ Settle][ Fail]: person_id=",
 			personId, "error=", err.Error())
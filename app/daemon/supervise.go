@@ -14,6 +14,8 @@ import (
 	"github.com/garyburd/redigo/redis"
 	"github.com/jsix/gof/util"
 	"go2o/core"
+	"go2o/core/domain/interface/events"
+	"go2o/core/infrastructure/eventbus"
 	"go2o/core/service/rsi"
 	"go2o/core/variable"
 	"log"
@@ -22,116 +24,157 @@ import (
 	"time"
 )
 
-// 监视新订单
+// 消费组名称,多个守护进程属于同一消费组,每个事件仅由其中一个处理。
+// 全部服务共用一个消费组而非每个服务一个:服务按注册顺序组成观察链,
+// 返回false时不再通知后续服务,拆分消费组将改变Service的约定。
+// 事件重新投递时已处理的服务将再次收到通知,服务的处理需保证幂等
+const eventGroup = "daemon"
+
+// 消费主题的事件,处理失败的事件将重新投递
+func consumeEvents(topic string, h eventbus.Handler) {
+	eventbus.NewConsumer(topic, eventGroup, h,
+		eventbus.DefaultOptions(instance)).Run()
+}
+
+// 监视订单变更
 func superviseOrder(ss []Service) {
 	sv := rsi.ShoppingService
-	notify := func(orderNo string, sub bool, ss []Service) {
-		o, _ := sv.GetOrder(orderNo, sub)
-		if o != nil {
-			for _, v := range ss {
-				if !v.OrderObs(o) {
-					break
-				}
+	consumeEvents(events.TopicOrder, func(m *eventbus.Message) error {
+		// 延长收货时间,推迟自动收货
+		if m.Type == (&events.OrderReceiveExtended{}).Type() {
+			e := events.OrderReceiveExtended{}
+			if err := m.Decode(&e); err != nil {
+				return err
 			}
+			return extendAutoReceive(e.OrderNo, e.Sub, e.Hours)
 		}
-	}
-	// 监听队列
-	conn := core.GetRedisConn()
-	defer conn.Close()
-	for {
-		arr, err := redis.ByteSlices(conn.Do("BLPOP",
-			variable.KvOrderBusinessQueue, 0)) //取出队列的一个元素
-		if err == nil {
-			//通知订单更新
-			orderNo := string(arr[1])
-			sub := strings.HasPrefix(orderNo, "sub!")
-			if sub {
-				orderNo = orderNo[4:]
+		e := events.OrderChanged{}
+		if err := m.Decode(&e); err != nil {
+			return err
+		}
+		o, err := sv.GetOrder(e.OrderNo, e.Sub)
+		if o == nil {
+			return err
+		}
+		for _, v := range ss {
+			if !v.OrderObs(o) {
+				break
 			}
-			//log.Println("----- 订单号：",orderNo, "; 是否子订单：", isSub)
-			go notify(orderNo, sub, ss)
-
-		} else {
-			appCtx.Log().Println("[ Daemon][ OrderQueue][ Error]:",
-				err.Error(), "; retry after 10 seconds.")
-			time.Sleep(time.Second * 10)
 		}
-	}
+		return nil
+	})
 }
 
-// 监视新会员
+// 监视会员新增或修改
 func superviseMemberUpdate(ss []Service) {
 	sv := rsi.MemberService
-	notify := func(id int64, action string, ss []Service) {
-		m, _ := sv.GetMember(id)
-		if m != nil {
-			for _, v := range ss {
-				if !v.MemberObs(m, action == "create") {
-					break
-				}
-			}
+	consumeEvents(events.TopicMember, func(m *eventbus.Message) error {
+		e := events.MemberChanged{}
+		if err := m.Decode(&e); err != nil {
+			return err
 		}
-	}
-	id := 0
-	conn := core.GetRedisConn()
-	defer conn.Close()
-	for {
-		arr, err := redis.ByteSlices(conn.Do("BLPOP",
-			variable.KvMemberUpdateQueue, 0))
-		if err == nil {
-			//通知会员修改,格式如: 1-[create|update]
-			s := string(arr[1])
-			mArr := strings.Split(s, "-")
-			id, err = strconv.Atoi(mArr[0])
-			if err == nil {
-				go notify(int64(id), mArr[1], ss)
+		mm, err := sv.GetMember(e.MemberId)
+		if mm == nil {
+			return err
+		}
+		for _, v := range ss {
+			if !v.MemberObs(mm, e.Create) {
+				break
 			}
-		} else {
-			appCtx.Log().Println("[ Daemon][ MemberQueue][ Error]:",
-				err.Error(), "; retry after 10 seconds.")
-			time.Sleep(time.Second * 10)
 		}
-	}
+		return nil
+	})
 }
 
 // 监视支付单完成
 func supervisePaymentOrderFinish(ss []Service) {
 	sv := rsi.PaymentService
-	notify := func(id int, ss []Service) {
-		order, _ := sv.GetPaymentOrderById(int32(id))
-		if order != nil {
-			for _, v := range ss {
-				if !v.PaymentOrderObs(order) {
-					break
-				}
+	consumeEvents(events.TopicPayment, func(m *eventbus.Message) error {
+		e := events.PaymentFinished{}
+		if err := m.Decode(&e); err != nil {
+			return err
+		}
+		order, err := sv.GetPaymentOrderById(e.PaymentOrderId)
+		if order == nil {
+			return err
+		}
+		for _, v := range ss {
+			if !v.PaymentOrderObs(order) {
+				break
 			}
 		}
+		return nil
+	})
+}
+
+// 监视售后单状态变更,通知实现了AfterSalesObserver的服务
+func superviseAfterSales(ss []Service) {
+	consumeEvents(events.TopicAfterSales, func(m *eventbus.Message) error {
+		e := events.AfterSalesStateChanged{}
+		if err := m.Decode(&e); err != nil {
+			return err
+		}
+		for _, v := range ss {
+			if o, ok := v.(AfterSalesObserver); ok && !o.AfterSalesObs(&e) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// 执行死信事件管理命令
+func deadEventCommand(list bool, replay, drop string) error {
+	if list {
+		arr, err := eventbus.ListDead(eventGroup, 50)
+		if err != nil {
+			return err
+		}
+		for _, d := range arr {
+			m := d.Message
+			if d.Missing() {
+				m = &eventbus.Message{Topic: m.Topic, Type: "(missing)", Key: d.OriginId}
+			}
+			fmt.Printf("%-16s %-12s %-20s %-16s %s %s\n", d.Id, m.Topic, m.Type,
+				m.Key, time.Unix(d.DeadTime, 0).Format("2006-01-02 15:04:05"), d.Error)
+		}
 	}
-	id := 0
-	conn := core.GetRedisConn()
-	defer conn.Close()
-	for {
-		arr, err := redis.ByteSlices(conn.Do("BLPOP",
-			variable.KvPaymentOrderFinishQueue, 0))
-		if err == nil {
-			//通知服务
-			s := string(arr[1])
-			id, err = strconv.Atoi(s)
-			if err == nil {
-				go notify(id, ss)
+	if replay == "all" {
+		arr, err := eventbus.ListDead(eventGroup, 1000)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for _, d := range arr {
+			// 原事件已删除的死信不能重放,需手动删除
+			if d.Missing() {
+				continue
+			}
+			if err = eventbus.Replay(eventGroup, d.Id); err != nil {
+				return err
 			}
-		} else {
-			appCtx.Log().Println("[ Daemon][ PaymentOrderQueue][ Error]:",
-				err.Error(), "; retry after 10 seconds.")
-			time.Sleep(time.Second * 10)
+			n++
 		}
+		fmt.Println(n, "events replayed")
+	} else if replay != "" {
+		if err := eventbus.Replay(eventGroup, replay); err != nil {
+			return err
+		}
+		fmt.Println("event", replay, "replayed")
 	}
+	if drop != "" {
+		if err := eventbus.DeleteDead(eventGroup, drop); err != nil {
+			return err
+		}
+		fmt.Println("event", drop, "deleted")
+	}
+	return nil
 }
 
-// 从RDS键中找到订单号，如：go2o:queue:sub!1234345435 , go2o:queue:2
+// 从RDS键中找到订单号，如：go2o:queue:sub!1234345435:20-8-1 , go2o:queue:2:20-8-1
 func testIdFromRdsKey(key string) (orderNo string, sub bool, err error) {
 	arr := strings.Split(key, ":")
-	orderNo = arr[len(arr)-1]
+	orderNo = arr[len(arr)-2]
 	sub = strings.HasPrefix(orderNo, "sub!")
 	if sub {
 		orderNo = orderNo[4:]
@@ -208,3 +251,67 @@ func orderAutoReceive() {
 		time.Sleep(time.Second * 10)
 	}
 }
+
+// 订单自动确认
+func orderAutoConfirm() {
+	if appCtx.Debug() {
+		log.Println("[ Order]: order auto confirm ...")
+	}
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	tick := getTick(time.Now())
+	key := fmt.Sprintf("%s:*:%s", variable.KvOrderAutoConfirm, tick)
+	//获取标记为自动确认的订单
+	ss := rsi.ShoppingService
+	list, err := redis.Strings(conn.Do("KEYS", key))
+	if err == nil {
+		for _, oKey := range list {
+			orderNo, isSub, err := testIdFromRdsKey(oKey)
+			if err == nil && orderNo != "" {
+				ss.ConfirmOrder(orderNo, isSub)
+				conn.Do("DEL", oKey)
+			}
+		}
+	} else {
+		log.Println("[ Daemon][ Order][ Confirm][ Error]:",
+			err.Error(), "; retry after 10 seconds.")
+		time.Sleep(time.Second * 10)
+	}
+}
+
+// 推迟订单的自动收货时间。尚未设置自动收货时,由设置时按订单的延长时间推迟
+func extendAutoReceive(orderNo string, sub bool, hours int32) error {
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	prefix := util.BoolExt.TString(sub, "sub!", "")
+	key := fmt.Sprintf("%s:%s%s:*", variable.KvOrderAutoReceive, prefix, orderNo)
+	list, err := redis.Strings(conn.Do("KEYS", key))
+	if err != nil {
+		return err
+	}
+	// 尚未设置自动收货,或设置时已推迟,不再重复推迟
+	if len(list) == 0 || !markReceiveExtended(conn, prefix, orderNo) {
+		return nil
+	}
+	for _, oKey := range list {
+		unix, err := redis.Int64(conn.Do("GET", oKey))
+		if err != nil {
+			continue
+		}
+		unix += int64(hours) * 3600
+		nKey := fmt.Sprintf("%s:%s%s:%s", variable.KvOrderAutoReceive,
+			prefix, orderNo, getTick(time.Unix(unix, 0)))
+		if _, err = conn.Do("SET", nKey, unix); err != nil {
+			return err
+		}
+		conn.Do("DEL", oKey)
+	}
+	return nil
+}
+
+// 标记订单已推迟自动收货,返回是否为首次标记,避免重复推迟
+func markReceiveExtended(conn redis.Conn, prefix string, orderNo string) bool {
+	key := fmt.Sprintf("%s-ext:%s%s", variable.KvOrderAutoReceive, prefix, orderNo)
+	r, err := conn.Do("SET", key, 1, "EX", 3600*24*30, "NX")
+	return err == nil && r != nil
+}
@@ -0,0 +1,132 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : webhook
+ * author : jarryliu
+ * date : 2026-10-19 10:10
+ * description :
+ * history :
+ */
+package daemon
+
+import (
+	"database/sql"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/order"
+	"go2o/core/service/rsi"
+	"go2o/core/service/thrift/idl/gen-go/define"
+	"log"
+	"time"
+)
+
+// 订单状态对应的通知事件
+var orderWebhookEvents = map[int32]string{
+	order.StatAwaitingPayment: merchant.EventOrderCreated,
+	order.StatAwaitingConfirm: merchant.EventOrderPaid,
+	order.StatShipped:         merchant.EventOrderShipped,
+	order.StatCompleted:       merchant.EventOrderCompleted,
+	order.StatCancelled:       merchant.EventOrderCancelled,
+}
+
+// 发布订单通知,只处理子订单
+func publishOrderWebhook(o *define.ComplexOrder) {
+	if o.SubOrderId <= 0 || o.VendorId <= 0 {
+		return
+	}
+	event, ok := orderWebhookEvents[o.State]
+	if !ok {
+		return
+	}
+	_, err := rsi.MerchantService.PublishWebhook(o.VendorId, event, map[string]interface{}{
+		"order_no":     o.OrderNo,
+		"buyer_id":     o.BuyerId,
+		"final_amount": o.FinalAmount,
+		"state":        o.State,
+		"update_time":  o.UpdateTime,
+	})
+	if err != nil {
+		log.Println("[ Webhook][ Order][ Error]:", o.OrderNo, err.Error())
+	}
+	// 下单后检查库存
+	if o.State == order.StatAwaitingPayment {
+		publishStockLowWebhook(o)
+	}
+}
+
+// 发布库存不足通知
+func publishStockLowWebhook(o *define.ComplexOrder) {
+	_, items := rsi.ShoppingService.GetSubOrderAndItemsByNo(o.OrderNo)
+	for _, it := range items {
+		v := rsi.ItemService.GetVendorItem(o.VendorId, int64(it.ItemId))
+		if v == nil {
+			continue
+		}
+		stock := v.StockNum
+		for _, sku := range v.SkuArray {
+			if sku.ID == int64(it.SkuId) {
+				stock = sku.Stock
+				break
+			}
+		}
+		if stock > merchant.WebhookStockLowNum {
+			continue
+		}
+		_, err := rsi.MerchantService.PublishWebhook(o.VendorId, merchant.EventStockLow,
+			map[string]interface{}{
+				"item_id": it.ItemId,
+				"sku_id":  it.SkuId,
+				"stock":   stock,
+			})
+		if err != nil {
+			log.Println("[ Webhook][ Stock][ Error]:", it.ItemId, err.Error())
+		}
+	}
+}
+
+// 发布支付完成通知
+func publishPaymentWebhook(p *define.PaymentOrder) {
+	if p.VendorId <= 0 {
+		return
+	}
+	_, err := rsi.MerchantService.PublishWebhook(p.VendorId, merchant.EventPaymentFinished,
+		map[string]interface{}{
+			"trade_no":     p.TradeNo,
+			"order_id":     p.OrderId,
+			"final_amount": p.FinalAmount,
+			"paid_time":    p.PaidTime,
+		})
+	if err != nil {
+		log.Println("[ Webhook][ Payment][ Error]:", p.TradeNo, err.Error())
+	}
+}
+
+// 发送到期的通知消息,失败的消息按指数退避重试
+func webhookDeliver() {
+	unix := time.Now().Unix()
+	size := 50
+	var lastId int64
+	for {
+		idArr := []int64{}
+		mchArr := []int32{}
+		appCtx.Db().Query(`SELECT id,mch_id FROM mch_webhook_msg WHERE
+			id>? AND state=? AND next_time<=? ORDER BY id LIMIT ?`,
+			func(rs *sql.Rows) {
+				var id int64
+				var mchId int32
+				for rs.Next() {
+					rs.Scan(&id, &mchId)
+					idArr = append(idArr, id)
+					mchArr = append(mchArr, mchId)
+				}
+			}, lastId, merchant.WebhookPending, unix, size)
+		for i, id := range idArr {
+			err := rsi.MerchantService.DeliverWebhook(mchArr[i], id)
+			if err != nil {
+				log.Println("[ Webhook][ Deliver][ Error]:", id, err.Error())
+			}
+			lastId = id
+		}
+		if len(idArr) < size {
+			break
+		}
+	}
+}
@@ -0,0 +1,297 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : document.go
+ * author : jarryliu
+ * date : 2026-10-19 13:20
+ * description : OpenAPI 3 文档
+ * history :
+ */
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// 表单内容类型
+	FormContentType = "application/x-www-form-urlencoded"
+	// JSON内容类型
+	JsonContentType = "application/json"
+)
+
+type (
+	// 接口文档
+	Document struct {
+		OpenApi    string               `json:"openapi"`
+		Info       Info                 `json:"info"`
+		Servers    []*Server            `json:"servers,omitempty"`
+		Paths      map[string]*PathItem `json:"paths"`
+		Components Components           `json:"components"`
+	}
+
+	// 文档信息
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	// 服务地址
+	Server struct {
+		Url         string `json:"url"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// 路径下的操作,键为小写的请求方法
+	PathItem map[string]*Operation
+
+	// 接口操作
+	Operation struct {
+		Tags        []string             `json:"tags,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		Description string               `json:"description,omitempty"`
+		OperationId string               `json:"operationId,omitempty"`
+		Parameters  []*Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+		// 所属文档,用于解析引用
+		doc *Document
+	}
+
+	// 参数
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	// 请求内容
+	RequestBody struct {
+		Description string                `json:"description,omitempty"`
+		Required    bool                  `json:"required,omitempty"`
+		Content     map[string]*MediaType `json:"content"`
+	}
+
+	// 内容格式
+	MediaType struct {
+		Schema *Schema `json:"schema,omitempty"`
+	}
+
+	// 响应
+	Response struct {
+		Description string                `json:"description"`
+		Content     map[string]*MediaType `json:"content,omitempty"`
+	}
+
+	// 数据结构
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Enum                 []interface{}      `json:"enum,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		MinLength            int                `json:"minLength,omitempty"`
+		MaxLength            int                `json:"maxLength,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+	}
+
+	// 组件
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	}
+)
+
+// 创建接口文档,prefix为接口路径的前缀
+func NewDocument(title string, version string, prefix string) *Document {
+	d := &Document{
+		OpenApi: "3.0.0",
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+		},
+	}
+	if prefix != "" {
+		d.Servers = []*Server{{Url: prefix}}
+	}
+	return d
+}
+
+// 获取路径前缀
+func (d *Document) Prefix() string {
+	if len(d.Servers) > 0 {
+		return d.Servers[0].Url
+	}
+	return ""
+}
+
+// 添加接口操作
+func (d *Document) Add(method string, path string, op *Operation) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	if op.OperationId == "" {
+		op.OperationId = operationId(method, path)
+	}
+	op.doc = d
+	(*item)[strings.ToLower(method)] = op
+	return op
+}
+
+// 查找请求对应的接口操作,支持路径中的{param}参数
+func (d *Document) Find(method string, path string) *Operation {
+	method = strings.ToLower(method)
+	if method == "head" {
+		method = "get"
+	}
+	path = strings.TrimPrefix(path, d.Prefix())
+	if item, ok := d.Paths[path]; ok {
+		return (*item)[method]
+	}
+	segments := strings.Split(path, "/")
+	for _, p := range d.sortedPaths() {
+		if !strings.Contains(p, "{") || !matchPath(strings.Split(p, "/"), segments) {
+			continue
+		}
+		if op := (*d.Paths[p])[method]; op != nil {
+			return op
+		}
+	}
+	return nil
+}
+
+// 按顺序获取路径,保证查找结果稳定
+func (d *Document) sortedPaths() []string {
+	arr := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		arr = append(arr, p)
+	}
+	sort.Strings(arr)
+	return arr
+}
+
+func sortedKeys(mp map[string]*Schema) []string {
+	arr := make([]string, 0, len(mp))
+	for k := range mp {
+		arr = append(arr, k)
+	}
+	sort.Strings(arr)
+	return arr
+}
+
+func matchPath(tpl []string, segments []string) bool {
+	if len(tpl) != len(segments) {
+		return false
+	}
+	for i, s := range tpl {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if s != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 根据请求方法及路径生成操作编号,如:get_mch_item_list
+func operationId(method string, path string) string {
+	r := strings.NewReplacer("/", "_", "{", "", "}", "", ".", "_", "!", "")
+	return strings.ToLower(method) + r.Replace(path)
+}
+
+// 创建接口操作
+func NewOperation(tag string, summary string) *Operation {
+	return &Operation{
+		Tags:      []string{tag},
+		Summary:   summary,
+		Responses: map[string]*Response{},
+	}
+}
+
+// 添加查询参数
+func (o *Operation) Query(params ...*Parameter) *Operation {
+	for _, p := range params {
+		p.In = "query"
+		o.Parameters = append(o.Parameters, p)
+	}
+	return o
+}
+
+// 添加路径参数
+func (o *Operation) Path(params ...*Parameter) *Operation {
+	for _, p := range params {
+		p.In = "path"
+		p.Required = true
+		o.Parameters = append(o.Parameters, p)
+	}
+	return o
+}
+
+// 添加表单参数,作为请求内容
+func (o *Operation) Form(params ...*Parameter) *Operation {
+	s := o.bodySchema(FormContentType)
+	for _, p := range params {
+		ps := *p.Schema
+		ps.Description = p.Description
+		s.Properties[p.Name] = &ps
+		if p.Required {
+			s.Required = append(s.Required, p.Name)
+		}
+	}
+	return o
+}
+
+// 设置JSON请求内容
+func (o *Operation) Json(s *Schema) *Operation {
+	o.body().Required = true
+	o.body().Content[JsonContentType] = &MediaType{Schema: s}
+	return o
+}
+
+// 设置成功的响应
+func (o *Operation) Returns(contentType string, s *Schema) *Operation {
+	return o.Response(http.StatusOK, "成功", contentType, s)
+}
+
+// 设置响应
+func (o *Operation) Response(code int, desc string, contentType string, s *Schema) *Operation {
+	r := &Response{Description: desc}
+	if contentType != "" {
+		r.Content = map[string]*MediaType{contentType: {Schema: s}}
+	}
+	o.Responses[strconv.Itoa(code)] = r
+	return o
+}
+
+func (o *Operation) body() *RequestBody {
+	if o.RequestBody == nil {
+		o.RequestBody = &RequestBody{Content: map[string]*MediaType{}}
+	}
+	return o.RequestBody
+}
+
+func (o *Operation) bodySchema(contentType string) *Schema {
+	m, ok := o.body().Content[contentType]
+	if !ok {
+		m = &MediaType{Schema: &Schema{Type: "object", Properties: map[string]*Schema{}}}
+		o.body().Content[contentType] = m
+	}
+	return m.Schema
+}
@@ -0,0 +1,175 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : schema.go
+ * author : jarryliu
+ * date : 2026-10-19 13:40
+ * description : 数据结构及参数
+ * history :
+ */
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// 整数参数
+func Integer(name string, desc string) *Parameter {
+	return &Parameter{Name: name, Description: desc,
+		Schema: &Schema{Type: "integer", Format: "int64"}}
+}
+
+// 数字参数
+func Number(name string, desc string) *Parameter {
+	return &Parameter{Name: name, Description: desc,
+		Schema: &Schema{Type: "number", Format: "double"}}
+}
+
+// 字符串参数
+func String(name string, desc string) *Parameter {
+	return &Parameter{Name: name, Description: desc,
+		Schema: &Schema{Type: "string"}}
+}
+
+// 布尔参数
+func Boolean(name string, desc string) *Parameter {
+	return &Parameter{Name: name, Description: desc,
+		Schema: &Schema{Type: "boolean"}}
+}
+
+// 设为必填
+func (p *Parameter) Require() *Parameter {
+	p.Required = true
+	return p
+}
+
+// 设置取值范围
+func (p *Parameter) Range(min float64, max float64) *Parameter {
+	p.Schema.Minimum = &min
+	p.Schema.Maximum = &max
+	return p
+}
+
+// 设置最小值
+func (p *Parameter) Min(min float64) *Parameter {
+	p.Schema.Minimum = &min
+	return p
+}
+
+// 设置字符串长度
+func (p *Parameter) Length(min int, max int) *Parameter {
+	p.Schema.MinLength = min
+	p.Schema.MaxLength = max
+	return p
+}
+
+// 设置正则表达式
+func (p *Parameter) Match(pattern string) *Parameter {
+	p.Schema.Pattern = pattern
+	return p
+}
+
+// 设置可选值
+func (p *Parameter) Enum(values ...interface{}) *Parameter {
+	p.Schema.Enum = values
+	return p
+}
+
+// 数组
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// 对象,properties为属性
+func ObjectOf(properties map[string]*Schema) *Schema {
+	return &Schema{Type: "object", Properties: properties}
+}
+
+// 二进制内容,如图片
+func BinarySchema() *Schema {
+	return &Schema{Type: "string", Format: "binary"}
+}
+
+// 根据值的类型生成数据结构,结构体将注册为组件并返回引用
+func (d *Document) SchemaOf(v interface{}) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return d.schemaOf(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return ArrayOf(d.schemaOf(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// 先占位,避免结构体相互引用时无限递归
+			d.Components.Schemas[name] = &Schema{}
+			d.Components.Schemas[name] = d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	// 其他类型不限制
+	return &Schema{}
+}
+
+// 生成结构体的数据结构,匿名嵌入的结构体将展开
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := ObjectOf(map[string]*Schema{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			for k, v := range d.structSchema(ft).Properties {
+				s.Properties[k] = v
+			}
+			continue
+		}
+		s.Properties[name] = d.schemaOf(f.Type)
+	}
+	return s
+}
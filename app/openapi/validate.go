@@ -0,0 +1,249 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : validate.go
+ * author : jarryliu
+ * date : 2026-10-19 14:05
+ * description : 按接口文档校验请求
+ * history :
+ */
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 字段错误
+type FieldError struct {
+	// 字段名称
+	Field string `json:"field"`
+	// 错误信息
+	Message string `json:"message"`
+}
+
+var (
+	regexpMap = map[string]*regexp.Regexp{}
+	regexpMux sync.Mutex
+)
+
+// 校验请求的中间件,未在文档中定义的请求不校验。
+// 校验失败时调用onError输出错误
+func Validator(d *Document, onError func(c echo.Context,
+	errs []*FieldError) error) echo.MiddlewareFunc {
+	return func(h echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			if op := d.Find(r.Method, r.URL.Path); op != nil {
+				if errs := op.Validate(r); len(errs) > 0 {
+					return onError(c, errs)
+				}
+			}
+			return h(c)
+		}
+	}
+}
+
+// 输出接口文档
+func (d *Document) Handler(c echo.Context) error {
+	return c.JSON(http.StatusOK, d)
+}
+
+// 校验请求,返回字段错误
+func (o *Operation) Validate(r *http.Request) []*FieldError {
+	var errs []*FieldError
+	// JSON请求只解析查询字符串,不读取请求内容
+	r.ParseForm()
+	for _, p := range o.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		if msg := checkValue(r.Form, p.Name, p.Required, o.resolve(p.Schema)); msg != "" {
+			errs = append(errs, &FieldError{Field: p.Name, Message: msg})
+		}
+	}
+	if o.RequestBody == nil {
+		return errs
+	}
+	if _, ok := o.RequestBody.Content[JsonContentType]; ok &&
+		strings.HasPrefix(r.Header.Get("Content-Type"), JsonContentType) {
+		return append(errs, o.validateJson(r)...)
+	}
+	if m, ok := o.RequestBody.Content[FormContentType]; ok {
+		s := o.resolve(m.Schema)
+		for _, name := range sortedKeys(s.Properties) {
+			msg := checkValue(r.Form, name, contains(s.Required, name),
+				o.resolve(s.Properties[name]))
+			if msg != "" {
+				errs = append(errs, &FieldError{Field: name, Message: msg})
+			}
+		}
+	} else if _, ok := o.RequestBody.Content[JsonContentType]; ok {
+		errs = append(errs, &FieldError{Field: "Content-Type",
+			Message: "必须为" + JsonContentType})
+	}
+	return errs
+}
+
+// 校验JSON请求内容,只校验第一层属性,读取后恢复请求内容
+func (o *Operation) validateJson(r *http.Request) []*FieldError {
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	mp := map[string]interface{}{}
+	if err == nil {
+		err = json.Unmarshal(data, &mp)
+	}
+	if err != nil {
+		return []*FieldError{{Field: "body", Message: "不是有效的JSON对象"}}
+	}
+	var errs []*FieldError
+	s := o.resolve(o.RequestBody.Content[JsonContentType].Schema)
+	for _, name := range s.Required {
+		if _, ok := mp[name]; !ok {
+			errs = append(errs, &FieldError{Field: name, Message: "不能为空"})
+		}
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		v, ok := mp[name]
+		if !ok || v == nil {
+			continue
+		}
+		if t := o.resolve(s.Properties[name]).Type; !checkJsonType(v, t) {
+			errs = append(errs, &FieldError{Field: name, Message: "必须为" + t})
+		}
+	}
+	return errs
+}
+
+// 解析数据结构的引用,引用不存在时不限制
+func (o *Operation) resolve(s *Schema) *Schema {
+	const prefix = "#/components/schemas/"
+	// 限制解析次数,避免引用自身时无限循环
+	for i := 0; s != nil && s.Ref != "" && i < 10; i++ {
+		if o.doc == nil || !strings.HasPrefix(s.Ref, prefix) {
+			return &Schema{}
+		}
+		s = o.doc.Components.Schemas[strings.TrimPrefix(s.Ref, prefix)]
+	}
+	if s == nil || s.Ref != "" {
+		return &Schema{}
+	}
+	return s
+}
+
+func checkJsonType(v interface{}, t string) bool {
+	switch t {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// 校验参数值,返回错误信息
+func checkValue(form url.Values, name string, required bool, s *Schema) string {
+	arr, ok := form[name]
+	if !ok || len(arr) == 0 || arr[0] == "" {
+		if required {
+			return "不能为空"
+		}
+		return ""
+	}
+	v := arr[0]
+	switch s.Type {
+	case "integer":
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "必须为整数"
+		}
+		if msg := checkRange(float64(i), s); msg != "" {
+			return msg
+		}
+	case "number":
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "必须为数字"
+		}
+		if msg := checkRange(f, s); msg != "" {
+			return msg
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(v); err != nil {
+			return "必须为true或false"
+		}
+	case "string":
+		n := utf8.RuneCountInString(v)
+		if (s.MinLength > 0 && n < s.MinLength) || (s.MaxLength > 0 && n > s.MaxLength) {
+			return fmt.Sprintf("长度必须在%d-%d之间", s.MinLength, s.MaxLength)
+		}
+		if s.Pattern != "" && !getRegexp(s.Pattern).MatchString(v) {
+			return "格式不正确"
+		}
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			values[i] = fmt.Sprint(e)
+		}
+		if !contains(values, v) {
+			return "必须为以下值之一:" + strings.Join(values, ",")
+		}
+	}
+	return ""
+}
+
+func checkRange(f float64, s *Schema) string {
+	if s.Minimum != nil && f < *s.Minimum {
+		return fmt.Sprintf("不能小于%v", *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		return fmt.Sprintf("不能大于%v", *s.Maximum)
+	}
+	return ""
+}
+
+// 获取编译后的正则表达式
+func getRegexp(pattern string) *regexp.Regexp {
+	regexpMux.Lock()
+	defer regexpMux.Unlock()
+	re, ok := regexpMap[pattern]
+	if !ok {
+		re = regexp.MustCompile(pattern)
+		regexpMap[pattern] = re
+	}
+	return re
+}
+
+func contains(arr []string, s string) bool {
+	for _, v := range arr {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -56,6 +56,20 @@ func (mc *MemberC) Login(c echo.Context) error {
 				Token:      token,
 				UpdateTime: time.Now().Unix(),
 			}
+			// 按设备创建会话
+			s, err := rsi.MemberService.CreateSession(r.ID,
+				c.Request().FormValue("device_id"),
+				c.Request().FormValue("device_name"), getRemoteIp(c))
+			if err != nil {
+				result.Result = false
+				result.Message = err.Error()
+				result.Member = nil
+			} else {
+				result.Member.AccessToken = s.AccessToken
+				result.Member.ExpiresIn = s.ExpiresIn
+				result.Member.RefreshToken = s.RefreshToken
+				result.Member.DeviceId = s.DeviceId
+			}
 		}
 	}
 	return c.JSON(http.StatusOK, result)
@@ -74,10 +88,7 @@ func (mc *MemberC) Register(c echo.Context) error {
 	phone := r.FormValue("phone")
 	registerFrom := r.FormValue("reg_from")          // 注册来源
 	invitationCode := r.FormValue("invitation_code") // 邀请码
-	var regIp string
-	if i := strings.Index(r.RemoteAddr, ":"); i != -1 {
-		regIp = r.RemoteAddr[:i]
-	}
+	regIp := getRemoteIp(c)
 	m := &define.Member{}
 	pro := &define.Profile{}
 	m.Usr = usr
@@ -91,6 +102,40 @@ func (mc *MemberC) Register(c echo.Context) error {
 	return c.JSON(http.StatusOK, result.Error(err))
 }
 
+// 刷新访问令牌,刷新令牌只能使用一次
+func (mc *MemberC) RefreshToken(c echo.Context) error {
+	s, err := rsi.MemberService.RefreshSession(
+		c.Request().FormValue("refresh_token"), getRemoteIp(c))
+	if err != nil {
+		e, _ := newApiError(err)
+		return c.JSON(http.StatusUnauthorized, e)
+	}
+	return c.JSON(http.StatusOK, s)
+}
+
+// 已登录的设备
+func (mc *MemberC) Devices(c echo.Context) error {
+	return c.JSON(http.StatusOK, rsi.MemberService.GetDevices(GetMemberId(c)))
+}
+
+// 退出设备,未传入设备编号时退出当前设备
+func (mc *MemberC) RevokeDevice(c echo.Context) error {
+	deviceId := c.Request().FormValue("device_id")
+	if deviceId == "" {
+		deviceId, _ = c.Get("device_id").(string)
+	}
+	err := rsi.MemberService.RevokeDevice(GetMemberId(c), deviceId)
+	return opResult(c, 0, err)
+}
+
+// 延长订单收货时间,每个订单只能延长一次;sub为0时为批发订单
+func (mc *MemberC) OrderExtendReceive(c echo.Context) error {
+	r := c.Request()
+	err := rsi.ShoppingService.ExtendReceive(GetMemberId(c),
+		r.FormValue("order_no"), r.FormValue("sub") != "0")
+	return opResult(c, 0, err)
+}
+
 func (mc *MemberC) Ping(c echo.Context) error {
 	//log.Println("---", ctx.Request.FormValue("member_id"), ctx.Request.FormValue("member_token"))
 	return c.String(http.StatusOK, "PONG")
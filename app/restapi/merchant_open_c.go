@@ -0,0 +1,291 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : merchant_open_c.go
+ * author : jarryliu
+ * date : 2026-10-18 16:40
+ * description : 商户开放接口,供ERP等第三方系统调用
+ * history :
+ */
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo"
+	"go2o/app/cache"
+	"go2o/core/domain/interface/after-sales"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/order"
+	"go2o/core/dto"
+	"go2o/core/service/rsi"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 商户开放接口,已通过接口编号及密钥验证
+type mchOpenC struct {
+}
+
+// 商品列表,按编号倒序,cursor为上一页最后的商品编号
+func (m *mchOpenC) ItemList(c echo.Context) error {
+	size := formPageSize(c)
+	list := rsi.ItemService.GetVendorItems(getMerchantId(c),
+		formInt64(c, "cursor"), size)
+	p := CursorPager{Data: list}
+	if l := len(list); l == size {
+		p.Cursor = list[l-1].ID
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// 获取商品及SKU
+func (m *mchOpenC) ItemGet(c echo.Context) error {
+	it := rsi.ItemService.GetVendorItem(getMerchantId(c), formInt64(c, "item_id"))
+	if it == nil {
+		return errorResult(c, item.ErrNoSuchItem)
+	}
+	return c.JSON(http.StatusOK, it)
+}
+
+// 保存商品,请求内容为商品的JSON数据
+func (m *mchOpenC) ItemSave(c echo.Context) error {
+	v := &item.GoodsItem{}
+	if err := json.NewDecoder(c.Request().Body).Decode(v); err != nil {
+		return errorResult(c, err)
+	}
+	id, err := rsi.ItemService.SaveItemValue(v, getMerchantId(c))
+	return opResult(c, id, err)
+}
+
+// 删除商品
+func (m *mchOpenC) ItemDelete(c echo.Context) error {
+	itemId := formInt64(c, "item_id")
+	return opResult(c, itemId, rsi.ItemService.DeleteGoods(getMerchantId(c), itemId))
+}
+
+// 商品上下架
+func (m *mchOpenC) ItemShelve(c echo.Context) error {
+	itemId := formInt64(c, "item_id")
+	err := rsi.ItemService.SetItemShelve(getMerchantId(c), itemId,
+		int32(formInt64(c, "item_type")), int32(formInt64(c, "state")),
+		c.Request().FormValue("remark"))
+	return opResult(c, itemId, err)
+}
+
+// 更新SKU库存
+func (m *mchOpenC) SkuStock(c echo.Context) error {
+	skuId := formInt64(c, "sku_id")
+	err := rsi.ItemService.SetSkuStock(getMerchantId(c), formInt64(c, "item_id"),
+		skuId, int32(formInt64(c, "stock")))
+	return opResult(c, skuId, err)
+}
+
+// 订单列表,按编号倒序,cursor为上一页最后的订单编号
+func (m *mchOpenC) OrderList(c echo.Context) error {
+	size := formPageSize(c)
+	where := []string{}
+	if cursor := formInt64(c, "cursor"); cursor > 0 {
+		where = append(where, fmt.Sprintf("o.id<%d", cursor))
+	}
+	if state := formInt64(c, "state"); state > 0 {
+		where = append(where, fmt.Sprintf("o.state=%d", state))
+	}
+	_, list := rsi.MerchantService.PagedNormalOrderOfVendor(getMerchantId(c),
+		0, size, false, strings.Join(where, " AND "), "o.id DESC")
+	p := CursorPager{Data: list}
+	if l := len(list); l == size {
+		p.Cursor = list[l-1].Id
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// 获取商户的订单及商品项
+func (m *mchOpenC) getOrderAndItems(c echo.Context) (*order.NormalSubOrder,
+	[]*dto.OrderItem, error) {
+	o, items := rsi.ShoppingService.GetSubOrderAndItemsByNo(
+		c.Request().FormValue("order_no"))
+	if o == nil || o.VendorId != getMerchantId(c) {
+		return nil, nil, order.ErrNoSuchOrder
+	}
+	return o, items, nil
+}
+
+// 获取商户的订单
+func (m *mchOpenC) getOrder(c echo.Context) (*order.NormalSubOrder, error) {
+	o, _, err := m.getOrderAndItems(c)
+	return o, err
+}
+
+// 订单详情
+func (m *mchOpenC) OrderGet(c echo.Context) error {
+	o, items, err := m.getOrderAndItems(c)
+	if err != nil {
+		return errorResult(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"order": o,
+		"items": items,
+	})
+}
+
+// 确认订单
+func (m *mchOpenC) OrderConfirm(c echo.Context) error {
+	o, err := m.getOrder(c)
+	if err == nil {
+		err = rsi.ShoppingService.ConfirmOrder(o.OrderNo, true)
+	}
+	return m.orderResult(c, o, err)
+}
+
+// 备货完成
+func (m *mchOpenC) OrderPickup(c echo.Context) error {
+	o, err := m.getOrder(c)
+	if err == nil {
+		err = rsi.ShoppingService.PickUp(o.OrderNo, true)
+	}
+	return m.orderResult(c, o, err)
+}
+
+// 订单发货,需提供快递服务商编号及快递单号
+func (m *mchOpenC) OrderShip(c echo.Context) error {
+	spOrder := strings.TrimSpace(c.Request().FormValue("sp_order"))
+	o, err := m.getOrder(c)
+	if err == nil {
+		err = rsi.ShoppingService.Ship(o.OrderNo, true,
+			int32(formInt64(c, "sp_id")), spOrder)
+	}
+	return m.orderResult(c, o, err)
+}
+
+func (m *mchOpenC) orderResult(c echo.Context, o *order.NormalSubOrder, err error) error {
+	if err != nil {
+		return errorResult(c, err)
+	}
+	return opResult(c, o.ID, nil)
+}
+
+// 售后单列表,按编号倒序,cursor为上一页最后的售后单编号
+func (m *mchOpenC) AfterSalesList(c echo.Context) error {
+	size := formPageSize(c)
+	where := ""
+	if cursor := formInt64(c, "cursor"); cursor > 0 {
+		where = fmt.Sprintf("ao.id<%d", cursor)
+	}
+	_, list := rsi.AfterSalesService.QueryPagerAfterSalesOrderOfVendor(
+		getMerchantId(c), 0, size, where)
+	p := CursorPager{Data: list}
+	if l := len(list); l == size {
+		p.Cursor = int64(list[l-1].Id)
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// 获取商户的售后单编号
+func (m *mchOpenC) getAfterSalesId(c echo.Context) (int32, error) {
+	id := int32(formInt64(c, "id"))
+	v := rsi.AfterSalesService.GetAfterSaleOrder(id)
+	if v == nil || v.VendorId != getMerchantId(c) {
+		return 0, afterSales.ErrNoSuchOrder
+	}
+	return id, nil
+}
+
+// 售后单详情,包括留言及仲裁记录
+func (m *mchOpenC) AfterSalesGet(c echo.Context) error {
+	id, err := m.getAfterSalesId(c)
+	if err != nil {
+		return errorResult(c, err)
+	}
+	return c.JSON(http.StatusOK, rsi.AfterSalesService.GetAfterSalesHistory(id))
+}
+
+// 同意售后
+func (m *mchOpenC) AfterSalesAgree(c echo.Context) error {
+	id, err := m.getAfterSalesId(c)
+	if err == nil {
+		err = rsi.AfterSalesService.AgreeAfterSales(id, c.Request().FormValue("remark"))
+	}
+	return opResult(c, int64(id), err)
+}
+
+// 拒绝售后
+func (m *mchOpenC) AfterSalesDecline(c echo.Context) error {
+	id, err := m.getAfterSalesId(c)
+	if err == nil {
+		err = rsi.AfterSalesService.DeclineAfterSales(id, c.Request().FormValue("reason"))
+	}
+	return opResult(c, int64(id), err)
+}
+
+// 售后收货
+func (m *mchOpenC) AfterSalesReceive(c echo.Context) error {
+	id, err := m.getAfterSalesId(c)
+	if err == nil {
+		err = rsi.AfterSalesService.ReceiveReturnShipment(id)
+	}
+	return opResult(c, int64(id), err)
+}
+
+// 售后留言,多张图片用","分隔
+func (m *mchOpenC) AfterSalesMessage(c echo.Context) error {
+	r := c.Request()
+	id, err := m.getAfterSalesId(c)
+	var msgId int32
+	if err == nil {
+		msgId, err = rsi.AfterSalesService.PostAfterSalesMessage(id,
+			afterSales.RoleVendor, int64(getMerchantId(c)), r.FormValue("content"),
+			strings.Split(r.FormValue("images"), ","))
+	}
+	return opResult(c, int64(msgId), err)
+}
+
+// 订单时效设置,未设置的订单类型或时效使用商户的销售设置
+func (m *mchOpenC) OrderTimeoutList(c echo.Context) error {
+	return c.JSON(http.StatusOK, rsi.MerchantService.SelectOrderTimeout(getMerchantId(c)))
+}
+
+// 保存订单类型的时效设置,未传入自动确认时间时使用销售设置
+func (m *mchOpenC) OrderTimeoutSave(c echo.Context) error {
+	confirm := merchant.ConfirmMinuteUnset
+	if c.Request().FormValue("confirm_minute") != "" {
+		confirm = int(formInt64(c, "confirm_minute"))
+	}
+	v := &merchant.OrderTimeout{
+		OrderType:         int32(formInt64(c, "order_type")),
+		TimeoutMinute:     int(formInt64(c, "timeout_minute")),
+		ConfirmMinute:     confirm,
+		ReceiveHour:       int(formInt64(c, "receive_hour")),
+		ReceiveExtendHour: int(formInt64(c, "receive_extend_hour")),
+	}
+	err := rsi.MerchantService.SaveOrderTimeout(getMerchantId(c), v)
+	return opResult(c, 0, err)
+}
+
+// 删除订单类型的时效设置
+func (m *mchOpenC) OrderTimeoutDelete(c echo.Context) error {
+	err := rsi.MerchantService.DeleteOrderTimeout(getMerchantId(c),
+		int32(formInt64(c, "order_type")))
+	return opResult(c, 0, err)
+}
+
+// 接口调用统计,begin及end格式如:20261019,默认为最近7天,当日统计为实时数据
+func (m *mchOpenC) ApiUsage(c echo.Context) error {
+	mchId := getMerchantId(c)
+	now := time.Now()
+	today, _ := strconv.Atoi(now.Format("20060102"))
+	begin, end := int(formInt64(c, "begin")), int(formInt64(c, "end"))
+	if end <= 0 || end > today {
+		end = today
+	}
+	if begin <= 0 {
+		begin, _ = strconv.Atoi(now.AddDate(0, 0, -6).Format("20060102"))
+	}
+	list := rsi.MerchantService.GetApiUsages(mchId, begin, end)
+	if end == today {
+		list = append(list, cache.GetApiUsages(mchId, today)...)
+	}
+	return c.JSON(http.StatusOK, list)
+}
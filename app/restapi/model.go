@@ -8,10 +8,32 @@
  */
 package restapi
 
+import "go2o/app/openapi"
+
 type (
 	AsyncResult struct {
 		MemberId       int64 // 会员编号
 		MemberUpdated  bool  //会员已经更新
 		AccountUpdated bool  //会员账户已经更新
 	}
+
+	// 接口错误,Code为领域错误的键
+	ApiError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		// 参数错误的字段
+		Fields []*openapi.FieldError `json:"fields,omitempty"`
+	}
+
+	// 游标分页数据,Cursor为下一页的游标,0表示没有更多数据
+	CursorPager struct {
+		Cursor int64       `json:"cursor"`
+		Data   interface{} `json:"data"`
+	}
+
+	// 操作结果
+	ApiResult struct {
+		Result bool  `json:"result"`
+		Id     int64 `json:"id,omitempty"`
+	}
 )
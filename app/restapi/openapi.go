@@ -0,0 +1,250 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : openapi.go
+ * author : jarryliu
+ * date : 2026-10-19 14:40
+ * description : 接口文档,同时用于校验请求参数
+ * history :
+ */
+package restapi
+
+import (
+	"github.com/jsix/gof"
+	"github.com/labstack/echo"
+	"go2o/app/openapi"
+	"go2o/core/domain/interface/ad"
+	"go2o/core/domain/interface/after-sales"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/order"
+	"go2o/core/dto"
+	"go2o/core/module"
+	"net/http"
+)
+
+// 接口文档的路径
+const docPath = "/openapi.json"
+
+var apiDoc = newApiDocument()
+
+// 获取接口文档
+func GetApiDocument() *openapi.Document {
+	return apiDoc
+}
+
+// 输出参数错误
+func fieldErrorResult(c echo.Context, errs []*openapi.FieldError) error {
+	return c.JSON(http.StatusBadRequest, ApiError{
+		Code:    "err_api_params",
+		Message: "请求参数不正确",
+		Fields:  errs,
+	})
+}
+
+func newApiDocument() *openapi.Document {
+	d := openapi.NewDocument("Go2o REST API", "1.0", PathPrefix)
+	d.Info.Description = "调用接口需传入商户接口编号、时间戳、随机串及签名," +
+		"JSON请求的请求内容的SHA256值同时参与签名"
+	registerDtoSchemas(d)
+	mm := "member"
+	mch := "merchant"
+	open := "merchant_open"
+	result := d.SchemaOf(ApiResult{})
+	// 游标分页数据
+	pager := func(v interface{}) *openapi.Schema {
+		return openapi.ObjectOf(map[string]*openapi.Schema{
+			"cursor": {Type: "integer", Format: "int64"},
+			"data":   openapi.ArrayOf(d.SchemaOf(v)),
+		})
+	}
+	cursor := func() []*openapi.Parameter {
+		return []*openapi.Parameter{
+			openapi.Integer("cursor", "上一页最后的编号").Min(0),
+			openapi.Integer("size", "每页数量,默认20").Range(1, 100),
+		}
+	}
+	// 需要会员访问令牌
+	member := func(op *openapi.Operation) *openapi.Operation {
+		op.Description = "需通过\"Authorization: Bearer\"头或access_token参数传入会员访问令牌"
+		return op
+	}
+	add := func(method string, path string, op *openapi.Operation) {
+		signed(d, method, op)
+		d.Add(method, path, op)
+	}
+
+	add("GET", "/get/invite_qr", openapi.NewOperation(mm, "下载邀请二维码").
+		Query(openapi.String("domain", "域名"),
+			openapi.Integer("member_id", "会员编号").Require().Min(1),
+			openapi.String("target_url", "目标跳转地址")).
+		Returns("image/jpeg", openapi.BinarySchema()))
+	add("GET", "/get/gen_qr", openapi.NewOperation(mm, "生成二维码").
+		Query(openapi.String("url", "二维码地址").Require()).
+		Returns("image/jpeg", openapi.BinarySchema()))
+	add("POST", "/mm_login", openapi.NewOperation(mm, "会员登录").
+		Form(openapi.String("usr", "用户名").Require(),
+			openapi.String("pwd", "密码").Require(),
+			openapi.String("device_id", "设备编号,为空时自动生成").Match(`^[\w\-]{0,64}$`),
+			openapi.String("device_name", "设备名称").Length(0, 40)).
+		Returns(openapi.JsonContentType, d.SchemaOf(dto.MemberLoginResult{})))
+	add("POST", "/mm_token/refresh", openapi.NewOperation(mm, "刷新访问令牌").
+		Form(openapi.String("refresh_token", "刷新令牌").Require()).
+		Returns(openapi.JsonContentType, d.SchemaOf(module.MemberSession{})))
+	add("GET", "/mm_devices", member(openapi.NewOperation(mm, "已登录的设备")).
+		Returns(openapi.JsonContentType, openapi.ArrayOf(d.SchemaOf(module.MemberDevice{}))))
+	add("POST", "/mm_devices/revoke", member(openapi.NewOperation(mm, "退出设备")).
+		Form(openapi.String("device_id", "设备编号,为空时退出当前设备")).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mm_order/extend_receive", member(openapi.NewOperation(mm, "延长收货时间")).
+		Form(openapi.String("order_no", "订单号").Require(),
+			openapi.Integer("sub", "是否为子订单,0为批发订单")).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mm_register", openapi.NewOperation(mm, "会员注册").
+		Form(openapi.String("usr", "用户名").Require().Length(4, 20),
+			openapi.String("pwd", "密码").Require().Length(6, 32),
+			openapi.String("phone", "手机号码").Match(`^\d{11}$`),
+			openapi.String("reg_from", "注册来源"),
+			openapi.String("invitation_code", "邀请码")).
+		Returns(openapi.JsonContentType, d.SchemaOf(gof.Message{})))
+	for _, p := range []string{"/merchant/get_ad", "/partner/get_ad"} {
+		add("POST", p, openapi.NewOperation(mch, "获取广告数据").
+			Form(openapi.String("ad_name", "广告名称").Require()).
+			Returns(openapi.JsonContentType, d.SchemaOf(ad.AdDto{})))
+	}
+
+	// 商户开放接口
+	add("GET", "/mch/item/list", openapi.NewOperation(open, "商品列表").
+		Query(cursor()...).
+		Returns(openapi.JsonContentType, pager(item.GoodsItem{})))
+	add("GET", "/mch/item/get", openapi.NewOperation(open, "获取商品及SKU").
+		Query(openapi.Integer("item_id", "商品编号").Require().Min(1)).
+		Returns(openapi.JsonContentType, d.SchemaOf(item.GoodsItem{})))
+	add("POST", "/mch/item/save", openapi.NewOperation(open, "保存商品").
+		Json(d.SchemaOf(item.GoodsItem{})).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/item/delete", openapi.NewOperation(open, "删除商品").
+		Form(openapi.Integer("item_id", "商品编号").Require().Min(1)).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/item/shelve", openapi.NewOperation(open, "商品上下架").
+		Form(openapi.Integer("item_id", "商品编号").Require().Min(1),
+			openapi.Integer("item_type", "商品类型"),
+			openapi.Integer("state", "上架状态").Require(),
+			openapi.String("remark", "备注").Length(0, 100)).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/item/sku_stock", openapi.NewOperation(open, "更新SKU库存").
+		Form(openapi.Integer("item_id", "商品编号").Require().Min(1),
+			openapi.Integer("sku_id", "SKU编号").Require().Min(1),
+			openapi.Integer("stock", "库存").Require().Min(0)).
+		Returns(openapi.JsonContentType, result))
+	add("GET", "/mch/order/list", openapi.NewOperation(open, "订单列表").
+		Query(append(cursor(), openapi.Integer("state", "订单状态"))...).
+		Returns(openapi.JsonContentType, pager(dto.PagedVendorOrder{})))
+	add("GET", "/mch/order/get", openapi.NewOperation(open, "订单详情").
+		Query(openapi.String("order_no", "订单号").Require()).
+		Returns(openapi.JsonContentType, openapi.ObjectOf(map[string]*openapi.Schema{
+			"order": d.SchemaOf(order.NormalSubOrder{}),
+			"items": openapi.ArrayOf(d.SchemaOf(dto.OrderItem{})),
+		})))
+	add("POST", "/mch/order/confirm", openapi.NewOperation(open, "确认订单").
+		Form(openapi.String("order_no", "订单号").Require()).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/order/pickup", openapi.NewOperation(open, "备货完成").
+		Form(openapi.String("order_no", "订单号").Require()).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/order/ship", openapi.NewOperation(open, "订单发货").
+		Form(openapi.String("order_no", "订单号").Require(),
+			openapi.Integer("sp_id", "快递服务商编号").Require().Min(1),
+			openapi.String("sp_order", "快递单号").Require().Length(1, 40)).
+		Returns(openapi.JsonContentType, result))
+	add("GET", "/mch/after_sales/list", openapi.NewOperation(open, "售后单列表").
+		Query(cursor()...).
+		Returns(openapi.JsonContentType, pager(dto.PagedVendorAfterSalesOrder{})))
+	add("GET", "/mch/after_sales/get", openapi.NewOperation(open, "售后单详情").
+		Query(openapi.Integer("id", "售后单编号").Require().Min(1)).
+		Returns(openapi.JsonContentType, d.SchemaOf(afterSales.History{})))
+	add("POST", "/mch/after_sales/agree", openapi.NewOperation(open, "同意售后").
+		Form(openapi.Integer("id", "售后单编号").Require().Min(1),
+			openapi.String("remark", "备注").Length(0, 100)).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/after_sales/decline", openapi.NewOperation(open, "拒绝售后").
+		Form(openapi.Integer("id", "售后单编号").Require().Min(1),
+			openapi.String("reason", "拒绝原因").Require().Length(1, 100)).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/after_sales/receive", openapi.NewOperation(open, "售后收货").
+		Form(openapi.Integer("id", "售后单编号").Require().Min(1)).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/after_sales/message", openapi.NewOperation(open, "售后留言").
+		Form(openapi.Integer("id", "售后单编号").Require().Min(1),
+			openapi.String("content", "留言内容").Require().Length(1, 512),
+			openapi.String("images", "图片地址,多张用\",\"分隔")).
+		Returns(openapi.JsonContentType, result))
+	add("GET", "/mch/order_timeout/list", openapi.NewOperation(open, "订单时效设置").
+		Returns(openapi.JsonContentType, openapi.ArrayOf(d.SchemaOf(merchant.OrderTimeout{}))))
+	add("POST", "/mch/order_timeout/save", openapi.NewOperation(open, "保存订单时效设置").
+		Form(openapi.Integer("order_type", "订单类型").Require().Min(1),
+			openapi.Integer("timeout_minute", "未支付自动取消的分钟数,0为使用销售设置").Min(0),
+			openapi.Integer("confirm_minute", "支付后自动确认的分钟数,0为立即确认,-1或不传为使用销售设置").Min(-1),
+			openapi.Integer("receive_hour", "发货后自动收货的小时数,0为使用销售设置").Min(0),
+			openapi.Integer("receive_extend_hour", "买家可延长收货的小时数,0为使用销售设置").Min(0)).
+		Returns(openapi.JsonContentType, result))
+	add("POST", "/mch/order_timeout/delete", openapi.NewOperation(open, "删除订单时效设置").
+		Form(openapi.Integer("order_type", "订单类型").Require().Min(1)).
+		Returns(openapi.JsonContentType, result))
+	add("GET", "/mch/api/usage", openapi.NewOperation(open, "接口调用统计").
+		Query(openapi.Integer("begin", "开始日期,如:20261001").Range(19700101, 99991231),
+			openapi.Integer("end", "结束日期,如:20261019").Range(19700101, 99991231)).
+		Returns(openapi.JsonContentType, openapi.ArrayOf(d.SchemaOf(merchant.ApiUsage{}))))
+	add("POST", "/mch/ws_ticket", openapi.NewOperation(open, "获取WebSocket连接凭据").
+		Returns(openapi.JsonContentType, openapi.ObjectOf(map[string]*openapi.Schema{
+			"ticket":     {Type: "string"},
+			"expires_in": {Type: "integer"},
+			"url":        {Type: "string"},
+		})))
+	// WebSocket使用令牌验证,无需签名
+	ws := openapi.NewOperation("realtime", "实时通知(WebSocket)").
+		Query(openapi.String("access_token", "会员访问令牌"),
+			openapi.String("ticket", "商户连接凭据").Length(32, 32)).
+		Response(http.StatusSwitchingProtocols, "连接成功,推送JSON格式的事件", "", nil).
+		Response(http.StatusUnauthorized, "令牌或凭据无效", openapi.JsonContentType,
+			d.SchemaOf(ApiError{}))
+	ws.Description = "会员传入访问令牌,商户传入通过接口获取的连接凭据"
+	d.Add("GET", wsPath, ws)
+	return d
+}
+
+// 添加签名参数及错误响应,GET请求的签名参数在查询字符串中,其他在表单中
+func signed(d *openapi.Document, method string, op *openapi.Operation) {
+	params := []*openapi.Parameter{
+		openapi.String("merchant_id", "商户接口编号").Require(),
+		openapi.Integer("timestamp", "时间戳(秒)").Require(),
+		openapi.String("nonce", "随机串").Require().Length(8, 64),
+		openapi.String("sign", "签名").Require(),
+	}
+	if method == "GET" || op.RequestBody != nil &&
+		op.RequestBody.Content[openapi.JsonContentType] != nil {
+		// JSON请求的签名参数同样在查询字符串中
+		op.Query(params...)
+	} else {
+		op.Form(params...)
+	}
+	e := d.SchemaOf(ApiError{})
+	op.Response(http.StatusBadRequest, "参数或业务错误", openapi.JsonContentType, e)
+	op.Response(http.StatusUnauthorized, "签名验证失败", openapi.JsonContentType, e)
+	op.Response(http.StatusTooManyRequests, "请求过于频繁", openapi.JsonContentType, e)
+}
+
+// 注册数据传输对象
+func registerDtoSchemas(d *openapi.Document) {
+	for _, v := range []interface{}{
+		dto.MessageResult{}, dto.SiteMessage{}, dto.GoodsComplex{},
+		dto.PagedMemberAfterSalesOrder{}, dto.PagedVendorAfterSalesOrder{},
+		dto.PagedShopFav{}, dto.PagedGoodsFav{}, dto.ListOnlineShop{},
+		dto.LoginMember{}, dto.MemberLoginResult{}, dto.SimpleMember{},
+		dto.InvitationMember{}, dto.MemberSummary{}, dto.PagedMemberSubOrder{},
+		dto.PagedVendorOrder{}, dto.OrderItem{}, dto.SimpleCoupon{},
+		dto.SettleDeliverMeta{}, dto.SettleMeta{}, dto.SettleShopMeta{},
+		dto.TextObject{}, dto.RankMember{},
+	} {
+		d.SchemaOf(v)
+	}
+}
@@ -0,0 +1,87 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : openapi_test.go
+ * author : jarryliu
+ * date : 2026-10-19 15:40
+ * description :
+ * history :
+ */
+package restapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// 测试所有接口均已在文档中定义
+func TestApiDocumentRoutes(t *testing.T) {
+	for _, r := range GetServe().Routes() {
+		if r.Path == "/" || r.Path == PathPrefix+docPath {
+			continue
+		}
+		if apiDoc.Find(r.Method, r.Path) == nil {
+			t.Error("接口未定义文档:", r.Method, r.Path)
+		}
+	}
+}
+
+// 测试按文档校验请求参数
+func TestApiDocumentValidate(t *testing.T) {
+	form := url.Values{
+		"merchant_id": []string{"1000000001"},
+		"timestamp":   []string{"1792400000"},
+		"nonce":       []string{"abcdefgh"},
+		"sign":        []string{"-"},
+		"item_id":     []string{"1"},
+		"sku_id":      []string{"x"},
+	}
+	r, _ := http.NewRequest("POST", PathPrefix+"/mch/item/sku_stock",
+		strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	op := apiDoc.Find(r.Method, r.URL.Path)
+	if op == nil {
+		t.Error("未找到接口定义")
+		t.FailNow()
+	}
+	errs := op.Validate(r)
+	if len(errs) != 2 || errs[0].Field != "sku_id" || errs[1].Field != "stock" {
+		t.Errorf("校验结果不正确:%#v", errs)
+	}
+}
+
+// 测试JSON请求同时校验查询参数及引用的数据结构
+func TestApiDocumentValidateJson(t *testing.T) {
+	q := url.Values{
+		"merchant_id": []string{"1000000001"},
+		"timestamp":   []string{"1792400000"},
+		"nonce":       []string{"abcdefgh"},
+	}
+	body := `{"ID":"x","CatId":1}`
+	r, _ := http.NewRequest("POST", PathPrefix+"/mch/item/save?"+q.Encode(),
+		strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	op := apiDoc.Find(r.Method, r.URL.Path)
+	if op == nil {
+		t.Error("未找到接口定义")
+		t.FailNow()
+	}
+	errs := op.Validate(r)
+	if len(errs) != 2 || errs[0].Field != "sign" || errs[1].Field != "ID" {
+		t.Errorf("校验结果不正确:%#v", errs)
+	}
+	// 校验后请求内容可再次读取
+	if data, _ := ioutil.ReadAll(r.Body); string(data) != body {
+		t.Error("请求内容未恢复:", string(data))
+	}
+
+	q.Set("sign", "-")
+	r, _ = http.NewRequest("POST", PathPrefix+"/mch/item/save?"+q.Encode(),
+		strings.NewReader(`{"ID":1,"CatId":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	if errs := op.Validate(r); len(errs) != 0 {
+		t.Errorf("校验结果不正确:%#v", errs)
+	}
+}
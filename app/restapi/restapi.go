@@ -9,14 +9,21 @@
 package restapi
 
 import (
+	"bytes"
 	"github.com/jsix/gof/storage"
 	"github.com/jsix/gof/util"
 	"github.com/labstack/echo"
 	"go2o/app/cache"
+	autil "go2o/app/util"
 	"go2o/core/domain/interface/merchant"
+	"go2o/core/infrastructure/domain"
 	"go2o/core/service/thrift"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // 获取存储
@@ -24,42 +31,81 @@ func GetStorage() storage.Interface {
 	return sto
 }
 
-// 获取传入的商户接口编号和密钥
-func getUserInfo(c echo.Context) (string, string) {
+// 获取传入的商户接口编号
+func getUserInfo(c echo.Context) string {
 	r := c.Request()
 	apiId := r.FormValue("merchant_id")
-	apiSecret := r.FormValue("secret")
-	if len(apiId) == 0 {
-		apiId = r.URL.Query().Get("merchant_id")
-	}
-
 	//todo: 兼容partner_id  ,将删除
 	if len(apiId) == 0 {
 		apiId = r.FormValue("partner_id")
-		if len(apiId) == 0 {
-			apiId = r.URL.Query().Get("partner_id")
-		}
 	}
+	return apiId
+}
 
-	if len(apiSecret) == 0 {
-		apiSecret = r.URL.Query().Get("secret")
+// 检查是否有权限调用接口(商户),需传入timestamp,nonce及sign
+func chkMerchantApiSecret(c echo.Context) error {
+	r := c.Request()
+	body, err := readSignBody(r)
+	if err != nil {
+		return domain.ErrApiSign
 	}
-	return apiId, apiSecret
+	mchId, err := CheckApiPermission(getUserInfo(c), r.Method, r.URL.Path, r.Form, body)
+	if err == nil {
+		c.Set("merchant_id", mchId)
+	}
+	return err
 }
 
-// 检查是否有权限调用接口(商户)
-func chkMerchantApiSecret(c echo.Context) bool {
-	i, s := getUserInfo(c)
-	ok, mchId := CheckApiPermission(i, s)
-	if ok {
-		c.Set("merchant_id", mchId)
+// 读取表单以外(如JSON)的请求内容用于签名,读取后重置以便后续处理
+func readSignBody(r *http.Request) ([]byte, error) {
+	ct := r.Header.Get("Content-Type")
+	if r.Body == nil || strings.HasPrefix(ct, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(ct, "multipart/form-data") {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, err
+}
+
+// 获取接口类别,GET请求为查询类,其他为操作类
+func getApiClass(method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return merchant.ApiClassRead
+	}
+	return merchant.ApiClassWrite
+}
+
+// 检查商户接口调用频率,并输出限流响应头
+func checkApiRateLimit(c echo.Context) bool {
+	mchId := getMerchantId(c)
+	apiInfo := cache.GetMerchantApiInfo(mchId)
+	class := getApiClass(c.Request().Method)
+	r := cache.TakeApiToken(apiInfo, class)
+	h := c.Response().Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(r.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.Itoa(r.Reset))
+	if !r.Allowed {
+		h.Set("Retry-After", strconv.Itoa(r.RetryAfter))
 	}
-	return ok
+	date, _ := strconv.Atoi(time.Now().Format("20060102"))
+	cache.AddApiUsage(mchId, class, date, !r.Allowed)
+	return r.Allowed
 }
 
-// 检查会员令牌信息
+// 检查会员令牌信息,优先验证访问令牌
 func checkMemberToken(c echo.Context) bool {
 	r := c.Request()
+	if token := autil.GetAccessToken(r); token != "" {
+		claims, err := autil.VerifyAccessToken(token)
+		if err == nil {
+			c.Set("member_id", claims.MemberId)
+			c.Set("device_id", claims.DeviceId)
+		}
+		return err == nil
+	}
 	memberId, _ := util.I64Err(strconv.Atoi(r.FormValue("member_id")))
 	token := r.FormValue("member_token")
 	cli, err := thrift.MemberServeClient()
@@ -73,6 +119,21 @@ func checkMemberToken(c echo.Context) bool {
 	return false
 }
 
+// 会员访问令牌验证失败
+func memberAuthFail(c echo.Context, err error) error {
+	e, _ := newApiError(err)
+	return c.JSON(http.StatusUnauthorized, e)
+}
+
+// 获取客户端IP
+func getRemoteIp(c echo.Context) string {
+	addr := c.Request().RemoteAddr
+	if i := strings.LastIndex(addr, ":"); i != -1 {
+		return addr[:i]
+	}
+	return addr
+}
+
 // 获取商户编号
 func getMerchantId(c echo.Context) int32 {
 	return c.Get("merchant_id").(int32)
@@ -87,14 +148,82 @@ func ApiTest(c echo.Context) error {
 	return c.String(http.StatusOK, "It's working!")
 }
 
-// 检查是否有权限
-func CheckApiPermission(apiId string, secret string) (bool, int32) {
-	if len(apiId) != 0 && len(secret) != 0 {
-		mchId := cache.GetMerchantIdByApiId(apiId)
-		var apiInfo *merchant.ApiInfo = cache.GetMerchantApiInfo(mchId)
-		if apiInfo != nil {
-			return apiInfo.ApiSecret == secret, mchId
-		}
+// 检查是否有权限,验证时间戳、签名及随机串,body为表单以外的请求内容
+func CheckApiPermission(apiId string, method string, path string,
+	form url.Values, body []byte) (int32, error) {
+	var mchId int32
+	if len(apiId) != 0 {
+		mchId = cache.GetMerchantIdByApiId(apiId)
+	}
+	if mchId <= 0 {
+		return 0, domain.ErrApiSign
+	}
+	var apiInfo *merchant.ApiInfo = cache.GetMerchantApiInfo(mchId)
+	if apiInfo == nil {
+		return 0, domain.ErrApiSign
+	}
+	if apiInfo.Enabled == 0 {
+		return 0, domain.ErrApiDisabled
+	}
+	timestamp := form.Get("timestamp")
+	nonce := form.Get("nonce")
+	if !domain.CheckApiTimestamp(timestamp, time.Now().Unix()) {
+		return 0, domain.ErrApiTimestamp
+	}
+	if !domain.CheckHmacApiSign(apiInfo.Secrets(), form.Get("sign"),
+		method, path, form, body, timestamp, nonce) {
+		return 0, domain.ErrApiSign
+	}
+	// 签名通过后再记录随机串,避免伪造请求占用
+	if !cache.CheckApiNonce(apiId, nonce) {
+		return 0, domain.ErrApiNonce
+	}
+	return mchId, nil
+}
+
+// 转换为接口错误,领域错误返回错误键,其他错误作为系统错误
+func newApiError(err error) (ApiError, bool) {
+	if de, ok := err.(*domain.DomainError); ok {
+		return ApiError{Code: de.Key, Message: de.Error()}, true
+	}
+	return ApiError{Code: "err_api_internal", Message: err.Error()}, false
+}
+
+// 输出错误
+func errorResult(c echo.Context, err error) error {
+	if domain.IsConflict(err) {
+		return c.JSON(http.StatusConflict, ApiError{
+			Code: "err_api_conflict", Message: err.Error()})
+	}
+	e, ok := newApiError(err)
+	if ok {
+		return c.JSON(http.StatusBadRequest, e)
+	}
+	return c.JSON(http.StatusInternalServerError, e)
+}
+
+// 输出操作结果
+func opResult(c echo.Context, id int64, err error) error {
+	if err != nil {
+		return errorResult(c, err)
+	}
+	return c.JSON(http.StatusOK, ApiResult{Result: true, Id: id})
+}
+
+// 获取整数参数
+func formInt64(c echo.Context, key string) int64 {
+	i, _ := strconv.ParseInt(c.Request().FormValue(key), 10, 64)
+	return i
+}
+
+// 获取分页数量,默认20条,最多100条
+func formPageSize(c echo.Context) int {
+	size := int(formInt64(c, "size"))
+	if size <= 0 {
+		return 20
+	}
+	if size > 100 {
+		return 100
 	}
-	return false, 0
+	return size
 }
@@ -13,6 +13,9 @@ import (
 	"github.com/jsix/gof/storage"
 	"github.com/labstack/echo"
 	mw "github.com/labstack/echo/middleware"
+	"go2o/app/openapi"
+	autil "go2o/app/util"
+	"go2o/core/infrastructure/domain"
 	"go2o/core/variable"
 	"log"
 	"net/http"
@@ -36,6 +39,7 @@ func newServe() *echo.Echo {
 	serve := echo.New()
 	serve.Use(mw.Recover())
 	serve.Use(beforeRequest())
+	serve.Use(openapi.Validator(apiDoc, fieldErrorResult))
 
 	//todo:  echo
 	//serve.Hook(splitPath) // 获取新的路径,在请求之前发生
@@ -51,6 +55,7 @@ func GetServe() *echo.Echo {
 func Run(app gof.App, port int) {
 	sto = app.Storage()
 	API_DOMAIN = app.Config().GetString(variable.ApiDomain)
+	go hub.run()
 	log.Println("** [ Go2o][ API][ Booted] - Api server running on port " +
 		strconv.Itoa(port))
 	http.ListenAndServe(":"+strconv.Itoa(port), serve)
@@ -60,15 +65,46 @@ func registerRoutes(s *echo.Echo) {
 	pc := &merchantC{}
 	mc := &MemberC{}
 	gc := &getC{}
+	oc := &mchOpenC{}
 
 	s.GET("/", ApiTest)
+	s.GET(PathPrefix+docPath, apiDoc.Handler)        // 接口文档
 	s.GET(PathPrefix+"/get/invite_qr", gc.Invite_qr) // 获取二维码
 	s.GET(PathPrefix+"/get/gen_qr", gc.GenQr)        //生成二维码
 	s.POST(PathPrefix+"/mm_login", mc.Login)         // 会员登录接口
 	s.POST(PathPrefix+"/mm_register", mc.Register)   // 会员注册接口
+	s.POST(PathPrefix+"/mm_token/refresh", mc.RefreshToken)
+	s.GET(PathPrefix+"/mm_devices", mc.Devices, autil.MemberJwtAuth(memberAuthFail))
+	s.POST(PathPrefix+"/mm_devices/revoke", mc.RevokeDevice, autil.MemberJwtAuth(memberAuthFail))
+	s.POST(PathPrefix+"/mm_order/extend_receive", mc.OrderExtendReceive, autil.MemberJwtAuth(memberAuthFail))
 	s.POST(PathPrefix+"/merchant/get_ad", pc.Get_ad) // 商户广告接口
 	s.POST(PathPrefix+"/partner/get_ad", pc.Get_ad)  // 商户广告接口
 	//s.Post("/member/*",mc)  // 会员接口
+
+	// 商户开放接口
+	s.GET(PathPrefix+"/mch/item/list", oc.ItemList)
+	s.GET(PathPrefix+"/mch/item/get", oc.ItemGet)
+	s.POST(PathPrefix+"/mch/item/save", oc.ItemSave)
+	s.POST(PathPrefix+"/mch/item/delete", oc.ItemDelete)
+	s.POST(PathPrefix+"/mch/item/shelve", oc.ItemShelve)
+	s.POST(PathPrefix+"/mch/item/sku_stock", oc.SkuStock)
+	s.GET(PathPrefix+"/mch/order/list", oc.OrderList)
+	s.GET(PathPrefix+"/mch/order/get", oc.OrderGet)
+	s.POST(PathPrefix+"/mch/order/confirm", oc.OrderConfirm)
+	s.POST(PathPrefix+"/mch/order/pickup", oc.OrderPickup)
+	s.POST(PathPrefix+"/mch/order/ship", oc.OrderShip)
+	s.GET(PathPrefix+"/mch/after_sales/list", oc.AfterSalesList)
+	s.GET(PathPrefix+"/mch/after_sales/get", oc.AfterSalesGet)
+	s.POST(PathPrefix+"/mch/after_sales/agree", oc.AfterSalesAgree)
+	s.POST(PathPrefix+"/mch/after_sales/decline", oc.AfterSalesDecline)
+	s.POST(PathPrefix+"/mch/after_sales/receive", oc.AfterSalesReceive)
+	s.POST(PathPrefix+"/mch/after_sales/message", oc.AfterSalesMessage)
+	s.GET(PathPrefix+"/mch/order_timeout/list", oc.OrderTimeoutList)
+	s.POST(PathPrefix+"/mch/order_timeout/save", oc.OrderTimeoutSave)
+	s.POST(PathPrefix+"/mch/order_timeout/delete", oc.OrderTimeoutDelete)
+	s.GET(PathPrefix+"/mch/api/usage", oc.ApiUsage)
+	s.POST(PathPrefix+"/mch/ws_ticket", oc.WsTicket)
+	s.GET(PathPrefix+wsPath, wsHandler) // 实时通知
 }
 
 func beforeRequest() echo.MiddlewareFunc {
@@ -81,11 +117,17 @@ func beforeRequest() echo.MiddlewareFunc {
 				return c.String(http.StatusNotFound, "no such file")
 			}
 
-			if path != "/" {
+			// 接口文档及WebSocket无需签名,WebSocket使用令牌验证
+			if path != "/" && path != PathPrefix+docPath && path != PathPrefix+wsPath {
 				//检查商户接口权限
 				c.Request().ParseForm()
-				if !chkMerchantApiSecret(c) {
-					return c.String(http.StatusOK, "{error:\"incorrent secret\"}")
+				if err := chkMerchantApiSecret(c); err != nil {
+					e, _ := newApiError(err)
+					return c.JSON(http.StatusUnauthorized, e)
+				}
+				if !checkApiRateLimit(c) {
+					e, _ := newApiError(domain.ErrApiRateLimit)
+					return c.JSON(http.StatusTooManyRequests, e)
 				}
 				//检查会员会话
 				if strings.HasPrefix(path, "/member") && !checkMemberToken(c) {
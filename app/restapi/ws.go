@@ -0,0 +1,241 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : ws.go
+ * author : jarryliu
+ * date : 2026-10-19 16:55
+ * description : WebSocket实时通知,会员使用访问令牌,商户使用连接凭据。
+ *   事件通过Redis发布订阅分发到各个实例,再推送给本实例的连接
+ * history :
+ */
+package restapi
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/jsix/gof/storage"
+	"github.com/labstack/echo"
+	"go2o/app/cache"
+	autil "go2o/app/util"
+	"go2o/core/infrastructure/push"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	wsPath = "/ws"
+	// 写入超时
+	wsWriteWait = 10 * time.Second
+	// 未收到PONG时断开
+	wsPongWait = 60 * time.Second
+	// 发送PING的间隔
+	wsPingPeriod = wsPongWait * 9 / 10
+	// 待发送消息的缓冲数量,超出时断开连接
+	wsSendBuffer = 64
+)
+
+var (
+	// 使用令牌验证,不依赖Cookie,允许跨域连接
+	wsUpgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     func(r *http.Request) bool { return true },
+	}
+	hub = newWsHub()
+)
+
+// 连接
+type wsClient struct {
+	conn     *websocket.Conn
+	memberId int64
+	mchId    int32
+	send     chan []byte
+}
+
+// 本实例的连接
+type wsHub struct {
+	mux       sync.RWMutex
+	members   map[int64]map[*wsClient]bool
+	merchants map[int32]map[*wsClient]bool
+}
+
+func newWsHub() *wsHub {
+	return &wsHub{
+		members:   make(map[int64]map[*wsClient]bool),
+		merchants: make(map[int32]map[*wsClient]bool),
+	}
+}
+
+func (h *wsHub) register(c *wsClient) {
+	h.mux.Lock()
+	if c.memberId > 0 {
+		if h.members[c.memberId] == nil {
+			h.members[c.memberId] = make(map[*wsClient]bool)
+		}
+		h.members[c.memberId][c] = true
+	} else {
+		if h.merchants[c.mchId] == nil {
+			h.merchants[c.mchId] = make(map[*wsClient]bool)
+		}
+		h.merchants[c.mchId][c] = true
+	}
+	h.mux.Unlock()
+}
+
+// 注销连接并关闭发送通道,与推送事件互斥
+func (h *wsHub) unregister(c *wsClient) {
+	h.mux.Lock()
+	if m := h.members[c.memberId]; m != nil && m[c] {
+		delete(m, c)
+		if len(m) == 0 {
+			delete(h.members, c.memberId)
+		}
+		close(c.send)
+	}
+	if m := h.merchants[c.mchId]; m != nil && m[c] {
+		delete(m, c)
+		if len(m) == 0 {
+			delete(h.merchants, c.mchId)
+		}
+		close(c.send)
+	}
+	h.mux.Unlock()
+}
+
+// 推送事件给接收的连接
+func (h *wsHub) dispatch(e *push.Event, raw []byte) {
+	var arr []*wsClient
+	h.mux.RLock()
+	switch {
+	case e.MemberId > 0:
+		for c := range h.members[e.MemberId] {
+			arr = append(arr, c)
+		}
+	case e.MchId > 0:
+		for c := range h.merchants[e.MchId] {
+			arr = append(arr, c)
+		}
+	default:
+		if e.Role != push.RoleMerchant {
+			for _, m := range h.members {
+				for c := range m {
+					arr = append(arr, c)
+				}
+			}
+		}
+		if e.Role != push.RoleMember {
+			for _, m := range h.merchants {
+				for c := range m {
+					arr = append(arr, c)
+				}
+			}
+		}
+	}
+	// 持有读锁时发送,避免与注销连接时关闭通道并发
+	for _, c := range arr {
+		select {
+		case c.send <- raw:
+		default:
+			// 客户端接收过慢,断开连接
+			c.conn.Close()
+		}
+	}
+	h.mux.RUnlock()
+}
+
+// 订阅事件,连接断开后重新订阅
+func (h *wsHub) run() {
+	for {
+		conn := sto.(storage.IRedisStorage).GetConn()
+		err := push.SubscribeEvents(conn, h.dispatch)
+		conn.Close()
+		log.Println("[ Go2o][ WS]: subscribe events error:", err)
+		time.Sleep(time.Second)
+	}
+}
+
+// 连接WebSocket,会员传入access_token,商户传入ticket
+func wsHandler(c echo.Context) error {
+	r := c.Request()
+	cli := &wsClient{send: make(chan []byte, wsSendBuffer)}
+	if ticket := r.FormValue("ticket"); ticket != "" {
+		cli.mchId = cache.TakeWsTicket(ticket)
+	} else if token := autil.GetAccessToken(r); token != "" {
+		if claims, err := autil.VerifyAccessToken(token); err == nil {
+			cli.memberId = claims.MemberId
+		}
+	}
+	if cli.memberId <= 0 && cli.mchId <= 0 {
+		return c.JSON(http.StatusUnauthorized, ApiError{
+			Code:    "err_ws_auth",
+			Message: "access token or ticket is invalid",
+		})
+	}
+	conn, err := wsUpgrader.Upgrade(c.Response(), r, nil)
+	if err != nil {
+		return nil
+	}
+	cli.conn = conn
+	hub.register(cli)
+	go cli.writePump()
+	cli.readPump()
+	return nil
+}
+
+// 读取客户端消息,仅用于维持连接
+func (w *wsClient) readPump() {
+	defer func() {
+		hub.unregister(w)
+		w.conn.Close()
+	}()
+	w.conn.SetReadLimit(512)
+	w.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	w.conn.SetPongHandler(func(string) error {
+		return w.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		if _, _, err := w.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// 发送事件及PING
+func (w *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		w.conn.Close()
+	}()
+	for {
+		select {
+		case b, ok := <-w.send:
+			w.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				w.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := w.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+				return
+			}
+		case <-ticker.C:
+			w.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := w.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// 获取WebSocket连接凭据,凭据60秒内有效且只能使用一次
+func (m *mchOpenC) WsTicket(c echo.Context) error {
+	ticket, err := cache.CreateWsTicket(getMerchantId(c))
+	if err != nil {
+		return errorResult(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"ticket":     ticket,
+		"expires_in": 60,
+		"url":        PathPrefix + wsPath + "?ticket=" + ticket,
+	})
+}
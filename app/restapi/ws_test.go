@@ -0,0 +1,57 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : ws_test.go
+ * author : jarryliu
+ * date : 2026-10-20 11:00
+ * description :
+ * history :
+ */
+package restapi
+
+import (
+	"go2o/core/infrastructure/push"
+	"sync"
+	"testing"
+)
+
+// 测试推送事件时并发注销连接,需使用-race运行
+func TestWsHubDispatchUnregister(t *testing.T) {
+	h := newWsHub()
+	var list []*wsClient
+	for i := 0; i < 50; i++ {
+		// 缓冲足够大,避免因接收过慢关闭连接
+		c := &wsClient{memberId: 1, send: make(chan []byte, 1000)}
+		if i%2 == 1 {
+			c.memberId, c.mchId = 0, 1
+		}
+		h.register(c)
+		list = append(list, c)
+	}
+	raw := []byte("{}")
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			h.dispatch(&push.Event{MemberId: 1}, raw)
+			h.dispatch(&push.Event{MchId: 1}, raw)
+			h.dispatch(&push.Event{Role: push.RoleAll}, raw)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for _, c := range list {
+			h.unregister(c)
+		}
+	}()
+	wg.Wait()
+	if len(h.members) != 0 || len(h.merchants) != 0 {
+		t.Error("连接未全部注销")
+	}
+	// 注销后通道已关闭,再次推送不应发送到已注销的连接
+	h.dispatch(&push.Event{Role: push.RoleAll}, raw)
+	for _, c := range list {
+		for range c.send {
+		}
+	}
+}
@@ -3,52 +3,88 @@
  * name : tcp_coder
  * author : jarryliu
  * date : 2015-11-23 18:59
- * description :
+ * description : V2协议的分包与解包
  * history :
  */
 package tcpserve
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/binary"
+	"errors"
+	"io"
 )
 
-// 分包与解包
-func encodeContent(content string) ([]byte, error) {
-	// 读取消息的长度
-	var length int32 = int32(len(content))
-	var pkg *bytes.Buffer = new(bytes.Buffer)
-	// 写入消息头
-	err := binary.Write(pkg, binary.LittleEndian, length)
-	if err != nil {
-		return nil, err
-	}
-	// 写入消息实体
-	err = binary.Write(pkg, binary.LittleEndian, []byte(content))
-	if err != nil {
-		return nil, err
-	}
-	return pkg.Bytes(), nil
+// 帧格式: 4字节长度(大端,不含自身) + 1字节类型 + 8字节消息编号 + 消息内容
+const (
+	frameLenSize  = 4
+	frameHeadSize = 9
+	// 帧的最大长度
+	maxFrameSize = 1 << 20
+)
+
+// 帧类型
+const (
+	// 商户验证,内容为:API_ID#TIMESTAMP#NONCE#SIGN#VERSION
+	FrameAuth byte = 1
+	// 会员验证,内容为访问令牌或:MEMBER_ID#TOKEN
+	FrameMemberAuth byte = 2
+	// 订阅主题,内容为以","分隔的主题
+	FrameSubscribe byte = 3
+	// 取消订阅
+	FrameUnsubscribe byte = 4
+	// 服务端推送,编号为消息编号,内容为JSON
+	FramePush byte = 5
+	// 客户端确认推送,编号为推送的消息编号
+	FrameAck  byte = 6
+	FramePing byte = 7
+	FramePong byte = 8
+	// 执行命令,内容与文本协议相同,如:MGET:SUMMARY:0
+	FrameCommand byte = 9
+	// 请求成功,编号为请求的编号
+	FrameReply byte = 10
+	// 请求失败,内容为错误信息
+	FrameError byte = 11
+)
+
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// 数据帧
+type Frame struct {
+	Type    byte
+	Id      uint64
+	Payload []byte
+}
+
+// 编码数据帧
+func encodeFrame(f *Frame) []byte {
+	b := make([]byte, frameLenSize+frameHeadSize+len(f.Payload))
+	binary.BigEndian.PutUint32(b, uint32(frameHeadSize+len(f.Payload)))
+	b[frameLenSize] = f.Type
+	binary.BigEndian.PutUint64(b[frameLenSize+1:], f.Id)
+	copy(b[frameLenSize+frameHeadSize:], f.Payload)
+	return b
 }
 
-func decodeContent(reader *bufio.Reader) (string, error) {
-	// 获取读取的内容长度
-	var length int
-	lenBytes, err := reader.Peek(4)
-	err = binary.Read(bytes.NewBuffer(lenBytes), binary.LittleEndian, &length)
-	if err != nil {
-		return "", err
+// 读取完整的数据帧
+func readFrame(r io.Reader) (*Frame, error) {
+	var lb [frameLenSize]byte
+	if _, err := io.ReadFull(r, lb[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(lb[:])
+	if length < frameHeadSize {
+		return nil, errors.New("invalid frame length")
 	}
-	// 判断是否是完整的包
-	if reader.Buffered() < 4+length {
-		return "", err
+	if length > maxFrameSize {
+		return nil, ErrFrameTooLarge
 	}
-	// 获取完整包的内容
-	var bytes []byte = make([]byte, 4+length)
-	_, err = reader.Read(bytes)
-	if err != nil {
-		return "", err
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
 	}
-	return string(bytes[4:]), nil
+	return &Frame{
+		Type:    b[0],
+		Id:      binary.BigEndian.Uint64(b[1:]),
+		Payload: b[frameHeadSize:],
+	}, nil
 }
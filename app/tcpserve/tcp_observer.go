@@ -13,7 +13,9 @@ import (
 	"github.com/garyburd/redigo/redis"
 	"github.com/jsix/gof/net/nc"
 	"go2o/core"
+	"go2o/core/infrastructure/push"
 	"go2o/core/variable"
+	"log"
 	"net"
 	"strconv"
 )
@@ -28,15 +30,31 @@ func AccountNotifyJob(s *nc.SocketServer) {
 		if err == nil {
 			id, err := strconv.Atoi(string(values[1].([]byte)))
 			if err == nil {
-				connList := s.GetConnections(int64(id))
-				if len(connList) > 0 {
-					go pushMemberAccount(s, connList, int64(id))
+				if s != nil {
+					connList := s.GetConnections(int64(id))
+					if len(connList) > 0 {
+						go pushMemberAccount(s, connList, int64(id))
+					}
+				}
+				if serveV2 != nil {
+					go publishMemberAccount(int64(id))
 				}
 			}
 		}
 	}
 }
 
+// 发布账户变动,由V2协议推送给订阅的客户端
+func publishMemberAccount(memberId int64) {
+	if acc := getMemberAccount(memberId, 0); acc != nil {
+		conn := core.GetRedisConn()
+		defer conn.Close()
+		if _, err := push.Publish(conn, memberId, push.TopicAccount, acc); err != nil {
+			log.Println("[ TCP][ V2]: publish account error:", err.Error())
+		}
+	}
+}
+
 // push member summary to tcp client
 func pushMemberAccount(s *nc.SocketServer, connList []net.Conn, memberId int64) {
 	s.Printf("[ TCP][ NOTIFY] - notify account update - %d", memberId)
@@ -12,9 +12,13 @@ import (
 	"errors"
 	"github.com/jsix/gof/net/nc"
 	"github.com/jsix/gof/util"
+	"go2o/app/cache"
+	autil "go2o/app/util"
+	"go2o/core/infrastructure/domain"
 	"go2o/core/service/rsi"
 	"go2o/core/service/thrift"
 	"net"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -68,55 +72,88 @@ func Handle(cmd string, handler nc.CmdFunc) {
 	handlers[cmd] = handler
 }
 
-// auth connection
+// auth connection, command like 'AUTH:API_ID#TIMESTAMP#NONCE#SIGN#VERSION',
+// SIGN is HMAC-SHA256 of method 'AUTH', empty path and param 'version'
 func connAuth(s *nc.SocketServer, conn net.Conn, line string) error {
 	if strings.HasPrefix(line, "AUTH:") {
-		arr := strings.Split(line[5:], "#") // AUTH:API_ID#SECRET#VERSION
-		if len(arr) == 3 {
+		arr := strings.Split(line[5:], "#")
+		if len(arr) == 5 {
 			var af nc.AuthFunc = func() (int64, error) {
-				mchId := rsi.MerchantService.GetMerchantIdByApiId(arr[0])
-				apiInfo := rsi.MerchantService.GetApiInfo(mchId)
-				if apiInfo != nil && apiInfo.ApiSecret == arr[1] {
-					if apiInfo.Enabled == 0 {
-						return int64(mchId), errors.New("api has exipres")
-					}
-				}
-				return int64(mchId), nil
+				return checkApiSign(arr[0], arr[1], arr[2], arr[3], arr[4])
 			}
 			if err := s.Auth(conn, af); err != nil {
 				return err
 			}
-			s.Printf("[ CLIENT] - Version = %s", arr[2])
+			s.Printf("[ CLIENT] - Version = %s", arr[4])
 			return nil
 		}
 	}
 	return errors.New("conn reject")
 }
 
-// member auth,command like 'MAUTH:1#3234234242342342'
+// 验证商户接口签名,返回商户编号
+func checkApiSign(apiId, timestamp, nonce, sign, version string) (int64, error) {
+	mchId := rsi.MerchantService.GetMerchantIdByApiId(apiId)
+	if mchId <= 0 {
+		return 0, domain.ErrApiSign
+	}
+	apiInfo := rsi.MerchantService.GetApiInfo(mchId)
+	if apiInfo == nil {
+		return 0, domain.ErrApiSign
+	}
+	if apiInfo.Enabled == 0 {
+		return int64(mchId), domain.ErrApiDisabled
+	}
+	if !domain.CheckApiTimestamp(timestamp, time.Now().Unix()) {
+		return int64(mchId), domain.ErrApiTimestamp
+	}
+	params := url.Values{"version": []string{version}}
+	if !domain.CheckHmacApiSign(apiInfo.Secrets(), sign, "AUTH", "",
+		params, nil, timestamp, nonce) {
+		return int64(mchId), domain.ErrApiSign
+	}
+	if !cache.CheckApiNonce(apiId, nonce) {
+		return int64(mchId), domain.ErrApiNonce
+	}
+	return int64(mchId), nil
+}
+
+// member auth,command like 'MAUTH:1#3234234242342342',
+// or 'MAUTH:ACCESS_TOKEN' with the access token of member
 func memberAuth(s *nc.SocketServer, id *nc.Client, param string) ([]byte, error) {
-	var err error
+	f := func() (int64, error) {
+		return checkMemberAuth(param)
+	}
+	if err := s.UAuth(id.Conn, f); err != nil {
+		return nil, err
+	}
+	//验证成功
+	return []byte("ok"), nil
+}
+
+// 验证会员令牌,返回会员编号
+func checkMemberAuth(param string) (int64, error) {
 	arr := strings.Split(param, "#")
+	if len(arr) == 1 {
+		claims, err := autil.VerifyAccessToken(arr[0])
+		if err != nil {
+			return 0, err
+		}
+		return claims.MemberId, nil
+	}
 	if len(arr) == 2 {
-		f := func() (int64, error) {
-			memberId, _ := util.I64Err(strconv.Atoi(arr[0]))
-			cli, err := thrift.MemberServeClient()
-			if err == nil {
-				defer cli.Transport.Close()
-				if b, _ := cli.CheckToken(memberId, arr[1]); b {
-					return memberId, nil
-				}
-				return memberId, errors.New("auth fail")
+		memberId, _ := util.I64Err(strconv.Atoi(arr[0]))
+		cli, err := thrift.MemberServeClient()
+		if err == nil {
+			defer cli.Transport.Close()
+			if b, _ := cli.CheckToken(memberId, arr[1]); b {
+				return memberId, nil
 			}
-			return memberId, errors.New("connect refused")
-		}
-
-		if err = s.UAuth(id.Conn, f); err == nil {
-			//验证成功
-			return []byte("ok"), nil
+			return memberId, errors.New("auth fail")
 		}
+		return memberId, errors.New("connect refused")
 	}
-	return nil, err
+	return 0, errors.New("auth fail")
 }
 
 // Handle command of client sending.
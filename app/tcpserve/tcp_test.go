@@ -10,11 +10,16 @@ package tcpserve
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"go2o/core/infrastructure/domain"
 	"io"
 	"log"
 	"net"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestConn(t *testing.T) {
@@ -34,7 +39,11 @@ func TestConn(t *testing.T) {
 
 	var buffer []byte = make([]byte, 6048)
 
-	cli.Write([]byte("AUTH:6000037440#0befdb52f387cc93#1.0\n"))
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 36)
+	sign := domain.HmacApiSign("0befdb52f387cc93", "AUTH", "",
+		url.Values{"version": []string{"1.0"}}, nil, ts, nonce)
+	cli.Write([]byte(fmt.Sprintf("AUTH:6000037440#%s#%s#%s#1.0\n", ts, nonce, sign)))
 	n, _ := cli.Read(buffer)
 	line := string(buffer[:n])
 	if line != "ok\n" {
@@ -64,3 +73,27 @@ func listenTcp(conn net.Conn) {
 		log.Println(line)
 	}
 }
+
+// 测试V2协议的编码与解码
+func TestFrameCoder(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	buf.Write(encodeFrame(&Frame{Type: FramePing, Id: 1}))
+	buf.Write(encodeFrame(&Frame{Type: FrameSubscribe, Id: 2, Payload: []byte("order,account")}))
+	f, err := readFrame(buf)
+	if err != nil || f.Type != FramePing || f.Id != 1 || len(f.Payload) != 0 {
+		t.Fatalf("decode ping frame failed: %#v, %v", f, err)
+	}
+	f, err = readFrame(buf)
+	if err != nil || f.Type != FrameSubscribe || f.Id != 2 || string(f.Payload) != "order,account" {
+		t.Fatalf("decode subscribe frame failed: %#v, %v", f, err)
+	}
+	if _, err = readFrame(buf); err != io.EOF {
+		t.Fatalf("expect EOF, got %v", err)
+	}
+	// 超出长度的帧
+	b := encodeFrame(&Frame{Type: FramePush})
+	b[0] = 0xff
+	if _, err = readFrame(bytes.NewReader(b)); err != ErrFrameTooLarge {
+		t.Fatalf("expect too large error, got %v", err)
+	}
+}
@@ -0,0 +1,358 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : tcp_v2.go
+ * author : jarryliu
+ * date : 2026-10-19 14:50
+ * description : V2二进制协议,支持主题订阅、推送确认及重连后重新投递
+ * history :
+ */
+package tcpserve
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"github.com/jsix/gof/net/nc"
+	"go2o/core"
+	"go2o/core/infrastructure/push"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// 写入超时时间
+	writeDeadLine = time.Second * 10
+	// 待发送数据帧的缓冲数量,超出时断开连接
+	sendBuffer = 64
+	// 可订阅的主题
+	topics = map[string]bool{
+		push.TopicOrder:   true,
+		push.TopicAccount: true,
+		push.TopicMessage: true,
+	}
+	errNotAuth       = errors.New("not auth")
+	errMemberNotAuth = errors.New("member not auth")
+	errConnClosed    = errors.New("connection closed")
+	errSendOverflow  = errors.New("send queue overflow")
+	// 已创建的V2服务
+	serveV2 *ServerV2
+)
+
+// V2协议服务
+type ServerV2 struct {
+	// 读取超时时间,客户端需在此时间内发送PING
+	ReadDeadLine time.Duration
+	output       bool
+	mux          sync.RWMutex
+	// 会员的连接
+	members map[int64]map[*connV2]bool
+}
+
+// 连接,数据帧写入发送队列,由单独的协程发送
+type connV2 struct {
+	conn     net.Conn
+	send     chan []byte
+	smux     sync.Mutex
+	closed   bool
+	mux      sync.RWMutex
+	mchId    int64
+	memberId int64
+	topics   map[string]bool
+}
+
+func NewServeV2(output bool) *ServerV2 {
+	serveV2 = &ServerV2{
+		ReadDeadLine: defaultReadDeadLine,
+		output:       output,
+		members:      make(map[int64]map[*connV2]bool),
+	}
+	return serveV2
+}
+
+func (s *ServerV2) printf(format string, args ...interface{}) {
+	if s.output {
+		log.Printf("[ TCP][ V2]"+format+"\n", args...)
+	}
+}
+
+// 监听地址并处理连接
+func (s *ServerV2) Listen(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			log.Println("[ TCP][ V2]: accept error:", err.Error())
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *ServerV2) serveConn(conn net.Conn) {
+	c := &connV2{
+		conn:   conn,
+		send:   make(chan []byte, sendBuffer),
+		topics: make(map[string]bool),
+	}
+	// 关闭发送队列后,由发送协程发送剩余的数据帧并关闭连接
+	defer func() {
+		s.detach(c)
+		c.close()
+	}()
+	go c.writeLoop()
+	s.printf("[ CONNECT] - %s", conn.RemoteAddr().String())
+	r := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(s.ReadDeadLine))
+		f, err := readFrame(r)
+		if err != nil {
+			s.printf("[ CLOSE] - %s : %s", conn.RemoteAddr().String(), err.Error())
+			return
+		}
+		if err = s.handle(c, f); err != nil {
+			c.write(&Frame{Type: FrameError, Id: f.Id, Payload: []byte(err.Error())})
+			// 未通过验证,关闭连接
+			if c.mchId <= 0 {
+				return
+			}
+		}
+	}
+}
+
+// 处理客户端的数据帧
+func (s *ServerV2) handle(c *connV2, f *Frame) error {
+	if f.Type == FramePing {
+		return c.write(&Frame{Type: FramePong, Id: f.Id})
+	}
+	if f.Type == FrameAuth {
+		arr := strings.Split(string(f.Payload), "#")
+		if len(arr) != 5 {
+			return errNotAuth
+		}
+		mchId, err := checkApiSign(arr[0], arr[1], arr[2], arr[3], arr[4])
+		if err != nil {
+			return err
+		}
+		c.mchId = mchId
+		s.printf("[ AUTH] - merchant %d, version %s", mchId, arr[4])
+		return c.reply(f)
+	}
+	if c.mchId <= 0 {
+		return errNotAuth
+	}
+	switch f.Type {
+	case FrameMemberAuth:
+		memberId, err := checkMemberAuth(string(f.Payload))
+		if err != nil {
+			return err
+		}
+		s.attach(c, memberId)
+		return c.reply(f)
+	case FrameSubscribe:
+		if c.memberId <= 0 {
+			return errMemberNotAuth
+		}
+		arr, err := c.subscribe(string(f.Payload), true)
+		if err == nil {
+			if err = c.reply(f); err == nil {
+				s.redeliver(c, arr)
+			}
+		}
+		return err
+	case FrameUnsubscribe:
+		if _, err := c.subscribe(string(f.Payload), false); err != nil {
+			return err
+		}
+		return c.reply(f)
+	case FrameAck:
+		if c.memberId <= 0 {
+			return errMemberNotAuth
+		}
+		conn := core.GetRedisConn()
+		defer conn.Close()
+		return push.Ack(conn, c.memberId, int64(f.Id))
+	case FrameCommand:
+		return s.command(c, f)
+	}
+	return errors.New("unknown frame type")
+}
+
+// 执行文本协议的命令
+func (s *ServerV2) command(c *connV2, f *Frame) error {
+	cmd := string(f.Payload)
+	i := strings.Index(cmd, ":")
+	if i == -1 {
+		return errors.New("unknown command:" + cmd)
+	}
+	mux.Lock()
+	h, ok := handlers[cmd[:i]]
+	mux.Unlock()
+	if !ok {
+		return errors.New("unknown command:" + cmd)
+	}
+	d, err := h(&nc.Client{
+		Conn:              c.conn,
+		Source:            c.mchId,
+		User:              c.memberId,
+		LatestConnectTime: time.Now(),
+	}, cmd[i+1:])
+	if err != nil {
+		return err
+	}
+	return c.write(&Frame{Type: FrameReply, Id: f.Id, Payload: d})
+}
+
+// 绑定会员
+func (s *ServerV2) attach(c *connV2, memberId int64) {
+	s.detach(c)
+	s.mux.Lock()
+	c.memberId = memberId
+	m, ok := s.members[memberId]
+	if !ok {
+		m = make(map[*connV2]bool)
+		s.members[memberId] = m
+	}
+	m[c] = true
+	s.mux.Unlock()
+	s.printf("[ MAUTH] - member %d", memberId)
+}
+
+func (s *ServerV2) detach(c *connV2) {
+	s.mux.Lock()
+	if m, ok := s.members[c.memberId]; ok {
+		delete(m, c)
+		if len(m) == 0 {
+			delete(s.members, c.memberId)
+		}
+	}
+	s.mux.Unlock()
+}
+
+// 重新投递未确认的消息
+func (s *ServerV2) redeliver(c *connV2, topics []string) {
+	conn := core.GetRedisConn()
+	list, err := push.Pending(conn, c.memberId)
+	conn.Close()
+	if err != nil {
+		log.Println("[ TCP][ V2]: get pending messages error:", err.Error())
+		return
+	}
+	for _, m := range list {
+		for _, t := range topics {
+			if m.Topic == t {
+				c.push(m)
+				break
+			}
+		}
+	}
+}
+
+// 推送消息给订阅了主题的会员连接,仅写入连接的发送队列,不阻塞订阅
+func (s *ServerV2) Deliver(m *push.Message) {
+	s.mux.RLock()
+	arr := make([]*connV2, 0, len(s.members[m.MemberId]))
+	for c := range s.members[m.MemberId] {
+		arr = append(arr, c)
+	}
+	s.mux.RUnlock()
+	for _, c := range arr {
+		if c.subscribed(m.Topic) {
+			c.push(m)
+		}
+	}
+}
+
+// 订阅推送的消息并推送给本实例的连接,连接断开后重新订阅
+func (s *ServerV2) PushJob() {
+	for {
+		conn := core.GetRedisConn()
+		err := push.Subscribe(conn, s.Deliver)
+		conn.Close()
+		log.Println("[ TCP][ V2]: subscribe push error:", err.Error())
+		time.Sleep(time.Second)
+	}
+}
+
+// 订阅或取消订阅主题,返回变动的主题
+func (c *connV2) subscribe(s string, sub bool) ([]string, error) {
+	var arr []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !topics[t] {
+			return nil, errors.New("unknown topic:" + t)
+		}
+		arr = append(arr, t)
+	}
+	c.mux.Lock()
+	for _, t := range arr {
+		if sub {
+			c.topics[t] = true
+		} else {
+			delete(c.topics, t)
+		}
+	}
+	c.mux.Unlock()
+	return arr, nil
+}
+
+func (c *connV2) subscribed(topic string) bool {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.topics[topic]
+}
+
+func (c *connV2) push(m *push.Message) error {
+	d, _ := json.Marshal(m)
+	return c.write(&Frame{Type: FramePush, Id: uint64(m.Id), Payload: d})
+}
+
+func (c *connV2) reply(f *Frame) error {
+	return c.write(&Frame{Type: FrameReply, Id: f.Id, Payload: []byte("ok")})
+}
+
+// 写入发送队列,队列已满时断开连接
+func (c *connV2) write(f *Frame) error {
+	c.smux.Lock()
+	defer c.smux.Unlock()
+	if c.closed {
+		return errConnClosed
+	}
+	select {
+	case c.send <- encodeFrame(f):
+		return nil
+	default:
+		// 客户端接收过慢,断开连接
+		c.conn.Close()
+		return errSendOverflow
+	}
+}
+
+// 关闭发送队列,与写入互斥
+func (c *connV2) close() {
+	c.smux.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+	c.smux.Unlock()
+}
+
+// 发送队列中的数据帧,发送出错或队列关闭后关闭连接
+func (c *connV2) writeLoop() {
+	defer c.conn.Close()
+	for b := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(writeDeadLine))
+		if _, err := c.conn.Write(b); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : member_token
+ * author : jarryliu
+ * date : 2026-10-19 17:00
+ * description : 会员访问令牌验证,无需调用服务
+ * history :
+ */
+package util
+
+import (
+	"github.com/labstack/echo"
+	"go2o/core/infrastructure/domain"
+	"go2o/core/module"
+	"net/http"
+	"strings"
+)
+
+// 获取请求中的会员访问令牌,优先使用"Authorization: Bearer"头
+func GetAccessToken(r *http.Request) string {
+	if s := r.Header.Get("Authorization"); strings.HasPrefix(s, "Bearer ") {
+		return strings.TrimSpace(s[7:])
+	}
+	return r.FormValue("access_token")
+}
+
+// 验证会员访问令牌
+func VerifyAccessToken(token string) (*domain.MemberClaims, error) {
+	md := module.Get(module.M_MM).(*module.MemberModule)
+	return md.VerifyAccessToken(token)
+}
+
+// 验证请求中的会员访问令牌,成功后设置member_id及device_id。
+// onFail为空时,未登录的请求也将继续处理
+func MemberJwtAuth(onFail func(c echo.Context, err error) error) echo.MiddlewareFunc {
+	return func(h echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var err error = domain.ErrJwtFormat
+			if token := GetAccessToken(c.Request()); token != "" {
+				var claims *domain.MemberClaims
+				if claims, err = VerifyAccessToken(token); err == nil {
+					c.Set("member_id", claims.MemberId)
+					c.Set("device_id", claims.DeviceId)
+				}
+			}
+			if err != nil && onFail != nil {
+				return onFail(c, err)
+			}
+			return h(c)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : openapi.go
+ * author : jarryliu
+ * date : 2026-10-19 15:10
+ * description : 接口文档,同时用于校验请求参数
+ * history :
+ */
+package hapi
+
+import (
+	"github.com/jsix/goex/echox"
+	"github.com/jsix/gof"
+	"github.com/labstack/echo"
+	"go2o/app/openapi"
+	"go2o/core/domain/interface/ad"
+	"go2o/core/domain/interface/member"
+	"net/http"
+)
+
+// 接口文档的路径
+const docPath = "/openapi.json"
+
+var apiDoc = newApiDocument()
+
+// 获取接口文档
+func GetApiDocument() *openapi.Document {
+	return apiDoc
+}
+
+// 输出接口文档
+func apiDocHandler(c *echox.Context) error {
+	return c.JSON(http.StatusOK, apiDoc)
+}
+
+// 输出参数错误
+func fieldErrorResult(c echo.Context, errs []*openapi.FieldError) error {
+	msg := gof.Message{Message: "请求参数不正确", Data: errs}
+	return c.JSONP(http.StatusOK, c.QueryParam("callback"), msg)
+}
+
+func newApiDocument() *openapi.Document {
+	d := openapi.NewDocument("Go2o HAPI", "1.0", "")
+	d.Info.Description = "供页面通过JSONP调用的接口,需登录的接口通过Cookie识别会员"
+	message := d.SchemaOf(gof.Message{})
+	callback := func() *openapi.Parameter {
+		return openapi.String("callback", "JSONP回调函数").Match(`^[\w\.]*$`)
+	}
+	js := "application/javascript"
+
+	d.Add("GET", "/api_info", openapi.NewOperation("main", "接口信息").
+		Returns("text/plain", &openapi.Schema{Type: "string"}))
+	d.Add("GET", "/test", openapi.NewOperation("main", "测试接口").
+		Query(callback()).
+		Returns(js, message))
+	d.Add("GET", "/request_login", openapi.NewOperation("main", "跳转到登录").
+		Query(openapi.String("return_url", "登录后返回的地址")).
+		Response(http.StatusFound, "跳转到登录页面", "", nil))
+	d.Add("GET", "/r/uc", openapi.NewOperation("main", "跳转到会员中心").
+		Query(openapi.String("url", "会员中心的页面地址")).
+		Response(http.StatusFound, "跳转到会员中心", "", nil))
+	d.Add("GET", "/user/sync_m.p", openapi.NewOperation("main", "同步登录及登出").
+		Query(openapi.Boolean("out", "是否登出"),
+			callback(),
+			openapi.String("device", "访问设备")).
+		Returns(js, message))
+	d.Add("GET", "/gad_api", openapi.NewOperation("present", "获取广告").
+		Query(openapi.String("keys", "广告名称,多个用\"|\"分隔").Require(),
+			openapi.Integer("user_id", "广告用户编号").Min(0),
+			callback()).
+		Returns(js, &openapi.Schema{Type: "object",
+			AdditionalProperties: d.SchemaOf(ad.AdDto{})}))
+
+	// 按方法名称自动路由的服务
+	service := func(tag string, desc string) *openapi.Operation {
+		op := openapi.NewOperation(tag, "服务").Path(
+			openapi.String("action", desc).Require()).
+			Query(callback())
+		op.Description = desc
+		return op
+	}
+	svcDesc := "Device:切换设备;LoginState:登录状态;Favorite:收藏;QrCode:二维码"
+	for _, p := range []string{"/service/{action}", "/!s/{action}"} {
+		d.Add("GET", p, service("service", svcDesc).
+			Query(openapi.String("device", "设备(Device)"),
+				openapi.String("app", "应用(Device)"),
+				openapi.String("type", "收藏类型,shop或goods(Favorite)"),
+				openapi.Integer("id", "收藏的店铺或商品编号(Favorite)").Min(0),
+				openapi.String("url", "二维码地址(QrCode)")).
+			Returns(js, message))
+	}
+	d.Add("GET", "/!sp/{action}", service("shopping", "AddressList:收货地址列表").
+		Returns(js, openapi.ArrayOf(d.SchemaOf(member.Address{}))))
+	return d
+}
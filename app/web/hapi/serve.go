@@ -12,6 +12,7 @@ import (
 	"github.com/jsix/goex/echox"
 	"github.com/jsix/gof"
 	"github.com/labstack/echo"
+	"go2o/app/openapi"
 	"go2o/app/web/shared"
 	"net/http"
 )
@@ -21,6 +22,7 @@ func NewServe() *echox.Echo {
 	//s.Use(mw.Recover())
 	// s.Use(echox.StopAttack)
 	s.Use(beforeHanding)
+	s.Use(openapi.Validator(apiDoc, fieldErrorResult))
 	registerRoutes(s)
 	return s
 }
@@ -32,6 +34,7 @@ func registerRoutes(s *echox.Echo) {
 	sc := &serviceC{app}
 	pc := &presentC{}
 	s.GET("/api_info", mc.Info)
+	s.GET(docPath, apiDocHandler) // 接口文档
 	s.GET("/test", mc.Test)
 	s.GET("/request_login", mc.RequestLogin)
 	s.GET("/r/uc", mc.RedirectUc)
@@ -31,6 +31,12 @@ func GetMemberId(c *echox.Context) int64 {
 	if v != nil {
 		return v.(int64)
 	}
+	// 未登录时使用访问令牌
+	if token := util.GetAccessToken(c.Request()); token != "" {
+		if claims, err := util.VerifyAccessToken(token); err == nil {
+			return claims.MemberId
+		}
+	}
 	return 0
 }
 
@@ -0,0 +1,65 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : go2o-grpc.go
+ * author : jarryliu
+ * date : 2026-10-19 11:50
+ * description :
+ * history :
+ */
+package main
+
+import (
+	"flag"
+	"github.com/jsix/gof"
+	"github.com/jsix/gof/log"
+	"go2o/app"
+	"go2o/core"
+	"go2o/core/service/grpc"
+	"go2o/core/service/rsi"
+	"go2o/core/variable"
+	"os"
+)
+
+func main() {
+	var (
+		addr  string
+		conf  string
+		cert  string
+		key   string
+		token string
+		debug bool
+		trace bool
+	)
+
+	flag.StringVar(&addr, "addr", "localhost:14289", "Address to listen to")
+	flag.StringVar(&conf, "conf", "app.conf", "Config file path")
+	flag.StringVar(&cert, "cert", "", "TLS certificate file")
+	flag.StringVar(&key, "key", "", "TLS private key file")
+	flag.StringVar(&token, "token", "", "Access token, default read from config")
+	flag.BoolVar(&debug, "debug", false, "Enable debug")
+	flag.BoolVar(&trace, "trace", false, "Enable trace")
+	flag.Parse()
+
+	newApp := core.NewApp(conf)
+	if !core.Init(newApp, debug, trace) {
+		os.Exit(1)
+	}
+	gof.CurrentApp = newApp
+	rsi.Init(newApp, app.FlagRpcServe)
+
+	if token == "" {
+		token = newApp.Config().GetString(variable.GrpcToken)
+	}
+	if token == "" {
+		log.Println("[ Grpc][ Warning]: no access token, requests will not be authenticated")
+	}
+	err := grpc.ListenAndServe(&grpc.Options{
+		Addr:     addr,
+		CertFile: cert,
+		KeyFile:  key,
+		Token:    token,
+	})
+	if err != nil {
+		log.Println("error running ", addr, " :", err.Error())
+	}
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : go2o-migrate.go
+ * author : jarryliu
+ * date : 2026-10-20 16:50
+ * description : 数据库结构迁移
+ *   go2o-migrate -conf=app.conf up       执行全部未执行的迁移
+ *   go2o-migrate -n=1 up                 执行一个迁移
+ *   go2o-migrate down                    回滚最近的一个迁移
+ *   go2o-migrate status                  查看迁移状态
+ *   go2o-migrate baseline 13             将版本13及之前的迁移标记为已执行
+ * history :
+ */
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go2o/core"
+	"go2o/core/infrastructure/migrate"
+	_ "go2o/core/migration"
+	"os"
+	"strconv"
+	"time"
+)
+
+func main() {
+	var (
+		conf  string
+		steps int
+	)
+	flag.StringVar(&conf, "conf", "app.conf", "Config file path")
+	flag.IntVar(&steps, "n", 0, "Number of migrations to apply or revert")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: go2o-migrate [options] up|down|status|baseline <version>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	conn := core.NewApp(conf).Db()
+	var list []*migrate.Migration
+	var err error
+	switch flag.Arg(0) {
+	case "up":
+		list, err = migrate.Up(conn, steps)
+		printMigrations("applied", list)
+	case "down":
+		list, err = migrate.Down(conn, steps)
+		printMigrations("reverted", list)
+	case "baseline":
+		version, _ := strconv.Atoi(flag.Arg(1))
+		if version <= 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		list, err = migrate.Baseline(conn, version)
+		printMigrations("baseline", list)
+	case "status":
+		var arr []*migrate.Status
+		arr, err = migrate.Statuses(conn)
+		for _, s := range arr {
+			state := "pending"
+			if s.Applied {
+				state = time.Unix(s.ApplyTime, 0).Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state += " (modified)"
+			}
+			fmt.Printf("%04d  %-24s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err.Error())
+		os.Exit(1)
+	}
+}
+
+func printMigrations(action string, list []*migrate.Migration) {
+	for _, m := range list {
+		fmt.Printf("%s %04d_%s\n", action, m.Version, m.Name)
+	}
+}
@@ -12,9 +12,11 @@ import (
 	"flag"
 	"github.com/jsix/gof"
 	"github.com/jsix/gof/log"
+	"go2o/app"
 	"go2o/core"
 	"go2o/core/service/rsi"
 	"go2o/core/service/thrift"
+	"net/http"
 	"os"
 )
 
@@ -24,12 +26,23 @@ func main() {
 		conf  string
 		debug bool
 		trace bool
+		rate  int
+		opt   = thrift.DefaultOptions("")
+		stat  string
 	)
 
 	flag.StringVar(&addr, "addr", "localhost:14288", "Address to listen to")
 	flag.StringVar(&conf, "conf", "app.conf", "Config file path")
 	flag.BoolVar(&debug, "debug", false, "Enable debug")
 	flag.BoolVar(&trace, "trace", false, "Enable trace")
+	flag.IntVar(&rate, "rate", 0, "Requests per minute of each service, 0 is unlimited")
+	flag.StringVar(&opt.CertFile, "cert", "", "TLS certificate file")
+	flag.StringVar(&opt.KeyFile, "key", "", "TLS private key file")
+	flag.StringVar(&opt.ClientCAFile, "ca", "", "CA file to verify client certificates")
+	flag.IntVar(&opt.Workers, "workers", opt.Workers, "Max concurrent connections")
+	flag.DurationVar(&opt.CallTimeout, "timeout", opt.CallTimeout, "Timeout of each call, 0 is unlimited")
+	flag.DurationVar(&opt.ShutdownTimeout, "shutdown", opt.ShutdownTimeout, "Time to wait calls finish on shutdown")
+	flag.StringVar(&stat, "stat", "", "Address to serve call stats, e.g. localhost:14290")
 	flag.Parse()
 
 	newApp := core.NewApp(conf)
@@ -37,9 +50,15 @@ func main() {
 		os.Exit(1)
 	}
 	gof.CurrentApp = newApp
-	rsi.Init(newApp)
+	rsi.Init(newApp, app.FlagRpcServe)
 
-	err := thrift.ListenAndServe(addr, false)
+	thrift.RateLimit = rate
+	if stat != "" {
+		http.HandleFunc("/stats", thrift.StatsHandler)
+		go http.ListenAndServe(stat, nil)
+	}
+	opt.Addr = addr
+	err := thrift.ListenAndServe(opt)
 	if err != nil {
 		log.Println("error running ", addr, " :", err.Error())
 	}
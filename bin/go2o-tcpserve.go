@@ -0,0 +1,62 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : go2o-tcpserve.go
+ * author : jarryliu
+ * date : 2026-10-20 10:10
+ * description : TCP服务,同时提供V1文本协议及V2二进制协议
+ * history :
+ */
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jsix/gof"
+	"go2o/app"
+	"go2o/app/tcpserve"
+	"go2o/core"
+	"go2o/core/service/rsi"
+	"log"
+	"os"
+)
+
+func main() {
+	var (
+		port   int
+		portV2 int
+		conf   string
+		debug  bool
+		trace  bool
+	)
+	flag.IntVar(&port, "port", 14197, "V1 protocol port")
+	flag.IntVar(&portV2, "port2", 14198, "V2 protocol port, 0 is disabled")
+	flag.StringVar(&conf, "conf", "app.conf", "Config file path")
+	flag.BoolVar(&debug, "debug", false, "Enable debug")
+	flag.BoolVar(&trace, "trace", false, "Enable trace")
+	flag.Parse()
+
+	newApp := core.NewApp(conf)
+	if !core.Init(newApp, debug, trace) {
+		os.Exit(1)
+	}
+	gof.CurrentApp = newApp
+	rsi.Init(newApp, app.FlagTcpServe)
+
+	s := tcpserve.NewServe(debug)
+	if portV2 > 0 {
+		// V2协议的推送需在各实例订阅,由持有会员连接的实例推送
+		s2 := tcpserve.NewServeV2(debug)
+		go s2.PushJob()
+		go func() {
+			if err := s2.Listen(fmt.Sprintf(":%d", portV2)); err != nil {
+				log.Fatalln("[ TCP][ V2]: listen error:", err.Error())
+			}
+		}()
+	}
+	go tcpserve.MemberSummaryNotifyJob(s)
+	go tcpserve.AccountNotifyJob(s)
+	if err := s.Listen(fmt.Sprintf(":%d", port)); err != nil {
+		log.Println("[ TCP]: listen error:", err.Error())
+		os.Exit(1)
+	}
+}
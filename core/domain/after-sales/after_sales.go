@@ -9,13 +9,17 @@
 package afterSales
 
 import (
+	"database/sql"
 	"errors"
+	"github.com/jsix/gof/db"
 	"github.com/jsix/gof/db/orm"
 	"go2o/core/domain/interface/after-sales"
+	"go2o/core/domain/interface/events"
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/order"
 	"go2o/core/domain/interface/payment"
-	"go2o/core/domain/tmp"
+	"go2o/core/infrastructure/outbox"
+	"go2o/core/infrastructure/tx"
 	"strings"
 	"time"
 )
@@ -23,17 +27,20 @@ import (
 var _ afterSales.IAfterSalesOrder = new(afterSalesOrderImpl)
 
 type afterSalesOrderImpl struct {
+	conn        db.Connector
 	value       *afterSales.AfterSalesOrder
 	rep         afterSales.IAfterSalesRepo
 	order       order.ISubOrder
 	orderRepo   order.IOrderRepo
 	paymentRepo payment.IPaymentRepo
+	// 已保存的状态
+	savedState int
 }
 
-func NewAfterSalesOrder(v *afterSales.AfterSalesOrder,
+func NewAfterSalesOrder(conn db.Connector, v *afterSales.AfterSalesOrder,
 	rep afterSales.IAfterSalesRepo, orderRepo order.IOrderRepo,
 	memberRepo member.IMemberRepo, paymentRepo payment.IPaymentRepo) afterSales.IAfterSalesOrder {
-	as := newAfterSalesOrder(v, rep, orderRepo, paymentRepo)
+	as := newAfterSalesOrder(conn, v, rep, orderRepo, paymentRepo)
 	switch v.Type {
 	case afterSales.TypeReturn:
 		return newReturnOrderImpl(as, memberRepo, paymentRepo)
@@ -45,14 +52,16 @@ func NewAfterSalesOrder(v *afterSales.AfterSalesOrder,
 	panic(errors.New("不支持的售后单类型"))
 }
 
-func newAfterSalesOrder(v *afterSales.AfterSalesOrder,
+func newAfterSalesOrder(conn db.Connector, v *afterSales.AfterSalesOrder,
 	rep afterSales.IAfterSalesRepo, orderRepo order.IOrderRepo,
 	paymentRepo payment.IPaymentRepo) *afterSalesOrderImpl {
 	return &afterSalesOrderImpl{
+		conn:        conn,
 		value:       v,
 		rep:         rep,
 		orderRepo:   orderRepo,
 		paymentRepo: paymentRepo,
+		savedState:  v.State,
 	}
 }
 
@@ -74,9 +83,32 @@ func (a *afterSalesOrderImpl) saveAfterSalesOrder() error {
 		panic(errors.New("售后单缺少商品"))
 	}
 	a.value.UpdateTime = time.Now().Unix()
-	id, err := orm.I32(orm.Save(tmp.Db().GetOrm(), a.value, int(a.GetDomainId())))
+	if a.value.State == a.savedState {
+		id, err := orm.I32(orm.Save(a.conn.GetOrm(), a.value, int(a.GetDomainId())))
+		if err == nil {
+			a.value.Id = id
+		}
+		return err
+	}
+	// 状态变更时,记录变更时间并在同一事务中记录事件
+	a.value.StateTime = a.value.UpdateTime
+	err := tx.Do(a.conn, func(t *sql.Tx) error {
+		_, err := tx.Save(t, a.value)
+		if err == nil {
+			err = outbox.Append(t, &events.AfterSalesStateChanged{
+				Id:             a.value.Id,
+				OrderId:        a.value.OrderId,
+				VendorId:       a.value.VendorId,
+				BuyerId:        a.value.BuyerId,
+				AfterSalesType: a.value.Type,
+				FromState:      a.savedState,
+				State:          a.value.State,
+			})
+		}
+		return err
+	})
 	if err == nil {
-		a.value.Id = id
+		a.savedState = a.value.State
 	}
 	return err
 }
@@ -272,3 +304,89 @@ func (a *afterSalesOrderImpl) Process() error {
 	a.value.State = afterSales.StatCompleted
 	return a.saveAfterSalesOrder()
 }
+
+// 留言,可附带多张图片凭证
+func (a *afterSalesOrderImpl) PostMessage(role int, senderId int64,
+	content string, images []string) (int32, error) {
+	switch a.value.State {
+	case afterSales.StatCompleted, afterSales.StatCancelled,
+		afterSales.StateRejected:
+		return 0, afterSales.ErrMessageClosed
+	}
+	switch role {
+	case afterSales.RoleBuyer:
+		if senderId != a.value.BuyerId {
+			return 0, afterSales.ErrMessageRole
+		}
+	case afterSales.RoleVendor:
+		if senderId != int64(a.value.VendorId) {
+			return 0, afterSales.ErrMessageRole
+		}
+	case afterSales.RolePlatform:
+	default:
+		return 0, afterSales.ErrMessageRole
+	}
+	imgArr := []string{}
+	for _, v := range images {
+		if v = strings.TrimSpace(v); v != "" {
+			imgArr = append(imgArr, v)
+		}
+	}
+	if len(imgArr) > afterSales.MaxMessageImages {
+		return 0, afterSales.ErrMessageImages
+	}
+	content = strings.TrimSpace(content)
+	if content == "" && len(imgArr) == 0 {
+		return 0, afterSales.ErrMessageContent
+	}
+	return a.rep.SaveMessage(&afterSales.Message{
+		AfterSalesId: a.GetDomainId(),
+		SenderRole:   role,
+		SenderId:     senderId,
+		Content:      content,
+		Images:       strings.Join(imgArr, ","),
+		CreateTime:   time.Now().Unix(),
+	})
+}
+
+// 获取留言记录
+func (a *afterSalesOrderImpl) Messages() []*afterSales.Message {
+	return a.rep.GetMessages(a.GetDomainId())
+}
+
+// 仲裁,支持买家则进入确认后的流程,支持商户则退回售后单
+func (a *afterSalesOrderImpl) Arbitrate(v *afterSales.Arbitration) error {
+	if a.value.State != afterSales.StatIntercede {
+		return afterSales.ErrNotIntercede
+	}
+	if v.ArbiterId <= 0 {
+		return afterSales.ErrNoArbiter
+	}
+	if v.Amount < 0 {
+		return afterSales.ErrArbitrateAmount
+	}
+	var err error
+	switch v.Outcome {
+	case afterSales.ArbitrateForBuyer:
+		a.value.State = afterSales.StatAwaitingConfirm
+		err = a.Confirm()
+	case afterSales.ArbitrateForVendor:
+		a.value.Remark = v.Remark
+		a.value.State = afterSales.StateRejected
+		err = a.saveAfterSalesOrder()
+	default:
+		return afterSales.ErrArbitrateOutcome
+	}
+	if err == nil {
+		v.Id = 0
+		v.AfterSalesId = a.GetDomainId()
+		v.CreateTime = time.Now().Unix()
+		v.Id, err = a.rep.SaveArbitration(v)
+	}
+	return err
+}
+
+// 获取仲裁记录,未仲裁返回nil
+func (a *afterSalesOrderImpl) GetArbitration() *afterSales.Arbitration {
+	return a.rep.GetArbitration(a.GetDomainId())
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/jsix/gof/db/orm"
 	"go2o/core/domain/interface/after-sales"
 	"go2o/core/domain/interface/order"
-	"go2o/core/domain/tmp"
 	"time"
 )
 
@@ -42,7 +41,7 @@ func (e *exchangeOrderImpl) getValue() *afterSales.ExchangeOrder {
 			panic(errors.New("换货单还未提交"))
 		}
 		v := &afterSales.ExchangeOrder{}
-		if tmp.Db().GetOrm().Get(e.GetDomainId(), v) != nil {
+		if e.conn.GetOrm().Get(e.GetDomainId(), v) != nil {
 			panic(errors.New("换货单不存在"))
 		}
 		e.refValue = v
@@ -83,14 +82,14 @@ func (e *exchangeOrderImpl) Submit() (int32, error) {
 			ShipTime:    0,
 			ReceiveTime: 0,
 		}
-		_, err = orm.Save(tmp.Db().GetOrm(), e.refValue, 0)
+		_, err = orm.Save(e.conn.GetOrm(), e.refValue, 0)
 	}
 	return id, err
 }
 
 // 保存换货单
 func (e *exchangeOrderImpl) saveExchangeOrder(v *afterSales.ExchangeOrder) error {
-	_, err := orm.Save(tmp.Db().GetOrm(), v, int(v.Id))
+	_, err := orm.Save(e.conn.GetOrm(), v, int(v.Id))
 	return err
 }
 
@@ -15,7 +15,6 @@ import (
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/order"
 	"go2o/core/domain/interface/payment"
-	"go2o/core/domain/tmp"
 	"math"
 )
 
@@ -46,7 +45,7 @@ func (r *refundOrderImpl) getValue() *afterSales.RefundOrder {
 			panic(errors.New("退款单还未提交"))
 		}
 		v := &afterSales.RefundOrder{}
-		if tmp.Db().GetOrm().Get(r.GetDomainId(), v) != nil {
+		if r.conn.GetOrm().Get(r.GetDomainId(), v) != nil {
 			panic(errors.New("退款单不存在"))
 		}
 		r.refValue = v
@@ -64,7 +63,7 @@ func (r *refundOrderImpl) Value() afterSales.AfterSalesOrder {
 
 // 保存
 func (r *refundOrderImpl) saveRefundOrder() error {
-	_, err := orm.Save(tmp.Db().GetOrm(), r.refValue, int(r.GetDomainId()))
+	_, err := orm.Save(r.conn.GetOrm(), r.refValue, int(r.GetDomainId()))
 	return err
 }
 
@@ -123,7 +122,7 @@ func (r *refundOrderImpl) submitRefundOrder() (err error) {
 	if r.refValue.Amount <= 0 || math.IsNaN(float64(r.refValue.Amount)) {
 		return afterSales.ErrOrderAmount
 	}
-	_, err = orm.Save(tmp.Db().GetOrm(), r.refValue, 0)
+	_, err = orm.Save(r.conn.GetOrm(), r.refValue, 0)
 	return err
 }
 
@@ -147,6 +146,24 @@ func (r *refundOrderImpl) Reject(remark string) error {
 	return err
 }
 
+// 仲裁,支持商户时撤销退货数量,支持买家时可调整退款金额
+func (r *refundOrderImpl) Arbitrate(v *afterSales.Arbitration) error {
+	if v.Amount > r.getValue().Amount {
+		return afterSales.ErrArbitrateAmount
+	}
+	err := r.afterSalesOrderImpl.Arbitrate(v)
+	if err == nil {
+		if v.Outcome == afterSales.ArbitrateForVendor {
+			// 撤销退货数量
+			err = r.GetOrder().RevertReturn(r.value.SnapshotId, r.value.Quantity)
+		} else if v.Amount > 0 {
+			r.refValue.Amount = v.Amount
+			err = r.saveRefundOrder()
+		}
+	}
+	return err
+}
+
 // 完成退款
 func (r *refundOrderImpl) Process() error {
 	err := r.afterSalesOrderImpl.Process()
@@ -167,6 +184,10 @@ func (r *refundOrderImpl) handleReturn() error {
 	if err == nil {
 		err = r.backAmount(v.Amount)
 	}
+	if err == nil {
+		// 撤销分销佣金
+		err = r.GetOrder().ReverseCommission(r.value.SnapshotId, v.Amount)
+	}
 	return err
 }
 
@@ -0,0 +1,38 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : timeout
+ * author : jarryliu
+ * date : 2026-10-18 15:30
+ * description :
+ * history :
+ */
+package afterSales
+
+import (
+	"go2o/core/domain/interface/after-sales"
+)
+
+// 处理超时的售后单,返回执行的超时操作,未超时返回0。
+// 通过接口调用,以便退款单、退货单执行各自的取消逻辑
+func HandleTimeout(o afterSales.IAfterSalesOrder, sla afterSales.SLA,
+	unix int64) (int, error) {
+	v := o.Value()
+	action, deadline := sla.Deadline(&v)
+	if action == 0 || deadline > unix {
+		return 0, nil
+	}
+	var err error
+	switch action {
+	case afterSales.TimeoutAgree:
+		err = o.Agree()
+	case afterSales.TimeoutCancel:
+		err = o.Cancel()
+	case afterSales.TimeoutReceive:
+		if err = o.ReturnReceive(); err == nil && v.Type != afterSales.TypeExchange {
+			err = o.Process()
+		}
+	case afterSales.TimeoutIntercede:
+		err = o.RequestIntercede()
+	}
+	return action, err
+}
@@ -0,0 +1,81 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : timeout_test.go
+ * author : jarryliu
+ * date : 2026-10-21 11:20
+ * description :
+ * history :
+ */
+package afterSales
+
+import (
+	"go2o/core/domain/interface/after-sales"
+	"testing"
+)
+
+var testSLA = afterSales.SLA{
+	VendorHour:     72,
+	ReturnShipHour: 168,
+	ReceiveHour:    240,
+	DeclinedHour:   0,
+}
+
+// 用于测试超时处理的售后单
+type testAfterSalesOrder struct {
+	afterSales.IAfterSalesOrder
+	value  afterSales.AfterSalesOrder
+	agreed bool
+}
+
+func (t *testAfterSalesOrder) Value() afterSales.AfterSalesOrder {
+	return t.value
+}
+
+func (t *testAfterSalesOrder) Agree() error {
+	t.agreed = true
+	return nil
+}
+
+// 测试截止时间按状态变更时间计算
+func TestSLADeadline(t *testing.T) {
+	v := &afterSales.AfterSalesOrder{
+		State:      afterSales.StatAwaitingVendor,
+		StateTime:  1000,
+		UpdateTime: 5000,
+	}
+	action, unix := testSLA.Deadline(v)
+	if action != afterSales.TimeoutAgree || unix != 1000+72*3600 {
+		t.Fatalf("截止时间不正确:%d %d", action, unix)
+	}
+	// 未记录状态变更时间的旧数据按更新时间计算
+	v.StateTime = 0
+	if _, unix = testSLA.Deadline(v); unix != 5000+72*3600 {
+		t.Fatalf("截止时间不正确:%d", unix)
+	}
+	// 不限时的状态无需处理
+	v.State = afterSales.StatDeclined
+	if action, _ = testSLA.Deadline(v); action != 0 {
+		t.Fatal("不限时的状态不应超时")
+	}
+	v.State = afterSales.StatCompleted
+	if action, _ = testSLA.Deadline(v); action != 0 {
+		t.Fatal("已完成的售后单不应超时")
+	}
+}
+
+// 测试超时检测,其他修改不影响时效
+func TestHandleTimeout(t *testing.T) {
+	o := &testAfterSalesOrder{value: afterSales.AfterSalesOrder{
+		State:      afterSales.StatAwaitingVendor,
+		StateTime:  1000,
+		UpdateTime: 1000 + 72*3600,
+	}}
+	action, err := HandleTimeout(o, testSLA, 1000+72*3600-1)
+	if action != 0 || err != nil || o.agreed {
+		t.Fatal("未超时的售后单不应处理")
+	}
+	action, err = HandleTimeout(o, testSLA, 1000+72*3600)
+	if action != afterSales.TimeoutAgree || err != nil || !o.agreed {
+		t.Fatalf("超时的售后单应自动同意:%d %v", action, err)
+	}
+}
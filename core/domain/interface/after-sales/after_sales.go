@@ -153,6 +153,18 @@ type (
 
 		// 处理售后单,处理完成后将变为已完成
 		Process() error
+
+		// 留言,可附带多张图片凭证
+		PostMessage(role int, senderId int64, content string, images []string) (int32, error)
+
+		// 获取留言记录
+		Messages() []*Message
+
+		// 仲裁,只有在调解状态下才能仲裁
+		Arbitrate(v *Arbitration) error
+
+		// 获取仲裁记录,未仲裁返回nil
+		GetArbitration() *Arbitration
 	}
 
 	IAfterSalesRepo interface {
@@ -164,6 +176,18 @@ type (
 
 		// 获取订单的售后单
 		GetAllOfSaleOrder(orderId int64) []IAfterSalesOrder
+
+		// 保存留言
+		SaveMessage(v *Message) (int32, error)
+
+		// 获取售后单的留言
+		GetMessages(afterSalesId int32) []*Message
+
+		// 保存仲裁记录
+		SaveArbitration(v *Arbitration) (int32, error)
+
+		// 获取售后单的仲裁记录
+		GetArbitration(afterSalesId int32) *Arbitration
 	}
 
 	// 售后单
@@ -206,6 +230,8 @@ type (
 		CreateTime int64 `db:"create_time"`
 		// 更新时间
 		UpdateTime int64 `db:"update_time"`
+		// 状态变更时间
+		StateTime int64 `db:"state_time"`
 		// 售后单数据,如退款单、退货单、换货单等
 		Data interface{} `db:"-"`
 		// 订单状态
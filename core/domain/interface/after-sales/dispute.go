@@ -0,0 +1,120 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : dispute
+ * author : jarryliu
+ * date : 2026-10-18 16:10
+ * description :
+ * history :
+ */
+package afterSales
+
+import (
+	"go2o/core/infrastructure/domain"
+	"strings"
+)
+
+const (
+	// 买家
+	RoleBuyer = 1 + iota
+	// 商户
+	RoleVendor
+	// 平台客服
+	RolePlatform
+)
+
+const (
+	// 支持买家
+	ArbitrateForBuyer = 1 + iota
+	// 支持商户
+	ArbitrateForVendor
+)
+
+// 每条留言最多上传的图片数量
+const MaxMessageImages = 6
+
+var (
+	ErrMessageContent *domain.DomainError = domain.NewDomainError(
+		"err_after_sales_message_content", "请填写留言内容")
+
+	ErrMessageImages *domain.DomainError = domain.NewDomainError(
+		"err_after_sales_message_images", "每条留言最多上传6张图片")
+
+	ErrMessageRole *domain.DomainError = domain.NewDomainError(
+		"err_after_sales_message_role", "无权对该售后单留言")
+
+	ErrMessageClosed *domain.DomainError = domain.NewDomainError(
+		"err_after_sales_message_closed", "售后单已关闭,无法留言")
+
+	ErrNotIntercede *domain.DomainError = domain.NewDomainError(
+		"err_after_sales_not_intercede", "售后单不在调解状态")
+
+	ErrArbitrateOutcome *domain.DomainError = domain.NewDomainError(
+		"err_after_sales_arbitrate_outcome", "不正确的仲裁结果")
+
+	ErrArbitrateAmount *domain.DomainError = domain.NewDomainError(
+		"err_after_sales_arbitrate_amount", "仲裁金额不能超过售后单金额")
+
+	ErrNoArbiter *domain.DomainError = domain.NewDomainError(
+		"err_after_sales_no_arbiter", "缺少仲裁人员")
+)
+
+type (
+	// 售后单留言,用于买家、商户和平台客服之间沟通及提交凭证
+	Message struct {
+		// 编号
+		Id int32 `db:"id" pk:"yes" auto:"yes"`
+		// 售后单编号
+		AfterSalesId int32 `db:"as_id"`
+		// 发送人角色
+		SenderRole int `db:"sender_role"`
+		// 发送人编号
+		SenderId int64 `db:"sender_id"`
+		// 留言内容
+		Content string `db:"content"`
+		// 图片凭证,多张图片用","分隔
+		Images string `db:"images"`
+		// 创建时间
+		CreateTime int64 `db:"create_time"`
+	}
+
+	// 仲裁记录,售后单调解完成时由平台客服创建
+	Arbitration struct {
+		// 编号
+		Id int32 `db:"id" pk:"yes" auto:"yes"`
+		// 售后单编号
+		AfterSalesId int32 `db:"as_id"`
+		// 仲裁人员编号
+		ArbiterId int32 `db:"arbiter_id"`
+		// 仲裁人员名称
+		ArbiterName string `db:"arbiter_name"`
+		// 仲裁结果
+		Outcome int `db:"outcome"`
+		// 支持买家时的退款金额,0表示按售后单金额
+		Amount float32 `db:"amount"`
+		// 仲裁说明
+		Remark string `db:"remark"`
+		// 创建时间
+		CreateTime int64 `db:"create_time"`
+	}
+
+	// 售后单记录,包括留言和仲裁结果
+	History struct {
+		// 售后单
+		Order *AfterSalesOrder
+		// 留言
+		Messages []*Message
+		// 仲裁记录,未仲裁为nil
+		Arbitration *Arbitration
+	}
+)
+
+// 获取图片列表
+func (m Message) ImageArray() []string {
+	arr := []string{}
+	for _, s := range strings.Split(m.Images, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			arr = append(arr, s)
+		}
+	}
+	return arr
+}
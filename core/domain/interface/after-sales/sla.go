@@ -0,0 +1,56 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : sla
+ * author : jarryliu
+ * date : 2026-10-18 15:20
+ * description :
+ * history :
+ */
+package afterSales
+
+const (
+	// 商户超时未处理,自动同意
+	TimeoutAgree = 1 + iota
+	// 买家超时未退货,自动取消
+	TimeoutCancel
+	// 商户超时未收货,自动收货
+	TimeoutReceive
+	// 商户拒绝后超时,自动申请调解
+	TimeoutIntercede
+)
+
+// 售后单各状态的处理时效(小时),0表示不限时
+type SLA struct {
+	// 商户处理时效
+	VendorHour int
+	// 买家退货时效
+	ReturnShipHour int
+	// 商户收货时效
+	ReceiveHour int
+	// 拒绝后自动申请调解时效
+	DeclinedHour int
+}
+
+// 获取售后单当前状态的超时操作及截止时间,无需处理返回0。
+// 截止时间按状态变更时间计算,其他修改不影响时效
+func (s SLA) Deadline(v *AfterSalesOrder) (action int, unix int64) {
+	var hour int
+	switch v.State {
+	case StatAwaitingVendor:
+		action, hour = TimeoutAgree, s.VendorHour
+	case StatAwaitingReturnShip:
+		action, hour = TimeoutCancel, s.ReturnShipHour
+	case StatReturnShipped:
+		action, hour = TimeoutReceive, s.ReceiveHour
+	case StatDeclined:
+		action, hour = TimeoutIntercede, s.DeclinedHour
+	}
+	if action == 0 || hour <= 0 {
+		return 0, 0
+	}
+	from := v.StateTime
+	if from <= 0 {
+		from = v.UpdateTime
+	}
+	return action, from + int64(hour)*3600
+}
@@ -0,0 +1,98 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : events.go
+ * author : jarryliu
+ * date : 2026-10-19 19:15
+ * description : 领域事件,由聚合发布到事件总线
+ * history :
+ */
+package events
+
+import "strconv"
+
+// 事件主题
+const (
+	// 订单
+	TopicOrder = "order"
+	// 会员
+	TopicMember = "member"
+	// 支付单
+	TopicPayment = "payment"
+	// 售后单
+	TopicAfterSales = "after_sales"
+)
+
+// 订单状态变更
+type OrderChanged struct {
+	// 订单号
+	OrderNo string `json:"orderNo"`
+	// 是否为子订单
+	Sub bool `json:"sub"`
+}
+
+func (o *OrderChanged) Topic() string { return TopicOrder }
+func (o *OrderChanged) Type() string  { return "order.changed" }
+func (o *OrderChanged) Key() string   { return o.OrderNo }
+
+// 订单延长收货时间
+type OrderReceiveExtended struct {
+	// 订单号
+	OrderNo string `json:"orderNo"`
+	// 是否为子订单
+	Sub bool `json:"sub"`
+	// 延长的小时数
+	Hours int32 `json:"hours"`
+}
+
+func (o *OrderReceiveExtended) Topic() string { return TopicOrder }
+func (o *OrderReceiveExtended) Type() string  { return "order.receive_extended" }
+func (o *OrderReceiveExtended) Key() string   { return o.OrderNo }
+
+// 会员新增或修改
+type MemberChanged struct {
+	// 会员编号
+	MemberId int64 `json:"memberId"`
+	// 是否为新注册会员
+	Create bool `json:"create"`
+}
+
+func (m *MemberChanged) Topic() string { return TopicMember }
+func (m *MemberChanged) Type() string {
+	if m.Create {
+		return "member.created"
+	}
+	return "member.updated"
+}
+func (m *MemberChanged) Key() string { return strconv.Itoa(int(m.MemberId)) }
+
+// 支付单完成
+type PaymentFinished struct {
+	// 支付单编号
+	PaymentOrderId int32 `json:"paymentOrderId"`
+}
+
+func (p *PaymentFinished) Topic() string { return TopicPayment }
+func (p *PaymentFinished) Type() string  { return "payment.finished" }
+func (p *PaymentFinished) Key() string   { return strconv.Itoa(int(p.PaymentOrderId)) }
+
+// 售后单状态变更
+type AfterSalesStateChanged struct {
+	// 售后单编号
+	Id int32 `json:"id"`
+	// 订单编号
+	OrderId int64 `json:"orderId"`
+	// 商户编号
+	VendorId int32 `json:"vendorId"`
+	// 买家编号
+	BuyerId int64 `json:"buyerId"`
+	// 售后类型
+	AfterSalesType int `json:"type"`
+	// 原状态
+	FromState int `json:"fromState"`
+	// 当前状态
+	State int `json:"state"`
+}
+
+func (a *AfterSalesStateChanged) Topic() string { return TopicAfterSales }
+func (a *AfterSalesStateChanged) Type() string  { return "after_sales.state" }
+func (a *AfterSalesStateChanged) Key() string   { return strconv.Itoa(int(a.Id)) }
@@ -202,6 +202,8 @@ type (
 		CreateTime int64 `db:"create_time"`
 		// 更新时间
 		UpdateTime int64 `db:"update_time"`
+		// 数据版本,用于乐观并发控制
+		Version int32 `db:"version"`
 		// 促销价
 		PromPrice float32 `db:"-"`
 
@@ -303,6 +303,8 @@ type (
 		PriorityPay int `db:"priority_pay"`
 		//更新时间
 		UpdateTime int64 `db:"update_time"`
+		// 数据版本,用于乐观并发控制
+		Version int32 `db:"version"`
 	}
 
 	// 积分记录
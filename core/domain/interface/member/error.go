@@ -90,6 +90,12 @@ var (
 	ErrMoreThanLevelRequireExp *domain.DomainError = domain.NewDomainError(
 		"member_level_more_than_exp", "经验值必须小于后一等级")
 
+	ErrLevelRulePeriod *domain.DomainError = domain.NewDomainError(
+		"member_level_rule_period", "等级规则统计周期不正确")
+
+	ErrLevelDiscountRate *domain.DomainError = domain.NewDomainError(
+		"member_level_discount_rate", "等级折扣率必须在0到1之间")
+
 	ErrNoSuchMember *domain.DomainError = domain.NewDomainError(
 		"member_no_such_member", "会员不存在")
 
@@ -0,0 +1,77 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : level_rule
+ * author : jarryliu
+ * date : 2026-10-18 09:12
+ * description :
+ * history :
+ */
+package member
+
+const (
+	// 累计统计
+	LevelPeriodTotal int32 = 1
+	// 滚动周期统计,如最近12个月
+	LevelPeriodRolling int32 = 2
+	// 自然年统计
+	LevelPeriodYear int32 = 3
+)
+
+type (
+	// 会员等级规则,满足全部条件才可升级到对应等级
+	LevelRule struct {
+		// 等级编号
+		LevelId int32 `db:"level_id" pk:"yes" auto:"no"`
+		// 需要消费金额
+		RequireAmount float32 `db:"require_amount"`
+		// 需要订单数量
+		RequireOrders int32 `db:"require_orders"`
+		// 需要经验值
+		RequireExp int32 `db:"require_exp"`
+		// 统计周期类型
+		PeriodType int32 `db:"period_type"`
+		// 滚动周期月数
+		PeriodMonths int32 `db:"period_months"`
+		// 周期结束后不满足条件是否自动降级
+		AutoDowngrade int32 `db:"auto_downgrade"`
+		// 是否启用
+		Enabled int32 `db:"enabled"`
+		// 更新时间
+		UpdateTime int64 `db:"update_time"`
+	}
+
+	// 会员等级权益
+	LevelBenefit struct {
+		// 等级编号
+		LevelId int32 `db:"level_id" pk:"yes" auto:"no"`
+		// 是否包邮
+		FreeShipping int32 `db:"free_shipping"`
+		// 折扣率,如:0.95为95折,0或1为不打折
+		DiscountRate float32 `db:"discount_rate"`
+		// 积分倍数,0或1为不加倍
+		IntegralMultiple float32 `db:"integral_multiple"`
+		// 更新时间
+		UpdateTime int64 `db:"update_time"`
+	}
+
+	// 会员等级统计数据
+	LevelStat struct {
+		// 消费金额
+		Amount float32
+		// 订单数量
+		Orders int32
+	}
+)
+
+// 是否有折扣
+func (l LevelBenefit) HasDiscount() bool {
+	return l.DiscountRate > 0 && l.DiscountRate < 1
+}
+
+// 获取积分倍数
+func (l LevelBenefit) Multiple() float32 {
+	if l.IntegralMultiple > 1 {
+		return l.IntegralMultiple
+	}
+	return 1
+}
@@ -92,6 +92,9 @@ type (
 		// 标记已经处理升级
 		ConfirmLevelUp(id int32) error
 
+		// 根据等级规则重新评定等级,返回等级是否变更
+		ReEvaluateLevel() (bool, error)
+
 		// 更换用户名
 		ChangeUsr(string) error
 
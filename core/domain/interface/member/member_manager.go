@@ -85,5 +85,20 @@ type (
 
 		// 根据经验值获取等级值
 		GetLevelIdByExp(exp int32) int32
+
+		// 获取等级规则,未设置返回nil
+		GetLevelRule(levelId int32) *LevelRule
+
+		// 保存等级规则
+		SaveLevelRule(*LevelRule) error
+
+		// 获取等级权益,未设置返回nil
+		GetLevelBenefit(levelId int32) *LevelBenefit
+
+		// 保存等级权益
+		SaveLevelBenefit(*LevelBenefit) error
+
+		// 根据经验值及等级规则评定会员可达到的等级,均未达到时返回最低的等级
+		EvaluateLevel(memberId int64, exp int32) int32
 	}
 )
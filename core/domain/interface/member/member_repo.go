@@ -122,6 +122,24 @@ type IMemberRepo interface {
 	// 保存会员升级记录
 	SaveLevelUpLog(l *LevelUpLog) (int32, error)
 
+	// 获取会员最近的等级变更记录
+	GetLatestLevelUpLog(memberId int64) *LevelUpLog
+
+	// 获取等级规则
+	GetLevelRule(levelId int32) *LevelRule
+
+	// 保存等级规则
+	SaveLevelRule(v *LevelRule) error
+
+	// 获取等级权益
+	GetLevelBenefit(levelId int32) *LevelBenefit
+
+	// 保存等级权益
+	SaveLevelBenefit(v *LevelBenefit) error
+
+	// 获取会员在时间段内已完成订单的统计数据
+	GetLevelStat(memberId int64, begin, end int64) *LevelStat
+
 	// 保存地址
 	SaveDeliver(*Address) (int64, error)
 
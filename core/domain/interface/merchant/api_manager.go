@@ -8,6 +8,20 @@
  */
 package merchant
 
+const (
+	// 查询类接口
+	ApiClassRead = "read"
+	// 操作类接口
+	ApiClassWrite = "write"
+)
+
+var (
+	// 查询类接口默认每分钟限额
+	DefaultApiReadRate = 600
+	// 操作类接口默认每分钟限额
+	DefaultApiWriteRate = 120
+)
+
 type (
 	// 商户接口信息
 	ApiInfo struct {
@@ -17,10 +31,34 @@ type (
 		ApiId string `db:"api_id"`
 		// 密钥
 		ApiSecret string `db:"api_secret"`
+		// 轮换前的密钥,轮换期间仍然有效
+		ApiSecret2 string `db:"api_secret2"`
 		// IP白名单
 		WhiteList string `db:"white_list"`
 		// 是否启用,0:停用,1启用
 		Enabled int `db:"enabled"`
+		// 查询类接口每分钟限额,0为默认限额
+		ReadRate int `db:"read_rate"`
+		// 操作类接口每分钟限额,0为默认限额
+		WriteRate int `db:"write_rate"`
+	}
+
+	// 接口每日调用统计
+	ApiUsage struct {
+		// 编号
+		Id int64 `db:"id" pk:"yes" auto:"yes"`
+		// 商户编号
+		MchId int32 `db:"mch_id"`
+		// 统计日期,如:20261019
+		StatDate int `db:"stat_date"`
+		// 接口类别
+		ApiClass string `db:"api_class"`
+		// 请求次数
+		Requests int64 `db:"requests"`
+		// 被限流的次数
+		Limited int64 `db:"limited"`
+		// 更新时间
+		UpdateTime int64 `db:"update_time"`
 	}
 
 	// Api接口管理器
@@ -36,5 +74,61 @@ type (
 
 		// 禁用API权限
 		DisableApiPerm() error
+
+		// 轮换密钥,原密钥在撤销前仍然有效,返回新密钥
+		RotateSecret() (string, error)
+
+		// 撤销轮换前的密钥
+		RevokeOldSecret() error
+
+		// 保存接口每日调用统计,同一日期及类别的统计将被覆盖
+		SaveApiUsage(*ApiUsage) error
+
+		// 获取接口调用统计,日期格式如:20261019
+		GetApiUsages(begin int, end int) []*ApiUsage
+
+		// 获取通知地址
+		GetWebhooks() []*Webhook
+
+		// 保存通知地址
+		SaveWebhook(*Webhook) (int32, error)
+
+		// 删除通知地址
+		DeleteWebhook(id int32) error
+
+		// 发布事件,为订阅该事件的通知地址生成待发送的消息,返回消息数量
+		PublishWebhook(event string, data interface{}) (int, error)
+
+		// 发送通知消息,失败后按指数退避重试
+		DeliverWebhook(msgId int64) error
+
+		// 重新发送通知消息
+		RedeliverWebhook(msgId int64) error
+
+		// 获取通知消息的发送日志
+		GetWebhookLogs(msgId int64) []*WebhookLog
 	}
 )
+
+// 获取接口类别的每分钟限额
+func (a ApiInfo) RateLimit(class string) int {
+	if class == ApiClassWrite {
+		if a.WriteRate > 0 {
+			return a.WriteRate
+		}
+		return DefaultApiWriteRate
+	}
+	if a.ReadRate > 0 {
+		return a.ReadRate
+	}
+	return DefaultApiReadRate
+}
+
+// 获取有效的密钥
+func (a ApiInfo) Secrets() []string {
+	arr := []string{a.ApiSecret}
+	if a.ApiSecret2 != "" {
+		arr = append(arr, a.ApiSecret2)
+	}
+	return arr
+}
@@ -22,6 +22,14 @@ type (
 		SelectBuyerGroup() []*BuyerGroup
 		// 根据分组编号获取分组设置
 		GetGroupByGroupId(groupId int32) *MchBuyerGroup
+		// 获取订单类型的时效,未设置的项使用销售设置
+		GetOrderTimeout(orderType int32) OrderTimeout
+		// 获取按订单类型的时效设置
+		SelectOrderTimeout() []*OrderTimeout
+		// 保存订单类型的时效设置
+		SaveOrderTimeout(v *OrderTimeout) error
+		// 删除订单类型的时效设置
+		DeleteOrderTimeout(orderType int32) error
 	}
 
 	// 商户客户分组设置
@@ -68,10 +76,22 @@ type (
 		AutoSetupOrder int `db:"oa_open"`
 		// 订单超时分钟数
 		OrderTimeOutMinute int `db:"oa_timeout_minute"`
-		// 订单自动确认时间
+		// 订单自动确认时间,0为立即确认,ConfirmMinuteUnset为使用系统设置
 		OrderConfirmAfterMinute int `db:"oa_confirm_minute"`
 		// 订单超时自动收货
 		OrderTimeOutReceiveHour int `db:"oa_receive_hour"`
+		// 买家延长收货的小时数
+		OrderReceiveExtendHour int `db:"oa_receive_extend_hour"`
+		// 分销佣金冻结天数(售后期)
+		CommissionFreezeDays int `db:"cms_freeze_days"`
+		// 售后商户处理时效(小时),超时自动同意
+		AsVendorHour int `db:"as_vendor_hour"`
+		// 售后买家退货时效(小时),超时自动取消
+		AsReturnShipHour int `db:"as_return_ship_hour"`
+		// 售后商户收货时效(小时),超时自动收货
+		AsReceiveHour int `db:"as_receive_hour"`
+		// 售后拒绝后自动申请调解时效(小时)
+		AsDeclinedHour int `db:"as_declined_hour"`
 
 		//IntegralBackNum         int     `db:"ib_num"`                         // 每一元返多少积分
 		//IntegralBackExtra       int     `db:"ib_extra"`                       // 每单额外赠送
@@ -80,4 +100,26 @@ type (
 		//FlowConvertCsn          float32 `db:"flow_convert_csn"`               // 活动账户转为赠送可提现奖金手续费费率
 		//PresentConvertCsn       float32 `db:"present_convert_csn"`            // 钱包账户转换手续费费率
 	}
+
+	// 按订单类型的时效设置,为0的项使用销售设置;
+	// 自动确认时间为0时立即确认,为ConfirmMinuteUnset时使用销售设置
+	OrderTimeout struct {
+		// 编号
+		ID int32 `db:"id" pk:"yes" auto:"yes"`
+		// 商户编号
+		MchId int32 `db:"mch_id"`
+		// 订单类型
+		OrderType int32 `db:"order_type"`
+		// 订单超时分钟数
+		TimeoutMinute int `db:"timeout_minute"`
+		// 订单自动确认时间(分钟),0为立即确认
+		ConfirmMinute int `db:"confirm_minute"`
+		// 订单超时自动收货(小时)
+		ReceiveHour int `db:"receive_hour"`
+		// 买家延长收货的小时数
+		ReceiveExtendHour int `db:"receive_extend_hour"`
+	}
 )
+
+// 未设置订单自动确认时间,使用上级设置
+const ConfirmMinuteUnset = -1
@@ -0,0 +1,137 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : distribution_manager
+ * author : jarryliu
+ * date : 2026-10-18 11:05
+ * description :
+ * history :
+ */
+package merchant
+
+import (
+	"go2o/core/domain/interface/order"
+	"strconv"
+	"strings"
+)
+
+const (
+	// 佣金冻结中,等待售后期结束
+	CommissionFrozen int32 = 1
+	// 佣金已发放
+	CommissionReleased int32 = 2
+	// 佣金已全部撤销
+	CommissionReversed int32 = 3
+)
+
+type (
+	// 分销佣金规则,商品规则优先于分类规则,分类规则优先于默认规则
+	CommissionRule struct {
+		// 编号
+		Id int32 `db:"id" pk:"yes" auto:"yes"`
+		// 商户编号
+		MchId int32 `db:"mch_id"`
+		// 分类编号,0表示不限分类
+		CatId int32 `db:"cat_id"`
+		// 商品编号,0表示不限商品
+		ItemId int64 `db:"item_id"`
+		// 各级佣金比例,用","分隔,如:0.1,0.05,0.02
+		Rates string `db:"rates"`
+		// 是否启用
+		Enabled int32 `db:"enabled"`
+		// 更新时间
+		UpdateTime int64 `db:"update_time"`
+	}
+
+	// 分销佣金
+	Commission struct {
+		// 编号
+		Id int64 `db:"id" pk:"yes" auto:"yes"`
+		// 商户编号
+		MchId int32 `db:"mch_id"`
+		// 子订单编号
+		OrderId int64 `db:"order_id"`
+		// 订单号
+		OrderNo string `db:"order_no"`
+		// 快照编号
+		SnapshotId int64 `db:"snap_id"`
+		// 商品编号
+		ItemId int64 `db:"item_id"`
+		// 买家编号
+		BuyerId int64 `db:"buyer_id"`
+		// 推荐人(获得佣金的会员)编号
+		MemberId int64 `db:"member_id"`
+		// 推荐层级,从1开始
+		Depth int32 `db:"depth"`
+		// 计算佣金的商品金额
+		BaseAmount float32 `db:"base_amount"`
+		// 佣金比例
+		Rate float32 `db:"rate"`
+		// 佣金金额
+		Amount float32 `db:"amount"`
+		// 已撤销金额
+		ReverseAmount float32 `db:"reverse_amount"`
+		// 状态
+		State int32 `db:"state"`
+		// 解冻时间
+		UnfreezeTime int64 `db:"unfreeze_time"`
+		// 创建时间
+		CreateTime int64 `db:"create_time"`
+		// 更新时间
+		UpdateTime int64 `db:"update_time"`
+	}
+
+	// 推荐人佣金报表
+	CommissionReport struct {
+		// 推荐人编号
+		MemberId int64
+		// 订单数量
+		Orders int32
+		// 冻结中的佣金
+		FrozenAmount float32
+		// 已发放的佣金
+		ReleasedAmount float32
+		// 已撤销的佣金
+		ReversedAmount float32
+	}
+
+	// 分销管理器
+	IDistributionManager interface {
+		// 获取全部佣金规则
+		GetRules() []*CommissionRule
+		// 是否有启用的佣金规则
+		HasEnabledRule() bool
+		// 获取佣金规则
+		GetRule(id int32) *CommissionRule
+		// 保存佣金规则
+		SaveRule(*CommissionRule) (int32, error)
+		// 删除佣金规则
+		DeleteRule(id int32) error
+		// 根据商品及分类匹配佣金规则,未匹配返回nil
+		MatchRule(itemId int64, catId int32) *CommissionRule
+		// 计算订单佣金,并冻结到推荐人钱包账户,返回佣金条数
+		Calculate(o *order.NormalSubOrder, items []*order.SubOrderItem) (int, error)
+		// 售后期结束后发放佣金
+		Release(id int64) error
+		// 退款时按比例撤销商品对应的佣金
+		Reverse(orderId int64, snapshotId int64, refundAmount float32) error
+		// 获取推荐人佣金报表
+		GetReport(memberId int64, begin int64, end int64) *CommissionReport
+	}
+)
+
+// 获取各级佣金比例
+func (c CommissionRule) RateArray() []float32 {
+	arr := []float32{}
+	for _, s := range strings.Split(c.Rates, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil
+		}
+		arr = append(arr, float32(f))
+	}
+	return arr
+}
@@ -76,4 +76,28 @@ var (
 
 	ErrMissingPersonImage *domain.DomainError = domain.NewDomainError(
 		"err_mch_missing_person_image", "请上传法人身份证复印件")
+
+	ErrNoSuchCommissionRule *domain.DomainError = domain.NewDomainError(
+		"err_mch_no_such_commission_rule", "佣金规则不存在")
+
+	ErrCommissionRates *domain.DomainError = domain.NewDomainError(
+		"err_mch_commission_rates", "佣金比例不正确,各级比例之和不能超过1")
+
+	ErrExistsCommissionRule *domain.DomainError = domain.NewDomainError(
+		"err_mch_exists_commission_rule", "已存在相同商品或分类的佣金规则")
+
+	ErrNoSuchCommission *domain.DomainError = domain.NewDomainError(
+		"err_mch_no_such_commission", "佣金记录不存在")
+
+	ErrCommissionNotFrozen *domain.DomainError = domain.NewDomainError(
+		"err_mch_commission_not_frozen", "佣金不是冻结状态")
+
+	ErrCommissionNotExpired *domain.DomainError = domain.NewDomainError(
+		"err_mch_commission_not_expired", "售后期未结束,佣金无法发放")
+
+	ErrOrderType *domain.DomainError = domain.NewDomainError(
+		"err_mch_order_type", "订单类型不正确")
+
+	ErrOrderTimeout *domain.DomainError = domain.NewDomainError(
+		"err_mch_order_timeout", "订单时效设置不正确")
 )
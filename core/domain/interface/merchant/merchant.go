@@ -89,6 +89,9 @@ type (
 		// 获取会员键值管理器
 		MemberKvManager() IKvManager
 
+		// 分销服务
+		DistributionManager() IDistributionManager
+
 		// 消息系统管理器
 		//MssManager() mss.IMssManager
 	}
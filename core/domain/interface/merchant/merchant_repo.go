@@ -45,6 +45,30 @@ type IMerchantRepo interface {
 	// 保存商户账户信息
 	UpdateAccount(v *Account) error
 
+	// 根据编号获取余额变动信息
+	GetBalanceLog(id int32) *BalanceLog
+
+	// 根据外部单号获取余额变动信息
+	GetBalanceLogByOuterNo(outerNo string) *BalanceLog
+
+	// 保存余额变动信息
+	SaveBalanceLog(v *BalanceLog) (int32, error)
+
+	// 获取商户申请信息
+	GetSignUpInfo(id int32) *MchSignUp
+
+	// 获取会员申请的商户信息
+	GetSignUpInfoByMemberId(memberId int64) *MchSignUp
+
+	// 保存商户申请信息
+	SaveSignUpInfo(v *MchSignUp) (int32, error)
+
+	// 删除会员的商户申请信息
+	DeleteSignUpInfo(memberId int64) error
+
+	// 获取会员关联的商户
+	GetMerchantByMemberId(memberId int64) IMerchant
+
 	// 保存API信息
 	SaveApiInfo(d *ApiInfo) error
 
@@ -84,6 +108,60 @@ type IMerchantRepo interface {
 	// 保存等级
 	SaveMemberLevel(mchId int32, v *MemberLevel) (int32, error)
 
+	// 获取商户的佣金规则
+	GetCommissionRules(mchId int32) []*CommissionRule
+
+	// 获取佣金规则
+	GetCommissionRule(mchId int32, id int32) *CommissionRule
+
+	// 保存佣金规则
+	SaveCommissionRule(v *CommissionRule) (int32, error)
+
+	// 删除佣金规则
+	DeleteCommissionRule(mchId int32, id int32) error
+
+	// 获取佣金
+	GetCommission(id int64) *Commission
+
+	// 获取订单的佣金,snapshotId为0时返回订单的全部佣金
+	GetCommissions(orderId int64, snapshotId int64) []*Commission
+
+	// 保存佣金
+	SaveCommission(v *Commission) (int64, error)
+
+	// 获取推荐人的佣金报表,mchId为0时统计全部商户
+	GetCommissionReport(mchId int32, memberId int64, begin int64, end int64) *CommissionReport
+
+	// 获取商户的通知地址
+	GetWebhooks(mchId int32) []*Webhook
+
+	// 保存通知地址
+	SaveWebhook(v *Webhook) (int32, error)
+
+	// 删除通知地址
+	DeleteWebhook(mchId int32, id int32) error
+
+	// 获取通知消息
+	GetWebhookMessage(id int64) *WebhookMessage
+
+	// 保存通知消息
+	SaveWebhookMessage(v *WebhookMessage) (int64, error)
+
+	// 保存通知发送日志
+	SaveWebhookLog(v *WebhookLog) (int64, error)
+
+	// 获取通知消息的发送日志
+	GetWebhookLogs(msgId int64) []*WebhookLog
+
+	// 获取接口某日某类别的调用统计
+	GetApiUsage(mchId int32, date int, class string) *ApiUsage
+
+	// 保存接口调用统计
+	SaveApiUsage(v *ApiUsage) (int64, error)
+
+	// 获取接口调用统计
+	GetApiUsages(mchId int32, begin int, end int) []*ApiUsage
+
 	// Get MchEnterpriseInfo
 	GetMchEnterpriseInfo(mchId int32) *EnterpriseInfo
 	// Save MchEnterpriseInfo
@@ -96,6 +174,15 @@ type IMerchantRepo interface {
 	// Save MchBuyerGroup
 	SaveMchBuyerGroup(v *MchBuyerGroup) (int, error)
 
+	// 获取订单类型的时效设置
+	GetMchOrderTimeout(mchId int32, orderType int32) *OrderTimeout
+	// 获取商户按订单类型的时效设置
+	SelectMchOrderTimeout(mchId int32) []*OrderTimeout
+	// 保存订单类型的时效设置
+	SaveMchOrderTimeout(v *OrderTimeout) (int, error)
+	// 删除订单类型的时效设置
+	DeleteMchOrderTimeout(mchId int32, orderType int32) error
+
 	//
 	//  //修改线下支付利润
 	//UpdateMechOfflineRate(id int, rate float32, return_rate float32) error
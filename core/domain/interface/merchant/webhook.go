@@ -0,0 +1,164 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : webhook
+ * author : jarryliu
+ * date : 2026-10-18 17:40
+ * description :
+ * history :
+ */
+package merchant
+
+import (
+	"go2o/core/infrastructure/domain"
+	"strings"
+)
+
+// 通知事件
+const (
+	// 订单已创建
+	EventOrderCreated = "order.created"
+	// 订单已支付
+	EventOrderPaid = "order.paid"
+	// 订单已发货
+	EventOrderShipped = "order.shipped"
+	// 订单已完成
+	EventOrderCompleted = "order.completed"
+	// 订单已取消
+	EventOrderCancelled = "order.cancelled"
+	// 售后单状态变更
+	EventAfterSalesState = "after_sales.state"
+	// 支付完成
+	EventPaymentFinished = "payment.finished"
+	// 商品库存不足
+	EventStockLow = "item.stock_low"
+)
+
+const (
+	// 等待发送
+	WebhookPending = 1
+	// 发送成功
+	WebhookDelivered = 2
+	// 超过重试次数,发送失败
+	WebhookFailed = 3
+)
+
+var (
+	// 支持的通知事件
+	WebhookEvents = []string{
+		EventOrderCreated,
+		EventOrderPaid,
+		EventOrderShipped,
+		EventOrderCompleted,
+		EventOrderCancelled,
+		EventAfterSalesState,
+		EventPaymentFinished,
+		EventStockLow,
+	}
+
+	// 最大重试次数
+	WebhookMaxRetries = 8
+	// 首次重试间隔(秒),之后按指数递增
+	WebhookRetryBaseSeconds int64 = 60
+	// 库存低于该数量时发送库存不足通知
+	WebhookStockLowNum int32 = 10
+	// 错误信息及响应内容保存的最大长度(字符)
+	WebhookErrorMaxLen    = 255
+	WebhookResponseMaxLen = 512
+
+	ErrWebhookEvent *domain.DomainError = domain.NewDomainError(
+		"err_mch_webhook_event", "不支持的通知事件")
+	ErrWebhookUrl *domain.DomainError = domain.NewDomainError(
+		"err_mch_webhook_url", "通知地址必须以http://或https://开头")
+	ErrNoSuchWebhook *domain.DomainError = domain.NewDomainError(
+		"err_mch_no_such_webhook", "通知地址不存在")
+	ErrNoSuchWebhookMessage *domain.DomainError = domain.NewDomainError(
+		"err_mch_no_such_webhook_message", "通知消息不存在")
+	ErrWebhookDelivered *domain.DomainError = domain.NewDomainError(
+		"err_mch_webhook_delivered", "通知消息已发送成功")
+	ErrWebhookDisabled *domain.DomainError = domain.NewDomainError(
+		"err_mch_webhook_disabled", "通知地址已停用")
+)
+
+type (
+	// 通知地址,每个事件可注册一个地址
+	Webhook struct {
+		// 编号
+		Id int32 `db:"id" pk:"yes" auto:"yes"`
+		// 商户编号
+		MchId int32 `db:"mch_id"`
+		// 通知事件
+		Event string `db:"event"`
+		// 通知地址
+		Url string `db:"url"`
+		// 是否启用
+		Enabled int32 `db:"enabled"`
+		// 更新时间
+		UpdateTime int64 `db:"update_time"`
+	}
+
+	// 待发送的通知消息(发件箱)
+	WebhookMessage struct {
+		// 编号
+		Id int64 `db:"id" pk:"yes" auto:"yes"`
+		// 商户编号
+		MchId int32 `db:"mch_id"`
+		// 通知地址编号
+		HookId int32 `db:"hook_id"`
+		// 通知事件
+		Event string `db:"event"`
+		// 通知内容(JSON)
+		Payload string `db:"payload"`
+		// 状态
+		State int32 `db:"state"`
+		// 已重试次数
+		Retries int `db:"retries"`
+		// 下次发送时间
+		NextTime int64 `db:"next_time"`
+		// 最后的错误信息
+		LastError string `db:"last_error"`
+		// 创建时间
+		CreateTime int64 `db:"create_time"`
+		// 更新时间
+		UpdateTime int64 `db:"update_time"`
+	}
+
+	// 通知发送日志
+	WebhookLog struct {
+		// 编号
+		Id int64 `db:"id" pk:"yes" auto:"yes"`
+		// 通知消息编号
+		MsgId int64 `db:"msg_id"`
+		// 通知地址
+		Url string `db:"url"`
+		// HTTP状态码,请求失败为0
+		StatusCode int `db:"status_code"`
+		// 响应内容
+		Response string `db:"response"`
+		// 错误信息
+		Error string `db:"error"`
+		// 耗时(毫秒)
+		Duration int64 `db:"duration"`
+		// 创建时间
+		CreateTime int64 `db:"create_time"`
+	}
+
+	// 通知发送器,返回HTTP状态码及响应内容
+	WebhookSender func(url string, header map[string]string,
+		body []byte) (int, string, error)
+)
+
+// 是否为支持的通知事件
+func IsWebhookEvent(event string) bool {
+	for _, v := range WebhookEvents {
+		if v == event {
+			return true
+		}
+	}
+	return false
+}
+
+// 检查通知地址
+func (w Webhook) CheckUrl() bool {
+	return strings.HasPrefix(w.Url, "http://") ||
+		strings.HasPrefix(w.Url, "https://")
+}
@@ -194,6 +194,12 @@ var (
 	ErrIsCompleted *domain.DomainError = domain.NewDomainError(
 		"err_order_is_completed", "订单已经完成")
 
+	ErrReceiveExtended *domain.DomainError = domain.NewDomainError(
+		"err_order_receive_extended", "订单已延长过收货时间")
+
+	ErrReceiveExtendDisabled *domain.DomainError = domain.NewDomainError(
+		"err_order_receive_extend_disabled", "订单不支持延长收货时间")
+
 	ErrOrderBreakUpFail *domain.DomainError = domain.NewDomainError(
 		"err_order_break_up_fail", "拆分订单操作失败")
 
@@ -304,6 +310,8 @@ type (
 		Ship(spId int32, spOrder string) error
 		// 已收货
 		BuyerReceived() error
+		// 延长收货时间,每个订单只能延长一次
+		ExtendReceive() error
 		// 获取订单的日志
 		LogBytes() []byte
 		// 挂起
@@ -314,6 +322,8 @@ type (
 		Return(snapshotId int64, quantity int32) error
 		// 撤销退回商品
 		RevertReturn(snapshotId int64, quantity int32) error
+		// 退款时按比例撤销分销佣金
+		ReverseCommission(snapshotId int64, amount float32) error
 		// 谢绝订单
 		Decline(reason string) error
 		// 提交子订单
@@ -347,6 +357,8 @@ type (
 		Ship(spId int32, spOrder string) error
 		// 已收货
 		BuyerReceived() error
+		// 延长收货时间,每个订单只能延长一次
+		ExtendReceive() error
 		// 获取订单的日志
 		LogBytes() []byte
 		// 取消订单/退款
@@ -536,6 +548,10 @@ type (
 		CreateTime int64 `db:"create_time"`
 		// 更新时间
 		UpdateTime int64 `db:"update_time" json:"updateTime"`
+		// 延长收货的小时数,0表示未延长
+		ReceiveExtend int32 `db:"receive_extend" json:"receiveExtend"`
+		// 数据版本,用于乐观并发控制
+		Version int32 `db:"version" json:"version"`
 		// 订单项
 		Items []*SubOrderItem `db:"-"`
 	}
@@ -618,6 +634,8 @@ type (
 		CreateTime int64 `db:"create_time"`
 		// 订单更新时间
 		UpdateTime int64 `db:"update_time"`
+		// 延长收货的小时数,0表示未延长
+		ReceiveExtend int32 `db:"receive_extend"`
 	}
 
 	// 批发订单商品
@@ -721,3 +739,6 @@ type (
 		SendIntegral int     `db:"send_integral"`
 	}
 )
+
+// 复合订单扩展数据中已延长收货的小时数
+const ComplexReceiveExtend = "ReceiveExtend"
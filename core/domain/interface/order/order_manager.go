@@ -67,6 +67,8 @@ type (
 		Ship(spId int32, spOrder string) error
 		// 消费者收货
 		BuyerReceived() error
+		// 延长收货时间,每个订单只能延长一次
+		ExtendReceive() error
 		// 获取订单日志
 		LogBytes() []byte
 	}
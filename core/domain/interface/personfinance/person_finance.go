@@ -69,6 +69,7 @@ const (
 	RiseTypeGenerateInterest                //计算利息
 	RiseTypeMonthSettle                     //月结算,红利再投资
 	RiseTypeAdjust                          //人工调整
+	RiseTypePenalty                         //提前转出违约金
 )
 
 var (
@@ -103,8 +104,8 @@ type (
 		GetMemberAccount() member.IAccount
 		// 获取增利账户信息(类:余额宝)
 		GetRiseInfo() IRiseInfo
-		// 创建增利账户信息
-		CreateRiseInfo() error
+		// 创建增利账户信息,planId为选择的理财计划
+		CreateRiseInfo(planId int32) error
 		// 同步到会员账户理财数据
 		SyncToAccount() error
 	}
@@ -116,12 +117,26 @@ type (
 		// 获取值
 		Value() (RiseInfoValue, error)
 
+		// 获取理财计划
+		GetPlan() *FinancePlan
+
+		// 获取指定日期的日收益比率
+		GetDayRatio(unixDate int64) float32
+
+		// 获取锁定期内的金额,按每笔转入分别计算锁定期
+		GetLockedAmount() float32
+
+		// 获取转出金额需支付的违约金,优先转出锁定期外的金额,
+		// 仅对锁定期内的部分收取违约金
+		GetTransferOutPenalty(amount float32) float32
+
 		// 转入
 		TransferIn(amount float32, w TransferWith) error
 
 		// 转出,w为转出方式(如银行,余额等),state为日志的状态,某些操作
-		// 需要确认,有些不需要.通过state来传入
-		TransferOut(amount float32, w TransferWith, state int) error
+		// 需要确认,有些不需要.通过state来传入.锁定期内转出将扣除违约金,
+		// 转出日志记录扣除违约金后的金额,返回违约金
+		TransferOut(amount float32, w TransferWith, state int) (penalty float32, err error)
 
 		// 根据日志记录提交转入转出,如果已经确认操作,则返回错误
 		// 通常是由系统计划任务来完成此操作,转入和转出必须经过提交!
@@ -149,6 +164,7 @@ type (
 		TotalAmount      float32 `db:"total_amount"`                 //总金额
 		TotalRise        float32 `db:"total_rise"`                   //总收益
 		SettledDate      int64   `db:"settled_date"`                 //结算日期,用于筛选需要结算的数据
+		PlanId           int32   `db:"plan_id"`                      //理财计划编号
 		UpdateTime       int64   `db:"update_time"`
 	}
 
@@ -203,7 +219,31 @@ type (
 		// 获取日志
 		GetRiseLogs(personId int64, date int64, riseType int) []*RiseLog
 
+		// 获取指定时间后已确认的日志
+		GetRiseLogsAfter(personId int64, riseType int, begin int64) []*RiseLog
+
 		// 保存每日收益
 		SaveRiseDayInfo(*RiseDayInfo) (int32, error)
+
+		// 获取理财计划管理器
+		GetPlanManager() IPlanManager
+
+		// 获取全部理财计划
+		GetPlans() []*FinancePlan
+
+		// 获取理财计划
+		GetPlan(planId int32) *FinancePlan
+
+		// 保存理财计划
+		SavePlan(*FinancePlan) (int32, error)
+
+		// 获取理财计划的利率
+		GetPlanRates(planId int32) []*PlanRate
+
+		// 保存理财计划利率
+		SavePlanRate(*PlanRate) (int32, error)
+
+		// 删除理财计划利率
+		DeletePlanRate(planId int32, id int32) error
 	}
 )
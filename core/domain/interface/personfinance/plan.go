@@ -0,0 +1,102 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : plan
+ * author : jarryliu
+ * date : 2026-10-18 14:10
+ * description :
+ * history :
+ */
+package personfinance
+
+import (
+	"go2o/core/infrastructure/domain"
+)
+
+var (
+	ErrNoSuchPlan *domain.DomainError = domain.NewDomainError(
+		"err_pf_no_such_plan", "理财计划不存在")
+	ErrPlanDisabled *domain.DomainError = domain.NewDomainError(
+		"err_pf_plan_disabled", "理财计划已停用")
+	ErrPlanName *domain.DomainError = domain.NewDomainError(
+		"err_pf_plan_name", "请填写理财计划名称")
+	ErrPlanSettleT *domain.DomainError = domain.NewDomainError(
+		"err_pf_plan_settle_t", "T+N结算天数不正确")
+	ErrPlanAmount *domain.DomainError = domain.NewDomainError(
+		"err_pf_plan_amount", "理财计划金额限制不正确")
+	ErrPlanPenaltyRate *domain.DomainError = domain.NewDomainError(
+		"err_pf_plan_penalty_rate", "违约金比例必须在0到1之间")
+	ErrPlanRateDate *domain.DomainError = domain.NewDomainError(
+		"err_pf_plan_rate_date", "利率生效日期不正确")
+	ErrPlanRateExists *domain.DomainError = domain.NewDomainError(
+		"err_pf_plan_rate_exists", "该日期已设置利率")
+	ErrMoreThanMaxAmount *domain.DomainError = domain.NewDomainError(
+		"err_pf_more_than_max_amount", "超出理财计划最高持有金额%s")
+)
+
+const (
+	// 默认理财计划编号,使用系统默认配置
+	DefaultPlanId int32 = 0
+)
+
+type (
+	// 理财计划(产品)
+	FinancePlan struct {
+		// 编号
+		Id int32 `db:"id" pk:"yes" auto:"yes"`
+		// 名称
+		Name string `db:"name"`
+		// T+N开始计算收益
+		SettleTValue int `db:"settle_t"`
+		// 锁定天数,0为随存随取
+		LockDays int `db:"lock_days"`
+		// 最低转入金额
+		MinTransferIn float32 `db:"min_in"`
+		// 最低转出金额
+		MinTransferOut float32 `db:"min_out"`
+		// 最高持有金额,0为不限
+		MaxAmount float32 `db:"max_amount"`
+		// 锁定期内转出的违约金比例
+		PenaltyRate float32 `db:"penalty_rate"`
+		// 是否启用
+		Enabled int32 `db:"enabled"`
+		// 更新时间
+		UpdateTime int64 `db:"update_time"`
+	}
+
+	// 理财计划利率,按生效日期变更
+	PlanRate struct {
+		// 编号
+		Id int32 `db:"id" pk:"yes" auto:"yes"`
+		// 理财计划编号
+		PlanId int32 `db:"plan_id"`
+		// 年化利率,如:0.05为5%
+		AnnualRate float32 `db:"annual_rate"`
+		// 生效日期(不含时间)
+		EffectiveDate int64 `db:"effective_date"`
+		// 创建时间
+		CreateTime int64 `db:"create_time"`
+	}
+
+	// 理财计划管理器
+	IPlanManager interface {
+		// 获取全部理财计划
+		GetAllPlans() []*FinancePlan
+		// 获取理财计划,planId为0时返回默认计划
+		GetPlan(planId int32) *FinancePlan
+		// 保存理财计划
+		SavePlan(*FinancePlan) (int32, error)
+		// 获取理财计划的利率表,按生效日期排序
+		GetRates(planId int32) []*PlanRate
+		// 保存利率
+		SaveRate(*PlanRate) (int32, error)
+		// 删除利率
+		DeleteRate(planId int32, id int32) error
+		// 获取理财计划在指定日期的日收益比率
+		GetDayRatio(planId int32, personId int64, unixDate int64) float32
+	}
+)
+
+// 按365天计算日收益比率
+func (p PlanRate) DayRatio() float32 {
+	return p.AnnualRate / 365
+}
@@ -270,6 +270,18 @@ type (
 		OrderConfirmAfterMinute int
 		// 订单超时自动收货
 		OrderTimeOutReceiveHour int
+		// 买家延长收货的小时数
+		OrderReceiveExtendHour int
+		// 分销佣金冻结天数(售后期)
+		CommissionFreezeDays int
+		// 售后商户处理时效(小时)
+		AsVendorHour int
+		// 售后买家退货时效(小时)
+		AsReturnShipHour int
+		// 售后商户收货时效(小时)
+		AsReceiveHour int
+		// 售后拒绝后自动申请调解时效(小时)
+		AsDeclinedHour int
 	}
 
 	IValueRepo interface {
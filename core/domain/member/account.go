@@ -14,7 +14,6 @@ import (
 	"go2o/core/domain/interface/enum"
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/valueobject"
-	"go2o/core/domain/tmp"
 	"go2o/core/infrastructure/domain"
 	"go2o/core/infrastructure/format"
 	"math"
@@ -250,11 +249,7 @@ func (a *accountImpl) chargePresentNoLimit(kind int32, title string,
 
 // 根据编号获取余额变动信息
 func (a *accountImpl) GetWalletLog(id int32) *member.WalletLog {
-	e := member.WalletLog{}
-	if tmp.Db().GetOrm().Get(id, &e) == nil {
-		return &e
-	}
-	return nil
+	return a.rep.GetWalletLog(id)
 }
 
 // 扣减余额
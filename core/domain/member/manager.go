@@ -17,6 +17,7 @@ import (
 	"go2o/core/variable"
 	"sort"
 	"strings"
+	"time"
 )
 
 var _ member.IMemberManager = new(MemberManagerImpl)
@@ -449,3 +450,112 @@ func (l *levelManagerImpl) GetLevelIdByExp(exp int32) int32 {
 	}
 	return levelVal
 }
+
+// 获取等级规则
+func (l *levelManagerImpl) GetLevelRule(levelId int32) *member.LevelRule {
+	return l.rep.GetLevelRule(levelId)
+}
+
+// 保存等级规则
+func (l *levelManagerImpl) SaveLevelRule(v *member.LevelRule) error {
+	if lv := l.GetLevelById(v.LevelId); lv == nil {
+		return member.ErrLevelDisabled
+	}
+	switch v.PeriodType {
+	case member.LevelPeriodTotal, member.LevelPeriodYear:
+		v.PeriodMonths = 0
+	case member.LevelPeriodRolling:
+		if v.PeriodMonths <= 0 {
+			return member.ErrLevelRulePeriod
+		}
+	default:
+		return member.ErrLevelRulePeriod
+	}
+	// 累计统计的数据不会减少,无需降级
+	if v.PeriodType == member.LevelPeriodTotal {
+		v.AutoDowngrade = 0
+	}
+	v.UpdateTime = time.Now().Unix()
+	return l.rep.SaveLevelRule(v)
+}
+
+// 获取等级权益
+func (l *levelManagerImpl) GetLevelBenefit(levelId int32) *member.LevelBenefit {
+	if levelId <= 0 {
+		return nil
+	}
+	return l.rep.GetLevelBenefit(levelId)
+}
+
+// 保存等级权益
+func (l *levelManagerImpl) SaveLevelBenefit(v *member.LevelBenefit) error {
+	if lv := l.GetLevelById(v.LevelId); lv == nil {
+		return member.ErrLevelDisabled
+	}
+	if v.DiscountRate < 0 || v.DiscountRate > 1 {
+		return member.ErrLevelDiscountRate
+	}
+	if v.IntegralMultiple < 0 {
+		v.IntegralMultiple = 0
+	}
+	v.UpdateTime = time.Now().Unix()
+	return l.rep.SaveLevelBenefit(v)
+}
+
+// 根据经验值及等级规则评定会员可达到的等级,
+// 未设置规则的等级仍按经验值判断,均未达到时返回最低的等级
+func (l *levelManagerImpl) EvaluateLevel(memberId int64, exp int32) int32 {
+	now := time.Now()
+	stats := make(map[int64]*member.LevelStat)
+	arr := l.GetLevelSet()
+	var lowest int32
+	for i := len(arr); i > 0; i-- {
+		lv := arr[i-1]
+		if lv.Enabled != 1 {
+			continue
+		}
+		lowest = lv.ID
+		r := l.GetLevelRule(lv.ID)
+		if r == nil || r.Enabled != 1 {
+			if exp >= lv.RequireExp {
+				return lv.ID
+			}
+			continue
+		}
+		if exp < r.RequireExp {
+			continue
+		}
+		begin := levelPeriodBegin(r, now)
+		st, ok := stats[begin]
+		if !ok {
+			st = l.rep.GetLevelStat(memberId, begin, now.Unix())
+			stats[begin] = st
+		}
+		if st.Amount >= r.RequireAmount && st.Orders >= r.RequireOrders {
+			return lv.ID
+		}
+	}
+	return lowest
+}
+
+// 获取等级规则统计周期的开始时间
+func levelPeriodBegin(r *member.LevelRule, now time.Time) int64 {
+	switch r.PeriodType {
+	case member.LevelPeriodRolling:
+		return now.AddDate(0, -int(r.PeriodMonths), 0).Unix()
+	case member.LevelPeriodYear:
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()).Unix()
+	}
+	return 0
+}
+
+// 获取等级规则统计周期的长度,累计统计返回0
+func levelPeriodLength(r *member.LevelRule, from time.Time) time.Duration {
+	switch r.PeriodType {
+	case member.LevelPeriodRolling:
+		return from.AddDate(0, int(r.PeriodMonths), 0).Sub(from)
+	case member.LevelPeriodYear:
+		return from.AddDate(1, 0, 0).Sub(from)
+	}
+	return 0
+}
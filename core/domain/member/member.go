@@ -281,12 +281,12 @@ func (m *memberImpl) GetLevel() *member.Level {
 // 检查升级
 func (m *memberImpl) checkLevelUp() bool {
 	lg := m.manager.LevelManager()
-	levelId := lg.GetLevelIdByExp(m.value.Exp)
+	levelId := lg.EvaluateLevel(m.GetAggregateRootId(), m.value.Exp)
 	if levelId == 0 {
 		return false
 	}
 	// 判断是否大于当前等级
-	if m.value.Level > levelId {
+	if m.value.Level >= levelId {
 		return false
 	}
 	// 判断等级是否启用
@@ -294,6 +294,41 @@ func (m *memberImpl) checkLevelUp() bool {
 	if lv.Enabled == 0 {
 		return false
 	}
+	m.changeLevelByRule(levelId)
+	return true
+}
+
+// 根据等级规则重新评定等级,返回等级是否变更
+func (m *memberImpl) ReEvaluateLevel() (bool, error) {
+	lg := m.manager.LevelManager()
+	levelId := lg.EvaluateLevel(m.GetAggregateRootId(), m.value.Exp)
+	if levelId == 0 || levelId == m.value.Level {
+		return false, nil
+	}
+	// 降级需当前等级满足降级条件
+	if levelId < m.value.Level && !m.levelDowngradable(m.value.Level) {
+		return false, nil
+	}
+	return true, m.changeLevelByRule(levelId)
+}
+
+// 判断当前等级是否可以自动降级,等级变更后需满一个统计周期
+func (m *memberImpl) levelDowngradable(levelId int32) bool {
+	r := m.manager.LevelManager().GetLevelRule(levelId)
+	if r == nil || r.Enabled != 1 || r.AutoDowngrade != 1 {
+		return false
+	}
+	from := m.value.RegTime
+	if l := m.rep.GetLatestLevelUpLog(m.GetAggregateRootId()); l != nil {
+		from = l.CreateTime
+	}
+	t := time.Unix(from, 0)
+	d := levelPeriodLength(r, t)
+	return d > 0 && time.Now().After(t.Add(d))
+}
+
+// 根据规则变更等级,并记录变更日志
+func (m *memberImpl) changeLevelByRule(levelId int32) error {
 	origin := m.value.Level
 	unix := time.Now().Unix()
 	m.value.Level = levelId
@@ -312,7 +347,7 @@ func (m *memberImpl) checkLevelUp() bool {
 		}
 		_, err = m.rep.SaveLevelUpLog(lvLog)
 	}
-	return true
+	return err
 }
 
 // 更改会员等级
@@ -6,22 +6,40 @@
  * description :
  * history :
  */
-package member
+package member_test
 
 import (
 	"go2o/core/domain/interface/member"
+	"go2o/core/repository/memory"
 	"testing"
 )
 
 func TestDeliverAddressSave(t *testing.T) {
-	m := NewMember(NewMemberManager(nil, nil), &member.Member{
-		Id: 1,
-	}, nil, nil, nil)
-	d := m.Profile().GetDeliverAddress()[0]
+	repo := memory.NewRepos().MemberRepo
+	m := repo.CreateMember(&member.Member{Usr: "member001", Pwd: "123456"})
+	memberId, err := m.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = repo.GetMember(memberId)
+	d := m.Profile().CreateDeliver(&member.Address{
+		MemberId: memberId,
+		RealName: "测试用户",
+		Phone:    "13800138000",
+		Address:  "测试街道一号",
+	})
 	v := d.GetValue()
 	v.Province = 440000
 	v.City = 440600
 	v.District = 440605
-	d.SetValue(&v)
-	d.Save()
+	if err = d.SetValue(&v); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.Save(); err != nil {
+		t.Fatal(err)
+	}
+	list := m.Profile().GetDeliverAddress()
+	if len(list) != 1 || list[0].GetValue().District != 440605 {
+		t.Fatal("收货地址保存失败")
+	}
 }
@@ -11,6 +11,7 @@ package merchant
 import (
 	"go2o/core/domain/interface/merchant"
 	"go2o/core/infrastructure/domain"
+	"time"
 )
 
 var _ merchant.IApiManager = new(apiManagerImpl)
@@ -71,3 +72,35 @@ func (a *apiManagerImpl) DisableApiPerm() error {
 	v.Enabled = 0
 	return a.SaveApiInfo(v)
 }
+
+// 轮换密钥,原密钥在撤销前仍然有效
+func (a *apiManagerImpl) RotateSecret() (string, error) {
+	v := a.getApiInfo()
+	v.ApiSecret2 = v.ApiSecret
+	v.ApiSecret = domain.NewApiSecret()
+	return v.ApiSecret, a.SaveApiInfo(v)
+}
+
+// 撤销轮换前的密钥
+func (a *apiManagerImpl) RevokeOldSecret() error {
+	v := a.getApiInfo()
+	v.ApiSecret2 = ""
+	return a.SaveApiInfo(v)
+}
+
+// 保存接口每日调用统计,同一日期及类别的统计将被覆盖
+func (a *apiManagerImpl) SaveApiUsage(v *merchant.ApiUsage) error {
+	mchId := a.GetAggregateRootId()
+	if origin := a._rep.GetApiUsage(mchId, v.StatDate, v.ApiClass); origin != nil {
+		v.Id = origin.Id
+	}
+	v.MchId = mchId
+	v.UpdateTime = time.Now().Unix()
+	_, err := a._rep.SaveApiUsage(v)
+	return err
+}
+
+// 获取接口调用统计
+func (a *apiManagerImpl) GetApiUsages(begin int, end int) []*merchant.ApiUsage {
+	return a._rep.GetApiUsages(a.GetAggregateRootId(), begin, end)
+}
@@ -76,6 +76,15 @@ func (c *confManagerImpl) loadGlobSaleConf(dst *merchant.SaleConf) error {
 	dst.OrderConfirmAfterMinute = cfg.OrderConfirmAfterMinute
 	// 订单超时自动收货
 	dst.OrderTimeOutReceiveHour = cfg.OrderTimeOutReceiveHour
+	// 买家延长收货的小时数
+	dst.OrderReceiveExtendHour = cfg.OrderReceiveExtendHour
+	// 分销佣金冻结天数
+	dst.CommissionFreezeDays = cfg.CommissionFreezeDays
+	// 售后时效
+	dst.AsVendorHour = cfg.AsVendorHour
+	dst.AsReturnShipHour = cfg.AsReturnShipHour
+	dst.AsReceiveHour = cfg.AsReceiveHour
+	dst.AsDeclinedHour = cfg.AsDeclinedHour
 	return nil
 }
 
@@ -110,12 +119,31 @@ func (c *confManagerImpl) verifySaleConf(v *merchant.SaleConf) error {
 	if v.OrderTimeOutMinute <= 0 {
 		v.OrderTimeOutMinute = cfg.OrderTimeOutMinute
 	}
-	if v.OrderConfirmAfterMinute <= 0 {
+	// 0为立即确认,仅未设置时使用系统设置
+	if v.OrderConfirmAfterMinute < 0 {
 		v.OrderConfirmAfterMinute = cfg.OrderConfirmAfterMinute
 	}
 	if v.OrderTimeOutReceiveHour <= 0 {
 		v.OrderTimeOutReceiveHour = cfg.OrderTimeOutReceiveHour
 	}
+	if v.OrderReceiveExtendHour <= 0 {
+		v.OrderReceiveExtendHour = cfg.OrderReceiveExtendHour
+	}
+	if v.CommissionFreezeDays <= 0 {
+		v.CommissionFreezeDays = cfg.CommissionFreezeDays
+	}
+	if v.AsVendorHour <= 0 {
+		v.AsVendorHour = cfg.AsVendorHour
+	}
+	if v.AsReturnShipHour <= 0 {
+		v.AsReturnShipHour = cfg.AsReturnShipHour
+	}
+	if v.AsReceiveHour <= 0 {
+		v.AsReceiveHour = cfg.AsReceiveHour
+	}
+	if v.AsDeclinedHour <= 0 {
+		v.AsDeclinedHour = cfg.AsDeclinedHour
+	}
 	if v.CashBackPercent >= 1 || (v.CashBackTg1Percent+
 		v.CashBackTg2Percent+v.CashBackMemberPercent) > 1 {
 		v.FxSalesEnabled = 0 //自动关闭分销
@@ -178,3 +206,55 @@ func (c *confManagerImpl) GetGroupByGroupId(groupId int32) *merchant.MchBuyerGro
 	}
 	return nil
 }
+
+// 获取订单类型的时效,未设置的项使用销售设置
+func (c *confManagerImpl) GetOrderTimeout(orderType int32) merchant.OrderTimeout {
+	dst := merchant.OrderTimeout{MchId: c.mchId, OrderType: orderType,
+		ConfirmMinute: merchant.ConfirmMinuteUnset}
+	if v := c.repo.GetMchOrderTimeout(c.mchId, orderType); v != nil {
+		dst = *v
+	}
+	conf := c.GetSaleConf()
+	if dst.TimeoutMinute <= 0 {
+		dst.TimeoutMinute = conf.OrderTimeOutMinute
+	}
+	// 0为立即确认,仅未设置时使用销售设置
+	if dst.ConfirmMinute < 0 {
+		dst.ConfirmMinute = conf.OrderConfirmAfterMinute
+	}
+	if dst.ReceiveHour <= 0 {
+		dst.ReceiveHour = conf.OrderTimeOutReceiveHour
+	}
+	if dst.ReceiveExtendHour <= 0 {
+		dst.ReceiveExtendHour = conf.OrderReceiveExtendHour
+	}
+	return dst
+}
+
+// 获取按订单类型的时效设置
+func (c *confManagerImpl) SelectOrderTimeout() []*merchant.OrderTimeout {
+	return c.repo.SelectMchOrderTimeout(c.mchId)
+}
+
+// 保存订单类型的时效设置
+func (c *confManagerImpl) SaveOrderTimeout(v *merchant.OrderTimeout) error {
+	if v.OrderType <= 0 {
+		return merchant.ErrOrderType
+	}
+	if v.TimeoutMinute < 0 || v.ConfirmMinute < merchant.ConfirmMinuteUnset ||
+		v.ReceiveHour < 0 || v.ReceiveExtendHour < 0 {
+		return merchant.ErrOrderTimeout
+	}
+	v.ID = 0
+	v.MchId = c.mchId
+	if origin := c.repo.GetMchOrderTimeout(c.mchId, v.OrderType); origin != nil {
+		v.ID = origin.ID
+	}
+	_, err := c.repo.SaveMchOrderTimeout(v)
+	return err
+}
+
+// 删除订单类型的时效设置
+func (c *confManagerImpl) DeleteOrderTimeout(orderType int32) error {
+	return c.repo.DeleteMchOrderTimeout(c.mchId, orderType)
+}
@@ -0,0 +1,323 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : distribution_manager
+ * author : jarryliu
+ * date : 2026-10-18 11:20
+ * description :
+ * history :
+ */
+package merchant
+
+import (
+	"fmt"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/order"
+	"time"
+)
+
+var _ merchant.IDistributionManager = new(distributionManagerImpl)
+
+type distributionManagerImpl struct {
+	*merchantImpl
+	rules []*merchant.CommissionRule
+}
+
+func newDistributionManager(m *merchantImpl) merchant.IDistributionManager {
+	return &distributionManagerImpl{
+		merchantImpl: m,
+	}
+}
+
+// 获取全部佣金规则
+func (d *distributionManagerImpl) GetRules() []*merchant.CommissionRule {
+	if d.rules == nil {
+		d.rules = d._rep.GetCommissionRules(d.GetAggregateRootId())
+	}
+	return d.rules
+}
+
+// 是否有启用的佣金规则
+func (d *distributionManagerImpl) HasEnabledRule() bool {
+	for _, v := range d.GetRules() {
+		if v.Enabled == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取佣金规则
+func (d *distributionManagerImpl) GetRule(id int32) *merchant.CommissionRule {
+	for _, v := range d.GetRules() {
+		if v.Id == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// 保存佣金规则
+func (d *distributionManagerImpl) SaveRule(v *merchant.CommissionRule) (int32, error) {
+	arr := v.RateArray()
+	if len(arr) == 0 {
+		return 0, merchant.ErrCommissionRates
+	}
+	var total float32
+	for _, r := range arr {
+		if r < 0 {
+			return 0, merchant.ErrCommissionRates
+		}
+		total += r
+	}
+	if total > 1 {
+		return 0, merchant.ErrCommissionRates
+	}
+	// 同一商品或分类只能有一条规则
+	for _, r := range d.GetRules() {
+		if r.Id != v.Id && r.ItemId == v.ItemId && r.CatId == v.CatId {
+			return 0, merchant.ErrExistsCommissionRule
+		}
+	}
+	if v.Id > 0 && d.GetRule(v.Id) == nil {
+		return 0, merchant.ErrNoSuchCommissionRule
+	}
+	v.MchId = d.GetAggregateRootId()
+	v.UpdateTime = time.Now().Unix()
+	id, err := d._rep.SaveCommissionRule(v)
+	if err == nil {
+		v.Id = id
+		d.rules = nil
+	}
+	return id, err
+}
+
+// 删除佣金规则
+func (d *distributionManagerImpl) DeleteRule(id int32) error {
+	if d.GetRule(id) == nil {
+		return merchant.ErrNoSuchCommissionRule
+	}
+	err := d._rep.DeleteCommissionRule(d.GetAggregateRootId(), id)
+	if err == nil {
+		d.rules = nil
+	}
+	return err
+}
+
+// 根据商品及分类匹配佣金规则,商品规则>分类规则>默认规则
+func (d *distributionManagerImpl) MatchRule(itemId int64, catId int32) *merchant.CommissionRule {
+	var catRule, defRule *merchant.CommissionRule
+	for _, v := range d.GetRules() {
+		if v.Enabled != 1 {
+			continue
+		}
+		if v.ItemId > 0 {
+			if v.ItemId == itemId {
+				return v
+			}
+			continue
+		}
+		if v.CatId > 0 {
+			if v.CatId == catId {
+				catRule = v
+			}
+			continue
+		}
+		defRule = v
+	}
+	if catRule != nil {
+		return catRule
+	}
+	return defRule
+}
+
+// 获取规则中的最大层级
+func (d *distributionManagerImpl) maxDepth() int32 {
+	var depth int32
+	for _, v := range d.GetRules() {
+		if l := int32(len(v.RateArray())); v.Enabled == 1 && l > depth {
+			depth = l
+		}
+	}
+	return depth
+}
+
+// 计算订单佣金,并冻结到推荐人钱包账户
+func (d *distributionManagerImpl) Calculate(o *order.NormalSubOrder,
+	items []*order.SubOrderItem) (int, error) {
+	depth := d.maxDepth()
+	if depth <= 0 {
+		return 0, nil
+	}
+	buyer := d._memberRepo.GetMember(o.BuyerId)
+	if buyer == nil {
+		return 0, member.ErrNoSuchMember
+	}
+	// 已经计算过佣金
+	if len(d._rep.GetCommissions(o.ID, 0)) > 0 {
+		return 0, nil
+	}
+	inviters := buyer.Invitation().InviterArray(o.BuyerId, depth)
+	if len(inviters) == 0 || inviters[0] <= 0 {
+		return 0, nil
+	}
+	conf := d.ConfManager().GetSaleConf()
+	unix := time.Now().Unix()
+	unfreezeTime := unix + int64(conf.CommissionFreezeDays)*24*3600
+	n := 0
+	for _, it := range items {
+		if it.Quantity <= it.ReturnQuantity {
+			continue
+		}
+		var catId int32
+		if gv := d._itemRepo.GetValueGoodsById(it.ItemId); gv != nil {
+			catId = gv.CatId
+		}
+		rule := d.MatchRule(it.ItemId, catId)
+		if rule == nil {
+			continue
+		}
+		// 扣除已退货的商品金额
+		base := it.FinalAmount * float32(it.Quantity-it.ReturnQuantity) /
+			float32(it.Quantity)
+		for i, rate := range rule.RateArray() {
+			if i >= len(inviters) || inviters[i] <= 0 {
+				break
+			}
+			amount := base * rate
+			if amount <= 0 {
+				continue
+			}
+			c := &merchant.Commission{
+				MchId:        d.GetAggregateRootId(),
+				OrderId:      o.ID,
+				OrderNo:      o.OrderNo,
+				SnapshotId:   it.SnapshotId,
+				ItemId:       it.ItemId,
+				BuyerId:      o.BuyerId,
+				MemberId:     inviters[i],
+				Depth:        int32(i + 1),
+				BaseAmount:   base,
+				Rate:         rate,
+				Amount:       amount,
+				State:        merchant.CommissionFrozen,
+				UnfreezeTime: unfreezeTime,
+				CreateTime:   unix,
+				UpdateTime:   unix,
+			}
+			if err := d.freezeCommission(c); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// 佣金入账并冻结
+func (d *distributionManagerImpl) freezeCommission(c *merchant.Commission) error {
+	m := d._memberRepo.GetMember(c.MemberId)
+	if m == nil {
+		return member.ErrNoSuchMember
+	}
+	acc := m.GetAccount()
+	title := fmt.Sprintf("推广佣金(%d级),订单号:%s", c.Depth, c.OrderNo)
+	err := acc.Charge(member.AccountWallet, member.KindWalletAdd,
+		title, c.OrderNo, c.Amount, member.DefaultRelateUser)
+	if err == nil {
+		err = acc.FreezeWallet("推广佣金冻结,售后期结束后解冻",
+			c.OrderNo, c.Amount, member.DefaultRelateUser)
+	}
+	if err == nil {
+		c.Id, err = d._rep.SaveCommission(c)
+	}
+	return err
+}
+
+// 售后期结束后发放佣金
+func (d *distributionManagerImpl) Release(id int64) error {
+	c := d._rep.GetCommission(id)
+	if c == nil || c.MchId != d.GetAggregateRootId() {
+		return merchant.ErrNoSuchCommission
+	}
+	if c.State != merchant.CommissionFrozen {
+		return merchant.ErrCommissionNotFrozen
+	}
+	unix := time.Now().Unix()
+	if c.UnfreezeTime > unix {
+		return merchant.ErrCommissionNotExpired
+	}
+	m := d._memberRepo.GetMember(c.MemberId)
+	if m == nil {
+		return member.ErrNoSuchMember
+	}
+	if amount := c.Amount - c.ReverseAmount; amount > 0 {
+		err := m.GetAccount().UnfreezeWallet("推广佣金解冻",
+			c.OrderNo, amount, member.DefaultRelateUser)
+		if err != nil {
+			return err
+		}
+	}
+	c.State = merchant.CommissionReleased
+	c.UpdateTime = unix
+	_, err := d._rep.SaveCommission(c)
+	return err
+}
+
+// 退款时按比例撤销商品对应的佣金
+func (d *distributionManagerImpl) Reverse(orderId int64, snapshotId int64,
+	refundAmount float32) error {
+	if refundAmount <= 0 {
+		return nil
+	}
+	unix := time.Now().Unix()
+	for _, c := range d._rep.GetCommissions(orderId, snapshotId) {
+		if c.State == merchant.CommissionReversed || c.BaseAmount <= 0 {
+			continue
+		}
+		amount := c.Amount * refundAmount / c.BaseAmount
+		if remain := c.Amount - c.ReverseAmount; amount > remain {
+			amount = remain
+		}
+		if amount <= 0 {
+			continue
+		}
+		if err := d.reverseCommission(c, amount); err != nil {
+			return err
+		}
+		c.ReverseAmount += amount
+		if c.ReverseAmount >= c.Amount {
+			c.State = merchant.CommissionReversed
+		}
+		c.UpdateTime = unix
+		if _, err := d._rep.SaveCommission(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 从推荐人钱包扣除佣金,冻结中的佣金需先解冻
+func (d *distributionManagerImpl) reverseCommission(c *merchant.Commission, amount float32) error {
+	m := d._memberRepo.GetMember(c.MemberId)
+	if m == nil {
+		return member.ErrNoSuchMember
+	}
+	acc := m.GetAccount()
+	if c.State == merchant.CommissionFrozen {
+		err := acc.UnfreezeWallet("订单退款,推广佣金解冻",
+			c.OrderNo, amount, member.DefaultRelateUser)
+		if err != nil {
+			return err
+		}
+	}
+	title := fmt.Sprintf("订单退款,扣除推广佣金,订单号:%s", c.OrderNo)
+	return acc.DiscountWallet(title, c.OrderNo, amount,
+		member.DefaultRelateUser, false)
+}
+
+// 获取推荐人佣金报表
+func (d *distributionManagerImpl) GetReport(memberId int64, begin int64,
+	end int64) *merchant.CommissionReport {
+	return d._rep.GetCommissionReport(d.GetAggregateRootId(), memberId, begin, end)
+}
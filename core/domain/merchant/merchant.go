@@ -12,7 +12,6 @@ package merchant
 import (
 	"errors"
 	"fmt"
-	"github.com/jsix/gof/db/orm"
 	"go2o/core/domain/interface/enum"
 	"go2o/core/domain/interface/item"
 	"go2o/core/domain/interface/member"
@@ -24,7 +23,6 @@ import (
 	si "go2o/core/domain/merchant/shop"
 	userImpl "go2o/core/domain/merchant/user"
 	wsImpl "go2o/core/domain/merchant/wholesale"
-	"go2o/core/domain/tmp"
 	"go2o/core/infrastructure"
 	"go2o/core/infrastructure/domain"
 	"go2o/core/infrastructure/domain/util"
@@ -61,12 +59,11 @@ func (m *merchantManagerImpl) GetMemberFromSignUpToken(token string) int64 {
 
 // 删除会员的商户申请资料
 func (m *merchantManagerImpl) RemoveSignUp(memberId int64) error {
-	_, err := tmp.Db().GetOrm().Delete(merchant.MchSignUp{}, "member_id=?", memberId)
-	return err
+	return m.rep.DeleteSignUpInfo(memberId)
 }
 func (m *merchantManagerImpl) saveSignUpInfo(v *merchant.MchSignUp) (int32, error) {
 	v.UpdateTime = time.Now().Unix()
-	return orm.I32(orm.Save(tmp.Db().GetOrm(), v, int(v.Id)))
+	return m.rep.SaveSignUpInfo(v)
 }
 
 // 检查商户注册信息是否正确
@@ -217,29 +214,17 @@ func (m *merchantManagerImpl) createNewMerchant(v *merchant.MchSignUp) error {
 
 // 获取商户申请信息
 func (m *merchantManagerImpl) GetSignUpInfo(id int32) *merchant.MchSignUp {
-	v := merchant.MchSignUp{}
-	if tmp.Db().GetOrm().Get(id, &v) != nil {
-		return nil
-	}
-	return &v
+	return m.rep.GetSignUpInfo(id)
 }
 
 // 获取会员申请的商户信息
 func (m *merchantManagerImpl) GetSignUpInfoByMemberId(memberId int64) *merchant.MchSignUp {
-	v := merchant.MchSignUp{}
-	if tmp.Db().GetOrm().GetBy(&v, "member_id=?", memberId) != nil {
-		return nil
-	}
-	return &v
+	return m.rep.GetSignUpInfoByMemberId(memberId)
 }
 
 // 获取会员关联的商户
 func (m *merchantManagerImpl) GetMerchantByMemberId(memberId int64) merchant.IMerchant {
-	v := merchant.Merchant{}
-	if tmp.Db().GetOrm().GetBy(&v, "member_id=?", memberId) == nil {
-		return m.rep.CreateMerchant(&v)
-	}
-	return nil
+	return m.rep.GetMerchantByMemberId(memberId)
 }
 
 var _ merchant.IMerchant = new(merchantImpl)
@@ -266,6 +251,7 @@ type merchantImpl struct {
 	_profileManager merchant.IProfileManager
 	_apiManager     merchant.IApiManager
 	_shopManager    shop.IShopManager
+	_distManager    merchant.IDistributionManager
 }
 
 func NewMerchant(v *merchant.Merchant, rep merchant.IMerchantRepo,
@@ -589,6 +575,14 @@ func (m *merchantImpl) ApiManager() merchant.IApiManager {
 	return m._apiManager
 }
 
+// 分销服务
+func (m *merchantImpl) DistributionManager() merchant.IDistributionManager {
+	if m._distManager == nil {
+		m._distManager = newDistributionManager(m)
+	}
+	return m._distManager
+}
+
 // 商店服务
 func (m *merchantImpl) ShopManager() shop.IShopManager {
 	if m._shopManager == nil {
@@ -626,29 +620,17 @@ func (a *accountImpl) GetValue() *merchant.Account {
 
 // 保存
 func (a *accountImpl) Save() error {
-	_, err := orm.Save(tmp.Db().GetOrm(), a.value, int(a.GetDomainId()))
-	//_, err := a.mchImpl._rep.SaveMerchantAccount(a)
-	return err
+	return a.mchImpl._rep.UpdateAccount(a.value)
 }
 
 // 根据编号获取余额变动信息
 func (a *accountImpl) GetBalanceLog(id int32) *merchant.BalanceLog {
-	e := merchant.BalanceLog{}
-	if tmp.Db().GetOrm().Get(id, &e) == nil {
-		return &e
-	}
-	return nil
-	//return a.mchImpl._rep.GetBalanceLog(id)
+	return a.mchImpl._rep.GetBalanceLog(id)
 }
 
 // 根据号码获取余额变动信息
 func (a *accountImpl) GetBalanceLogByOuterNo(outerNo string) *merchant.BalanceLog {
-	e := merchant.BalanceLog{}
-	if tmp.Db().GetOrm().GetBy(&e, "outer_no=?", outerNo) == nil {
-		return &e
-	}
-	return nil
-	//return a.mchImpl._rep.GetBalanceLogByOuterNo(outerNo)
+	return a.mchImpl._rep.GetBalanceLogByOuterNo(outerNo)
 }
 
 func (a *accountImpl) createBalanceLog(kind int, title string, outerNo string,
@@ -680,8 +662,7 @@ func (a *accountImpl) createBalanceLog(kind int, title string, outerNo string,
 
 // 保存余额变动信息
 func (a *accountImpl) SaveBalanceLog(v *merchant.BalanceLog) (int32, error) {
-	return orm.I32(orm.Save(tmp.Db().GetOrm(), v, int(v.Id)))
-	//return a.mchImpl._rep.SaveBalanceLog(v)
+	return a.mchImpl._rep.SaveBalanceLog(v)
 }
 
 // 支出
@@ -0,0 +1,248 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : webhook
+ * author : jarryliu
+ * date : 2026-10-19 09:20
+ * description :
+ * history :
+ */
+package merchant
+
+import (
+	"bytes"
+	"encoding/json"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/infrastructure/domain"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// 通知发送器,可替换用于测试
+	WebhookSender merchant.WebhookSender = httpWebhookSender
+	webhookClient                        = &http.Client{Timeout: 10 * time.Second}
+)
+
+// 使用HTTP POST发送通知
+func httpWebhookSender(url string, header map[string]string,
+	body []byte) (int, string, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rsp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer rsp.Body.Close()
+	// 只读取部分响应内容
+	data, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 4096))
+	return rsp.StatusCode, string(data), nil
+}
+
+// 截取字符串到指定字符数,避免超出字段长度
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// 获取通知地址
+func (a *apiManagerImpl) GetWebhooks() []*merchant.Webhook {
+	return a._rep.GetWebhooks(a.GetAggregateRootId())
+}
+
+// 获取通知地址
+func (a *apiManagerImpl) getWebhook(id int32) *merchant.Webhook {
+	for _, v := range a.GetWebhooks() {
+		if v.Id == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// 保存通知地址,每个事件只能注册一个地址
+func (a *apiManagerImpl) SaveWebhook(v *merchant.Webhook) (int32, error) {
+	if !merchant.IsWebhookEvent(v.Event) {
+		return 0, merchant.ErrWebhookEvent
+	}
+	v.Url = strings.TrimSpace(v.Url)
+	if !v.CheckUrl() {
+		return 0, merchant.ErrWebhookUrl
+	}
+	for _, h := range a.GetWebhooks() {
+		if h.Event == v.Event && h.Id != v.Id {
+			v.Id = h.Id
+			break
+		}
+	}
+	if v.Id > 0 && a.getWebhook(v.Id) == nil {
+		return 0, merchant.ErrNoSuchWebhook
+	}
+	v.MchId = a.GetAggregateRootId()
+	v.UpdateTime = time.Now().Unix()
+	id, err := a._rep.SaveWebhook(v)
+	if err == nil {
+		v.Id = id
+	}
+	return id, err
+}
+
+// 删除通知地址
+func (a *apiManagerImpl) DeleteWebhook(id int32) error {
+	if a.getWebhook(id) == nil {
+		return merchant.ErrNoSuchWebhook
+	}
+	return a._rep.DeleteWebhook(a.GetAggregateRootId(), id)
+}
+
+// 发布事件,为订阅该事件的通知地址生成待发送的消息
+func (a *apiManagerImpl) PublishWebhook(event string, data interface{}) (int, error) {
+	if !merchant.IsWebhookEvent(event) {
+		return 0, merchant.ErrWebhookEvent
+	}
+	n := 0
+	unix := time.Now().Unix()
+	for _, h := range a.GetWebhooks() {
+		if h.Event != event || h.Enabled != 1 {
+			continue
+		}
+		payload, err := json.Marshal(map[string]interface{}{
+			"event":     event,
+			"mch_id":    h.MchId,
+			"timestamp": unix,
+			"data":      data,
+		})
+		if err != nil {
+			return n, err
+		}
+		_, err = a._rep.SaveWebhookMessage(&merchant.WebhookMessage{
+			MchId:      h.MchId,
+			HookId:     h.Id,
+			Event:      event,
+			Payload:    string(payload),
+			State:      merchant.WebhookPending,
+			NextTime:   unix,
+			CreateTime: unix,
+			UpdateTime: unix,
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// 获取商户的通知消息
+func (a *apiManagerImpl) getWebhookMessage(msgId int64) *merchant.WebhookMessage {
+	m := a._rep.GetWebhookMessage(msgId)
+	if m == nil || m.MchId != a.GetAggregateRootId() {
+		return nil
+	}
+	return m
+}
+
+// 发送通知消息,失败后按指数退避重试
+func (a *apiManagerImpl) DeliverWebhook(msgId int64) error {
+	m := a.getWebhookMessage(msgId)
+	if m == nil {
+		return merchant.ErrNoSuchWebhookMessage
+	}
+	if m.State == merchant.WebhookDelivered {
+		return merchant.ErrWebhookDelivered
+	}
+	// 通知地址已删除或停用时不再发送
+	h := a.getWebhook(m.HookId)
+	if h == nil || h.Enabled != 1 {
+		err := merchant.ErrNoSuchWebhook
+		if h != nil {
+			err = merchant.ErrWebhookDisabled
+		}
+		m.State = merchant.WebhookFailed
+		m.LastError = err.Error()
+		m.UpdateTime = time.Now().Unix()
+		if _, err2 := a._rep.SaveWebhookMessage(m); err2 != nil {
+			return err2
+		}
+		return err
+	}
+	start := time.Now()
+	ts := strconv.FormatInt(start.Unix(), 10)
+	body := []byte(m.Payload)
+	header := map[string]string{
+		"Content-Type":      "application/json; charset=utf-8",
+		"X-Go2o-Event":      m.Event,
+		"X-Go2o-Message-Id": strconv.FormatInt(m.Id, 10),
+		"X-Go2o-Timestamp":  ts,
+		"X-Go2o-Signature": domain.HmacWebhookSign(
+			a.GetApiInfo().ApiSecret, ts, body),
+	}
+	code, rsp, err := WebhookSender(h.Url, header, body)
+	l := &merchant.WebhookLog{
+		MsgId:      m.Id,
+		Url:        h.Url,
+		StatusCode: code,
+		Response:   truncate(rsp, merchant.WebhookResponseMaxLen),
+		Duration:   int64(time.Since(start) / time.Millisecond),
+		CreateTime: start.Unix(),
+	}
+	if err == nil && (code < 200 || code >= 300) {
+		err = domain.NewDomainError("err_mch_webhook_status",
+			"通知地址返回状态码:"+strconv.Itoa(code))
+	}
+	unix := time.Now().Unix()
+	if err == nil {
+		m.State = merchant.WebhookDelivered
+		m.LastError = ""
+	} else {
+		l.Error = truncate(err.Error(), merchant.WebhookErrorMaxLen)
+		m.LastError = l.Error
+		m.Retries++
+		if m.Retries >= merchant.WebhookMaxRetries {
+			m.State = merchant.WebhookFailed
+		} else {
+			// 按指数递增重试间隔
+			m.NextTime = unix + merchant.WebhookRetryBaseSeconds<<uint(m.Retries-1)
+		}
+	}
+	m.UpdateTime = unix
+	a._rep.SaveWebhookLog(l)
+	if _, err2 := a._rep.SaveWebhookMessage(m); err2 != nil {
+		return err2
+	}
+	return err
+}
+
+// 重新发送通知消息,重置重试次数
+func (a *apiManagerImpl) RedeliverWebhook(msgId int64) error {
+	m := a.getWebhookMessage(msgId)
+	if m == nil {
+		return merchant.ErrNoSuchWebhookMessage
+	}
+	m.State = merchant.WebhookPending
+	m.Retries = 0
+	m.NextTime = time.Now().Unix()
+	if _, err := a._rep.SaveWebhookMessage(m); err != nil {
+		return err
+	}
+	return a.DeliverWebhook(msgId)
+}
+
+// 获取通知消息的发送日志
+func (a *apiManagerImpl) GetWebhookLogs(msgId int64) []*merchant.WebhookLog {
+	if a.getWebhookMessage(msgId) == nil {
+		return []*merchant.WebhookLog{}
+	}
+	return a._rep.GetWebhookLogs(msgId)
+}
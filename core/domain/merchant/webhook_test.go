@@ -0,0 +1,101 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : webhook_test.go
+ * author : jarryliu
+ * date : 2026-10-21 13:40
+ * description :
+ * history :
+ */
+package merchant_test
+
+import (
+	"errors"
+	"go2o/core/domain/interface/merchant"
+	mchImpl "go2o/core/domain/merchant"
+	"go2o/core/repository/memory"
+	"strings"
+	"testing"
+)
+
+// 创建测试商户及通知地址,返回接口管理器及通知消息编号
+func createWebhookMessage(t *testing.T, r *memory.Repos) (merchant.IApiManager, int64) {
+	mchId, err := r.MerchantRepo.SaveMerchant(&merchant.Merchant{Usr: "mch001"})
+	if err != nil {
+		t.Fatal("创建商户失败:", err)
+	}
+	am := r.MerchantRepo.GetMerchant(mchId).ApiManager()
+	_, err = am.SaveWebhook(&merchant.Webhook{
+		Event:   merchant.EventOrderPaid,
+		Url:     "http://localhost/notify",
+		Enabled: 1,
+	})
+	if err != nil {
+		t.Fatal("保存通知地址失败:", err)
+	}
+	if n, err := am.PublishWebhook(merchant.EventOrderPaid, 1); n != 1 || err != nil {
+		t.Fatal("发布事件失败:", n, err)
+	}
+	return am, 1
+}
+
+// 替换通知发送器,返回发送次数
+func mockSender(code int, err error) *int {
+	n := 0
+	mchImpl.WebhookSender = func(url string, header map[string]string,
+		body []byte) (int, string, error) {
+		n++
+		return code, "", err
+	}
+	return &n
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	r := memory.NewRepos()
+	am, msgId := createWebhookMessage(t, r)
+	sent := mockSender(200, nil)
+	if err := am.DeliverWebhook(msgId); err != nil || *sent != 1 {
+		t.Fatal("发送通知失败:", err)
+	}
+	if m := r.MerchantRepo.GetWebhookMessage(msgId); m.State != merchant.WebhookDelivered {
+		t.Fatalf("通知消息状态不正确:%d", m.State)
+	}
+}
+
+// 测试发送失败后延迟重试,并截取过长的错误信息
+func TestDeliverWebhookRetry(t *testing.T) {
+	r := memory.NewRepos()
+	am, msgId := createWebhookMessage(t, r)
+	mockSender(0, errors.New(strings.Repeat("错", 300)))
+	if err := am.DeliverWebhook(msgId); err == nil {
+		t.Fatal("发送失败应返回错误")
+	}
+	m := r.MerchantRepo.GetWebhookMessage(msgId)
+	if m.State != merchant.WebhookPending || m.Retries != 1 || m.NextTime <= m.CreateTime {
+		t.Fatalf("通知消息应延迟重试:%#v", m)
+	}
+	if n := len([]rune(m.LastError)); n != merchant.WebhookErrorMaxLen {
+		t.Fatalf("错误信息长度不正确:%d", n)
+	}
+}
+
+// 测试通知地址删除或停用后不再发送
+func TestDeliverWebhookRemoved(t *testing.T) {
+	r := memory.NewRepos()
+	am, msgId := createWebhookMessage(t, r)
+	sent := mockSender(200, nil)
+	h := am.GetWebhooks()[0]
+	h.Enabled = 0
+	am.SaveWebhook(h)
+	if err := am.DeliverWebhook(msgId); err != merchant.ErrWebhookDisabled || *sent != 0 {
+		t.Fatal("通知地址停用后不应发送:", err)
+	}
+	if m := r.MerchantRepo.GetWebhookMessage(msgId); m.State != merchant.WebhookFailed {
+		t.Fatalf("通知消息应标记为失败:%d", m.State)
+	}
+
+	am.RedeliverWebhook(msgId)
+	am.DeleteWebhook(h.Id)
+	if err := am.DeliverWebhook(msgId); err != merchant.ErrNoSuchWebhook || *sent != 0 {
+		t.Fatal("通知地址删除后不应发送:", err)
+	}
+}
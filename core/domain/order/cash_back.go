@@ -68,14 +68,26 @@ func (o *subOrderImpl) handleCashBack() error {
 		// 处理返现促销
 		//todo: ????
 		//o.handleCashBackPromotions(mch, m)
-		// 三级返现
-		if back_fee > 0 {
+		// 分销佣金,未启用佣金规则时使用三级返现
+		dm := mch.DistributionManager()
+		if dm.HasEnabledRule() {
+			_, err = dm.Calculate(v, o.Items())
+		} else if back_fee > 0 {
 			err = o.backFor3R(mch, buyer, back_fee, now)
 		}
 	}
 	return err
 }
 
+// 退款时按比例撤销分销佣金
+func (o *subOrderImpl) ReverseCommission(snapshotId int64, amount float32) error {
+	mch := o.mchRepo.GetMerchant(o.value.VendorId)
+	if mch == nil {
+		return merchant.ErrNoSuchMerchant
+	}
+	return mch.DistributionManager().Reverse(o.GetDomainId(), snapshotId, amount)
+}
+
 func (o *subOrderImpl) updateMemberAccount(m member.IMember,
 	ptName, mName string, fee float32, unixTime int64) error {
 	if fee > 0 {
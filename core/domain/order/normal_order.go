@@ -289,12 +289,19 @@ func (o *normalOrderImpl) addItemToExpressCalculator(ue express.IUserExpress,
 // 更新订单金额,并返回运费
 func (o *normalOrderImpl) updateOrderFee(mp map[int32][]*order.SubOrderItem) map[int32]float32 {
 	o.value.ItemAmount = 0
+	o.value.DiscountAmount = 0
+	o.value.ExpressFee = 0
 	expCul := make(map[int32]express.IExpressCalculator)
 	expressMap := make(map[int32]float32)
+	bf := o.getBuyerLevelBenefit()
 	for k, v := range mp {
 		userExpress := o.expressRepo.GetUserExpress(k)
 		expCul[k] = userExpress.CreateCalculator()
 		for _, item := range v {
+			//应用会员等级折扣,按商品原价计算,避免重复计算时叠加折扣
+			if bf != nil && bf.HasDiscount() {
+				item.FinalAmount = item.Amount * bf.DiscountRate
+			}
 			//计算商品总金额
 			o.value.ItemAmount += item.Amount
 			//计算商品优惠金额
@@ -305,6 +312,10 @@ func (o *normalOrderImpl) updateOrderFee(mp map[int32][]*order.SubOrderItem) map
 		//计算商户的运费
 		expCul[k].Calculate("") //todo: 传入城市地区编号
 		expressMap[k] = expCul[k].Total()
+		//会员等级包邮
+		if bf != nil && bf.FreeShipping == 1 {
+			expressMap[k] = 0
+		}
 		//叠加运费
 		o.value.ExpressFee += expressMap[k]
 	}
@@ -315,6 +326,16 @@ func (o *normalOrderImpl) updateOrderFee(mp map[int32][]*order.SubOrderItem) map
 	return expressMap
 }
 
+// 获取购买会员的等级权益
+func (o *normalOrderImpl) getBuyerLevelBenefit() *member.LevelBenefit {
+	buyer := o.Buyer()
+	if buyer == nil {
+		return nil
+	}
+	lg := o.memberRepo.GetManager().LevelManager()
+	return lg.GetLevelBenefit(buyer.GetValue().Level)
+}
+
 // 根据运营商获取商品和运费信息,限未生成的订单
 func (o *normalOrderImpl) GetByVendor() (items map[int32][]*order.SubOrderItem,
 	expressFeeMap map[int32]float32) {
@@ -952,6 +973,9 @@ func (o *subOrderImpl) Complex() *order.ComplexOrder {
 	co.FinalAmount = float64(v.FinalAmount)
 	co.UpdateTime = v.UpdateTime
 	co.State = v.State
+	if v.ReceiveExtend > 0 {
+		co.Data[order.ComplexReceiveExtend] = strconv.Itoa(int(v.ReceiveExtend))
+	}
 	co.Items = []*order.ComplexItem{}
 	for _, v := range o.Items() {
 		co.Items = append(co.Items, o.parseComplexItem(v))
@@ -1271,6 +1295,29 @@ func (o *subOrderImpl) BuyerReceived() error {
 	return err
 }
 
+// 延长收货时间,每个订单只能延长一次
+func (o *subOrderImpl) ExtendReceive() error {
+	if o.value.State < order.StatShipped {
+		return order.ErrOrderNotShipped
+	}
+	if o.value.State >= order.StatCompleted {
+		return order.ErrIsCompleted
+	}
+	if o.value.ReceiveExtend > 0 {
+		return order.ErrReceiveExtended
+	}
+	hour := getReceiveExtendHour(o.mchRepo, o.value.VendorId, order.TRetail)
+	if hour <= 0 {
+		return order.ErrReceiveExtendDisabled
+	}
+	o.value.ReceiveExtend = int32(hour)
+	err := o.saveSubOrder()
+	if err == nil {
+		err = o.AppendLog(order.LogSetup, false, "{receive_extend}")
+	}
+	return err
+}
+
 func (s *subOrderImpl) getOrderAmount() (amount float32, refund float32) {
 	items := s.Items()
 	for _, item := range items {
@@ -1405,6 +1452,11 @@ func (o *subOrderImpl) updateAccountForOrder(m member.IMember) error {
 	// 增加积分
 	//todo: 增加阶梯的返积分,比如订单满30送100积分
 	integral := int64(amount*conf.IntegralRateByConsumption) + conf.IntegralBackExtra
+	// 会员等级积分倍数
+	lg := o.memberRepo.GetManager().LevelManager()
+	if bf := lg.GetLevelBenefit(m.GetValue().Level); bf != nil {
+		integral = int64(float32(integral) * bf.Multiple())
+	}
 	// 赠送积分
 	if integral > 0 {
 		err = m.GetAccount().AddIntegral(member.TypeIntegralShoppingPresent,
@@ -1418,6 +1470,12 @@ func (o *subOrderImpl) updateAccountForOrder(m member.IMember) error {
 	acv.TotalPay += ov.FinalAmount
 	acv.UpdateTime = time.Now().Unix()
 	_, err = acc.Save()
+	if err == nil {
+		// 根据等级规则评定等级,评定失败不影响订单完成,由定时任务重新评定
+		if _, er := m.ReEvaluateLevel(); er != nil {
+			log.Println("[ Order][ Level][ Error]:", m.GetAggregateRootId(), er.Error())
+		}
+	}
 	return err
 }
 
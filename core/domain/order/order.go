@@ -206,3 +206,13 @@ func FactoryNew(v *order.Order, manager order.IOrderManager,
 	}
 	return nil
 }
+
+// 获取商户设置的订单延长收货小时数
+func getReceiveExtendHour(mchRepo merchant.IMerchantRepo, vendorId int32,
+	orderType order.OrderType) int {
+	mch := mchRepo.GetMerchant(vendorId)
+	if mch == nil {
+		return 0
+	}
+	return mch.ConfManager().GetOrderTimeout(int32(orderType)).ReceiveExtendHour
+}
@@ -466,6 +466,21 @@ func (u *unifiedOrderAdapterImpl) buyerReceived() error {
 	return nil
 }
 
+// 延长收货时间
+func (u *unifiedOrderAdapterImpl) ExtendReceive() error {
+	if err := u.check(); err != nil {
+		return err
+	}
+	if u.sub {
+		return u.subOrder.ExtendReceive()
+	}
+	switch u.bigOrder.Type() {
+	case order.TWholesale:
+		return u.bigOrder.(order.IWholesaleOrder).ExtendReceive()
+	}
+	return order.ErrReceiveExtendDisabled
+}
+
 // 获取订单日志
 func (u *unifiedOrderAdapterImpl) LogBytes() []byte {
 	if err := u.check(); err != nil {
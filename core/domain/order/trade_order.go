@@ -228,6 +228,8 @@ func (o *tradeOrderImpl) CashPay() error {
 	}
 	err := py.PaymentFinish("现金支付", "000000000")
 	if err == nil {
+		// 支付完成后交易单已更新,重新读取
+		o.value = nil
 		o.getValue()
 		o.value.CashPay = 1
 		return o.saveTradeOrder()
@@ -225,6 +225,9 @@ func (o *wholesaleOrderImpl) Complex() *order.ComplexOrder {
 	co.BuyerComment = v.BuyerComment
 	co.IsBreak = 0
 	co.UpdateTime = v.UpdateTime
+	if v.ReceiveExtend > 0 {
+		co.Data[order.ComplexReceiveExtend] = strconv.Itoa(int(v.ReceiveExtend))
+	}
 	co.Items = []*order.ComplexItem{}
 	for _, v := range o.Items() {
 		co.Items = append(co.Items, o.parseComplexItem(v))
@@ -654,6 +657,29 @@ func (o *wholesaleOrderImpl) BuyerReceived() error {
 	return err
 }
 
+// 延长收货时间,每个订单只能延长一次
+func (o *wholesaleOrderImpl) ExtendReceive() error {
+	if o.value.State < order.StatShipped {
+		return order.ErrOrderNotShipped
+	}
+	if o.value.State >= order.StatCompleted {
+		return order.ErrIsCompleted
+	}
+	if o.value.ReceiveExtend > 0 {
+		return order.ErrReceiveExtended
+	}
+	hour := getReceiveExtendHour(o.mchRepo, o.value.VendorId, order.TWholesale)
+	if hour <= 0 {
+		return order.ErrReceiveExtendDisabled
+	}
+	o.value.ReceiveExtend = int32(hour)
+	err := o.saveWholesaleOrder()
+	if err == nil {
+		err = o.AppendLog(order.LogSetup, false, "{receive_extend}")
+	}
+	return err
+}
+
 func (s *wholesaleOrderImpl) getOrderAmount() (amount float32, refund float32) {
 	items := s.Items()
 	for _, item := range items {
@@ -14,7 +14,6 @@ import (
 	"go2o/core/domain/interface/payment"
 	"go2o/core/domain/interface/promotion"
 	"go2o/core/domain/interface/valueobject"
-	"go2o/core/infrastructure/domain"
 	"math"
 	"regexp"
 	"strings"
@@ -69,21 +68,18 @@ func (p *paymentOrderImpl) fixFee() {
 }
 
 // 更新订单状态, 需要注意,防止多次订单更新
-func (p *paymentOrderImpl) notifyPaymentFinish() {
+func (p *paymentOrderImpl) notifyPaymentFinish() error {
 	if p.GetAggregateRootId() <= 0 {
 		panic(payment.ErrNoSuchPaymentOrder)
 	}
-
-	//err := p._rep.NotifyPaymentFinish(p.GetAggregateRootId())
-	//if err != nil {
-	//	err = errors.New("Notify payment finish error :" + err.Error())
-	//	domain.HandleError(err, "domain")
-	//}
-	// 通知订单支付完成
+	// 通知订单支付完成,订单已支付时忽略
 	if p.value.OrderId > 0 {
 		err := p.orderManager.NotifyOrderTradeSuccess(int64(p.value.OrderId))
-		domain.HandleError(err, "domain")
+		if err != nil && err != order.ErrOrderPayed {
+			return err
+		}
 	}
+	return nil
 }
 
 // 优惠券抵扣
@@ -360,10 +356,10 @@ func (p *paymentOrderImpl) save() (int32, error) {
 		p.value.Id, err = p.rep.SavePaymentOrder(p.value)
 	}
 
-	//保存支付单后,通知支付成功。只通知一次
+	//保存支付单后,通知支付成功。只通知一次,在工作单元中时与支付单一同提交
 	if err == nil && p.firstFinishPayment {
 		p.firstFinishPayment = false
-		go p.notifyPaymentFinish()
+		err = p.notifyPaymentFinish()
 	}
 	return p.GetAggregateRootId(), err
 }
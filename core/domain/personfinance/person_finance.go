@@ -47,12 +47,20 @@ func (p *PersonFinance) GetRiseInfo() personfinance.IRiseInfo {
 	return newRiseInfo(p.GetAggregateRootId(), p, p.rep, p.accRepo)
 }
 
-// 创建增利账户信息
-func (p *PersonFinance) CreateRiseInfo() error {
+// 创建增利账户信息,planId为选择的理财计划
+func (p *PersonFinance) CreateRiseInfo(planId int32) error {
 	_, err := p.GetRiseInfo().Value()
 	if err != nil {
+		plan := p.rep.GetPlanManager().GetPlan(planId)
+		if plan == nil {
+			return personfinance.ErrNoSuchPlan
+		}
+		if plan.Enabled != 1 {
+			return personfinance.ErrPlanDisabled
+		}
 		v := &personfinance.RiseInfoValue{
 			PersonId:   p.GetAggregateRootId(),
+			PlanId:     plan.Id,
 			UpdateTime: time.Now().Unix(),
 		}
 		_, err = p.rep.SaveRiseInfo(v)
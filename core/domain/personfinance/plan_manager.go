@@ -0,0 +1,137 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : plan_manager
+ * author : jarryliu
+ * date : 2026-10-18 14:30
+ * description :
+ * history :
+ */
+package personfinance
+
+import (
+	"go2o/core/domain/interface/personfinance"
+	"go2o/core/infrastructure/tool"
+	"strings"
+	"time"
+)
+
+var _ personfinance.IPlanManager = new(planManagerImpl)
+
+type planManagerImpl struct {
+	rep personfinance.IPersonFinanceRepository
+}
+
+func NewPlanManager(rep personfinance.IPersonFinanceRepository) personfinance.IPlanManager {
+	return &planManagerImpl{
+		rep: rep,
+	}
+}
+
+// 获取默认理财计划,使用系统默认配置
+func (p *planManagerImpl) defaultPlan() *personfinance.FinancePlan {
+	return &personfinance.FinancePlan{
+		Id:             personfinance.DefaultPlanId,
+		Name:           "默认",
+		SettleTValue:   personfinance.RiseSettleTValue,
+		MinTransferIn:  personfinance.RiseMinTransferInAmount,
+		MinTransferOut: personfinance.RiseMinTransferOutAmount,
+		Enabled:        1,
+	}
+}
+
+// 获取全部理财计划
+func (p *planManagerImpl) GetAllPlans() []*personfinance.FinancePlan {
+	return p.rep.GetPlans()
+}
+
+// 获取理财计划,planId为0时返回默认计划
+func (p *planManagerImpl) GetPlan(planId int32) *personfinance.FinancePlan {
+	if planId == personfinance.DefaultPlanId {
+		return p.defaultPlan()
+	}
+	return p.rep.GetPlan(planId)
+}
+
+// 保存理财计划
+func (p *planManagerImpl) SavePlan(v *personfinance.FinancePlan) (int32, error) {
+	v.Name = strings.TrimSpace(v.Name)
+	if v.Name == "" {
+		return 0, personfinance.ErrPlanName
+	}
+	if v.SettleTValue < 0 || v.LockDays < 0 {
+		return 0, personfinance.ErrPlanSettleT
+	}
+	if v.MinTransferIn < 0 || v.MinTransferOut < 0 || v.MaxAmount < 0 ||
+		(v.MaxAmount > 0 && v.MaxAmount < v.MinTransferIn) {
+		return 0, personfinance.ErrPlanAmount
+	}
+	if v.PenaltyRate < 0 || v.PenaltyRate >= 1 {
+		return 0, personfinance.ErrPlanPenaltyRate
+	}
+	if v.Id > 0 && p.rep.GetPlan(v.Id) == nil {
+		return 0, personfinance.ErrNoSuchPlan
+	}
+	v.UpdateTime = time.Now().Unix()
+	id, err := p.rep.SavePlan(v)
+	if err == nil {
+		v.Id = id
+	}
+	return id, err
+}
+
+// 获取理财计划的利率表,按生效日期排序
+func (p *planManagerImpl) GetRates(planId int32) []*personfinance.PlanRate {
+	return p.rep.GetPlanRates(planId)
+}
+
+// 保存利率
+func (p *planManagerImpl) SaveRate(v *personfinance.PlanRate) (int32, error) {
+	if p.rep.GetPlan(v.PlanId) == nil {
+		return 0, personfinance.ErrNoSuchPlan
+	}
+	if v.AnnualRate < 0 || v.AnnualRate >= 1 {
+		return 0, personfinance.ErrRatio
+	}
+	if v.EffectiveDate <= 0 {
+		return 0, personfinance.ErrPlanRateDate
+	}
+	// 生效日期按天计算
+	v.EffectiveDate = tool.GetStartDate(time.Unix(v.EffectiveDate, 0)).Unix()
+	for _, r := range p.GetRates(v.PlanId) {
+		if r.Id != v.Id && r.EffectiveDate == v.EffectiveDate {
+			return 0, personfinance.ErrPlanRateExists
+		}
+	}
+	if v.CreateTime <= 0 {
+		v.CreateTime = time.Now().Unix()
+	}
+	id, err := p.rep.SavePlanRate(v)
+	if err == nil {
+		v.Id = id
+	}
+	return id, err
+}
+
+// 删除利率
+func (p *planManagerImpl) DeleteRate(planId int32, id int32) error {
+	return p.rep.DeletePlanRate(planId, id)
+}
+
+// 获取理财计划在指定日期的日收益比率,使用生效日期不晚于该日期的最新利率
+func (p *planManagerImpl) GetDayRatio(planId int32, personId int64,
+	unixDate int64) float32 {
+	if planId == personfinance.DefaultPlanId {
+		return personfinance.RiseDayRatioProvider(personId)
+	}
+	var rate *personfinance.PlanRate
+	for _, v := range p.GetRates(planId) {
+		if v.EffectiveDate <= unixDate &&
+			(rate == nil || v.EffectiveDate > rate.EffectiveDate) {
+			rate = v
+		}
+	}
+	if rate == nil {
+		return 0
+	}
+	return rate.DayRatio()
+}
@@ -25,6 +25,7 @@ var _ personfinance.IRiseInfo = new(riseInfo)
 type riseInfo struct {
 	personId int64
 	value    *personfinance.RiseInfoValue
+	plan     *personfinance.FinancePlan
 	rep      personfinance.IPersonFinanceRepository
 	mmRepo   member.IMemberRepo
 	pf       *PersonFinance
@@ -45,6 +46,68 @@ func (r *riseInfo) GetDomainId() int64 {
 	return r.personId
 }
 
+// 获取理财计划
+func (r *riseInfo) GetPlan() *personfinance.FinancePlan {
+	if r.plan == nil {
+		var planId int32
+		if v, err := r.Value(); err == nil {
+			planId = v.PlanId
+		}
+		r.plan = r.rep.GetPlanManager().GetPlan(planId)
+	}
+	return r.plan
+}
+
+// 获取指定日期的日收益比率
+func (r *riseInfo) GetDayRatio(unixDate int64) float32 {
+	if _, err := r.Value(); err != nil {
+		return 0
+	}
+	return r.rep.GetPlanManager().GetDayRatio(r.value.PlanId,
+		r.GetDomainId(), unixDate)
+}
+
+// 获取锁定期内的金额,按每笔转入分别计算锁定期,不超过余额
+func (r *riseInfo) GetLockedAmount() float32 {
+	if _, err := r.Value(); err != nil {
+		return 0
+	}
+	plan := r.GetPlan()
+	if plan == nil || plan.LockDays <= 0 {
+		return 0
+	}
+	begin := time.Now().Add(-time.Hour * 24 * time.Duration(plan.LockDays))
+	var locked float32
+	for _, v := range r.rep.GetRiseLogsAfter(r.GetDomainId(),
+		personfinance.RiseTypeTransferIn, begin.Unix()) {
+		locked += v.Amount
+	}
+	if locked > r.value.Balance {
+		return r.value.Balance
+	}
+	return locked
+}
+
+// 获取转出金额需支付的违约金,优先转出锁定期外的金额,
+// 仅对锁定期内的部分收取违约金
+func (r *riseInfo) GetTransferOutPenalty(amount float32) float32 {
+	if _, err := r.Value(); err != nil {
+		return 0
+	}
+	plan := r.GetPlan()
+	if plan == nil || plan.PenaltyRate <= 0 {
+		return 0
+	}
+	lockedOut := amount - (r.value.Balance - r.GetLockedAmount())
+	if lockedOut <= 0 {
+		return 0
+	}
+	if lockedOut > amount {
+		lockedOut = amount
+	}
+	return float32(format.FixedDecimal(float64(lockedOut * plan.PenaltyRate)))
+}
+
 // 根据日志记录提交转入转出,如果已经确认操作,则返回错误
 // 通常是由系统计划任务来完成此操作,转入和转出必须经过提交!
 func (r *riseInfo) CommitTransfer(logId int32) (err error) {
@@ -123,15 +186,22 @@ func (r *riseInfo) TransferIn(amount float32,
 	if amount <= 0 || math.IsNaN(float64(amount)) {
 		return personfinance.ErrIncorrectAmount
 	}
-	if amount < personfinance.RiseMinTransferInAmount {
-		return errors.New(fmt.Sprintf(personfinance.ErrLessThanMinTransferIn.Error(),
-			format.FormatFloat(personfinance.RiseMinTransferInAmount)))
+	plan := r.GetPlan()
+	if plan == nil {
+		return personfinance.ErrNoSuchPlan
 	}
-
-	if amount < personfinance.RiseMinTransferInAmount {
+	if plan.Enabled != 1 {
+		return personfinance.ErrPlanDisabled
+	}
+	if amount < plan.MinTransferIn {
 		//金额不足最低转入金额
 		return errors.New(fmt.Sprintf(personfinance.ErrLessThanMinTransferIn.Error(),
-			format.FormatFloat(personfinance.RiseMinTransferInAmount)))
+			format.FormatFloat(plan.MinTransferIn)))
+	}
+	if plan.MaxAmount > 0 && r.value.Balance+r.value.TransferIn+amount > plan.MaxAmount {
+		//超出最高持有金额
+		return errors.New(fmt.Sprintf(personfinance.ErrMoreThanMaxAmount.Error(),
+			format.FormatFloat(plan.MaxAmount)))
 	}
 	err = r.transferInPayment(amount, transferWith)
 	if err == nil {
@@ -161,38 +231,45 @@ func (r *riseInfo) TransferIn(amount float32,
 }
 
 // 转出,w为转出方式(如银行,余额等),state为日志的状态,某些操作
-// 需要确认,有些不需要.通过state来传入
+// 需要确认,有些不需要.通过state来传入;锁定期内转出时,转出日志记录
+// 扣除违约金后的金额,并返回违约金
 func (r *riseInfo) TransferOut(amount float32,
-	w personfinance.TransferWith, state int) (err error) {
+	w personfinance.TransferWith, state int) (penalty float32, err error) {
 	if r.value == nil {
 		//判断会员是否存在
 		if _, err = r.Value(); err != nil {
-			return err
+			return 0, err
 		}
 	}
 	if amount <= 0 || math.IsNaN(float64(amount)) {
-		return personfinance.ErrIncorrectAmount
+		return 0, personfinance.ErrIncorrectAmount
 	}
 
 	if amount > r.value.Balance {
 		//超出账户金额
-		return personfinance.ErrOutOfBalance
+		return 0, personfinance.ErrOutOfBalance
 	}
 
+	plan := r.GetPlan()
+	if plan == nil {
+		return 0, personfinance.ErrNoSuchPlan
+	}
 	// 低于最低转出金额,且不是全部转出.返回错误. 若转出到余额则无限制
 	if amount != r.value.Balance && //非全部转出
 		w != personfinance.TransferOutWithBalance && //非转出余额
-		amount < personfinance.RiseMinTransferOutAmount {
-		if r.value.Balance > personfinance.RiseMinTransferOutAmount {
+		amount < plan.MinTransferOut {
+		if r.value.Balance > plan.MinTransferOut {
 			//金额大于转出金额
-			return errors.New(fmt.Sprintf(personfinance.ErrLessThanMinTransferOut.Error(),
-				format.FormatFloat(personfinance.RiseMinTransferOutAmount)))
+			return 0, errors.New(fmt.Sprintf(personfinance.ErrLessThanMinTransferOut.Error(),
+				format.FormatFloat(plan.MinTransferOut)))
 		} else {
 			//金额小于转出金额
-			return errors.New(fmt.Sprintf(personfinance.ErrMustAllTransferOut.Error(),
-				format.FormatFloat(personfinance.RiseMinTransferOutAmount)))
+			return 0, errors.New(fmt.Sprintf(personfinance.ErrMustAllTransferOut.Error(),
+				format.FormatFloat(plan.MinTransferOut)))
 		}
 	}
+	// 锁定期内转出的违约金
+	penalty = r.GetTransferOutPenalty(amount)
 
 	dt := time.Now()
 	r.value.UpdateTime = dt.Unix()
@@ -215,7 +292,7 @@ func (r *riseInfo) TransferOut(amount float32,
 		_, err = r.rep.SaveRiseLog(&personfinance.RiseLog{
 			PersonId:     r.GetDomainId(),
 			Title:        "[转出]转出到" + personfinance.TransferOutWithText(w),
-			Amount:       amount,
+			Amount:       amount - penalty,
 			Type:         personfinance.RiseTypeTransferOut,
 			TransferWith: int(w),
 			State:        state,
@@ -224,8 +301,22 @@ func (r *riseInfo) TransferOut(amount float32,
 			UpdateTime:   r.value.UpdateTime,
 		})
 	}
+	if err == nil && penalty > 0 {
+		// 记录违约金日志
+		_, err = r.rep.SaveRiseLog(&personfinance.RiseLog{
+			PersonId:     r.GetDomainId(),
+			Title:        "[违约金]锁定期内提前转出",
+			Amount:       penalty,
+			Type:         personfinance.RiseTypePenalty,
+			TransferWith: int(w),
+			State:        personfinance.RiseStateOk,
+			UnixDate:     tool.GetStartDate(dt).Unix(),
+			LogTime:      r.value.UpdateTime,
+			UpdateTime:   r.value.UpdateTime,
+		})
+	}
 	//todo: 新增操作记录,如审核,打款,完成等
-	return err
+	return penalty, err
 }
 
 // 结算收益(按天结息),settleUnix:结算日期的时间戳(不含时间),
@@ -13,6 +13,14 @@ type LoginMember struct {
 	Id         int
 	Token      string
 	UpdateTime int64
+	// 访问令牌
+	AccessToken string
+	// 访问令牌有效时间(秒)
+	ExpiresIn int64
+	// 刷新令牌
+	RefreshToken string
+	// 设备编号
+	DeviceId string
 }
 
 // 会员登录返回结果
@@ -83,7 +83,7 @@ func (r *repoFactory) Init(db db.Connector, sto storage.Interface) {
 	r.itemWsRepo = repository.NewItemWholesaleRepo(db)
 	r.catRepo = repository.NewCategoryRepo(db, r.valueRepo, sto)
 	r.itemRepo = repository.NewGoodsItemRepo(db, r.catRepo, r.productRepo,
-		r.proMRepo, r.itemWsRepo, r.expressRepo, r.valueRepo)
+		r.proMRepo, r.itemWsRepo, r.expressRepo, r.valueRepo, sto)
 	r.tagSaleRepo = repository.NewTagSaleRepo(db, r.valueRepo)
 	r.promRepo = repository.NewPromotionRepo(db, r.itemRepo, r.memberRepo)
 
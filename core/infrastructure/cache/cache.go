@@ -0,0 +1,237 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : cache.go
+ * author : jarryliu
+ * date : 2026-10-20 18:20
+ * description : 仓储读穿缓存,进程内LRU在前,Redis在后;
+ *               数据保存后删除缓存并通过Redis广播,各实例清除本地缓存
+ * history :
+ */
+package cache
+
+import (
+	"encoding/json"
+	"github.com/garyburd/redigo/redis"
+	"github.com/jsix/gof/storage"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 失效广播频道
+const Channel = "go2o:pubsub:cache"
+
+var (
+	// 默认的缓存时间(秒)
+	DefaultSeconds int64 = 3600
+	// 本地缓存的最长时间,防止错过广播后长期使用旧数据
+	LocalMaxSeconds int64 = 60
+	// 本地缓存的默认容量
+	DefaultLocalSize = 1000
+)
+
+var (
+	mux    sync.RWMutex
+	caches = map[string][]*Cache{}
+)
+
+// 读穿缓存
+type Cache struct {
+	name  string
+	sto   storage.Interface
+	ttl   int64
+	local *LRU
+	// 提交后执行,不为nil时读取不使用缓存
+	after func(func())
+}
+
+// 创建缓存,name用于区分缓存及广播,ttl为缓存的秒数,
+// localSize为本地缓存的容量,为0时不使用本地缓存
+func New(name string, sto storage.Interface, ttl int64, localSize int) *Cache {
+	c := &Cache{name: name, sto: sto, ttl: ttl}
+	if localSize > 0 {
+		c.local = NewLRU(localSize)
+	}
+	mux.Lock()
+	caches[name] = append(caches[name], c)
+	mux.Unlock()
+	return c
+}
+
+// 缓存名称
+func (c *Cache) Name() string {
+	return c.name
+}
+
+// 返回用于事务的缓存副本,after用于注册事务提交后执行的函数。
+// 副本读取时不使用缓存,失效立即执行并在提交后再次执行
+func (c *Cache) Bind(after func(func())) *Cache {
+	b := *c
+	b.after = after
+	return &b
+}
+
+func (c *Cache) storeKey(key string) string {
+	return "go2o:cache:" + c.name + ":" + key
+}
+
+func (c *Cache) localTTL() time.Duration {
+	s := c.ttl
+	if s <= 0 || s > LocalMaxSeconds {
+		s = LocalMaxSeconds
+	}
+	return time.Duration(s) * time.Second
+}
+
+// 获取数据到dst,缓存中不存在时调用load填充dst并缓存;
+// load返回错误时不缓存,并返回该错误
+func (c *Cache) Get(key string, dst interface{}, load func() error) error {
+	if c.after != nil {
+		return load()
+	}
+	if c.local != nil {
+		if b, ok := c.local.Get(key); ok && json.Unmarshal(b, dst) == nil {
+			return nil
+		}
+	}
+	sk := c.storeKey(key)
+	b, err := c.sto.GetBytes(sk)
+	if err == nil && len(b) > 0 && json.Unmarshal(b, dst) == nil {
+		if c.local != nil {
+			c.local.Set(key, b, c.localTTL())
+		}
+		return nil
+	}
+	if err = load(); err != nil {
+		return err
+	}
+	if b, err = json.Marshal(dst); err == nil {
+		if c.ttl > 0 {
+			c.sto.SetExpire(sk, b, c.ttl)
+		} else {
+			c.sto.Set(sk, b)
+		}
+		if c.local != nil {
+			c.local.Set(key, b, c.localTTL())
+		}
+	}
+	return nil
+}
+
+// 使缓存失效并广播到其他实例
+func (c *Cache) Invalidate(keys ...string) {
+	for _, k := range keys {
+		c.invalidate(k)
+	}
+	if c.after != nil {
+		c.after(func() {
+			for _, k := range keys {
+				c.invalidate(k)
+			}
+		})
+	}
+}
+
+// 使指定前缀的缓存失效并广播到其他实例,前缀为空时清除全部
+func (c *Cache) InvalidatePrefix(prefix string) {
+	c.invalidate(prefix + "*")
+	if c.after != nil {
+		c.after(func() { c.invalidate(prefix + "*") })
+	}
+}
+
+// 删除缓存并广播,以"*"结尾时按前缀删除
+func (c *Cache) invalidate(key string) {
+	if strings.HasSuffix(key, "*") {
+		if _, err := c.sto.DelWith(c.storeKey(key)); err != nil {
+			log.Println("[ Go2o][ Cache]: clean by prefix ", key, " error:", err)
+		}
+	} else {
+		c.sto.Del(c.storeKey(key))
+	}
+	c.evict(key)
+	c.publish(key)
+}
+
+// 清除本地缓存,以"*"结尾时按前缀清除
+func (c *Cache) evict(key string) {
+	if c.local == nil {
+		return
+	}
+	if strings.HasSuffix(key, "*") {
+		c.local.DelPrefix(strings.TrimSuffix(key, "*"))
+	} else {
+		c.local.Del(key)
+	}
+}
+
+func (c *Cache) publish(key string) {
+	rds, ok := c.sto.(storage.IRedisStorage)
+	if !ok {
+		return
+	}
+	conn := rds.GetConn()
+	defer conn.Close()
+	if _, err := conn.Do("PUBLISH", Channel, c.name+"\n"+key); err != nil {
+		log.Println("[ Go2o][ Cache]: publish invalidation error:", err)
+	}
+}
+
+// 按名称清除各缓存的本地数据
+func evictLocal(name, key string) {
+	mux.RLock()
+	defer mux.RUnlock()
+	for _, c := range caches[name] {
+		c.evict(key)
+	}
+}
+
+// 清空所有本地缓存
+func purgeLocal() {
+	mux.RLock()
+	defer mux.RUnlock()
+	for _, arr := range caches {
+		for _, c := range arr {
+			if c.local != nil {
+				c.local.Purge()
+			}
+		}
+	}
+}
+
+// 订阅失效广播,直到连接出错时返回。订阅成功后清空本地缓存,
+// 以丢弃断开期间可能错过失效的数据
+func Subscribe(conn redis.Conn) error {
+	psc := redis.PubSubConn{Conn: conn}
+	if err := psc.Subscribe(Channel); err != nil {
+		return err
+	}
+	defer psc.Unsubscribe()
+	purgeLocal()
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			if i := strings.Index(string(v.Data), "\n"); i != -1 {
+				evictLocal(string(v.Data[:i]), string(v.Data[i+1:]))
+			}
+		case error:
+			return v
+		}
+	}
+}
+
+// 监听失效广播,断开后自动重连;非Redis存储时直接返回
+func Listen(sto storage.Interface) {
+	rds, ok := sto.(storage.IRedisStorage)
+	if !ok {
+		return
+	}
+	for {
+		conn := rds.GetConn()
+		err := Subscribe(conn)
+		conn.Close()
+		log.Println("[ Go2o][ Cache]: subscribe invalidation error:", err)
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,153 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : cache_test.go
+ * author : jarryliu
+ * date : 2026-10-20 19:10
+ * description :
+ * history :
+ */
+package cache_test
+
+import (
+	"errors"
+	"fmt"
+	"go2o/core/infrastructure/cache"
+	"go2o/core/repository/memory"
+	"testing"
+	"time"
+)
+
+type testValue struct {
+	Id   int32
+	Name string
+}
+
+func TestLRU(t *testing.T) {
+	l := cache.NewLRU(2)
+	l.Set("a", []byte("1"), time.Minute)
+	l.Set("b", []byte("2"), time.Minute)
+	l.Get("a")
+	l.Set("c", []byte("3"), time.Minute)
+	if _, ok := l.Get("b"); ok {
+		t.Fatal("最久未使用的项应被淘汰")
+	}
+	if b, ok := l.Get("a"); !ok || string(b) != "1" {
+		t.Fatal("最近使用的项不应被淘汰")
+	}
+	l.Set("d", []byte("4"), -time.Second)
+	if _, ok := l.Get("d"); ok {
+		t.Fatal("过期的项不应返回")
+	}
+	l = cache.NewLRU(10)
+	l.Set("x:1", nil, time.Minute)
+	l.Set("x:2", nil, time.Minute)
+	l.Set("y:1", nil, time.Minute)
+	l.DelPrefix("x:")
+	if l.Len() != 1 {
+		t.Fatalf("按前缀删除后应剩余1项,实际为%d", l.Len())
+	}
+}
+
+func TestCacheReadThrough(t *testing.T) {
+	sto := memory.NewStorage()
+	c := cache.New("test-rt", sto, 60, 10)
+	loads := 0
+	load := func(dst *testValue) func() error {
+		return func() error {
+			loads++
+			*dst = testValue{Id: 1, Name: "v1"}
+			return nil
+		}
+	}
+	for i := 0; i < 3; i++ {
+		v := testValue{}
+		if err := c.Get("1", &v, load(&v)); err != nil || v.Name != "v1" {
+			t.Fatalf("读取缓存失败: %v %#v", err, v)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("数据应只加载1次,实际为%d次", loads)
+	}
+	// 其他实例(无本地缓存)从存储中读取
+	other := cache.New("test-rt", sto, 60, 0)
+	v := testValue{}
+	other.Get("1", &v, load(&v))
+	if loads != 1 || v.Name != "v1" {
+		t.Fatal("应从存储中读取缓存")
+	}
+	c.Invalidate("1")
+	c.Get("1", &v, load(&v))
+	if loads != 2 {
+		t.Fatal("失效后应重新加载")
+	}
+	c.InvalidatePrefix("")
+	c.Get("1", &v, load(&v))
+	if loads != 3 {
+		t.Fatal("按前缀失效后应重新加载")
+	}
+}
+
+func TestCacheLoadError(t *testing.T) {
+	c := cache.New("test-err", memory.NewStorage(), 60, 10)
+	errNotFound := errors.New("not found")
+	v := testValue{}
+	if err := c.Get("1", &v, func() error { return errNotFound }); err != errNotFound {
+		t.Fatalf("应返回加载的错误: %v", err)
+	}
+	loaded := false
+	c.Get("1", &v, func() error { loaded = true; return nil })
+	if !loaded {
+		t.Fatal("加载出错时不应缓存")
+	}
+}
+
+func TestCacheBind(t *testing.T) {
+	c := cache.New("test-bind", memory.NewStorage(), 60, 10)
+	v := testValue{}
+	c.Get("1", &v, func() error { v.Name = "old"; return nil })
+
+	var after []func()
+	b := c.Bind(func(f func()) { after = append(after, f) })
+	loaded := false
+	b.Get("1", &v, func() error { loaded = true; v.Name = "tx"; return nil })
+	if !loaded || v.Name != "tx" {
+		t.Fatal("事务中读取不应使用缓存")
+	}
+	b.Invalidate("1")
+	if len(after) != 1 {
+		t.Fatal("失效应在提交后再次执行")
+	}
+	// 提交前被其他读取重新缓存,提交后再次清除
+	c.Get("1", &v, func() error { v.Name = "stale"; return nil })
+	after[0]()
+	v = testValue{}
+	c.Get("1", &v, func() error { v.Name = "new"; return nil })
+	if v.Name != "new" {
+		t.Fatalf("提交后应重新加载,实际为%s", v.Name)
+	}
+}
+
+func TestCacheWatch(t *testing.T) {
+	c := cache.New("test-watch", memory.NewStorage(), 60, 10)
+	c.Watch(testValue{}, func(v interface{}) []string {
+		return []string{fmt.Sprint(v.(*testValue).Id), "list*"}
+	})
+	loads := 0
+	load := func() error { loads++; return nil }
+	v := testValue{}
+	c.Get("1", &v, load)
+	c.Get("2", &v, load)
+	c.Get("list:1", &v, load)
+	cache.Changed(&testValue{Id: 1}, nil)
+	c.Get("1", &v, load)
+	c.Get("2", &v, load)
+	c.Get("list:1", &v, load)
+	if loads != 5 {
+		t.Fatalf("变更的实体及列表应失效,其他不变,实际加载%d次", loads)
+	}
+	var after []func()
+	cache.Changed(&testValue{Id: 2}, func(f func()) { after = append(after, f) })
+	if len(after) != 2 {
+		t.Fatalf("事务中变更应在提交后再次失效,实际为%d", len(after))
+	}
+}
@@ -0,0 +1,73 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : entity.go
+ * author : jarryliu
+ * date : 2026-10-21 10:20
+ * description : 实体变更驱动的缓存失效,缓存按实体类型注册失效的键,
+ *               仓储保存或删除实体后通知变更,不在各处手动失效
+ * history :
+ */
+package cache
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// 实体变更后失效的键,v为实体的指针;键以"*"结尾时按前缀失效
+type KeysFunc func(v interface{}) []string
+
+type watcher struct {
+	c    *Cache
+	keys KeysFunc
+}
+
+var (
+	watchMux sync.RWMutex
+	// 实体类型对应的缓存,同名缓存只保留最后注册的规则
+	watchers = map[reflect.Type]map[string]*watcher{}
+)
+
+// 关注实体的变更,实体通过Changed通知变更后,按keys使缓存失效
+func (c *Cache) Watch(entity interface{}, keys KeysFunc) {
+	t := entityType(entity)
+	watchMux.Lock()
+	defer watchMux.Unlock()
+	if watchers[t] == nil {
+		watchers[t] = map[string]*watcher{}
+	}
+	watchers[t][c.name] = &watcher{c: c, keys: keys}
+}
+
+// 通知实体已保存或删除,使关注该实体的缓存失效并广播到其他实例。
+// after不为nil时,在事务提交后再次失效
+func Changed(v interface{}, after func(func())) {
+	watchMux.RLock()
+	list := make([]*watcher, 0, len(watchers[entityType(v)]))
+	for _, w := range watchers[entityType(v)] {
+		list = append(list, w)
+	}
+	watchMux.RUnlock()
+	for _, w := range list {
+		c := w.c
+		if after != nil {
+			c = c.Bind(after)
+		}
+		for _, k := range w.keys(v) {
+			if strings.HasSuffix(k, "*") {
+				c.InvalidatePrefix(strings.TrimSuffix(k, "*"))
+			} else {
+				c.Invalidate(k)
+			}
+		}
+	}
+}
+
+func entityType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,113 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : lru.go
+ * author : jarryliu
+ * date : 2026-10-20 18:00
+ * description : 进程内的LRU缓存,超出容量时淘汰最久未使用的项
+ * history :
+ */
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key     string
+	data    []byte
+	expires time.Time
+}
+
+// LRU缓存,保存编码后的数据
+type LRU struct {
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+	mux   sync.Mutex
+}
+
+// 创建LRU缓存,size为最多保存的项数
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:  size,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// 获取数据,已过期时返回false
+func (l *LRU) Get(key string) ([]byte, bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*lruEntry)
+	if time.Now().After(e.expires) {
+		l.remove(el)
+		return nil, false
+	}
+	l.ll.MoveToFront(el)
+	return e.data, true
+}
+
+// 设置数据
+func (l *LRU) Set(key string, data []byte, ttl time.Duration) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	expires := time.Now().Add(ttl)
+	if el, ok := l.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.data, e.expires = data, expires
+		l.ll.MoveToFront(el)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry{key: key, data: data, expires: expires})
+	for l.size > 0 && l.ll.Len() > l.size {
+		l.remove(l.ll.Back())
+	}
+}
+
+// 删除数据
+func (l *LRU) Del(key string) {
+	l.mux.Lock()
+	if el, ok := l.items[key]; ok {
+		l.remove(el)
+	}
+	l.mux.Unlock()
+}
+
+// 删除指定前缀的数据
+func (l *LRU) DelPrefix(prefix string) {
+	l.mux.Lock()
+	for k, el := range l.items {
+		if strings.HasPrefix(k, prefix) {
+			l.remove(el)
+		}
+	}
+	l.mux.Unlock()
+}
+
+// 清空
+func (l *LRU) Purge() {
+	l.mux.Lock()
+	l.ll.Init()
+	l.items = map[string]*list.Element{}
+	l.mux.Unlock()
+}
+
+// 数据项数
+func (l *LRU) Len() int {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.ll.Len()
+}
+
+func (l *LRU) remove(el *list.Element) {
+	l.ll.Remove(el)
+	delete(l.items, el.Value.(*lruEntry).key)
+}
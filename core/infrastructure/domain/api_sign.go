@@ -0,0 +1,129 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : api_sign
+ * author : jarryliu
+ * date : 2026-10-18 17:10
+ * description : 商户接口签名
+ * history :
+ */
+package domain
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 签名时间戳允许的最大偏差(秒)
+const ApiSignMaxSkew int64 = 300
+
+var (
+	ErrApiSign *DomainError = NewDomainError(
+		"err_api_sign", "接口签名不正确")
+	ErrApiTimestamp *DomainError = NewDomainError(
+		"err_api_timestamp", "接口请求已过期,请校准时间")
+	ErrApiNonce *DomainError = NewDomainError(
+		"err_api_nonce", "接口请求重复")
+	ErrApiDisabled *DomainError = NewDomainError(
+		"err_api_disabled", "接口未启用")
+	ErrApiRateLimit *DomainError = NewDomainError(
+		"err_api_rate_limit", "接口请求过于频繁,请稍后再试")
+)
+
+// 创建接口密钥(32位),使用安全随机数
+func NewApiSecret() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// 签名内容:METHOD\nPATH\n排序后的参数\nTIMESTAMP\nNONCE,
+// 参数排除sign,sign_type,timestamp和nonce,多个值用","连接;
+// 请求内容(如JSON)不为空时,追加\n请求内容的SHA256值(小写十六进制)
+func apiSignBytes(method, path string, params url.Values, body []byte,
+	timestamp, nonce string) []byte {
+	keys := []string{}
+	for k := range params {
+		switch k {
+		case "sign", "sign_type", "timestamp", "nonce":
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf := bytes.NewBufferString(strings.ToUpper(method))
+	buf.WriteString("\n")
+	buf.WriteString(path)
+	buf.WriteString("\n")
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString("&")
+		}
+		buf.WriteString(k)
+		buf.WriteString("=")
+		buf.WriteString(strings.Join(params[k], ","))
+	}
+	buf.WriteString("\n")
+	buf.WriteString(timestamp)
+	buf.WriteString("\n")
+	buf.WriteString(nonce)
+	if len(body) > 0 {
+		h := sha256.Sum256(body)
+		buf.WriteString("\n")
+		buf.WriteString(hex.EncodeToString(h[:]))
+	}
+	return buf.Bytes()
+}
+
+// 生成接口签名(HMAC-SHA256),body为表单以外的请求内容
+func HmacApiSign(secret, method, path string, params url.Values,
+	body []byte, timestamp, nonce string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(apiSignBytes(method, path, params, body, timestamp, nonce))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// 使用任一密钥验证签名,用于密钥轮换期间新旧密钥同时有效
+func CheckHmacApiSign(secrets []string, sign, method, path string,
+	params url.Values, body []byte, timestamp, nonce string) bool {
+	if sign == "" {
+		return false
+	}
+	for _, s := range secrets {
+		if s == "" {
+			continue
+		}
+		v := HmacApiSign(s, method, path, params, body, timestamp, nonce)
+		if hmac.Equal([]byte(v), []byte(strings.ToLower(sign))) {
+			return true
+		}
+	}
+	return false
+}
+
+// 生成通知内容签名(HMAC-SHA256),签名内容为:TIMESTAMP\nBODY
+func HmacWebhookSign(secret string, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// 检查时间戳是否在允许的偏差范围内
+func CheckApiTimestamp(timestamp string, unix int64) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	skew := unix - ts
+	return skew <= ApiSignMaxSkew && skew >= -ApiSignMaxSkew
+}
@@ -0,0 +1,49 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : conflict.go
+ * author : jarryliu
+ * date : 2026-10-19 23:30
+ * description : 乐观并发控制,保存时数据版本已改变则返回冲突错误
+ * history :
+ */
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// 默认冲突重试次数
+const DefaultConflictRetries = 3
+
+// 并发修改冲突,数据在读取后已被其他操作修改
+type ConflictError struct {
+	// 表名
+	Table string
+	// 主键
+	Id interface{}
+	// 保存时的版本
+	Version int64
+}
+
+func (c *ConflictError) Error() string {
+	return fmt.Sprintf("数据已被修改,请重试(%s:%v,version:%d)",
+		c.Table, c.Id, c.Version)
+}
+
+// 是否为并发修改冲突
+func IsConflict(err error) bool {
+	_, ok := err.(*ConflictError)
+	return ok
+}
+
+// 发生冲突时重新执行,f每次执行需重新加载聚合,且失败时不能产生副作用
+func RetryOnConflict(times int, f func() error) (err error) {
+	for i := 0; i < times; i++ {
+		if err = f(); !IsConflict(err) {
+			return err
+		}
+		time.Sleep(time.Duration(10*(i+1)) * time.Millisecond)
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : jwt
+ * author : jarryliu
+ * date : 2026-10-19 16:10
+ * description : 会员访问令牌,使用HS256签名的JWT
+ * history :
+ */
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+var (
+	ErrJwtFormat *DomainError = NewDomainError(
+		"err_jwt_format", "访问令牌格式不正确")
+	ErrJwtSign *DomainError = NewDomainError(
+		"err_jwt_sign", "访问令牌签名不正确")
+	ErrJwtExpired *DomainError = NewDomainError(
+		"err_jwt_expired", "访问令牌已过期")
+)
+
+// 固定的JWT头部
+var jwtHeader = base64.RawURLEncoding.EncodeToString(
+	[]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// 会员访问令牌声明
+type MemberClaims struct {
+	// 会员编号
+	MemberId int64 `json:"sub"`
+	// 设备编号
+	DeviceId string `json:"did"`
+	// 签发时间
+	IssuedAt int64 `json:"iat"`
+	// 过期时间
+	ExpiresAt int64 `json:"exp"`
+}
+
+// 签发访问令牌
+func SignMemberJwt(secret []byte, c *MemberClaims) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	s := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(data)
+	return s + "." + jwtSign(secret, s), nil
+}
+
+// 验证访问令牌,无需访问存储
+func ParseMemberJwt(secret []byte, token string, unix int64) (*MemberClaims, error) {
+	arr := strings.Split(token, ".")
+	if len(arr) != 3 || arr[0] != jwtHeader {
+		return nil, ErrJwtFormat
+	}
+	sign := jwtSign(secret, arr[0]+"."+arr[1])
+	if !hmac.Equal([]byte(sign), []byte(arr[2])) {
+		return nil, ErrJwtSign
+	}
+	data, err := base64.RawURLEncoding.DecodeString(arr[1])
+	if err != nil {
+		return nil, ErrJwtFormat
+	}
+	c := &MemberClaims{}
+	if err = json.Unmarshal(data, c); err != nil || c.MemberId <= 0 {
+		return nil, ErrJwtFormat
+	}
+	if c.ExpiresAt <= unix {
+		return nil, ErrJwtExpired
+	}
+	return c, nil
+}
+
+func jwtSign(secret []byte, s string) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,260 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : consumer.go
+ * author : jarryliu
+ * date : 2026-10-19 18:45
+ * description : 消费组,事件至少投递一次,处理程序需保证幂等
+ * history :
+ */
+package eventbus
+
+import (
+	"fmt"
+	"github.com/garyburd/redigo/redis"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 事件处理程序,返回错误时不确认,事件将重新投递
+type Handler func(m *Message) error
+
+// 消费选项
+type Options struct {
+	// 消费者名称,同一消费组内唯一
+	Consumer string
+	// 每次读取的数量,同一批次的事件并行处理
+	Batch int
+	// 读取阻塞的时间
+	Block time.Duration
+	// 未确认的事件经过多久后重新投递
+	MinIdle time.Duration
+	// 最大投递次数,超过后转入死信队列
+	MaxDeliveries int64
+}
+
+// 默认消费选项
+func DefaultOptions(consumer string) *Options {
+	return &Options{
+		Consumer:      consumer,
+		Batch:         10,
+		Block:         5 * time.Second,
+		MinIdle:       time.Minute,
+		MaxDeliveries: 5,
+	}
+}
+
+// 消费者
+type Consumer struct {
+	topic   string
+	group   string
+	handler Handler
+	o       *Options
+	stop    chan bool
+}
+
+// 创建消费者
+func NewConsumer(topic string, group string, h Handler, o *Options) *Consumer {
+	if o == nil {
+		o = DefaultOptions("default")
+	}
+	return &Consumer{
+		topic:   topic,
+		group:   group,
+		handler: h,
+		o:       o,
+		stop:    make(chan bool),
+	}
+}
+
+// 停止消费
+func (c *Consumer) Stop() {
+	close(c.stop)
+}
+
+// 开始消费,阻塞直到停止
+func (c *Consumer) Run() {
+	var lastClaim time.Time
+	for {
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+		conn := getConn()
+		if conn == nil {
+			log.Println("[ Go2o][ EventBus]:", ErrNoRedis.Error())
+			time.Sleep(10 * time.Second)
+			continue
+		}
+		err := c.ensureGroup(conn)
+		if err == nil && time.Since(lastClaim) > c.o.MinIdle/2 {
+			err = c.claim(conn)
+			lastClaim = time.Now()
+		}
+		if err == nil {
+			err = c.read(conn)
+		}
+		conn.Close()
+		if err != nil {
+			log.Println("[ Go2o][ EventBus][ Error]:", c.topic, c.group,
+				err.Error(), "; retry after 10 seconds.")
+			time.Sleep(10 * time.Second)
+		}
+	}
+}
+
+// 创建消费组,已存在时忽略。从流的起始位置消费,
+// 避免丢失消费组创建前已发布的事件
+func (c *Consumer) ensureGroup(conn redis.Conn) error {
+	_, err := conn.Do("XGROUP", "CREATE", streamKey(c.topic), c.group, "0", "MKSTREAM")
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// 读取新的事件
+func (c *Consumer) read(conn redis.Conn) error {
+	reply, err := redis.Values(conn.Do("XREADGROUP", "GROUP", c.group, c.o.Consumer,
+		"COUNT", c.o.Batch, "BLOCK", int64(c.o.Block/time.Millisecond),
+		"STREAMS", streamKey(c.topic), ">"))
+	if err == redis.ErrNil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, v := range reply {
+		s, _ := redis.Values(v, nil)
+		if len(s) == 2 {
+			list := parseMessages(c.topic, s[1])
+			for _, m := range list {
+				m.Deliveries = 1
+			}
+			c.handleBatch(list)
+		}
+	}
+	return nil
+}
+
+// 重新投递超时未确认的事件,超过投递次数的转入死信队列
+func (c *Consumer) claim(conn redis.Conn) error {
+	key := streamKey(c.topic)
+	pending, err := redis.Values(conn.Do("XPENDING", key, c.group, "-", "+", c.o.Batch*10))
+	if err != nil {
+		return err
+	}
+	minIdle := int64(c.o.MinIdle / time.Millisecond)
+	var ids []interface{}
+	deliveries := map[string]int64{}
+	for _, v := range pending {
+		p, _ := redis.Values(v, nil)
+		if len(p) != 4 {
+			continue
+		}
+		id, _ := redis.String(p[0], nil)
+		idle, _ := redis.Int64(p[2], nil)
+		n, _ := redis.Int64(p[3], nil)
+		if idle < minIdle {
+			continue
+		}
+		if n >= c.o.MaxDeliveries {
+			if err = c.dead(conn, id, n); err != nil {
+				return err
+			}
+			continue
+		}
+		ids = append(ids, id)
+		deliveries[id] = n + 1
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	args := append([]interface{}{key, c.group, c.o.Consumer, minIdle}, ids...)
+	reply, err := conn.Do("XCLAIM", args...)
+	if err != nil {
+		return err
+	}
+	list := parseMessages(c.topic, reply)
+	for _, m := range list {
+		m.Deliveries = deliveries[m.Id]
+	}
+	c.handleBatch(list)
+	return nil
+}
+
+// 并行处理同一批次的事件
+func (c *Consumer) handleBatch(list []*Message) {
+	wg := sync.WaitGroup{}
+	for _, m := range list {
+		wg.Add(1)
+		go func(m *Message) {
+			defer wg.Done()
+			c.handle(m)
+		}(m)
+	}
+	wg.Wait()
+}
+
+// 处理事件,处理成功后确认
+func (c *Consumer) handle(m *Message) {
+	var err error
+	// 重放的死信仅投递给指定的消费组
+	if m.Group == "" || m.Group == c.group {
+		err = c.invoke(m)
+	}
+	conn := getConn()
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if err != nil {
+		log.Println("[ Go2o][ EventBus][ Handle]:", c.topic, c.group, m.Id,
+			m.Type, m.Key, err.Error())
+		conn.Do("HSET", errorPrefix+c.group, m.Id, err.Error())
+		return
+	}
+	conn.Do("XACK", streamKey(c.topic), c.group, m.Id)
+	if m.Deliveries > 1 {
+		conn.Do("HDEL", errorPrefix+c.group, m.Id)
+	}
+}
+
+// 调用处理程序,将panic转为错误
+func (c *Consumer) invoke(m *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.handler(m)
+}
+
+// 转入死信队列并确认。事件已从流中删除(如超出流的长度被裁剪)时,
+// 死信仅记录原事件编号,不能重放
+func (c *Consumer) dead(conn redis.Conn, id string, deliveries int64) error {
+	key := streamKey(c.topic)
+	reply, err := conn.Do("XRANGE", key, id, id)
+	if err != nil {
+		return err
+	}
+	m := &Message{Topic: c.topic}
+	if list := parseMessages(c.topic, reply); len(list) > 0 {
+		m = list[0]
+		m.Group = ""
+	}
+	errKey := errorPrefix + c.group
+	lastErr, _ := redis.String(conn.Do("HGET", errKey, id))
+	_, err = addMessage(conn, deadPrefix+c.group, m,
+		"origin_id", id, "error", lastErr, "deliveries", deliveries,
+		"dead_time", time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	log.Println("[ Go2o][ EventBus][ Dead]:", c.topic, c.group, id, m.Type, m.Key, lastErr)
+	conn.Do("HDEL", errKey, id)
+	_, err = conn.Do("XACK", key, c.group, id)
+	return err
+}
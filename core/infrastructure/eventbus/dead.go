@@ -0,0 +1,134 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : dead.go
+ * author : jarryliu
+ * date : 2026-10-19 19:05
+ * description : 死信队列的查看与重放
+ * history :
+ */
+package eventbus
+
+import (
+	"github.com/garyburd/redigo/redis"
+	"strconv"
+)
+
+// 死信
+type DeadLetter struct {
+	// 死信编号
+	Id string
+	// 原事件编号
+	OriginId string
+	// 事件消息,原事件已删除时仅包含主题
+	Message *Message
+	// 最后一次处理的错误
+	Error string
+	// 转入死信的时间
+	DeadTime int64
+}
+
+// 获取消费组最近的死信
+func ListDead(group string, size int) ([]*DeadLetter, error) {
+	conn := getConn()
+	if conn == nil {
+		return nil, ErrNoRedis
+	}
+	defer conn.Close()
+	entries, err := redis.Values(conn.Do("XREVRANGE", deadPrefix+group,
+		"+", "-", "COUNT", size))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*DeadLetter, 0, len(entries))
+	for _, v := range entries {
+		if d := parseDead(v); d != nil {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+// 获取死信数量
+func DeadCount(group string) (int, error) {
+	conn := getConn()
+	if conn == nil {
+		return 0, ErrNoRedis
+	}
+	defer conn.Close()
+	return redis.Int(conn.Do("XLEN", deadPrefix+group))
+}
+
+// 原事件是否已删除,已删除的死信不能重放
+func (d *DeadLetter) Missing() bool {
+	return d.Message.Type == ""
+}
+
+// 重放死信,重新发布到原主题并仅投递给该消费组
+func Replay(group string, id string) error {
+	conn := getConn()
+	if conn == nil {
+		return ErrNoRedis
+	}
+	defer conn.Close()
+	d, err := getDead(conn, group, id)
+	if err != nil {
+		return err
+	}
+	if d.Missing() {
+		return ErrEventMissing
+	}
+	m := d.Message
+	m.Group = group
+	if _, err = addMessage(conn, streamKey(m.Topic), m); err == nil {
+		_, err = conn.Do("XDEL", deadPrefix+group, id)
+	}
+	return err
+}
+
+// 删除死信
+func DeleteDead(group string, id string) error {
+	conn := getConn()
+	if conn == nil {
+		return ErrNoRedis
+	}
+	defer conn.Close()
+	n, err := redis.Int(conn.Do("XDEL", deadPrefix+group, id))
+	if err == nil && n == 0 {
+		err = ErrNoSuchMessage
+	}
+	return err
+}
+
+func getDead(conn redis.Conn, group string, id string) (*DeadLetter, error) {
+	entries, err := redis.Values(conn.Do("XRANGE", deadPrefix+group, id, id))
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoSuchMessage
+	}
+	if d := parseDead(entries[0]); d != nil {
+		return d, nil
+	}
+	return nil, ErrNoSuchMessage
+}
+
+func parseDead(v interface{}) *DeadLetter {
+	e, _ := redis.Values(v, nil)
+	if len(e) != 2 {
+		return nil
+	}
+	fields, _ := redis.StringMap(e[1], nil)
+	list := parseMessages(fields["topic"], []interface{}{v})
+	if len(list) == 0 {
+		return nil
+	}
+	d := &DeadLetter{
+		Id:       list[0].Id,
+		OriginId: fields["origin_id"],
+		Message:  list[0],
+		Error:    fields["error"],
+	}
+	d.DeadTime, _ = strconv.ParseInt(fields["dead_time"], 10, 64)
+	return d
+}
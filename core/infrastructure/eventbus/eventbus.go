@@ -0,0 +1,156 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : eventbus.go
+ * author : jarryliu
+ * date : 2026-10-19 18:30
+ * description : 基于Redis Streams的事件总线,每个主题对应一个流,
+ *   消费组确认后才移除事件,未确认的事件超时后重新投递,超过投递次数转入死信队列
+ * history :
+ */
+package eventbus
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/garyburd/redigo/redis"
+	"github.com/jsix/gof"
+	"strconv"
+	"time"
+)
+
+var (
+	// 流的键前缀
+	streamPrefix = "go2o:stream:"
+	// 死信队列的键前缀
+	deadPrefix = "go2o:stream:dead:"
+	// 最后一次处理错误的键前缀
+	errorPrefix = "go2o:stream:error:"
+	// 流保留的最大事件数量
+	MaxLen = 100000
+
+	ErrNoRedis       = errors.New("event bus redis not configured")
+	ErrNoSuchMessage = errors.New("no such dead letter")
+	ErrEventMissing  = errors.New("event of dead letter is missing")
+)
+
+// 事件
+type Event interface {
+	// 主题,同一主题的事件保存在同一个流中
+	Topic() string
+	// 事件类型
+	Type() string
+	// 事件关联的键,如订单号
+	Key() string
+}
+
+// 事件消息
+type Message struct {
+	// 流中的编号
+	Id string
+	// 主题
+	Topic string
+	// 事件类型
+	Type string
+	// 事件关联的键
+	Key string
+	// 事件数据(JSON)
+	Data []byte
+	// 发布时间
+	Time int64
+	// 仅投递给指定的消费组,重放死信时使用
+	Group string
+	// 投递次数
+	Deliveries int64
+}
+
+// 解析事件数据
+func (m *Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Data, v)
+}
+
+// 获取Redis连接
+var getConn = func() redis.Conn {
+	if gof.CurrentApp != nil {
+		if p, ok := gof.CurrentApp.Storage().Source().(*redis.Pool); ok {
+			return p.Get()
+		}
+	}
+	return nil
+}
+
+// 设置获取Redis连接的函数
+func SetConnFunc(f func() redis.Conn) {
+	getConn = f
+}
+
+func streamKey(topic string) string {
+	return streamPrefix + topic
+}
+
+// 发布事件
+func Publish(e Event) error {
+	conn := getConn()
+	if conn == nil {
+		return ErrNoRedis
+	}
+	defer conn.Close()
+	return PublishConn(conn, e)
+}
+
+// 使用指定的连接发布事件
+func PublishConn(conn redis.Conn, e Event) error {
+	data, err := json.Marshal(e)
+	if err == nil {
+		err = PublishRaw(conn, e.Topic(), e.Type(), e.Key(), data, time.Now().Unix())
+	}
+	return err
+}
+
+// 发布已序列化的事件
+func PublishRaw(conn redis.Conn, topic string, typ string, key string,
+	data []byte, unix int64) error {
+	_, err := conn.Do("XADD", streamKey(topic), "MAXLEN", "~", MaxLen, "*",
+		"type", typ, "key", key, "data", data, "time", unix)
+	return err
+}
+
+// 添加消息到流中
+func addMessage(conn redis.Conn, key string, m *Message, extra ...interface{}) (string, error) {
+	args := []interface{}{key, "MAXLEN", "~", MaxLen, "*",
+		"topic", m.Topic, "type", m.Type, "key", m.Key, "data", m.Data,
+		"time", m.Time}
+	if m.Group != "" {
+		args = append(args, "group", m.Group)
+	}
+	args = append(args, extra...)
+	return redis.String(conn.Do("XADD", args...))
+}
+
+// 解析流中的消息,格式如:[[id,[field,value...]]...]
+func parseMessages(topic string, reply interface{}) []*Message {
+	entries, _ := redis.Values(reply, nil)
+	list := make([]*Message, 0, len(entries))
+	for _, v := range entries {
+		e, _ := redis.Values(v, nil)
+		if len(e) != 2 {
+			continue
+		}
+		id, _ := redis.String(e[0], nil)
+		fields, _ := redis.StringMap(e[1], nil)
+		m := &Message{
+			Id:    id,
+			Topic: topic,
+			Type:  fields["type"],
+			Key:   fields["key"],
+			Data:  []byte(fields["data"]),
+			Group: fields["group"],
+		}
+		if t := fields["topic"]; t != "" {
+			m.Topic = t
+		}
+		m.Time, _ = strconv.ParseInt(fields["time"], 10, 64)
+		m.Deliveries, _ = strconv.ParseInt(fields["deliveries"], 10, 64)
+		list = append(list, m)
+	}
+	return list
+}
@@ -0,0 +1,116 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : idempotent.go
+ * author : jarryliu
+ * date : 2026-10-20 00:20
+ * description : 幂等键,重复提交的请求在窗口期内返回首次执行的结果。
+ *   键与业务数据在同一事务中保存,并发的相同请求等待首个请求提交
+ * history :
+ */
+package idempotent
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/jsix/gof/db"
+	"go2o/core/infrastructure/tx"
+	"go2o/core/infrastructure/uow"
+	"strings"
+	"time"
+)
+
+// 幂等键表
+const Table = "sys_idempotent_key"
+
+// 默认的窗口期
+const DefaultWindow = 24 * time.Hour
+
+// 幂等键记录
+type Record struct {
+	// 编号
+	Id int64 `db:"id" pk:"yes" auto:"yes"`
+	// 业务范围
+	Scope string `db:"scope"`
+	// 幂等键
+	Key string `db:"idem_key"`
+	// 执行结果
+	Result string `db:"result"`
+	// 创建时间
+	CreateTime int64 `db:"create_time"`
+	// 过期时间
+	ExpiresTime int64 `db:"expires_time"`
+}
+
+// 获取窗口期内已执行的结果,并解码到result
+func Get(conn db.Connector, scope, key string, result interface{}) (bool, error) {
+	var data string
+	err := conn.ExecScalar("SELECT result FROM "+Table+
+		" WHERE scope=? AND idem_key=? AND expires_time>=?",
+		&data, scope, key, time.Now().Unix())
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err == nil && result != nil {
+		err = json.Unmarshal([]byte(data), result)
+	}
+	return err == nil, err
+}
+
+// 以幂等键在工作单元中执行f,执行结果由f写入result。窗口期内已成功执行时,
+// 将保存的结果解码到result且不再执行;执行出错时不保存键,可使用相同的键重试。
+// 键为空时直接执行
+func Run(conn db.Connector, scope, key string, window time.Duration,
+	result interface{}, f func(u *uow.UnitOfWork) error) error {
+	if key == "" {
+		return uow.Run(conn, f)
+	}
+	if ok, err := Get(conn, scope, key, result); ok || err != nil {
+		return err
+	}
+	err := uow.Run(conn, func(u *uow.UnitOfWork) error {
+		now := time.Now()
+		// 清除已过期的键
+		_, err := u.Tx().Exec("DELETE FROM "+Table+
+			" WHERE scope=? AND idem_key=? AND expires_time<?", scope, key, now.Unix())
+		if err != nil {
+			return err
+		}
+		// 占用键,并发的相同请求在唯一索引上等待本事务提交
+		r := &Record{
+			Scope:       scope,
+			Key:         key,
+			CreateTime:  now.Unix(),
+			ExpiresTime: now.Add(window).Unix(),
+		}
+		if _, err = tx.Save(u.Tx(), r); err != nil {
+			return err
+		}
+		if err = f(u); err != nil {
+			return err
+		}
+		data, err := json.Marshal(result)
+		if err == nil {
+			r.Result = string(data)
+			_, err = tx.Save(u.Tx(), r)
+		}
+		return err
+	})
+	// 相同的请求已先提交,返回其结果
+	if err != nil && isDuplicate(err) {
+		if ok, err2 := Get(conn, scope, key, result); ok || err2 != nil {
+			return err2
+		}
+	}
+	return err
+}
+
+// 清除过期的键
+func Purge(conn db.Connector, before int64) (int, error) {
+	return conn.ExecNonQuery("DELETE FROM "+Table+
+		" WHERE expires_time<?", before)
+}
+
+// 是否为唯一键冲突
+func isDuplicate(err error) bool {
+	return strings.Contains(err.Error(), "Duplicate entry")
+}
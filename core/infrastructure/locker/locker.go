@@ -0,0 +1,77 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : locker.go
+ * author : jarryliu
+ * date : 2026-10-19 17:30
+ * description : 基于Redis的分布式锁,每次获得锁时生成递增的令牌(fencing token),
+ *   用于识别锁过期后仍在执行的旧持有者
+ * history :
+ */
+package locker
+
+import (
+	"github.com/garyburd/redigo/redis"
+	"go2o/core/infrastructure/domain"
+	"time"
+)
+
+var (
+	// 获得锁后递增令牌
+	acquireScript = redis.NewScript(2, `
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return redis.call('INCR', KEYS[2])
+end
+return 0`)
+	// 持有者延长锁
+	refreshScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+	// 持有者释放锁
+	releaseScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0`)
+)
+
+// 分布式锁
+type Lock struct {
+	key   string
+	token string
+	ttl   time.Duration
+	// 令牌,每次获得锁时递增
+	Fence int64
+}
+
+// 尝试获得锁,锁被占用时返回nil
+func Acquire(conn redis.Conn, key string, ttl time.Duration) (*Lock, error) {
+	l := &Lock{key: key, token: domain.NewApiSecret(), ttl: ttl}
+	fence, err := redis.Int64(acquireScript.Do(conn, key, key+":fence",
+		l.token, int64(ttl/time.Millisecond)))
+	if err != nil || fence == 0 {
+		return nil, err
+	}
+	l.Fence = fence
+	return l, nil
+}
+
+// 延长锁的有效时间,锁已过期或被其他持有者获得时返回false
+func (l *Lock) Refresh(conn redis.Conn) (bool, error) {
+	n, err := redis.Int(refreshScript.Do(conn, l.key, l.token,
+		int64(l.ttl/time.Millisecond)))
+	return n == 1, err
+}
+
+// 释放锁
+func (l *Lock) Release(conn redis.Conn) error {
+	_, err := releaseScript.Do(conn, l.key, l.token)
+	return err
+}
+
+// 检查令牌是否为最新,写入共享数据前检查,避免旧的持有者覆盖数据
+func CheckFence(conn redis.Conn, key string, fence int64) bool {
+	v, err := redis.Int64(conn.Do("GET", key+":fence"))
+	return err == nil && v == fence
+}
@@ -0,0 +1,349 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : migrate.go
+ * author : jarryliu
+ * date : 2026-10-20 15:10
+ * description : 数据库结构迁移,迁移脚本以版本号注册并编译到程序中,
+ *   已执行的版本及脚本校验码记录在迁移表中
+ * history :
+ */
+package migrate
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jsix/gof/db"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 迁移记录表
+const Table = "sys_schema_migration"
+
+const (
+	// 迁移锁的名称
+	lockName = "go2o:schema_migration"
+	// 等待迁移锁的秒数
+	lockTimeout = 10
+)
+
+var (
+	ErrOutdated    = errors.New("数据库结构不是最新版本,请先执行迁移")
+	ErrChecksum    = errors.New("已执行的迁移脚本被修改")
+	ErrUnknown     = errors.New("数据库包含程序未知的迁移版本,请升级程序")
+	ErrNoDown      = errors.New("迁移不支持回滚")
+	ErrNoMigration = errors.New("没有可执行的迁移")
+	ErrLocked      = errors.New("其他进程正在执行迁移")
+)
+
+// 迁移
+type Migration struct {
+	// 版本号,按版本号从小到大执行
+	Version int
+	// 名称
+	Name string
+	// 升级脚本,多条语句以";"分隔
+	Up string
+	// 回滚脚本,为空时不支持回滚
+	Down string
+}
+
+// 升级脚本的校验码
+func (m *Migration) Checksum() string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(strings.TrimSpace(m.Up))))
+}
+
+// 迁移记录
+type Record struct {
+	// 版本号
+	Version int `db:"version" pk:"yes"`
+	// 名称
+	Name string `db:"name"`
+	// 升级脚本的校验码
+	Checksum string `db:"checksum"`
+	// 执行时间
+	ApplyTime int64 `db:"apply_time"`
+}
+
+// 迁移状态
+type Status struct {
+	*Migration
+	// 是否已执行
+	Applied bool
+	// 执行时间
+	ApplyTime int64
+	// 已执行后脚本是否被修改
+	Modified bool
+}
+
+var (
+	registry = map[int]*Migration{}
+	mux      sync.RWMutex
+)
+
+// 注册迁移,版本号重复时panic
+func Register(list ...*Migration) {
+	mux.Lock()
+	defer mux.Unlock()
+	for _, m := range list {
+		if m.Version <= 0 {
+			panic(fmt.Sprintf("migrate: invalid version %d", m.Version))
+		}
+		if _, ok := registry[m.Version]; ok {
+			panic(fmt.Sprintf("migrate: duplicate version %d", m.Version))
+		}
+		registry[m.Version] = m
+	}
+}
+
+// 获取已注册的迁移,按版本号排序
+func Migrations() []*Migration {
+	mux.RLock()
+	defer mux.RUnlock()
+	list := make([]*Migration, 0, len(registry))
+	for _, m := range registry {
+		list = append(list, m)
+	}
+	sort.Sort(byVersion(list))
+	return list
+}
+
+type byVersion []*Migration
+
+func (b byVersion) Len() int           { return len(b) }
+func (b byVersion) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byVersion) Less(i, j int) bool { return b[i].Version < b[j].Version }
+
+// 创建迁移记录表
+func ensureTable(conn db.Connector) error {
+	_, err := conn.ExecNonQuery("CREATE TABLE IF NOT EXISTS " + Table + ` (
+  version    int(11) NOT NULL comment '版本号',
+  name       varchar(60) NOT NULL comment '名称',
+  checksum   varchar(40) NOT NULL comment '升级脚本的校验码',
+  apply_time int(11) NOT NULL comment '执行时间',
+  PRIMARY KEY (version)) comment='数据库结构迁移记录'`)
+	return err
+}
+
+// 迁移记录表是否存在
+func tableExists(conn db.Connector) (bool, error) {
+	n := 0
+	err := conn.ExecScalar(`SELECT COUNT(0) FROM information_schema.tables
+		WHERE table_schema=DATABASE() AND table_name=?`, &n, Table)
+	return n > 0, err
+}
+
+// 获取迁移锁,避免多个进程同时执行迁移。MySQL的命名锁属于会话,
+// 在事务占用的连接上获取,返回释放锁的函数
+func lock(conn db.Connector) (func(), error) {
+	t, err := conn.Raw().Begin()
+	if err != nil {
+		return nil, err
+	}
+	var ok sql.NullInt64
+	err = t.QueryRow("SELECT GET_LOCK(?,?)", lockName, lockTimeout).Scan(&ok)
+	if err == nil && ok.Int64 != 1 {
+		err = ErrLocked
+	}
+	if err != nil {
+		t.Rollback()
+		return nil, err
+	}
+	return func() {
+		t.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		t.Rollback()
+	}, nil
+}
+
+// 获取迁移锁并创建迁移记录表,用于执行迁移前
+func prepare(conn db.Connector) (func(), error) {
+	release, err := lock(conn)
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTable(conn); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// 获取已执行的迁移记录,迁移记录表不存在时返回空记录,不修改数据库
+func applied(conn db.Connector) (map[int]*Record, error) {
+	mp := map[int]*Record{}
+	exists, err := tableExists(conn)
+	if err != nil || !exists {
+		return mp, err
+	}
+	err = conn.Query("SELECT version,name,checksum,apply_time FROM "+Table,
+		func(rs *sql.Rows) {
+			for rs.Next() {
+				r := &Record{}
+				if rs.Scan(&r.Version, &r.Name, &r.Checksum, &r.ApplyTime) == nil {
+					mp[r.Version] = r
+				}
+			}
+		})
+	return mp, err
+}
+
+// 获取迁移状态,数据库中存在程序未知的版本时同时返回ErrUnknown
+func Statuses(conn db.Connector) ([]*Status, error) {
+	records, err := applied(conn)
+	if err != nil {
+		return nil, err
+	}
+	list := Migrations()
+	arr := make([]*Status, len(list))
+	for i, m := range list {
+		arr[i] = &Status{Migration: m}
+		if r, ok := records[m.Version]; ok {
+			arr[i].Applied = true
+			arr[i].ApplyTime = r.ApplyTime
+			arr[i].Modified = r.Checksum != m.Checksum()
+			delete(records, m.Version)
+		}
+	}
+	if len(records) > 0 {
+		err = ErrUnknown
+	}
+	return arr, err
+}
+
+// 检查数据库结构是否为最新版本,用于程序启动时检查,只读取不修改数据库
+func Check(conn db.Connector) error {
+	list, err := Statuses(conn)
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		if !s.Applied {
+			return ErrOutdated
+		}
+		if s.Modified {
+			return fmt.Errorf("%s: %d_%s", ErrChecksum.Error(), s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// 执行升级,steps为执行的数量,小于等于0时执行全部。
+// 已执行的迁移脚本被修改时不执行,返回已执行的迁移
+func Up(conn db.Connector, steps int) ([]*Migration, error) {
+	release, err := prepare(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	list, err := Statuses(conn)
+	if err != nil {
+		return nil, err
+	}
+	var pending []*Migration
+	for _, s := range list {
+		if s.Modified {
+			return nil, fmt.Errorf("%s: %d_%s", ErrChecksum.Error(), s.Version, s.Name)
+		}
+		if !s.Applied {
+			pending = append(pending, s.Migration)
+		}
+	}
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	}
+	var done []*Migration
+	for _, m := range pending {
+		if err = exec(conn, m.Up); err != nil {
+			return done, fmt.Errorf("migration %d_%s: %s", m.Version, m.Name, err.Error())
+		}
+		_, err = conn.ExecNonQuery("INSERT INTO "+Table+
+			" (version,name,checksum,apply_time) VALUES(?,?,?,?)",
+			m.Version, m.Name, m.Checksum(), time.Now().Unix())
+		if err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// 回滚最近执行的迁移,steps为回滚的数量,小于等于0时回滚一个
+func Down(conn db.Connector, steps int) ([]*Migration, error) {
+	release, err := prepare(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	list, err := Statuses(conn)
+	if err != nil {
+		return nil, err
+	}
+	if steps <= 0 {
+		steps = 1
+	}
+	var done []*Migration
+	for i := len(list) - 1; i >= 0 && len(done) < steps; i-- {
+		m := list[i]
+		if !m.Applied {
+			continue
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			return done, fmt.Errorf("%s: %d_%s", ErrNoDown.Error(), m.Version, m.Name)
+		}
+		if err = exec(conn, m.Down); err != nil {
+			return done, fmt.Errorf("migration %d_%s: %s", m.Version, m.Name, err.Error())
+		}
+		_, err = conn.ExecNonQuery("DELETE FROM "+Table+" WHERE version=?", m.Version)
+		if err != nil {
+			return done, err
+		}
+		done = append(done, m.Migration)
+	}
+	if len(done) == 0 {
+		return nil, ErrNoMigration
+	}
+	return done, nil
+}
+
+// 将指定版本及之前的迁移标记为已执行,不执行脚本。
+// 用于已手动执行过升级脚本的数据库开始使用迁移
+func Baseline(conn db.Connector, version int) ([]*Migration, error) {
+	release, err := prepare(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	list, err := Statuses(conn)
+	if err != nil {
+		return nil, err
+	}
+	var done []*Migration
+	for _, s := range list {
+		if s.Applied || s.Version > version {
+			continue
+		}
+		_, err = conn.ExecNonQuery("INSERT INTO "+Table+
+			" (version,name,checksum,apply_time) VALUES(?,?,?,?)",
+			s.Version, s.Name, s.Checksum(), time.Now().Unix())
+		if err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// 逐条执行脚本中的语句。MySQL的DDL语句会隐式提交,
+// 执行失败时已执行的语句不会回滚,需修复后重新执行
+func exec(conn db.Connector, script string) error {
+	for _, s := range Split(script) {
+		if _, err := conn.Raw().Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
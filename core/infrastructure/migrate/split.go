@@ -0,0 +1,61 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : split.go
+ * author : jarryliu
+ * date : 2026-10-20 15:30
+ * description :
+ * history :
+ */
+package migrate
+
+import "strings"
+
+// 按";"拆分脚本为多条语句,忽略引号中的";"及注释
+func Split(script string) []string {
+	var list []string
+	var quote byte
+	start := 0
+	// 是否包含注释以外的内容
+	code := false
+	flush := func(end int) {
+		if code {
+			list = append(list, strings.TrimSpace(script[start:end]))
+		}
+		code = false
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		if quote != 0 {
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			code = true
+		case c == '#' || strings.HasPrefix(script[i:], "-- "):
+			i = skipTo(script, i, "\n")
+		case strings.HasPrefix(script[i:], "/*"):
+			i = skipTo(script, i+2, "*/")
+		case c == ';':
+			flush(i)
+			start = i + 1
+		case c != ' ' && c != '\t' && c != '\r' && c != '\n':
+			code = true
+		}
+	}
+	flush(len(script))
+	return list
+}
+
+// 返回sep最后一个字符的位置,未找到时返回脚本末尾
+func skipTo(script string, i int, sep string) int {
+	if j := strings.Index(script[i:], sep); j != -1 {
+		return i + j + len(sep) - 1
+	}
+	return len(script) - 1
+}
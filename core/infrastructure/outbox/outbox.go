@@ -0,0 +1,94 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : outbox.go
+ * author : jarryliu
+ * date : 2026-10-19 20:40
+ * description : 事件发件箱,领域事件与聚合在同一事务中保存,
+ *   再由守护进程发布到事件总线,避免数据与事件不一致
+ * history :
+ */
+package outbox
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/garyburd/redigo/redis"
+	"github.com/jsix/gof/db"
+	"go2o/core/infrastructure/eventbus"
+	"time"
+)
+
+// 发件箱表
+const Table = "sys_event_outbox"
+
+// 发件箱记录
+type Record struct {
+	// 编号
+	Id int64 `db:"id" pk:"yes" auto:"yes"`
+	// 主题
+	Topic string `db:"topic"`
+	// 事件类型
+	EventType string `db:"event_type"`
+	// 事件关联的键
+	EventKey string `db:"event_key"`
+	// 事件数据
+	Data string `db:"data"`
+	// 发布次数
+	Attempts int `db:"attempts"`
+	// 最后一次发布的错误
+	LastError string `db:"last_error"`
+	// 是否已发布
+	Dispatched int `db:"dispatched"`
+	// 创建时间
+	CreateTime int64 `db:"create_time"`
+	// 发布时间
+	DispatchTime int64 `db:"dispatch_time"`
+}
+
+// 在事务中记录领域事件
+func Append(t *sql.Tx, events ...eventbus.Event) error {
+	unix := time.Now().Unix()
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err == nil {
+			_, err = t.Exec("INSERT INTO "+Table+" (topic,event_type,event_key,data,"+
+				"attempts,last_error,dispatched,create_time,dispatch_time) VALUES(?,?,?,?,0,'',0,?,0)",
+				e.Topic(), e.Type(), e.Key(), string(data), unix)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 发布未发布的事件,按记录顺序发布,遇到错误时停止,返回已发布的数量
+func Relay(conn db.Connector, rc redis.Conn, size int) (int, error) {
+	list := []*Record{}
+	err := conn.GetOrm().SelectByQuery(&list, "SELECT * FROM "+Table+
+		" WHERE dispatched=0 ORDER BY id LIMIT ?", size)
+	if err != nil {
+		return 0, err
+	}
+	for i, r := range list {
+		err = eventbus.PublishRaw(rc, r.Topic, r.EventType, r.EventKey,
+			[]byte(r.Data), r.CreateTime)
+		if err != nil {
+			conn.ExecNonQuery("UPDATE "+Table+" SET attempts=attempts+1,last_error=? WHERE id=?",
+				err.Error(), r.Id)
+			return i, err
+		}
+		_, err = conn.ExecNonQuery("UPDATE "+Table+" SET attempts=attempts+1,"+
+			"dispatched=1,dispatch_time=? WHERE id=?", time.Now().Unix(), r.Id)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(list), nil
+}
+
+// 清除指定时间前已发布的记录
+func Purge(conn db.Connector, before int64) (int, error) {
+	return conn.ExecNonQuery("DELETE FROM "+Table+
+		" WHERE dispatched=1 AND dispatch_time<?", before)
+}
@@ -0,0 +1,75 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : event.go
+ * author : jarryliu
+ * date : 2026-10-19 16:10
+ * description : 实时事件,通过Redis发布订阅分发到各个实例
+ * history :
+ */
+package push
+
+import (
+	"encoding/json"
+	"github.com/garyburd/redigo/redis"
+)
+
+// 事件类型
+const (
+	EventOrder   = "order"
+	EventMember  = "member"
+	EventPayment = "payment"
+	EventMessage = "message"
+)
+
+// 事件接收角色,用于广播
+const (
+	RoleAll      = 0
+	RoleMember   = 1
+	RoleMerchant = 2
+)
+
+// 事件频道
+var eventChannel = "go2o:pubsub:event"
+
+// 实时事件,MemberId与MchId均为0时按Role广播
+type Event struct {
+	// 事件类型
+	Type string `json:"type"`
+	// 接收的会员
+	MemberId int64 `json:"member_id,omitempty"`
+	// 接收的商户
+	MchId int32 `json:"mch_id,omitempty"`
+	// 广播的角色
+	Role int `json:"role,omitempty"`
+	// 数据
+	Data interface{} `json:"data"`
+}
+
+// 发布事件
+func PublishEvent(conn redis.Conn, e *Event) error {
+	b, err := json.Marshal(e)
+	if err == nil {
+		_, err = conn.Do("PUBLISH", eventChannel, b)
+	}
+	return err
+}
+
+// 订阅事件,直到连接出错时返回
+func SubscribeEvents(conn redis.Conn, h func(e *Event, raw []byte)) error {
+	psc := redis.PubSubConn{Conn: conn}
+	if err := psc.Subscribe(eventChannel); err != nil {
+		return err
+	}
+	defer psc.Unsubscribe()
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			e := &Event{}
+			if json.Unmarshal(v.Data, e) == nil {
+				h(e, v.Data)
+			}
+		case error:
+			return v
+		}
+	}
+}
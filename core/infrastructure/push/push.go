@@ -0,0 +1,120 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : push.go
+ * author : jarryliu
+ * date : 2026-10-19 14:20
+ * description : 会员消息推送,消息在确认前保存,客户端重连后重新投递。
+ *   消息通过Redis发布订阅分发到各个实例,由持有会员连接的实例推送
+ * history :
+ */
+package push
+
+import (
+	"encoding/json"
+	"github.com/garyburd/redigo/redis"
+	"strconv"
+)
+
+// 推送主题
+const (
+	// 订单状态
+	TopicOrder = "order"
+	// 账户变动
+	TopicAccount = "account"
+	// 站内信
+	TopicMessage = "message"
+)
+
+var (
+	// 推送频道
+	pushChannel = "go2o:pubsub:member_push"
+	// 消息编号
+	idKey = "go2o:push:msg_id"
+	// 未确认消息前缀
+	pendingPrefix = "go2o:push:pending:"
+	// 每个会员最多保存的未确认消息数量
+	MaxPending = 200
+	// 未确认消息保存时间(秒)
+	PendingSeconds = 3600 * 24 * 7
+)
+
+// 推送消息
+type Message struct {
+	// 消息编号
+	Id int64 `json:"id"`
+	// 会员编号
+	MemberId int64 `json:"member_id"`
+	// 主题
+	Topic string `json:"topic"`
+	// 数据
+	Data json.RawMessage `json:"data"`
+}
+
+func pendingKey(memberId int64) string {
+	return pendingPrefix + strconv.FormatInt(memberId, 10)
+}
+
+// 发布消息,保存为未确认消息并发布到推送频道
+func Publish(conn redis.Conn, memberId int64, topic string, data interface{}) (*Message, error) {
+	d, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	id, err := redis.Int64(conn.Do("INCR", idKey))
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{Id: id, MemberId: memberId, Topic: topic, Data: d}
+	b, _ := json.Marshal(m)
+	key := pendingKey(memberId)
+	conn.Send("MULTI")
+	conn.Send("ZADD", key, id, b)
+	conn.Send("ZREMRANGEBYRANK", key, 0, -MaxPending-1)
+	conn.Send("EXPIRE", key, PendingSeconds)
+	conn.Send("PUBLISH", pushChannel, b)
+	_, err = conn.Do("EXEC")
+	return m, err
+}
+
+// 订阅推送的消息,直到连接出错时返回。
+// 订阅中断期间的消息未投递,客户端重连后将重新投递未确认的消息
+func Subscribe(conn redis.Conn, h func(m *Message)) error {
+	psc := redis.PubSubConn{Conn: conn}
+	if err := psc.Subscribe(pushChannel); err != nil {
+		return err
+	}
+	defer psc.Unsubscribe()
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			m := &Message{}
+			if json.Unmarshal(v.Data, m) == nil {
+				h(m)
+			}
+		case error:
+			return v
+		}
+	}
+}
+
+// 确认消息,确认后不再投递
+func Ack(conn redis.Conn, memberId int64, id int64) error {
+	_, err := conn.Do("ZREMRANGEBYSCORE", pendingKey(memberId), id, id)
+	return err
+}
+
+// 获取会员未确认的消息,按编号排序
+func Pending(conn redis.Conn, memberId int64) ([]*Message, error) {
+	arr, err := redis.ByteSlices(conn.Do("ZRANGE", pendingKey(memberId), 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Message, 0, len(arr))
+	for _, b := range arr {
+		m := &Message{}
+		if json.Unmarshal(b, m) == nil {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,83 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : bucket
+ * author : jarryliu
+ * date : 2026-10-19 11:05
+ * description : 基于Redis的令牌桶,多个实例共享计数
+ * history :
+ */
+package ratelimit
+
+import (
+	"github.com/garyburd/redigo/redis"
+	"math"
+	"strconv"
+	"time"
+)
+
+// 令牌桶脚本,按流逝的时间补充令牌后尝试取出一个令牌,
+// 返回是否允许及剩余令牌数
+var bucketScript = redis.NewScript(1, `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local v = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(v[1])
+local ts = tonumber(v[2])
+if tokens == nil then
+  tokens = burst
+  ts = now
+end
+if now > ts then
+  tokens = math.min(burst, tokens + (now - ts) * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('EXPIRE', KEYS[1], math.ceil(burst / rate) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+// 取令牌的结果
+type Result struct {
+	// 是否允许
+	Allowed bool
+	// 每分钟限额
+	Limit int
+	// 剩余令牌数
+	Remaining int
+	// 令牌补满所需的秒数
+	Reset int
+	// 被拒绝时需等待的秒数
+	RetryAfter int
+}
+
+// 从令牌桶中取出一个令牌,perMinute为每分钟的限额,
+// 同时作为桶的容量,允许短时间内的突发请求
+func Take(conn redis.Conn, key string, perMinute int) (*Result, error) {
+	r := &Result{Allowed: true, Limit: perMinute, Remaining: perMinute}
+	if perMinute <= 0 {
+		return r, nil
+	}
+	rate := float64(perMinute) / 60
+	now := float64(time.Now().UnixNano()/1e6) / 1000
+	v, err := redis.Values(bucketScript.Do(conn, key,
+		strconv.FormatFloat(rate, 'f', -1, 64), perMinute,
+		strconv.FormatFloat(now, 'f', 3, 64)))
+	if err != nil {
+		return r, err
+	}
+	allowed, _ := redis.Int(v[0], nil)
+	s, _ := redis.String(v[1], nil)
+	tokens, _ := strconv.ParseFloat(s, 64)
+	r.Allowed = allowed == 1
+	r.Remaining = int(tokens)
+	r.Reset = int(math.Ceil((float64(perMinute) - tokens) / rate))
+	if !r.Allowed {
+		r.RetryAfter = int(math.Ceil((1 - tokens) / rate))
+	}
+	return r, nil
+}
@@ -0,0 +1,75 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : connector.go
+ * author : jarryliu
+ * date : 2026-10-19 22:10
+ * description : 绑定事务的数据库连接,仓储使用此连接时写入均在事务中
+ * history :
+ */
+package tx
+
+import (
+	"database/sql"
+	"github.com/jsix/gof/db"
+	"github.com/jsix/gof/db/orm"
+)
+
+var _ db.Connector = new(txConnector)
+
+type txConnector struct {
+	db.Connector
+	tx  *sql.Tx
+	orm orm.Orm
+}
+
+// 创建绑定事务的数据库连接
+func NewConnector(conn db.Connector, t *sql.Tx) db.Connector {
+	return &txConnector{
+		Connector: conn,
+		tx:        t,
+		orm:       NewOrm(conn.GetOrm(), t),
+	}
+}
+
+func (c *txConnector) GetOrm() orm.Orm {
+	return c.orm
+}
+
+func (c *txConnector) Query(s string, f func(*sql.Rows), arg ...interface{}) error {
+	rows, err := c.tx.Query(s, arg...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if f != nil {
+		f(rows)
+	}
+	return rows.Err()
+}
+
+func (c *txConnector) QueryRow(s string, f func(*sql.Row) error, arg ...interface{}) error {
+	row := c.tx.QueryRow(s, arg...)
+	if f != nil {
+		return f(row)
+	}
+	return nil
+}
+
+func (c *txConnector) ExecScalar(s string, result interface{}, arg ...interface{}) error {
+	return c.tx.QueryRow(s, arg...).Scan(result)
+}
+
+func (c *txConnector) Exec(s string, args ...interface{}) (int, int, error) {
+	r, err := c.tx.Exec(s, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	rows, _ := r.RowsAffected()
+	id, _ := r.LastInsertId()
+	return int(rows), int(id), nil
+}
+
+func (c *txConnector) ExecNonQuery(s string, args ...interface{}) (int, error) {
+	n, _, err := c.Exec(s, args...)
+	return n, err
+}
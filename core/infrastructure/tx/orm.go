@@ -0,0 +1,252 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : orm.go
+ * author : jarryliu
+ * date : 2026-10-19 21:50
+ * description : 绑定事务的ORM,实体需通过Mapper映射
+ * history :
+ */
+package tx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jsix/gof/db/orm"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var _ orm.Orm = new(txOrm)
+
+// 绑定事务的ORM,所有读写均在事务中执行,不再嵌入原ORM,
+// 避免未覆盖的方法绕过事务
+type txOrm struct {
+	o  orm.Orm
+	tx *sql.Tx
+}
+
+// 创建绑定事务的ORM
+func NewOrm(o orm.Orm, t *sql.Tx) orm.Orm {
+	return &txOrm{o: o, tx: t}
+}
+
+// 映射实体,同时注册到原ORM及事务的表映射
+func (o *txOrm) Mapping(v interface{}, table string) {
+	NewMapper(o.o).Mapping(v, table)
+}
+
+// 设置原ORM的跟踪,事务中的语句不受影响
+func (o *txOrm) SetTrace(b bool) {
+	o.o.SetTrace(b)
+}
+
+func (o *txOrm) Get(primaryVal interface{}, dst interface{}) error {
+	m, table, err := o.entityMeta(dst)
+	if err != nil {
+		return err
+	}
+	return o.GetByQuery(dst, fmt.Sprintf("SELECT * FROM %s WHERE %s=?",
+		table, m.pk.column), primaryVal)
+}
+
+func (o *txOrm) GetBy(dst interface{}, where string, arg ...interface{}) error {
+	table, err := tableOf(dst)
+	if err != nil {
+		return err
+	}
+	return o.GetByQuery(dst, fmt.Sprintf("SELECT * FROM %s WHERE %s",
+		table, where), arg...)
+}
+
+func (o *txOrm) GetByQuery(dst interface{}, s string, arg ...interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return ErrNotStructPtr
+	}
+	rows, err := o.tx.Query(s, arg...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+	return scanRow(rows, v.Elem())
+}
+
+func (o *txOrm) Select(dst interface{}, where string, arg ...interface{}) error {
+	table, err := tableOf(dst)
+	if err != nil {
+		return err
+	}
+	return o.SelectByQuery(dst, fmt.Sprintf("SELECT * FROM %s WHERE %s",
+		table, where), arg...)
+}
+
+func (o *txOrm) SelectByQuery(dst interface{}, s string, arg ...interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errors.New("dst must be a pointer to slice")
+	}
+	sv := v.Elem()
+	et := sv.Type().Elem()
+	isPtr := et.Kind() == reflect.Ptr
+	if isPtr {
+		et = et.Elem()
+	}
+	rows, err := o.tx.Query(s, arg...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e := reflect.New(et)
+		if err = scanRow(rows, e.Elem()); err != nil {
+			return err
+		}
+		if isPtr {
+			sv.Set(reflect.Append(sv, e))
+		} else {
+			sv.Set(reflect.Append(sv, e.Elem()))
+		}
+	}
+	return rows.Err()
+}
+
+func (o *txOrm) Delete(entity interface{}, where string, arg ...interface{}) (int64, error) {
+	table, err := tableOf(entity)
+	if err != nil {
+		return 0, err
+	}
+	r, err := o.tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", table, where), arg...)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (o *txOrm) DeleteByPk(entity interface{}, primary interface{}) error {
+	m, table, err := o.entityMeta(entity)
+	if err == nil {
+		_, err = o.tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s=?",
+			table, m.pk.column), primary)
+	}
+	return err
+}
+
+func (o *txOrm) Save(primaryKey interface{}, entity interface{}) (int64, int64, error) {
+	m, table, err := o.entityMeta(entity)
+	if err != nil {
+		return 0, 0, err
+	}
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr {
+		return 0, 0, ErrNotStructPtr
+	}
+	v = v.Elem()
+	if primaryKey == nil || isZero(reflect.ValueOf(primaryKey)) {
+		id, err := insert(o.tx, table, m, v)
+		if err != nil {
+			return 0, 0, err
+		}
+		return 1, id, nil
+	}
+	err = updateBy(o.tx, table, m, v, primaryKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	return 1, intValue(reflect.ValueOf(primaryKey)), nil
+}
+
+func (o *txOrm) entityMeta(v interface{}) (*meta, string, error) {
+	table, err := tableOf(v)
+	if err != nil {
+		return nil, "", err
+	}
+	m := getMeta(indirectType(reflect.TypeOf(v)))
+	if m.pk == nil {
+		return nil, "", fmt.Errorf("table %s has no primary key", table)
+	}
+	return m, table, nil
+}
+
+// 按列名扫描一行数据到实体
+func scanRow(rows *sql.Rows, v reflect.Value) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	m := getMeta(v.Type())
+	index := make(map[string]int, len(m.fields))
+	for _, f := range m.fields {
+		index[f.column] = f.index
+	}
+	values := make([]interface{}, len(cols))
+	for i := range values {
+		values[i] = new(interface{})
+	}
+	if err = rows.Scan(values...); err != nil {
+		return err
+	}
+	for i, c := range cols {
+		if fi, ok := index[strings.ToLower(c)]; ok {
+			if err = setField(v.Field(fi), *(values[i].(*interface{}))); err != nil {
+				return fmt.Errorf("column %s: %s", c, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// 将数据库的值赋给字段,NULL赋为零值
+func setField(f reflect.Value, src interface{}) error {
+	if src == nil {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+	var s string
+	switch t := src.(type) {
+	case []byte:
+		if f.Kind() == reflect.Slice {
+			f.SetBytes(append([]byte(nil), t...))
+			return nil
+		}
+		s = string(t)
+	case string:
+		s = t
+	default:
+		s = fmt.Sprint(t)
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		f.SetBool(s == "1" || s == "true")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Slice:
+		f.SetBytes([]byte(s))
+	}
+	return nil
+}
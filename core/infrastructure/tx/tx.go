@@ -0,0 +1,290 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : tx.go
+ * author : jarryliu
+ * date : 2026-10-19 20:10
+ * description : 数据库事务,在事务中按实体的db标签保存数据。
+ *   实体包含version列时,按版本更新并递增版本,版本已改变返回冲突错误
+ * history :
+ */
+package tx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jsix/gof/db"
+	"github.com/jsix/gof/db/orm"
+	"go2o/core/infrastructure/domain"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var ErrNotStructPtr = errors.New("entity must be a pointer to struct")
+
+// 乐观并发控制的版本列
+const VersionColumn = "version"
+
+// 执行语句,*sql.Tx和*sql.DB均实现
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// 在事务中执行,返回错误或发生panic时回滚。
+// 连接已绑定事务时,在该事务中执行,由外层事务提交
+func Do(conn db.Connector, f func(tx *sql.Tx) error) (err error) {
+	if c, ok := conn.(*txConnector); ok {
+		return f(c.tx)
+	}
+	t, err := conn.Raw().Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			t.Rollback()
+			panic(r)
+		}
+	}()
+	if err = f(t); err != nil {
+		t.Rollback()
+		return err
+	}
+	return t.Commit()
+}
+
+// 实体字段
+type field struct {
+	index  int
+	column string
+	pk     bool
+	auto   bool
+}
+
+// 实体元数据
+type meta struct {
+	fields  []*field
+	pk      *field
+	version *field
+}
+
+var (
+	metaCache = map[reflect.Type]*meta{}
+	metaMux   sync.RWMutex
+	// 实体对应的表
+	tables = map[reflect.Type]string{}
+)
+
+// 实体与表的映射,同时映射到ORM
+type Mapper struct {
+	orm.Orm
+}
+
+// 创建映射,在事务中读写实体需先映射
+func NewMapper(o orm.Orm) *Mapper {
+	return &Mapper{Orm: o}
+}
+
+func (m *Mapper) Mapping(v interface{}, table string) {
+	m.Orm.Mapping(v, table)
+	metaMux.Lock()
+	tables[indirectType(reflect.TypeOf(v))] = table
+	metaMux.Unlock()
+}
+
+// 获取实体对应的表
+func tableOf(v interface{}) (string, error) {
+	t := indirectType(reflect.TypeOf(v))
+	if t.Kind() == reflect.Slice {
+		t = indirectType(t.Elem())
+	}
+	metaMux.RLock()
+	table, ok := tables[t]
+	metaMux.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("entity %s not mapping", t.Name())
+	}
+	return table, nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func getMeta(t reflect.Type) *meta {
+	metaMux.RLock()
+	m, ok := metaCache[t]
+	metaMux.RUnlock()
+	if ok {
+		return m
+	}
+	m = &meta{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		col := f.Tag.Get("db")
+		if col == "" || col == "-" || !isColumnKind(f.Type) {
+			continue
+		}
+		fd := &field{
+			index:  i,
+			column: col,
+			pk:     f.Tag.Get("pk") == "yes",
+			auto:   f.Tag.Get("auto") == "yes",
+		}
+		if fd.pk {
+			m.pk = fd
+		}
+		if col == VersionColumn {
+			m.version = fd
+		}
+		m.fields = append(m.fields, fd)
+	}
+	metaMux.Lock()
+	metaCache[t] = m
+	metaMux.Unlock()
+	return m
+}
+
+func isColumnKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// 在事务中保存实体,主键为零值时新增并回写自增主键,否则按主键更新。
+// 实体对应的表通过Mapper映射
+func Save(t *sql.Tx, entity interface{}) (int64, error) {
+	return save(t, entity)
+}
+
+// 使用连接保存实体,连接绑定事务时在事务中保存
+func SaveBy(conn db.Connector, entity interface{}) (int64, error) {
+	if c, ok := conn.(*txConnector); ok {
+		return save(c.tx, entity)
+	}
+	return save(conn.Raw(), entity)
+}
+
+func save(e execer, entity interface{}) (int64, error) {
+	table, err := tableOf(entity)
+	if err != nil {
+		return 0, err
+	}
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return 0, ErrNotStructPtr
+	}
+	v = v.Elem()
+	m := getMeta(v.Type())
+	if m.pk == nil {
+		return 0, fmt.Errorf("entity %s has no primary key", v.Type().Name())
+	}
+	pkv := v.Field(m.pk.index)
+	if isZero(pkv) {
+		return insert(e, table, m, v)
+	}
+	return intValue(pkv), update(e, table, m, v)
+}
+
+func insert(t execer, table string, m *meta, v reflect.Value) (int64, error) {
+	var cols, marks []string
+	var args []interface{}
+	for _, f := range m.fields {
+		if f.pk && f.auto {
+			continue
+		}
+		cols = append(cols, f.column)
+		marks = append(marks, "?")
+		args = append(args, v.Field(f.index).Interface())
+	}
+	s := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table,
+		strings.Join(cols, ","), strings.Join(marks, ","))
+	r, err := t.Exec(s, args...)
+	if err != nil {
+		return 0, err
+	}
+	pkv := v.Field(m.pk.index)
+	if !m.pk.auto {
+		return intValue(pkv), nil
+	}
+	id, err := r.LastInsertId()
+	if err == nil {
+		setInt(pkv, id)
+	}
+	return id, err
+}
+
+func update(t execer, table string, m *meta, v reflect.Value) error {
+	return updateBy(t, table, m, v, v.Field(m.pk.index).Interface())
+}
+
+func updateBy(t execer, table string, m *meta, v reflect.Value, pk interface{}) error {
+	var sets []string
+	var args []interface{}
+	for _, f := range m.fields {
+		if f.pk || f == m.version {
+			continue
+		}
+		sets = append(sets, f.column+"=?")
+		args = append(args, v.Field(f.index).Interface())
+	}
+	if m.version == nil {
+		args = append(args, pk)
+		s := fmt.Sprintf("UPDATE %s SET %s WHERE %s=?", table,
+			strings.Join(sets, ","), m.pk.column)
+		_, err := t.Exec(s, args...)
+		return err
+	}
+	// 按版本更新,未更新任何行说明版本已改变
+	ver := v.Field(m.version.index)
+	sets = append(sets, fmt.Sprintf("%[1]s=%[1]s+1", m.version.column))
+	args = append(args, pk, ver.Interface())
+	s := fmt.Sprintf("UPDATE %s SET %s WHERE %s=? AND %s=?", table,
+		strings.Join(sets, ","), m.pk.column, m.version.column)
+	r, err := t.Exec(s, args...)
+	var n int64
+	if err == nil {
+		n, err = r.RowsAffected()
+	}
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &domain.ConflictError{Table: table, Id: pk, Version: intValue(ver)}
+	}
+	setInt(ver, intValue(ver)+1)
+	return nil
+}
+
+func isZero(v reflect.Value) bool {
+	return v.Interface() == reflect.Zero(v.Type()).Interface()
+}
+
+func intValue(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	}
+	return 0
+}
+
+func setInt(v reflect.Value, id int64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(id))
+	}
+}
@@ -0,0 +1,124 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : uow.go
+ * author : jarryliu
+ * date : 2026-10-19 22:20
+ * description : 工作单元,多个仓储的写入在同一事务中提交,出错时回滚。
+ *   仓储实现Binder后,绑定到工作单元的仓储使用事务连接读写,缓存在提交后写入
+ * history :
+ */
+package uow
+
+import (
+	"database/sql"
+	"github.com/jsix/gof/db"
+	"github.com/jsix/gof/storage"
+	"go2o/core/infrastructure/tx"
+	"log"
+)
+
+// 支持工作单元的仓储
+type Binder interface {
+	// 返回绑定到工作单元的仓储副本
+	BindUnitOfWork(u *UnitOfWork) interface{}
+}
+
+// 工作单元
+type UnitOfWork struct {
+	tx    *sql.Tx
+	conn  db.Connector
+	after []func()
+	// 已绑定的仓储,避免仓储间相互引用时重复绑定
+	bound map[interface{}]interface{}
+}
+
+// 在工作单元中执行,返回错误或发生panic时回滚,提交后执行AfterCommit注册的函数
+func Run(conn db.Connector, f func(u *UnitOfWork) error) error {
+	var u *UnitOfWork
+	err := tx.Do(conn, func(t *sql.Tx) error {
+		u = &UnitOfWork{
+			tx:    t,
+			conn:  tx.NewConnector(conn, t),
+			bound: map[interface{}]interface{}{},
+		}
+		return f(u)
+	})
+	if err == nil {
+		for _, fn := range u.after {
+			fn()
+		}
+	}
+	return err
+}
+
+// 事务
+func (u *UnitOfWork) Tx() *sql.Tx {
+	return u.tx
+}
+
+// 绑定事务的数据库连接
+func (u *UnitOfWork) Connector() db.Connector {
+	return u.conn
+}
+
+// 注册提交后执行的函数
+func (u *UnitOfWork) AfterCommit(f func()) {
+	u.after = append(u.after, f)
+}
+
+// 返回提交后才写入的缓存
+func (u *UnitOfWork) Storage(s storage.Interface) storage.Interface {
+	if _, ok := s.(*deferStorage); ok {
+		return s
+	}
+	return &deferStorage{Interface: s, u: u}
+}
+
+// 绑定仓储到工作单元,未实现Binder的仓储原样返回
+func Bind(u *UnitOfWork, repo interface{}) interface{} {
+	if repo == nil {
+		return nil
+	}
+	if r, ok := u.bound[repo]; ok {
+		return r
+	}
+	b, ok := repo.(Binder)
+	if !ok {
+		return repo
+	}
+	return b.BindUnitOfWork(u)
+}
+
+// 记录已绑定的仓储,需在绑定依赖的仓储前调用
+func (u *UnitOfWork) Bound(origin interface{}, bound interface{}) {
+	u.bound[origin] = bound
+}
+
+// 提交后写入的缓存,删除立即执行并在提交后再次执行
+type deferStorage struct {
+	storage.Interface
+	u *UnitOfWork
+}
+
+func (d *deferStorage) Set(key string, v interface{}) error {
+	d.u.AfterCommit(func() {
+		if err := d.Interface.Set(key, v); err != nil {
+			log.Println("[ Go2o][ Uow][ Cache]:", key, err.Error())
+		}
+	})
+	return nil
+}
+
+func (d *deferStorage) SetExpire(key string, v interface{}, seconds int64) error {
+	d.u.AfterCommit(func() {
+		if err := d.Interface.SetExpire(key, v, seconds); err != nil {
+			log.Println("[ Go2o][ Uow][ Cache]:", key, err.Error())
+		}
+	})
+	return nil
+}
+
+func (d *deferStorage) Del(key string) {
+	d.Interface.Del(key)
+	d.u.AfterCommit(func() { d.Interface.Del(key) })
+}
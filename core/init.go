@@ -37,8 +37,14 @@ import (
 	"go2o/core/domain/interface/shipment"
 	"go2o/core/domain/interface/valueobject"
 	"go2o/core/dto"
+	"go2o/core/infrastructure/idempotent"
+	"go2o/core/infrastructure/migrate"
+	"go2o/core/infrastructure/outbox"
+	"go2o/core/infrastructure/tx"
+	_ "go2o/core/migration"
 	"go2o/core/service/thrift/idl/gen-go/define"
 	"go2o/core/variable"
+	"log"
 	"strconv"
 	"strings"
 	"time"
@@ -73,6 +79,12 @@ func Init(a *AppImpl, debug, trace bool) bool {
 	if debug {
 		report.WATCH_CONF_FILE = true
 	}
+	// 数据库结构不是最新版本时不启动
+	if err := migrate.Check(a.Db()); err != nil {
+		log.Println("[ Go2o][ Migrate]:", err.Error(),
+			", please run: go2o-migrate -conf=<config> up")
+		return false
+	}
 	OrmMapping(a.Db())
 	// 初始化变量
 	variable.Domain = a._config.GetString(variable.ServerDomain)
@@ -86,7 +98,8 @@ func Init(a *AppImpl, debug, trace bool) bool {
 
 func OrmMapping(conn db.Connector) {
 	//table mapping
-	orm := conn.GetOrm()
+	//同时映射到事务,用于工作单元中读写
+	orm := tx.NewMapper(conn.GetOrm())
 	orm.Mapping(valueobject.Area{}, "china_area")
 	/* ad */
 	orm.Mapping(ad.Ad{}, "ad_list")
@@ -122,6 +135,8 @@ func OrmMapping(conn db.Connector) {
 	orm.Mapping(member.Favorite{}, "mm_favorite")
 	orm.Mapping(member.BankInfo{}, "mm_bank")
 	orm.Mapping(member.LevelUpLog{}, "mm_levelup")
+	orm.Mapping(member.LevelRule{}, "mm_level_rule")
+	orm.Mapping(member.LevelBenefit{}, "mm_level_benefit")
 	orm.Mapping(member.BuyerGroup{}, "mm_buyer_group")
 
 	//** ORDER **//
@@ -145,6 +160,8 @@ func OrmMapping(conn db.Connector) {
 	orm.Mapping(afterSales.ReturnOrder{}, "sale_return")
 	orm.Mapping(afterSales.ExchangeOrder{}, "sale_exchange")
 	orm.Mapping(afterSales.RefundOrder{}, "sale_refund")
+	orm.Mapping(afterSales.Message{}, "sale_after_message")
+	orm.Mapping(afterSales.Arbitration{}, "sale_after_arbitration")
 
 	//** Express **//
 	orm.Mapping(express.ExpressProvider{}, "express_provider")
@@ -177,6 +194,7 @@ func OrmMapping(conn db.Connector) {
 	orm.Mapping(merchant.Merchant{}, "mch_merchant")
 	orm.Mapping(merchant.EnterpriseInfo{}, "mch_enterprise_info")
 	orm.Mapping(merchant.ApiInfo{}, "mch_api_info")
+	orm.Mapping(merchant.ApiUsage{}, "mch_api_usage")
 	orm.Mapping(shop.Shop{}, "mch_shop")
 	orm.Mapping(shop.OnlineShop{}, "mch_online_shop")
 	orm.Mapping(shop.OfflineShop{}, "mch_offline_shop")
@@ -187,6 +205,12 @@ func OrmMapping(conn db.Connector) {
 	orm.Mapping(merchant.MchDayChart{}, "mch_day_chart")
 	orm.Mapping(merchant.MchSignUp{}, "mch_sign_up")
 	orm.Mapping(merchant.MchBuyerGroup{}, "mch_buyer_group")
+	orm.Mapping(merchant.OrderTimeout{}, "mch_order_timeout")
+	orm.Mapping(merchant.CommissionRule{}, "mch_commission_rule")
+	orm.Mapping(merchant.Commission{}, "mch_commission")
+	orm.Mapping(merchant.Webhook{}, "mch_webhook")
+	orm.Mapping(merchant.WebhookMessage{}, "mch_webhook_msg")
+	orm.Mapping(merchant.WebhookLog{}, "mch_webhook_log")
 	orm.Mapping(mss.MailTemplate{}, "pt_mail_template")
 	orm.Mapping(mss.MailTask{}, "pt_mail_queue")
 
@@ -201,6 +225,10 @@ func OrmMapping(conn db.Connector) {
 
 	orm.Mapping(payment.PaymentOrder{}, "pay_order")
 
+	/* 事件 */
+	orm.Mapping(outbox.Record{}, outbox.Table)
+	orm.Mapping(idempotent.Record{}, idempotent.Table)
+
 	/** 促销 **/
 	orm.Mapping(promotion.ValueCoupon{}, "pm_coupon")
 	orm.Mapping(promotion.ValueCouponBind{}, "pm_coupon_bind")
@@ -221,6 +249,8 @@ func OrmMapping(conn db.Connector) {
 	orm.Mapping(personfinance.RiseInfoValue{}, "pf_riseinfo")
 	orm.Mapping(personfinance.RiseDayInfo{}, "pf_riseday")
 	orm.Mapping(personfinance.RiseLog{}, "pf_riselog")
+	orm.Mapping(personfinance.FinancePlan{}, "pf_plan")
+	orm.Mapping(personfinance.PlanRate{}, "pf_plan_rate")
 
 	/* 通用模块 */
 	orm.Mapping(model.CommQrTemplate{}, "comm_qr_template")
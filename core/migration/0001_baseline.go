@@ -0,0 +1,21 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0001_baseline.go
+ * author : jarryliu
+ * date : 2026-10-20 16:00
+ * description : 数据库结构迁移,每个版本一个文件,文件名为"版本号_名称",
+ *   已发布的脚本不能修改,结构变更需新增版本。
+ *   版本1为基础结构,即docs/data/go2o.sql及2017-07-15之前的升级脚本,
+ *   新建的数据库需先导入上述脚本
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 1,
+		Name:    "baseline",
+	})
+}
@@ -0,0 +1,43 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0002_member_level.go
+ * author : jarryliu
+ * date : 2026-10-20 16:06
+ * description : 会员等级规则及权益
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 2,
+		Name:    "member_level",
+		Up: `
+CREATE TABLE mm_level_rule (
+  level_id       int(11) NOT NULL comment '等级编号',
+  require_amount decimal(10, 2) NOT NULL comment '需要消费金额',
+  require_orders int(11) NOT NULL comment '需要订单数量',
+  require_exp    int(11) NOT NULL comment '需要经验值',
+  period_type    tinyint(1) NOT NULL comment '统计周期类型,1:累计 2:滚动月份 3:自然年',
+  period_months  int(4) NOT NULL comment '滚动周期月数',
+  auto_downgrade tinyint(1) NOT NULL comment '是否自动降级',
+  enabled        tinyint(1) NOT NULL comment '是否启用',
+  update_time    int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (level_id)) comment='会员等级规则';
+
+CREATE TABLE mm_level_benefit (
+  level_id          int(11) NOT NULL comment '等级编号',
+  free_shipping     tinyint(1) NOT NULL comment '是否包邮',
+  discount_rate     decimal(4, 2) NOT NULL comment '折扣率',
+  integral_multiple decimal(4, 2) NOT NULL comment '积分倍数',
+  update_time       int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (level_id)) comment='会员等级权益';
+`,
+		Down: `
+DROP TABLE mm_level_benefit;
+DROP TABLE mm_level_rule;
+`,
+	})
+}
@@ -0,0 +1,61 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0003_commission.go
+ * author : jarryliu
+ * date : 2026-10-20 16:09
+ * description : 分销佣金
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 3,
+		Name:    "commission",
+		Up: `
+ALTER TABLE mch_sale_conf
+  ADD COLUMN cms_freeze_days INT(4) NOT NULL DEFAULT 7 COMMENT '分销佣金冻结天数(售后期)';
+
+CREATE TABLE mch_commission_rule (
+  id          int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id      int(11) NOT NULL comment '商户编号',
+  cat_id      int(11) NOT NULL comment '分类编号,0表示不限分类',
+  item_id     int(11) NOT NULL comment '商品编号,0表示不限商品',
+  rates       varchar(120) NOT NULL comment '各级佣金比例,用","分隔',
+  enabled     tinyint(1) NOT NULL comment '是否启用',
+  update_time int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id)) comment='分销佣金规则';
+
+CREATE TABLE mch_commission (
+  id             int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id         int(11) NOT NULL comment '商户编号',
+  order_id       int(11) NOT NULL comment '子订单编号',
+  order_no       varchar(45) NOT NULL comment '订单号',
+  snap_id        int(11) NOT NULL comment '快照编号',
+  item_id        int(11) NOT NULL comment '商品编号',
+  buyer_id       int(11) NOT NULL comment '买家编号',
+  member_id      int(11) NOT NULL comment '推荐人编号',
+  depth          int(4) NOT NULL comment '推荐层级',
+  base_amount    decimal(10, 2) NOT NULL comment '计算佣金的商品金额',
+  rate           decimal(6, 4) NOT NULL comment '佣金比例',
+  amount         decimal(10, 2) NOT NULL comment '佣金金额',
+  reverse_amount decimal(10, 2) NOT NULL comment '已撤销金额',
+  state          tinyint(1) NOT NULL comment '状态,1:冻结 2:已发放 3:已撤销',
+  unfreeze_time  int(11) NOT NULL comment '解冻时间',
+  create_time    int(11) NOT NULL comment '创建时间',
+  update_time    int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id),
+  INDEX idx_order (order_id, snap_id),
+  INDEX idx_member (member_id),
+  INDEX idx_state (state, unfreeze_time)) comment='分销佣金';
+`,
+		Down: `
+DROP TABLE mch_commission;
+DROP TABLE mch_commission_rule;
+ALTER TABLE mch_sale_conf
+  DROP COLUMN cms_freeze_days;
+`,
+	})
+}
@@ -0,0 +1,52 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0004_finance_plan.go
+ * author : jarryliu
+ * date : 2026-10-20 16:12
+ * description : 理财计划
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 4,
+		Name:    "finance_plan",
+		Up: `
+ALTER TABLE pf_riseinfo
+  ADD COLUMN plan_id INT(11) NOT NULL DEFAULT 0 COMMENT '理财计划编号' AFTER settled_date,
+  ADD COLUMN lock_expires INT(11) NOT NULL DEFAULT 0 COMMENT '锁定到期时间' AFTER plan_id;
+
+CREATE TABLE pf_plan (
+  id           int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  name         varchar(45) NOT NULL comment '名称',
+  settle_t     int(4) NOT NULL comment 'T+N开始计算收益',
+  lock_days    int(4) NOT NULL comment '锁定天数,0为随存随取',
+  min_in       decimal(10, 2) NOT NULL comment '最低转入金额',
+  min_out      decimal(10, 2) NOT NULL comment '最低转出金额',
+  max_amount   decimal(10, 2) NOT NULL comment '最高持有金额,0为不限',
+  penalty_rate decimal(6, 4) NOT NULL comment '锁定期内转出的违约金比例',
+  enabled      tinyint(1) NOT NULL comment '是否启用',
+  update_time  int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id)) comment='理财计划';
+
+CREATE TABLE pf_plan_rate (
+  id             int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  plan_id        int(11) NOT NULL comment '理财计划编号',
+  annual_rate    decimal(8, 6) NOT NULL comment '年化利率',
+  effective_date int(11) NOT NULL comment '生效日期',
+  create_time    int(11) NOT NULL comment '创建时间',
+  PRIMARY KEY (id),
+  INDEX idx_plan (plan_id, effective_date)) comment='理财计划利率';
+`,
+		Down: `
+DROP TABLE pf_plan_rate;
+DROP TABLE pf_plan;
+ALTER TABLE pf_riseinfo
+  DROP COLUMN lock_expires,
+  DROP COLUMN plan_id;
+`,
+	})
+}
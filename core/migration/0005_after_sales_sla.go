@@ -0,0 +1,37 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0005_after_sales_sla.go
+ * author : jarryliu
+ * date : 2026-10-20 16:15
+ * description : 售后处理时效
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 5,
+		Name:    "after_sales_sla",
+		Up: `
+ALTER TABLE mch_sale_conf
+  ADD COLUMN as_vendor_hour INT(4) NOT NULL DEFAULT 72 COMMENT '售后商户处理时效(小时)',
+  ADD COLUMN as_return_ship_hour INT(4) NOT NULL DEFAULT 168 COMMENT '售后买家退货时效(小时)',
+  ADD COLUMN as_receive_hour INT(4) NOT NULL DEFAULT 240 COMMENT '售后商户收货时效(小时)',
+  ADD COLUMN as_declined_hour INT(4) NOT NULL DEFAULT 72 COMMENT '售后拒绝后自动申请调解时效(小时)';
+
+ALTER TABLE sale_after_order
+  ADD INDEX idx_state (state);
+`,
+		Down: `
+ALTER TABLE sale_after_order
+  DROP INDEX idx_state;
+ALTER TABLE mch_sale_conf
+  DROP COLUMN as_vendor_hour,
+  DROP COLUMN as_return_ship_hour,
+  DROP COLUMN as_receive_hour,
+  DROP COLUMN as_declined_hour;
+`,
+	})
+}
@@ -0,0 +1,46 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0006_after_sales_message.go
+ * author : jarryliu
+ * date : 2026-10-20 16:18
+ * description : 售后留言及仲裁记录
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 6,
+		Name:    "after_sales_message",
+		Up: `
+CREATE TABLE sale_after_message (
+  id          int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  as_id       int(11) NOT NULL comment '售后单编号',
+  sender_role tinyint(2) NOT NULL comment '发送人角色,1:买家 2:商户 3:平台客服',
+  sender_id   bigint(20) NOT NULL comment '发送人编号',
+  content     varchar(512) NOT NULL comment '留言内容',
+  images      varchar(1024) NOT NULL comment '图片凭证',
+  create_time int(11) NOT NULL comment '创建时间',
+  PRIMARY KEY (id),
+  INDEX idx_as (as_id)) comment='售后单留言';
+
+CREATE TABLE sale_after_arbitration (
+  id           int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  as_id        int(11) NOT NULL comment '售后单编号',
+  arbiter_id   int(11) NOT NULL comment '仲裁人员编号',
+  arbiter_name varchar(20) NOT NULL comment '仲裁人员名称',
+  outcome      tinyint(2) NOT NULL comment '仲裁结果,1:支持买家 2:支持商户',
+  amount       decimal(10, 2) NOT NULL comment '退款金额',
+  remark       varchar(512) NOT NULL comment '仲裁说明',
+  create_time  int(11) NOT NULL comment '创建时间',
+  PRIMARY KEY (id),
+  INDEX idx_as (as_id)) comment='售后单仲裁记录';
+`,
+		Down: `
+DROP TABLE sale_after_arbitration;
+DROP TABLE sale_after_message;
+`,
+	})
+}
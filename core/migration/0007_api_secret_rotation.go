@@ -0,0 +1,26 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0007_api_secret_rotation.go
+ * author : jarryliu
+ * date : 2026-10-20 16:21
+ * description : 商户接口密钥轮换
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 7,
+		Name:    "api_secret_rotation",
+		Up: `
+ALTER TABLE mch_api_info
+  ADD COLUMN api_secret2 VARCHAR(32) NOT NULL DEFAULT '' COMMENT '轮换前的密钥' AFTER api_secret;
+`,
+		Down: `
+ALTER TABLE mch_api_info
+  DROP COLUMN api_secret2;
+`,
+	})
+}
@@ -0,0 +1,61 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0008_webhook.go
+ * author : jarryliu
+ * date : 2026-10-20 16:24
+ * description : 商户通知
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 8,
+		Name:    "webhook",
+		Up: `
+CREATE TABLE mch_webhook (
+  id          int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id      int(11) NOT NULL comment '商户编号',
+  event       varchar(40) NOT NULL comment '通知事件',
+  url         varchar(255) NOT NULL comment '通知地址',
+  enabled     tinyint(1) NOT NULL comment '是否启用',
+  update_time int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id),
+  INDEX idx_mch (mch_id)) comment='商户通知地址';
+
+CREATE TABLE mch_webhook_msg (
+  id          bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id      int(11) NOT NULL comment '商户编号',
+  hook_id     int(11) NOT NULL comment '通知地址编号',
+  event       varchar(40) NOT NULL comment '通知事件',
+  payload     text NOT NULL comment '通知内容(JSON)',
+  state       tinyint(2) NOT NULL comment '状态,1:待发送 2:已送达 3:失败',
+  retries     int(4) NOT NULL comment '已重试次数',
+  next_time   int(11) NOT NULL comment '下次发送时间',
+  last_error  varchar(255) NOT NULL comment '最后的错误信息',
+  create_time int(11) NOT NULL comment '创建时间',
+  update_time int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id),
+  INDEX idx_state (state, next_time)) comment='商户通知消息';
+
+CREATE TABLE mch_webhook_log (
+  id          bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  msg_id      bigint(20) NOT NULL comment '通知消息编号',
+  url         varchar(255) NOT NULL comment '通知地址',
+  status_code int(4) NOT NULL comment 'HTTP状态码',
+  response    varchar(512) NOT NULL comment '响应内容',
+  error       varchar(255) NOT NULL comment '错误信息',
+  duration    int(11) NOT NULL comment '耗时(毫秒)',
+  create_time int(11) NOT NULL comment '创建时间',
+  PRIMARY KEY (id),
+  INDEX idx_msg (msg_id)) comment='商户通知发送日志';
+`,
+		Down: `
+DROP TABLE mch_webhook_log;
+DROP TABLE mch_webhook_msg;
+DROP TABLE mch_webhook;
+`,
+	})
+}
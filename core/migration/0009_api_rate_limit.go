@@ -0,0 +1,40 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0009_api_rate_limit.go
+ * author : jarryliu
+ * date : 2026-10-20 16:27
+ * description : 商户接口限流
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 9,
+		Name:    "api_rate_limit",
+		Up: `
+ALTER TABLE mch_api_info
+  ADD COLUMN read_rate INT(11) NOT NULL DEFAULT 0 COMMENT '查询类接口每分钟限额,0为默认限额',
+  ADD COLUMN write_rate INT(11) NOT NULL DEFAULT 0 COMMENT '操作类接口每分钟限额,0为默认限额';
+
+CREATE TABLE mch_api_usage (
+  id          bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id      int(11) NOT NULL comment '商户编号',
+  stat_date   int(8) NOT NULL comment '统计日期',
+  api_class   varchar(10) NOT NULL comment '接口类别',
+  requests    bigint(20) NOT NULL comment '请求次数',
+  limited     bigint(20) NOT NULL comment '被限流的次数',
+  update_time int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE INDEX uk_usage (mch_id, stat_date, api_class)) comment='商户接口每日调用统计';
+`,
+		Down: `
+DROP TABLE mch_api_usage;
+ALTER TABLE mch_api_info
+  DROP COLUMN read_rate,
+  DROP COLUMN write_rate;
+`,
+	})
+}
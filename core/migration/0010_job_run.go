@@ -0,0 +1,35 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0010_job_run.go
+ * author : jarryliu
+ * date : 2026-10-20 16:30
+ * description : 计划任务执行记录
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 10,
+		Name:    "job_run",
+		Up: `
+CREATE TABLE sys_job_run (
+  id          bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  job_name    varchar(40) NOT NULL comment '任务名称',
+  trigger_by  varchar(10) NOT NULL comment '触发方式',
+  instance    varchar(60) NOT NULL comment '执行的实例',
+  fence       bigint(20) NOT NULL comment '锁令牌',
+  start_time  int(11) NOT NULL comment '开始时间',
+  end_time    int(11) NOT NULL comment '结束时间',
+  state       int(1) NOT NULL comment '状态:1执行中 2成功 3失败',
+  error       varchar(512) NOT NULL comment '错误信息',
+  PRIMARY KEY (id),
+  INDEX idx_job_name (job_name)) comment='计划任务执行记录';
+`,
+		Down: `
+DROP TABLE sys_job_run;
+`,
+	})
+}
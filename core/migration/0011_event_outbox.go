@@ -0,0 +1,36 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0011_event_outbox.go
+ * author : jarryliu
+ * date : 2026-10-20 16:33
+ * description : 领域事件发件箱
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 11,
+		Name:    "event_outbox",
+		Up: `
+CREATE TABLE sys_event_outbox (
+  id            bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  topic         varchar(20) NOT NULL comment '主题',
+  event_type    varchar(40) NOT NULL comment '事件类型',
+  event_key     varchar(40) NOT NULL comment '事件关联的键',
+  data          text NOT NULL comment '事件数据',
+  attempts      int(11) NOT NULL comment '发布次数',
+  last_error    varchar(255) NOT NULL comment '最后一次发布的错误',
+  dispatched    tinyint(1) NOT NULL comment '是否已发布',
+  create_time   int(11) NOT NULL comment '创建时间',
+  dispatch_time int(11) NOT NULL comment '发布时间',
+  PRIMARY KEY (id),
+  INDEX idx_dispatched (dispatched, id)) comment='领域事件发件箱';
+`,
+		Down: `
+DROP TABLE sys_event_outbox;
+`,
+	})
+}
@@ -0,0 +1,34 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0012_data_version.go
+ * author : jarryliu
+ * date : 2026-10-20 16:36
+ * description : 乐观并发控制的数据版本
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 12,
+		Name:    "data_version",
+		Up: `
+ALTER TABLE mm_account
+  ADD COLUMN version int(11) NOT NULL DEFAULT 0 COMMENT '数据版本';
+ALTER TABLE item_info
+  ADD COLUMN version int(11) NOT NULL DEFAULT 0 COMMENT '数据版本';
+ALTER TABLE sale_sub_order
+  ADD COLUMN version int(11) NOT NULL DEFAULT 0 COMMENT '数据版本';
+`,
+		Down: `
+ALTER TABLE mm_account
+  DROP COLUMN version;
+ALTER TABLE item_info
+  DROP COLUMN version;
+ALTER TABLE sale_sub_order
+  DROP COLUMN version;
+`,
+	})
+}
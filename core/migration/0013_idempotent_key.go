@@ -0,0 +1,33 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0013_idempotent_key.go
+ * author : jarryliu
+ * date : 2026-10-20 16:39
+ * description : 幂等键
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 13,
+		Name:    "idempotent_key",
+		Up: `
+CREATE TABLE sys_idempotent_key (
+  id           bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  scope        varchar(40) NOT NULL comment '业务范围',
+  idem_key     varchar(120) NOT NULL comment '幂等键',
+  result       text NOT NULL comment '执行结果',
+  create_time  int(11) NOT NULL comment '创建时间',
+  expires_time int(11) NOT NULL comment '过期时间',
+  PRIMARY KEY (id),
+  UNIQUE INDEX uk_scope_key (scope, idem_key),
+  INDEX idx_expires_time (expires_time)) comment='幂等键';
+`,
+		Down: `
+DROP TABLE sys_idempotent_key;
+`,
+	})
+}
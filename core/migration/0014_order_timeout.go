@@ -0,0 +1,45 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0014_order_timeout.go
+ * author : jarryliu
+ * date : 2026-10-20 20:10
+ * description : 按商户及订单类型的订单时效,买家延长收货
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 14,
+		Name:    "order_timeout",
+		Up: `
+CREATE TABLE mch_order_timeout (
+  id                  int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id              int(11) NOT NULL comment '商户编号',
+  order_type          int(11) NOT NULL comment '订单类型',
+  timeout_minute      int(11) NOT NULL DEFAULT 0 comment '订单超时取消(分钟),0使用销售设置',
+  confirm_minute      int(11) NOT NULL DEFAULT 0 comment '订单自动确认(分钟),0使用销售设置',
+  receive_hour        int(11) NOT NULL DEFAULT 0 comment '超时自动收货(小时),0使用销售设置',
+  receive_extend_hour int(11) NOT NULL DEFAULT 0 comment '延长收货(小时),0使用销售设置',
+  PRIMARY KEY (id),
+  UNIQUE INDEX uk_mch_type (mch_id, order_type)) comment='订单类型时效设置';
+ALTER TABLE mch_sale_conf
+  ADD COLUMN oa_receive_extend_hour int(11) NOT NULL DEFAULT 0 COMMENT '延长收货（小时）';
+ALTER TABLE sale_sub_order
+  ADD COLUMN receive_extend int(11) NOT NULL DEFAULT 0 COMMENT '延长收货的小时数';
+ALTER TABLE order_wholesale_order
+  ADD COLUMN receive_extend int(11) NOT NULL DEFAULT 0 COMMENT '延长收货的小时数';
+`,
+		Down: `
+DROP TABLE mch_order_timeout;
+ALTER TABLE mch_sale_conf
+  DROP COLUMN oa_receive_extend_hour;
+ALTER TABLE sale_sub_order
+  DROP COLUMN receive_extend;
+ALTER TABLE order_wholesale_order
+  DROP COLUMN receive_extend;
+`,
+	})
+}
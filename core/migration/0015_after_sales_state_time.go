@@ -0,0 +1,27 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0015_after_sales_state_time.go
+ * author : jarryliu
+ * date : 2026-10-21 11:05
+ * description : 售后单状态变更时间,用于计算处理时效
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 15,
+		Name:    "after_sales_state_time",
+		Up: `
+ALTER TABLE sale_after_order
+  ADD COLUMN state_time BIGINT(20) NOT NULL DEFAULT 0 COMMENT '状态变更时间';
+UPDATE sale_after_order SET state_time=update_time;
+`,
+		Down: `
+ALTER TABLE sale_after_order
+  DROP COLUMN state_time;
+`,
+	})
+}
@@ -0,0 +1,35 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : 0016_order_confirm_unset.go
+ * author : jarryliu
+ * date : 2026-10-21 14:30
+ * description : 订单自动确认时间以-1表示未设置,0为立即确认
+ * history :
+ */
+package migration
+
+import "go2o/core/infrastructure/migrate"
+
+func init() {
+	migrate.Register(&migrate.Migration{
+		Version: 16,
+		Name:    "order_confirm_unset",
+		Up: `
+UPDATE mch_order_timeout SET confirm_minute=-1 WHERE confirm_minute=0;
+ALTER TABLE mch_order_timeout
+  MODIFY COLUMN confirm_minute int(11) NOT NULL DEFAULT -1 comment '订单自动确认(分钟),0立即确认,-1使用销售设置';
+UPDATE mch_sale_conf SET oa_confirm_minute=-1
+  WHERE oa_confirm_minute IS NULL OR oa_confirm_minute=0;
+ALTER TABLE mch_sale_conf
+  MODIFY COLUMN oa_confirm_minute int(11) NOT NULL DEFAULT -1 COMMENT '订单自动确认（分钟）,0立即确认,-1使用系统设置';
+`,
+		Down: `
+ALTER TABLE mch_sale_conf
+  MODIFY COLUMN oa_confirm_minute int(11) DEFAULT NULL COMMENT '订单自动确认（分钟）';
+UPDATE mch_sale_conf SET oa_confirm_minute=0 WHERE oa_confirm_minute=-1;
+ALTER TABLE mch_order_timeout
+  MODIFY COLUMN confirm_minute int(11) NOT NULL DEFAULT 0 comment '订单自动确认(分钟),0使用销售设置';
+UPDATE mch_order_timeout SET confirm_minute=0 WHERE confirm_minute=-1;
+`,
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/jsix/gof/crypto"
 	"github.com/jsix/gof/storage"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,6 +25,8 @@ type MemberModule struct {
 	storage     storage.Interface
 	tokenHours  int64
 	tokenOffset string
+	jwtSecret   []byte
+	mux         sync.Mutex
 }
 
 // 模块数据
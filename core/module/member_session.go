@@ -0,0 +1,293 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : member_session.go
+ * author : jarryliu
+ * date : 2026-10-19 16:30
+ * description : 会员多设备会话,访问令牌为JWT,刷新令牌按设备存储
+ * history :
+ */
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/garyburd/redigo/redis"
+	"github.com/jsix/gof/storage"
+	"go2o/core/infrastructure/domain"
+	"go2o/core/variable"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 访问令牌有效时间(秒)
+	AccessTokenSeconds int64 = 900
+	// 刷新令牌有效时间(秒),刷新后重新计算
+	RefreshTokenSeconds int64 = 3600 * 24 * 30
+	// 每个会员最多同时登录的设备数量
+	MaxMemberDevices = 10
+)
+
+var (
+	ErrRefreshToken *domain.DomainError = domain.NewDomainError(
+		"err_member_refresh_token", "登录已失效,请重新登录")
+	ErrNoSuchDevice *domain.DomainError = domain.NewDomainError(
+		"err_member_no_such_device", "设备不存在或已退出登录")
+	ErrDeviceId *domain.DomainError = domain.NewDomainError(
+		"err_member_device_id", "设备编号不正确")
+
+	deviceIdRegex = regexp.MustCompile(`^[\w\-]{1,64}$`)
+
+	// 设备数据未被修改时替换为新数据,否则视为令牌重复使用并退出设备
+	rotateScript = redis.NewScript(1, `
+if redis.call('HGET', KEYS[1], ARGV[1]) == ARGV[2] then
+  redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+  redis.call('EXPIRE', KEYS[1], ARGV[4])
+  return 1
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+return 0`)
+)
+
+type (
+	// 会员登录的设备
+	MemberDevice struct {
+		// 设备编号
+		DeviceId string `json:"device_id"`
+		// 设备名称
+		DeviceName string `json:"device_name"`
+		// 登录IP
+		LoginIp string `json:"login_ip"`
+		// 登录时间
+		CreateTime int64 `json:"create_time"`
+		// 最后刷新时间
+		RefreshTime int64 `json:"refresh_time"`
+		// 刷新令牌的摘要,不返回给客户端
+		TokenHash string `json:"token_hash,omitempty"`
+	}
+
+	// 会员会话
+	MemberSession struct {
+		// 访问令牌
+		AccessToken string `json:"access_token"`
+		// 访问令牌有效时间(秒)
+		ExpiresIn int64 `json:"expires_in"`
+		// 刷新令牌,格式为:会员编号.设备编号.随机串
+		RefreshToken string `json:"refresh_token"`
+		// 设备编号
+		DeviceId string `json:"device_id"`
+	}
+)
+
+// 按最后刷新时间排序的设备
+type deviceList []*MemberDevice
+
+func (d deviceList) Len() int           { return len(d) }
+func (d deviceList) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d deviceList) Less(i, j int) bool { return d[i].RefreshTime < d[j].RefreshTime }
+
+// 获取访问令牌的签名密钥,未配置时使用共享存储中的随机密钥
+func (m *MemberModule) getJwtSecret() []byte {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if m.jwtSecret == nil {
+		s := m.app.Config().GetString(variable.JwtSecret)
+		if s == "" {
+			conn := m.getConn()
+			defer conn.Close()
+			key := "go2o:module:member:jwt-secret"
+			conn.Do("SETNX", key, domain.NewApiSecret())
+			s, _ = redis.String(conn.Do("GET", key))
+		}
+		m.jwtSecret = []byte(s)
+	}
+	return m.jwtSecret
+}
+
+func (m *MemberModule) getConn() redis.Conn {
+	return m.storage.(storage.IRedisStorage).GetConn()
+}
+
+func (m *MemberModule) getDevicesKey(memberId int64) string {
+	return fmt.Sprintf("go2o:module:member:devices:%d", memberId)
+}
+
+func hashRefreshToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// 验证访问令牌,不访问存储
+func (m *MemberModule) VerifyAccessToken(token string) (*domain.MemberClaims, error) {
+	return domain.ParseMemberJwt(m.getJwtSecret(), token, time.Now().Unix())
+}
+
+// 签发访问令牌及刷新令牌,并保存设备
+func (m *MemberModule) issueSession(conn redis.Conn, memberId int64,
+	d *MemberDevice) (*MemberSession, error) {
+	ss, data, err := m.newSession(memberId, d)
+	if err != nil {
+		return nil, err
+	}
+	key := m.getDevicesKey(memberId)
+	if _, err = conn.Do("HSET", key, d.DeviceId, data); err != nil {
+		return nil, err
+	}
+	conn.Do("EXPIRE", key, RefreshTokenSeconds)
+	return ss, nil
+}
+
+// 生成会话及设备数据
+func (m *MemberModule) newSession(memberId int64,
+	d *MemberDevice) (*MemberSession, []byte, error) {
+	unix := time.Now().Unix()
+	access, err := domain.SignMemberJwt(m.getJwtSecret(), &domain.MemberClaims{
+		MemberId:  memberId,
+		DeviceId:  d.DeviceId,
+		IssuedAt:  unix,
+		ExpiresAt: unix + AccessTokenSeconds,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	refresh := fmt.Sprintf("%d.%s.%s", memberId, d.DeviceId, domain.NewApiSecret())
+	d.TokenHash = hashRefreshToken(refresh)
+	d.RefreshTime = unix
+	data, _ := json.Marshal(d)
+	return &MemberSession{
+		AccessToken:  access,
+		ExpiresIn:    AccessTokenSeconds,
+		RefreshToken: refresh,
+		DeviceId:     d.DeviceId,
+	}, data, nil
+}
+
+// 在设备上登录,同一设备重复登录将替换原会话,不影响其他设备。
+// deviceId为空时自动生成
+func (m *MemberModule) CreateSession(memberId int64, deviceId string,
+	deviceName string, ip string) (*MemberSession, error) {
+	if deviceId == "" {
+		deviceId = domain.NewApiSecret()[:16]
+	}
+	if !deviceIdRegex.MatchString(deviceId) {
+		return nil, ErrDeviceId
+	}
+	conn := m.getConn()
+	defer conn.Close()
+	// 超出设备数量时,退出最久未使用的设备
+	list := m.getDevices(conn, memberId)
+	for i := 0; len(list)-i >= MaxMemberDevices; i++ {
+		if list[i].DeviceId != deviceId {
+			conn.Do("HDEL", m.getDevicesKey(memberId), list[i].DeviceId)
+		}
+	}
+	unix := time.Now().Unix()
+	return m.issueSession(conn, memberId, &MemberDevice{
+		DeviceId:   deviceId,
+		DeviceName: deviceName,
+		LoginIp:    ip,
+		CreateTime: unix,
+	})
+}
+
+// 使用刷新令牌获取新的会话,刷新令牌只能使用一次。
+// 使用已失效的刷新令牌时,视为令牌泄露并退出该设备
+func (m *MemberModule) RefreshSession(refreshToken string, ip string) (*MemberSession, error) {
+	arr := strings.Split(refreshToken, ".")
+	if len(arr) != 3 {
+		return nil, ErrRefreshToken
+	}
+	memberId, err := strconv.ParseInt(arr[0], 10, 64)
+	if err != nil || memberId <= 0 {
+		return nil, ErrRefreshToken
+	}
+	conn := m.getConn()
+	defer conn.Close()
+	key := m.getDevicesKey(memberId)
+	d, raw := m.getDevice(conn, memberId, arr[1])
+	if d == nil {
+		return nil, ErrRefreshToken
+	}
+	expired := d.RefreshTime+RefreshTokenSeconds < time.Now().Unix()
+	if expired || d.TokenHash != hashRefreshToken(refreshToken) {
+		conn.Do("HDEL", key, d.DeviceId)
+		return nil, ErrRefreshToken
+	}
+	if ip != "" {
+		d.LoginIp = ip
+	}
+	ss, data, err := m.newSession(memberId, d)
+	if err != nil {
+		return nil, err
+	}
+	// 读取后设备数据已变化,说明令牌被并发使用或设备已退出
+	n, err := redis.Int(rotateScript.Do(conn, key, d.DeviceId, raw,
+		data, RefreshTokenSeconds))
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrRefreshToken
+	}
+	return ss, nil
+}
+
+// 获取设备及其原始数据
+func (m *MemberModule) getDevice(conn redis.Conn, memberId int64,
+	deviceId string) (*MemberDevice, []byte) {
+	data, err := redis.Bytes(conn.Do("HGET", m.getDevicesKey(memberId), deviceId))
+	if err != nil {
+		return nil, nil
+	}
+	d := &MemberDevice{}
+	if json.Unmarshal(data, d) != nil {
+		return nil, nil
+	}
+	return d, data
+}
+
+// 获取设备,按最后刷新时间排序
+func (m *MemberModule) getDevices(conn redis.Conn, memberId int64) []*MemberDevice {
+	mp, _ := redis.StringMap(conn.Do("HGETALL", m.getDevicesKey(memberId)))
+	list := []*MemberDevice{}
+	for _, v := range mp {
+		d := &MemberDevice{}
+		if json.Unmarshal([]byte(v), d) == nil {
+			list = append(list, d)
+		}
+	}
+	sort.Sort(deviceList(list))
+	return list
+}
+
+// 获取已登录的设备
+func (m *MemberModule) GetDevices(memberId int64) []*MemberDevice {
+	conn := m.getConn()
+	defer conn.Close()
+	list := m.getDevices(conn, memberId)
+	for _, d := range list {
+		d.TokenHash = ""
+	}
+	return list
+}
+
+// 退出设备,已签发的访问令牌在过期前仍然有效
+func (m *MemberModule) RevokeDevice(memberId int64, deviceId string) error {
+	conn := m.getConn()
+	defer conn.Close()
+	n, err := redis.Int(conn.Do("HDEL", m.getDevicesKey(memberId), deviceId))
+	if err == nil && n == 0 {
+		err = ErrNoSuchDevice
+	}
+	return err
+}
+
+// 退出所有设备,如修改密码后
+func (m *MemberModule) RevokeDevices(memberId int64) {
+	m.storage.Del(m.getDevicesKey(memberId))
+}
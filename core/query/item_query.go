@@ -283,3 +283,20 @@ func (i ItemQuery) GetPagedOnShelvesGoodsByKeyword(shopId int32, start, end int,
 //	g.Connector.GetOrm().GetByQuery(&e, sql,item.ShelvesOn, goodsId)
 //	return &e
 //}
+
+// 获取商户的商品,按编号倒序,lastId为上一页最后的商品编号
+func (i ItemQuery) GetItemsOfVendor(vendorId int32, lastId int64, size int) []*item.GoodsItem {
+	list := []*item.GoodsItem{}
+	if size <= 0 {
+		return list
+	}
+	where := "vendor_id=?"
+	args := []interface{}{vendorId}
+	if lastId > 0 {
+		where += " AND id<?"
+		args = append(args, lastId)
+	}
+	args = append(args, size)
+	i.Connector.GetOrm().Select(&list, where+" ORDER BY id DESC LIMIT ?", args...)
+	return list
+}
@@ -9,12 +9,16 @@
 package repository
 
 import (
+	"database/sql"
 	"github.com/jsix/gof/db"
+	"github.com/jsix/gof/db/orm"
 	asImpl "go2o/core/domain/after-sales"
 	"go2o/core/domain/interface/after-sales"
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/order"
 	"go2o/core/domain/interface/payment"
+	"go2o/core/infrastructure/uow"
+	"log"
 )
 
 var _ afterSales.IAfterSalesRepo = new(afterSalesRepo)
@@ -37,9 +41,20 @@ func NewAfterSalesRepo(conn db.Connector, orderRepo order.IOrderRepo,
 
 }
 
+// 绑定到工作单元,依赖的仓储一并绑定
+func (a *afterSalesRepo) BindUnitOfWork(u *uow.UnitOfWork) interface{} {
+	c := *a
+	u.Bound(a, &c)
+	c.Connector = u.Connector()
+	c.orderRepo = uow.Bind(u, a.orderRepo).(order.IOrderRepo)
+	c.memberRepo = uow.Bind(u, a.memberRepo).(member.IMemberRepo)
+	c.paymentRepo = uow.Bind(u, a.paymentRepo).(payment.IPaymentRepo)
+	return &c
+}
+
 // 创建售后单
 func (a *afterSalesRepo) CreateAfterSalesOrder(v *afterSales.AfterSalesOrder) afterSales.IAfterSalesOrder {
-	return asImpl.NewAfterSalesOrder(v, a, a.orderRepo, a.memberRepo, a.paymentRepo)
+	return asImpl.NewAfterSalesOrder(a.Connector, v, a, a.orderRepo, a.memberRepo, a.paymentRepo)
 }
 
 // 获取售后单
@@ -62,3 +77,36 @@ func (a *afterSalesRepo) GetAllOfSaleOrder(orderId int64) []afterSales.IAfterSal
 	}
 	return orders
 }
+
+// 保存留言
+func (a *afterSalesRepo) SaveMessage(v *afterSales.Message) (int32, error) {
+	return orm.I32(orm.Save(a.GetOrm(), v, int(v.Id)))
+}
+
+// 获取售后单的留言
+func (a *afterSalesRepo) GetMessages(afterSalesId int32) []*afterSales.Message {
+	list := []*afterSales.Message{}
+	err := a.GetOrm().Select(&list, "as_id=? ORDER BY id", afterSalesId)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:AfterSalesMessage")
+	}
+	return list
+}
+
+// 保存仲裁记录
+func (a *afterSalesRepo) SaveArbitration(v *afterSales.Arbitration) (int32, error) {
+	return orm.I32(orm.Save(a.GetOrm(), v, int(v.Id)))
+}
+
+// 获取售后单的仲裁记录
+func (a *afterSalesRepo) GetArbitration(afterSalesId int32) *afterSales.Arbitration {
+	e := afterSales.Arbitration{}
+	err := a.GetOrm().GetBy(&e, "as_id=? ORDER BY id DESC LIMIT 1", afterSalesId)
+	if err == nil {
+		return &e
+	}
+	if err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:AfterSalesArbitration")
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"go2o/core/domain/interface/item"
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/merchant"
+	"go2o/core/infrastructure/uow"
 	"log"
 	"time"
 )
@@ -43,6 +44,17 @@ func NewCartRepo(conn db.Connector, memberRepo member.IMemberRepo,
 	}
 }
 
+// 绑定到工作单元
+func (c *cartRepo) BindUnitOfWork(u *uow.UnitOfWork) interface{} {
+	r := *c
+	u.Bound(c, &r)
+	r.Connector = u.Connector()
+	r._orm = r.Connector.GetOrm()
+	r._itemRepo = uow.Bind(u, c._itemRepo).(item.IGoodsItemRepo)
+	r._memberRepo = uow.Bind(u, c._memberRepo).(member.IMemberRepo)
+	return &r
+}
+
 // 获取买家的购物车
 func (c *cartRepo) GetMyCart(buyerId int64, k cart.CartKind) cart.ICart {
 	switch k {
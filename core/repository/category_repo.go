@@ -10,7 +10,6 @@ package repository
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"github.com/jsix/gof/db"
 	"github.com/jsix/gof/db/orm"
@@ -19,6 +18,7 @@ import (
 	"go2o/core/domain/interface/product"
 	"go2o/core/domain/interface/valueobject"
 	productImpl "go2o/core/domain/product"
+	"go2o/core/infrastructure/cache"
 	"go2o/core/infrastructure/format"
 	"log"
 	"sort"
@@ -32,16 +32,23 @@ type categoryRepo struct {
 	_globService product.IGlobCatService
 	_orm         orm.Orm
 	storage      storage.Interface
+	catCache     *cache.Cache
 }
 
 func NewCategoryRepo(conn db.Connector, valRepo valueobject.IValueRepo,
 	storage storage.Interface) product.ICategoryRepo {
-	return &categoryRepo{
+	c := &categoryRepo{
 		Connector: conn,
 		_orm:      conn.GetOrm(),
 		_valRepo:  valRepo,
 		storage:   storage,
+		catCache:  cache.New("cat", storage, DefaultCacheSeconds, cache.DefaultLocalSize),
 	}
+	// 分类变更后失效分类及分类列表
+	c.catCache.Watch(product.Category{}, func(v interface{}) []string {
+		return []string{fmt.Sprint(v.(*product.Category).ID), "list"}
+	})
+	return c
 }
 
 func (c *categoryRepo) GlobCatService() product.IGlobCatService {
@@ -51,18 +58,9 @@ func (c *categoryRepo) GlobCatService() product.IGlobCatService {
 	return c._globService
 }
 
-func (c *categoryRepo) getCategoryCacheKey(id int32) string {
-	return fmt.Sprintf("go2o:rep:cat:c%d", id)
-}
-
 func (c *categoryRepo) SaveCategory(v *product.Category) (int32, error) {
-	id, err := orm.I32(orm.Save(c.GetOrm(), v, int(v.ID)))
-	// 清理缓存
-	if err == nil {
-		c.storage.Del(c.getCategoryCacheKey(id))
-		PrefixDel(c.storage, "go2o:rep:cat:list")
-	}
-	return id, err
+	id, err := saveEntity(c.Connector, v, nil)
+	return int32(id), err
 }
 
 // 检查分类是否关联商品
@@ -75,6 +73,18 @@ func (c *categoryRepo) CheckGoodsContain(mchId, id int32) bool {
 }
 
 func (c *categoryRepo) DeleteCategory(mchId, id int32) error {
+	// 子类同时被删除,删除后同样失效
+	ids := []int32{id}
+	c.Connector.Query("SELECT id FROM pro_category WHERE parent_id=?",
+		func(rs *sql.Rows) {
+			var i int32
+			for rs.Next() {
+				if rs.Scan(&i) == nil {
+					ids = append(ids, i)
+				}
+			}
+		}, id)
+
 	//删除子类
 	_, _, err := c.Connector.Exec("DELETE FROM pro_category WHERE parent_id=?",
 		id)
@@ -83,24 +93,19 @@ func (c *categoryRepo) DeleteCategory(mchId, id int32) error {
 	_, _, err = c.Connector.Exec("DELETE FROM pro_category WHERE id=?",
 		id)
 
-	// 清理缓存
-	if err == nil {
-		c.storage.Del(c.getCategoryCacheKey(id))
-		PrefixDel(c.storage, "go2o:rep:cat:list")
+	for _, i := range ids {
+		entityChanged(&product.Category{ID: i}, err, nil)
 	}
-
 	return err
 }
 
 func (c *categoryRepo) GetCategory(mchId, id int32) *product.Category {
 	e := product.Category{}
-	key := c.getCategoryCacheKey(id)
-	if c.storage.Get(key, &e) != nil {
-		err := c.Connector.GetOrm().Get(id, &e)
-		if err != nil {
-			return nil
-		}
-		c.storage.Set(key, &e)
+	err := c.catCache.Get(fmt.Sprint(id), &e, func() error {
+		return c.Connector.GetOrm().Get(id, &e)
+	})
+	if err != nil {
+		return nil
 	}
 	return &e
 }
@@ -124,19 +129,12 @@ func (c *categoryRepo) redirectGetCats() []*product.Category {
 }
 
 func (c *categoryRepo) GetCategories(mchId int32) []*product.Category {
-	key := "go2o:rep:cat:list"
 	list := []*product.Category{}
-	jsonStr, err := c.storage.GetBytes(key)
-	if err == nil {
-		err = json.Unmarshal(jsonStr, &list)
-	}
+	err := c.catCache.Get("list", &list, func() error {
+		return c.Connector.GetOrm().Select(&list, "true ORDER BY sort_num DESC,id ASC")
+	})
 	if err != nil {
 		handleError(err)
-		err := c.Connector.GetOrm().Select(&list, "true ORDER BY sort_num DESC,id ASC")
-		if err == nil {
-			b, _ := json.Marshal(list)
-			c.storage.Set(key, b)
-		}
 	}
 	return list
 }
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"github.com/jsix/gof/db"
 	"github.com/jsix/gof/db/orm"
+	"github.com/jsix/gof/storage"
 	"go2o/core/domain/interface/enum"
 	"go2o/core/domain/interface/express"
 	"go2o/core/domain/interface/item"
@@ -20,7 +21,9 @@ import (
 	"go2o/core/domain/interface/product"
 	"go2o/core/domain/interface/valueobject"
 	itemImpl "go2o/core/domain/item"
+	"go2o/core/infrastructure/cache"
 	"go2o/core/infrastructure/format"
+	"go2o/core/infrastructure/uow"
 	"log"
 )
 
@@ -37,14 +40,17 @@ type goodsRepo struct {
 	expressRepo  express.IExpressRepo
 	valRepo      valueobject.IValueRepo
 	proMRepo     promodel.IProModelRepo
+	itemCache    *cache.Cache
+	// 事务提交后执行,未绑定工作单元时为nil
+	afterCommit func(func())
 }
 
 // 商品仓储
 func NewGoodsItemRepo(c db.Connector, catRepo product.ICategoryRepo,
 	proRepo product.IProductRepo, proMRepo promodel.IProModelRepo,
 	itemWsRepo item.IItemWholesaleRepo, expressRepo express.IExpressRepo,
-	valRepo valueobject.IValueRepo) *goodsRepo {
-	return &goodsRepo{
+	valRepo valueobject.IValueRepo, sto storage.Interface) *goodsRepo {
+	g := &goodsRepo{
 		Connector:   c,
 		_orm:        c.GetOrm(),
 		catRepo:     catRepo,
@@ -53,7 +59,26 @@ func NewGoodsItemRepo(c db.Connector, catRepo product.ICategoryRepo,
 		itemWsRepo:  itemWsRepo,
 		expressRepo: expressRepo,
 		valRepo:     valRepo,
+		itemCache:   cache.New("item", sto, DefaultCacheSeconds, cache.DefaultLocalSize),
 	}
+	// 商品变更后失效商品
+	g.itemCache.Watch(item.GoodsItem{}, func(v interface{}) []string {
+		return []string{fmt.Sprint(v.(*item.GoodsItem).ID)}
+	})
+	return g
+}
+
+// 绑定到工作单元
+func (g *goodsRepo) BindUnitOfWork(u *uow.UnitOfWork) interface{} {
+	c := *g
+	u.Bound(g, &c)
+	c.Connector = u.Connector()
+	c._orm = c.Connector.GetOrm()
+	c.itemCache = g.itemCache.Bind(u.AfterCommit)
+	c.afterCommit = u.AfterCommit
+	c._skuService = nil
+	c._snapService = nil
+	return &c
 }
 
 // 获取SKU服务
@@ -108,7 +133,10 @@ func (g *goodsRepo) GetValueGoods(itemId, skuId int64) *item.GoodsItem {
 // 获取商品
 func (g *goodsRepo) GetValueGoodsById(goodsId int64) *item.GoodsItem {
 	var e *item.GoodsItem = new(item.GoodsItem)
-	if g.Connector.GetOrm().Get(goodsId, e) == nil {
+	err := g.itemCache.Get(fmt.Sprint(goodsId), e, func() error {
+		return g.Connector.GetOrm().Get(goodsId, e)
+	})
+	if err == nil {
 		return e
 	}
 	return nil
@@ -153,9 +181,9 @@ func (g *goodsRepo) RemoveGoodsLevelPrice(id int32) error {
 	return g.Connector.GetOrm().DeleteByPk(item.MemberPrice{}, id)
 }
 
-// 保存商品
+// 保存商品,库存等数据已被修改时返回冲突错误
 func (g *goodsRepo) SaveValueGoods(v *item.GoodsItem) (int64, error) {
-	return orm.I64(orm.Save(g.GetOrm(), v, int(v.ID)))
+	return saveEntity(g.Connector, v, g.afterCommit)
 }
 
 // 获取已上架的商品
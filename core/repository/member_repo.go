@@ -17,13 +17,21 @@ import (
 	"github.com/jsix/gof/db/orm"
 	"github.com/jsix/gof/storage"
 	"go2o/core"
+	"go2o/core/domain/interface/enum"
+	"go2o/core/domain/interface/events"
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/mss"
+	"go2o/core/domain/interface/order"
 	"go2o/core/domain/interface/valueobject"
 	memberImpl "go2o/core/domain/member"
 	"go2o/core/dto"
+	"go2o/core/infrastructure/cache"
+	"go2o/core/infrastructure/domain"
+	"go2o/core/infrastructure/eventbus"
 	"go2o/core/infrastructure/format"
 	"go2o/core/infrastructure/tool"
+	"go2o/core/infrastructure/tx"
+	"go2o/core/infrastructure/uow"
 	"go2o/core/variable"
 	"log"
 	"strings"
@@ -43,17 +51,46 @@ type MemberRepo struct {
 	_orm     orm.Orm
 	_valRepo valueobject.IValueRepo
 	_mssRepo mss.IMssRepo
+	// 等级规则及权益缓存
+	levelCache *cache.Cache
+	// 事务提交后执行,未绑定工作单元时为nil
+	afterCommit func(func())
 }
 
 func NewMemberRepo(sto storage.Interface, c db.Connector, mssRepo mss.IMssRepo,
 	valRepo valueobject.IValueRepo) *MemberRepo {
-	return &MemberRepo{
+	m := &MemberRepo{
 		Storage:   sto,
 		Connector: c,
 		_orm:      c.GetOrm(),
 		_mssRepo:  mssRepo,
 		_valRepo:  valRepo,
+		levelCache: cache.New("level", sto, DefaultCacheSeconds,
+			cache.DefaultLocalSize),
 	}
+	// 等级变更后失效全部等级数据,规则及权益变更后失效对应的等级
+	m.levelCache.Watch(member.Level{}, func(v interface{}) []string {
+		return []string{"*"}
+	})
+	m.levelCache.Watch(member.LevelRule{}, func(v interface{}) []string {
+		return []string{m.getLevelRuleCk(v.(*member.LevelRule).LevelId)}
+	})
+	m.levelCache.Watch(member.LevelBenefit{}, func(v interface{}) []string {
+		return []string{m.getLevelBenefitCk(v.(*member.LevelBenefit).LevelId)}
+	})
+	return m
+}
+
+// 绑定到工作单元
+func (m *MemberRepo) BindUnitOfWork(u *uow.UnitOfWork) interface{} {
+	c := *m
+	u.Bound(m, &c)
+	c.Connector = u.Connector()
+	c._orm = c.Connector.GetOrm()
+	c.Storage = u.Storage(m.Storage)
+	c.levelCache = m.levelCache.Bind(u.AfterCommit)
+	c.afterCommit = u.AfterCommit
+	return &c
 }
 
 // 获取管理服务
@@ -120,22 +157,16 @@ func (m *MemberRepo) CancelFavorite(memberId int64, favType int, referId int32)
 	return err
 }
 
-var (
-	globLevels []*member.Level
-)
-
 // 获取会员等级
 func (m *MemberRepo) GetMemberLevels_New() []*member.Level {
-	const key = "go2o:rep:level:glob:cache"
-	i, err := m.Storage.GetInt(key)
-	load := err != nil || i != 1 || globLevels == nil
-	if load {
-		list := []*member.Level{}
-		m.Connector.GetOrm().Select(&list, "1=1 ORDER BY id ASC")
-		globLevels = list
-		m.Storage.Set(key, 1)
+	list := []*member.Level{}
+	err := m.levelCache.Get("list", &list, func() error {
+		return m.Connector.GetOrm().Select(&list, "1=1 ORDER BY id ASC")
+	})
+	if err != nil {
+		handleError(err)
 	}
-	return globLevels
+	return list
 }
 
 // 获取等级对应的会员数
@@ -148,19 +179,13 @@ func (m *MemberRepo) GetMemberNumByLevel_New(id int32) int {
 // 删除会员等级
 func (m *MemberRepo) DeleteMemberLevel_New(id int32) error {
 	err := m.Connector.GetOrm().DeleteByPk(&member.Level{}, id)
-	if err == nil {
-		PrefixDel(m.Storage, "go2o:rep:level:*")
-	}
-	return err
+	return entityChanged(&member.Level{ID: id}, err, m.afterCommit)
 }
 
 // 保存会员等级
 func (m *MemberRepo) SaveMemberLevel_New(v *member.Level) (int32, error) {
-	id, err := orm.I32(orm.Save(m.GetOrm(), v, int(v.ID)))
-	if err == nil {
-		PrefixDel(m.Storage, "go2o:rep:level:*")
-	}
-	return id, err
+	id, err := saveEntity(m.Connector, v, m.afterCommit)
+	return int32(id), err
 }
 
 // 根据用户名获取会员
@@ -241,14 +266,13 @@ func (m *MemberRepo) SaveMember(v *member.Member) (int64, error) {
 		rc.Do("SETEX", mutKey, 3600*400, v.UpdateTime)
 		rc.Do("RPUSH", variable.KvMemberUpdateTcpNotifyQueue, v.Id) // push to tcp notify queue
 
-		// 保存会员信息
-		_, _, err := m.Connector.GetOrm().Save(v.Id, v)
-
+		// 保存会员信息,并在同一事务中记录会员变更事件
+		_, err := saveWithEvents(m.Connector, v, func() []eventbus.Event {
+			return []eventbus.Event{&events.MemberChanged{MemberId: v.Id}}
+		})
 		if err == nil {
 			// 存储到缓存中
 			err = m.Storage.Set(m.getMemberCk(v.Id), *v)
-			// 存储到队列
-			rc.Do("RPUSH", variable.KvMemberUpdateQueue, fmt.Sprintf("%d-update", v.Id))
 		}
 		return v.Id, err
 	}
@@ -257,19 +281,15 @@ func (m *MemberRepo) SaveMember(v *member.Member) (int64, error) {
 }
 
 func (m *MemberRepo) createMember(v *member.Member) (int64, error) {
-	var id int64
-	_, id, err := m.Connector.GetOrm().Save(nil, v)
+	id, err := saveWithEvents(m.Connector, v, func() []eventbus.Event {
+		return []eventbus.Event{&events.MemberChanged{MemberId: v.Id, Create: true}}
+	})
 	if err != nil {
 		return -1, err
 	}
 	v.Id = id
 	m.initMember(v)
 
-	rc := core.GetRedisConn()
-	defer rc.Close()
-	rc.Do("RPUSH", variable.KvMemberUpdateQueue,
-		fmt.Sprintf("%d-create", v.Id)) // push to queue
-
 	// 更新会员数 todo: 考虑去掉
 	var total = 0
 	m.Connector.ExecScalar("SELECT COUNT(0) FROM mm_member", &total)
@@ -374,17 +394,31 @@ func (m *MemberRepo) GetAccount(memberId int64) *member.Account {
 	return e
 }
 
-// 保存账户，传入会员编号
+// 保存账户，传入会员编号。账户已被修改时返回冲突错误
 func (m *MemberRepo) SaveAccount(v *member.Account) (int64, error) {
-	_, _, err := m.Connector.GetOrm().Save(v.MemberId, v)
+	_, err := tx.SaveBy(m.Connector, v)
 	if err == nil {
 		m.pushToAccountUpdateQueue(v.MemberId, v.UpdateTime)
 		m.Storage.Set(m.getAccountCk(v.MemberId), *v)
+	} else if domain.IsConflict(err) {
+		// 清除缓存,重试时重新加载账户
+		m.Storage.Del(m.getAccountCk(v.MemberId))
 	}
 	return v.MemberId, err
 }
 
+// 通知账户更新,在工作单元中时待事务提交后再通知
 func (m *MemberRepo) pushToAccountUpdateQueue(memberId int64, updateTime int64) {
+	if m.afterCommit != nil {
+		m.afterCommit(func() {
+			m.notifyAccountUpdate(memberId, updateTime)
+		})
+		return
+	}
+	m.notifyAccountUpdate(memberId, updateTime)
+}
+
+func (m *MemberRepo) notifyAccountUpdate(memberId int64, updateTime int64) {
 	rc := core.GetRedisConn()
 	defer rc.Close()
 	// 保存最后更新时间
@@ -532,6 +566,87 @@ func (m *MemberRepo) SaveLevelUpLog(v *member.LevelUpLog) (int32, error) {
 	return orm.I32(orm.Save(m.GetOrm(), v, int(v.Id)))
 }
 
+// 获取会员最近的等级变更记录
+func (m *MemberRepo) GetLatestLevelUpLog(memberId int64) *member.LevelUpLog {
+	e := member.LevelUpLog{}
+	err := m.GetOrm().GetBy(&e, "member_id=? AND reviewed IN(?,?) ORDER BY id DESC LIMIT 1",
+		memberId, enum.ReviewPass, enum.ReviewConfirm)
+	if err == nil {
+		return &e
+	}
+	return nil
+}
+
+func (m *MemberRepo) getLevelRuleCk(levelId int32) string {
+	return fmt.Sprintf("rule:%d", levelId)
+}
+
+func (m *MemberRepo) getLevelBenefitCk(levelId int32) string {
+	return fmt.Sprintf("benefit:%d", levelId)
+}
+
+// 获取等级规则
+func (m *MemberRepo) GetLevelRule(levelId int32) *member.LevelRule {
+	e := &member.LevelRule{}
+	err := m.levelCache.Get(m.getLevelRuleCk(levelId), e, func() error {
+		return m.GetOrm().Get(levelId, e)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("[ Orm][ Error]:", err.Error(), "; Entity:LevelRule")
+		}
+		return nil
+	}
+	return e
+}
+
+// 保存等级规则
+func (m *MemberRepo) SaveLevelRule(v *member.LevelRule) error {
+	var err error
+	if m.GetLevelRule(v.LevelId) != nil {
+		_, _, err = m.GetOrm().Save(v.LevelId, v)
+	} else {
+		_, _, err = m.GetOrm().Save(nil, v)
+	}
+	return entityChanged(v, err, m.afterCommit)
+}
+
+// 获取等级权益
+func (m *MemberRepo) GetLevelBenefit(levelId int32) *member.LevelBenefit {
+	e := &member.LevelBenefit{}
+	err := m.levelCache.Get(m.getLevelBenefitCk(levelId), e, func() error {
+		return m.GetOrm().Get(levelId, e)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("[ Orm][ Error]:", err.Error(), "; Entity:LevelBenefit")
+		}
+		return nil
+	}
+	return e
+}
+
+// 保存等级权益
+func (m *MemberRepo) SaveLevelBenefit(v *member.LevelBenefit) error {
+	var err error
+	if m.GetLevelBenefit(v.LevelId) != nil {
+		_, _, err = m.GetOrm().Save(v.LevelId, v)
+	} else {
+		_, _, err = m.GetOrm().Save(nil, v)
+	}
+	return entityChanged(v, err, m.afterCommit)
+}
+
+// 获取会员在时间段内已完成订单的统计数据
+func (m *MemberRepo) GetLevelStat(memberId int64, begin, end int64) *member.LevelStat {
+	e := &member.LevelStat{}
+	m.Connector.QueryRow(`SELECT COUNT(0),IFNULL(SUM(final_amount),0) FROM sale_sub_order
+		WHERE buyer_id=? AND state=? AND update_time BETWEEN ? AND ?`, func(r *sql.Row) error {
+		return r.Scan(&e.Orders, &e.Amount)
+	}, memberId, order.StatCompleted, begin, end)
+	return e
+}
+
 // 保存地址
 func (m *MemberRepo) SaveDeliver(v *member.Address) (int64, error) {
 	return orm.I64(orm.Save(m.Connector.GetOrm(), v, int(v.ID)))
@@ -647,7 +762,7 @@ func (m *MemberRepo) SaveBalanceInfo(v *member.BalanceInfo) (int32, error) {
 func (m *MemberRepo) SaveGrowAccount(memberId int64, balance, totalAmount,
 	growEarnings, totalGrowEarnings float32, updateTime int64) error {
 	_, err := m.Connector.ExecNonQuery(`UPDATE mm_account SET grow_balance=?,
-		grow_amount=?,grow_earnings=?,grow_total_earnings=?,update_time=? where member_id=?`,
+		grow_amount=?,grow_earnings=?,grow_total_earnings=?,update_time=?,version=version+1 where member_id=?`,
 		balance, totalAmount, growEarnings, totalGrowEarnings, updateTime, memberId)
 	//清除缓存
 	m.Storage.Del(m.getAccountCk(memberId))
@@ -0,0 +1,173 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : cart_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 10:30
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	cartImpl "go2o/core/domain/cart"
+	"go2o/core/domain/interface/cart"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/merchant"
+	"time"
+)
+
+var _ cart.ICartRepo = new(cartRepo)
+
+type cartRepo struct {
+	*DB
+	itemRepo   item.IGoodsItemRepo
+	memberRepo member.IMemberRepo
+	mchRepo    merchant.IMerchantRepo
+}
+
+func NewCartRepo(d *DB, memberRepo member.IMemberRepo,
+	mchRepo merchant.IMerchantRepo, itemRepo item.IGoodsItemRepo) cart.ICartRepo {
+	return &cartRepo{
+		DB:         d,
+		memberRepo: memberRepo,
+		mchRepo:    mchRepo,
+		itemRepo:   itemRepo,
+	}
+}
+
+// 获取买家的购物车
+func (c *cartRepo) GetMyCart(buyerId int64, k cart.CartKind) cart.ICart {
+	unix := time.Now().Unix()
+	switch k {
+	case cart.KRetail:
+		v := &cart.RetailCart{}
+		if !c.Table(v).GetBy(v, "buyer_id=?", buyerId) {
+			v = &cart.RetailCart{BuyerId: buyerId, CreateTime: unix, UpdateTime: unix}
+		}
+		return cartImpl.CreateCart(v, c, c.memberRepo, c.itemRepo)
+	case cart.KWholesale:
+		v := &cart.WsCart{}
+		if !c.Table(v).GetBy(v, "buyer_id=?", buyerId) {
+			v = &cart.WsCart{BuyerId: buyerId, CreateTime: unix, UpdateTime: unix}
+		}
+		return cartImpl.CreateWholesaleCart(v, c, c.memberRepo, c.mchRepo, c.itemRepo)
+	}
+	return nil
+}
+
+// 创建一个购物车
+func (c *cartRepo) NewRetailCart(code string) cart.ICart {
+	return cartImpl.NewRetailCart(code, c, c.memberRepo, c.itemRepo)
+}
+
+// 获取购物车
+func (c *cartRepo) GetRetailCart(id int32) cart.ICart {
+	v := &cart.RetailCart{}
+	if c.Table(v).Get(id, v) {
+		return cartImpl.CreateCart(v, c, c.memberRepo, c.itemRepo)
+	}
+	return nil
+}
+
+// 获取购物车
+func (c *cartRepo) GetShoppingCartByKey(key string) cart.ICart {
+	if v := c.GetShoppingCart(key); v != nil {
+		return cartImpl.CreateCart(v, c, c.memberRepo, c.itemRepo)
+	}
+	return nil
+}
+
+// 获取购物车
+func (c *cartRepo) GetShoppingCart(key string) *cart.RetailCart {
+	v := &cart.RetailCart{}
+	if c.Table(v).GetBy(v, "code=?", key) {
+		v.Items = c.SelectRetailCartItem("cart_id=?", v.Id)
+		return v
+	}
+	return nil
+}
+
+// 获取最新的购物车
+func (c *cartRepo) GetLatestCart(buyerId int64) *cart.RetailCart {
+	v := &cart.RetailCart{}
+	if c.Table(v).GetBy(v, "buyer_id=? ORDER BY id DESC", buyerId) {
+		v.Items = c.SelectRetailCartItem("cart_id=?", v.Id)
+		return v
+	}
+	return nil
+}
+
+// 保存购物车
+func (c *cartRepo) SaveShoppingCart(v *cart.RetailCart) (int32, error) {
+	return i32(c.Table(v).Save(v))
+}
+
+// 移出购物车项
+func (c *cartRepo) RemoveCartItem(id int32) error {
+	c.Table(cart.RetailCartItem{}).DeleteByPk(id)
+	return nil
+}
+
+// 保存购物车项
+func (c *cartRepo) SaveCartItem(v *cart.RetailCartItem) (int32, error) {
+	return i32(c.Table(v).Save(v))
+}
+
+// 清空购物车项
+func (c *cartRepo) EmptyCartItems(cartId int32) error {
+	c.Table(cart.RetailCartItem{}).Delete("cart_id=?", cartId)
+	return nil
+}
+
+// 删除购物车
+func (c *cartRepo) DeleteCart(cartId int32) error {
+	c.Table(cart.RetailCart{}).DeleteByPk(cartId)
+	return nil
+}
+
+// Select SaleCartItem
+func (c *cartRepo) SelectRetailCartItem(where string, v ...interface{}) []*cart.RetailCartItem {
+	list := []*cart.RetailCartItem{}
+	c.Table(cart.RetailCartItem{}).Select(&list, where, v...)
+	return list
+}
+
+// Save SaleCart
+func (c *cartRepo) SaveRetailCart(v *cart.RetailCart) (int, error) {
+	return i(c.Table(v).Save(v))
+}
+
+// Delete SaleCart
+func (c *cartRepo) DeleteRetailCart(primary interface{}) error {
+	c.Table(cart.RetailCart{}).DeleteByPk(primary)
+	return nil
+}
+
+// Save WsCart
+func (c *cartRepo) SaveWsCart(v *cart.WsCart) (int, error) {
+	return i(c.Table(v).Save(v))
+}
+
+// Delete WsCart
+func (c *cartRepo) DeleteWsCart(primary interface{}) error {
+	c.Table(cart.WsCart{}).DeleteByPk(primary)
+	return nil
+}
+
+// Select WsCartItem
+func (c *cartRepo) SelectWsCartItem(where string, v ...interface{}) []*cart.WsCartItem {
+	list := []*cart.WsCartItem{}
+	c.Table(cart.WsCartItem{}).Select(&list, where, v...)
+	return list
+}
+
+// Save WsCartItem
+func (c *cartRepo) SaveWsCartItem(v *cart.WsCartItem) (int, error) {
+	return i(c.Table(v).Save(v))
+}
+
+// Batch Delete WsCartItem
+func (c *cartRepo) BatchDeleteWsCartItem(where string, v ...interface{}) (int64, error) {
+	return int64(c.Table(cart.WsCartItem{}).Delete(where, v...)), nil
+}
@@ -0,0 +1,103 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : category_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 12:55
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/pro_model"
+	"go2o/core/domain/interface/product"
+	"go2o/core/domain/interface/valueobject"
+	productImpl "go2o/core/domain/product"
+	"sort"
+)
+
+var _ product.ICategoryRepo = new(categoryRepo)
+
+type categoryRepo struct {
+	*DB
+	valRepo     valueobject.IValueRepo
+	globService product.IGlobCatService
+}
+
+func NewCategoryRepo(d *DB, valRepo valueobject.IValueRepo) product.ICategoryRepo {
+	return &categoryRepo{
+		DB:      d,
+		valRepo: valRepo,
+	}
+}
+
+// 获取系统的栏目服务
+func (c *categoryRepo) GlobCatService() product.IGlobCatService {
+	if c.globService == nil {
+		c.globService = productImpl.NewCategoryManager(0, c, c.valRepo)
+	}
+	return c.globService
+}
+
+// 保存分类
+func (c *categoryRepo) SaveCategory(v *product.Category) (int32, error) {
+	return i32(c.Table(v).Save(v))
+}
+
+// 检查分类是否关联商品
+func (c *categoryRepo) CheckGoodsContain(mchId, id int32) bool {
+	return c.Table(product.Product{}).Count("cat_id=?", id) > 0
+}
+
+// 删除分类及子类
+func (c *categoryRepo) DeleteCategory(mchId, id int32) error {
+	c.Table(product.Category{}).Delete("parent_id=?", id)
+	c.Table(product.Category{}).DeleteByPk(id)
+	return nil
+}
+
+// 获取分类
+func (c *categoryRepo) GetCategory(mchId, id int32) *product.Category {
+	e := &product.Category{}
+	if c.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取所有分类
+func (c *categoryRepo) GetCategories(mchId int32) []*product.Category {
+	list := []*product.Category{}
+	c.Table(product.Category{}).Select(&list, "")
+	sort.Sort(product.CategoryList(list))
+	return list
+}
+
+// 获取关联的品牌
+func (c *categoryRepo) GetRelationBrands(idArr []int32) []*promodel.ProBrand {
+	list := []*promodel.ProBrand{}
+	for _, id := range idArr {
+		cat := c.GetCategory(0, id)
+		if cat == nil {
+			continue
+		}
+		binds := []*promodel.ProModelBrand{}
+		c.Table(promodel.ProModelBrand{}).Select(&binds, "pro_model=?", cat.ProModel)
+		for _, b := range binds {
+			e := &promodel.ProBrand{}
+			if c.Table(e).Get(b.BrandId, e) && !containsBrand(list, e.ID) {
+				list = append(list, e)
+			}
+		}
+	}
+	return list
+}
+
+func containsBrand(list []*promodel.ProBrand, id int32) bool {
+	for _, v := range list {
+		if v.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : db.go
+ * author : jarryliu
+ * date : 2026-10-20 09:30
+ * description : 内存仓储,用于不依赖数据库和Redis的领域测试
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/infrastructure/eventbus"
+	"reflect"
+	"sync"
+)
+
+// 内存数据库,保存实体的内存表及仓储发布的领域事件
+type DB struct {
+	mux    sync.Mutex
+	tables map[reflect.Type]*Table
+	events []eventbus.Event
+}
+
+// 创建内存数据库
+func NewDB() *DB {
+	return &DB{tables: map[reflect.Type]*Table{}}
+}
+
+// 获取实体的内存表,不存在时创建
+func (d *DB) Table(entity interface{}) *Table {
+	t := indirect(reflect.TypeOf(entity))
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	tb, ok := d.tables[t]
+	if !ok {
+		tb = NewTable(entity)
+		d.tables[t] = tb
+	}
+	return tb
+}
+
+// 记录领域事件
+func (d *DB) Publish(events ...eventbus.Event) {
+	d.mux.Lock()
+	d.events = append(d.events, events...)
+	d.mux.Unlock()
+}
+
+// 获取已记录的领域事件
+func (d *DB) Events() []eventbus.Event {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return append([]eventbus.Event(nil), d.events...)
+}
+
+func i32(id int64, err error) (int32, error) {
+	return int32(id), err
+}
+
+func i(id int64, err error) (int, error) {
+	return int(id), err
+}
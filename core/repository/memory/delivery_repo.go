@@ -0,0 +1,73 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : delivery_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 13:10
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	deliverImpl "go2o/core/domain/delivery"
+	"go2o/core/domain/interface/delivery"
+	"strings"
+)
+
+var _ delivery.IDeliveryRepo = new(deliveryRepo)
+
+type deliveryRepo struct {
+	*DB
+}
+
+func NewDeliverRepo(d *DB) delivery.IDeliveryRepo {
+	return &deliveryRepo{DB: d}
+}
+
+// 获取配送
+func (d *deliveryRepo) GetDelivery(id int32) delivery.IDelivery {
+	return deliverImpl.NewDelivery(id, d)
+}
+
+// 根据区名获取区域
+func (d *deliveryRepo) GetAreaByArea(name string) []*delivery.AreaValue {
+	list := []*delivery.AreaValue{}
+	d.Table(delivery.AreaValue{}).Select(&list, "")
+	arr := []*delivery.AreaValue{}
+	for _, v := range list {
+		if strings.Contains(v.Name, name) {
+			arr = append(arr, v)
+		}
+	}
+	return arr
+}
+
+// 保存覆盖区域
+func (d *deliveryRepo) SaveCoverageArea(v *delivery.CoverageValue) (int32, error) {
+	return i32(d.Table(v).Save(v))
+}
+
+// 获取覆盖区域
+func (d *deliveryRepo) GetCoverageArea(areaId, id int32) *delivery.CoverageValue {
+	e := &delivery.CoverageValue{}
+	if d.Table(e).GetBy(e, "id=? AND area_id=?", id, areaId) {
+		return e
+	}
+	return nil
+}
+
+// 获取所有的覆盖区域
+func (d *deliveryRepo) GetAllCoverageAreas(areaId int32) []*delivery.CoverageValue {
+	list := []*delivery.CoverageValue{}
+	d.Table(delivery.CoverageValue{}).Select(&list, "area_id=?", areaId)
+	return list
+}
+
+// 获取配送绑定
+func (d *deliveryRepo) GetDeliveryBind(mchId, coverageId int32) *delivery.MerchantDeliverBind {
+	e := &delivery.MerchantDeliverBind{}
+	if d.Table(e).GetBy(e, "merchant_id=? AND coverage_id=?", mchId, coverageId) {
+		return e
+	}
+	return nil
+}
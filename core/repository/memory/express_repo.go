@@ -0,0 +1,97 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : express_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 10:00
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	expImpl "go2o/core/domain/express"
+	"go2o/core/domain/interface/express"
+	"go2o/core/domain/interface/valueobject"
+)
+
+var _ express.IExpressRepo = new(expressRepo)
+
+type expressRepo struct {
+	*DB
+	*expImpl.ExpressRepBase
+	valRepo valueobject.IValueRepo
+}
+
+func NewExpressRepo(d *DB, valRepo valueobject.IValueRepo) express.IExpressRepo {
+	return &expressRepo{
+		DB:      d,
+		valRepo: valRepo,
+	}
+}
+
+// 获取所有快递公司
+func (e *expressRepo) GetExpressProviders() []*express.ExpressProvider {
+	t := e.Table(express.ExpressProvider{})
+	if t.Count("") == 0 {
+		return e.SaveDefaultExpressProviders(e)
+	}
+	list := []*express.ExpressProvider{}
+	t.Select(&list, "")
+	return list
+}
+
+// 获取快递公司
+func (e *expressRepo) GetExpressProvider(id int32) *express.ExpressProvider {
+	for _, v := range e.GetExpressProviders() {
+		if v.Id == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// 保存快递公司
+func (e *expressRepo) SaveExpressProvider(v *express.ExpressProvider) (int32, error) {
+	return i32(e.Table(v).Save(v))
+}
+
+// 获取用户的快递
+func (e *expressRepo) GetUserExpress(userId int32) express.IUserExpress {
+	return expImpl.NewUserExpress(userId, e, e.valRepo)
+}
+
+// 获取用户的快递模板
+func (e *expressRepo) GetUserAllTemplate(userId int32) []*express.ExpressTemplate {
+	list := []*express.ExpressTemplate{}
+	e.Table(express.ExpressTemplate{}).Select(&list, "user_id=?", userId)
+	return list
+}
+
+// 删除快递模板
+func (e *expressRepo) DeleteExpressTemplate(userId int32, templateId int32) error {
+	e.Table(express.ExpressTemplate{}).Delete("id=? AND user_id=?", templateId, userId)
+	return nil
+}
+
+// 保存快递模板
+func (e *expressRepo) SaveExpressTemplate(v *express.ExpressTemplate) (int32, error) {
+	return i32(e.Table(v).Save(v))
+}
+
+// 获取模板的所有地区设置
+func (e *expressRepo) GetExpressTemplateAllAreaSet(templateId int32) []express.ExpressAreaTemplate {
+	list := []express.ExpressAreaTemplate{}
+	e.Table(express.ExpressAreaTemplate{}).Select(&list, "template_id=?", templateId)
+	return list
+}
+
+// 保存模板的地区设置
+func (e *expressRepo) SaveExpressTemplateAreaSet(v *express.ExpressAreaTemplate) (int32, error) {
+	return i32(e.Table(v).Save(v))
+}
+
+// 删除模板的地区设置
+func (e *expressRepo) DeleteAreaExpressTemplate(templateId int32, areaSetId int32) error {
+	e.Table(express.ExpressAreaTemplate{}).Delete("id=? AND template_id=?", areaSetId, templateId)
+	return nil
+}
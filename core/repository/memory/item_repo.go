@@ -0,0 +1,313 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : item_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 11:40
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/enum"
+	"go2o/core/domain/interface/express"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/pro_model"
+	"go2o/core/domain/interface/product"
+	"go2o/core/domain/interface/valueobject"
+	itemImpl "go2o/core/domain/item"
+)
+
+var _ item.IGoodsItemRepo = new(goodsRepo)
+
+type goodsRepo struct {
+	*DB
+	skuService  item.ISkuService
+	snapService item.ISnapshotService
+	catRepo     product.ICategoryRepo
+	proRepo     product.IProductRepo
+	itemWsRepo  item.IItemWholesaleRepo
+	expressRepo express.IExpressRepo
+	valRepo     valueobject.IValueRepo
+	proMRepo    promodel.IProModelRepo
+}
+
+// 商品仓储
+func NewGoodsItemRepo(d *DB, catRepo product.ICategoryRepo,
+	proRepo product.IProductRepo, proMRepo promodel.IProModelRepo,
+	itemWsRepo item.IItemWholesaleRepo, expressRepo express.IExpressRepo,
+	valRepo valueobject.IValueRepo) item.IGoodsItemRepo {
+	return &goodsRepo{
+		DB:          d,
+		catRepo:     catRepo,
+		proRepo:     proRepo,
+		proMRepo:    proMRepo,
+		itemWsRepo:  itemWsRepo,
+		expressRepo: expressRepo,
+		valRepo:     valRepo,
+	}
+}
+
+// 获取SKU服务
+func (g *goodsRepo) SkuService() item.ISkuService {
+	if g.skuService == nil {
+		g.skuService = itemImpl.NewSkuServiceImpl(g, g.proMRepo)
+	}
+	return g.skuService
+}
+
+// 获取快照服务
+func (g *goodsRepo) SnapshotService() item.ISnapshotService {
+	if g.snapService == nil {
+		g.snapService = itemImpl.NewSnapshotServiceImpl(g)
+	}
+	return g.snapService
+}
+
+// 创建商品
+func (g *goodsRepo) CreateItem(v *item.GoodsItem) item.IGoodsItem {
+	return itemImpl.NewItem(g.proRepo, g.catRepo, nil, v, g.valRepo, g,
+		g.proMRepo, g.itemWsRepo, g.expressRepo, nil)
+}
+
+// 获取商品
+func (g *goodsRepo) GetItem(itemId int64) item.IGoodsItem {
+	if v := g.GetValueGoodsById(itemId); v != nil {
+		return g.CreateItem(v)
+	}
+	return nil
+}
+
+// 根据SKU-ID获取商品,SKU-ID为商品ID
+func (g *goodsRepo) GetGoodsBySkuId(skuId int64) interface{} {
+	if g.GetLatestSnapshot(skuId) != nil {
+		return g.GetItem(skuId)
+	}
+	return nil
+}
+
+// 获取商品
+func (g *goodsRepo) GetValueGoods(itemId, skuId int64) *item.GoodsItem {
+	return g.GetValueGoodsBySku(itemId, skuId)
+}
+
+// 获取商品
+func (g *goodsRepo) GetValueGoodsById(goodsId int64) *item.GoodsItem {
+	e := &item.GoodsItem{}
+	if g.Table(e).Get(goodsId, e) {
+		return e
+	}
+	return nil
+}
+
+// 根据SKU获取商品
+func (g *goodsRepo) GetValueGoodsBySku(itemId, sku int64) *item.GoodsItem {
+	e := &item.GoodsItem{}
+	if g.Table(e).GetBy(e, "product_id=? AND sku_id=?", itemId, sku) {
+		return e
+	}
+	return nil
+}
+
+// 保存商品,库存等数据已被修改时返回冲突错误
+func (g *goodsRepo) SaveValueGoods(v *item.GoodsItem) (int64, error) {
+	return g.Table(v).Save(v)
+}
+
+// 转换为商品值对象
+func (g *goodsRepo) goodsOf(v *item.GoodsItem) *valueobject.Goods {
+	return &valueobject.Goods{
+		ItemId:        v.ID,
+		ProductId:     v.ProductId,
+		VendorId:      v.VendorId,
+		ShopId:        v.ShopId,
+		CategoryId:    v.CatId,
+		Name:          v.Title,
+		Title:         v.Title,
+		ShortTitle:    v.ShortTitle,
+		GoodsNo:       v.Code,
+		Image:         v.Image,
+		RetailPrice:   v.RetailPrice,
+		Price:         v.Price,
+		PriceRange:    v.PriceRange,
+		GoodsId:       v.ID,
+		SkuId:         v.SkuId,
+		IsPresent:     v.IsPresent,
+		PromotionFlag: v.PromFlag,
+		StockNum:      v.StockNum,
+		SaleNum:       v.SaleNum,
+	}
+}
+
+// 筛选商品并转换为商品值对象,返回总数及[start,end)范围内的商品
+func (g *goodsRepo) selectGoods(catIds []int32, start, end int,
+	where string, args ...interface{}) (int, []*valueobject.Goods) {
+	list := []*item.GoodsItem{}
+	g.Table(item.GoodsItem{}).Select(&list, where, args...)
+	arr := []*valueobject.Goods{}
+	for _, v := range list {
+		if len(catIds) == 0 || containsInt32(catIds, v.CatId) {
+			arr = append(arr, g.goodsOf(v))
+		}
+	}
+	total := len(arr)
+	if start >= total {
+		return total, []*valueobject.Goods{}
+	}
+	if end > total {
+		end = total
+	}
+	return total, arr[start:end]
+}
+
+// 获取已上架的商品
+func (g *goodsRepo) GetPagedOnShelvesGoods(shopId int32, catIds []int32,
+	start, end int, where, orderBy string) (int, []*valueobject.Goods) {
+	s := "review_state=? AND shelve_state=?"
+	args := []interface{}{enum.ReviewPass, item.ShelvesOn}
+	if shopId > 0 {
+		s += " AND shop_id=?"
+		args = append(args, shopId)
+	}
+	if len(where) != 0 {
+		s += " AND " + where
+	}
+	if len(orderBy) == 0 {
+		orderBy = "sort_num DESC"
+	}
+	return g.selectGoods(catIds, start, end, s+" ORDER BY "+orderBy, args...)
+}
+
+// 获取指定数量已上架的商品
+func (g *goodsRepo) GetOnShelvesGoods(mchId int32, start, end int, sortBy string) []*valueobject.Goods {
+	_, list := g.selectGoods(nil, start, end, "vendor_id=? AND review_state=? AND shelve_state=? ORDER BY "+sortBy,
+		mchId, enum.ReviewPass, item.ShelvesOn)
+	return list
+}
+
+// 根据编号获取商品
+func (g *goodsRepo) GetGoodsByIds(ids ...int64) ([]*valueobject.Goods, error) {
+	arr := []*valueobject.Goods{}
+	for _, id := range ids {
+		if v := g.GetValueGoodsById(id); v != nil {
+			arr = append(arr, g.goodsOf(v))
+		}
+	}
+	return arr, nil
+}
+
+// 获取会员价
+func (g *goodsRepo) GetGoodsLevelPrice(goodsId int64) []*item.MemberPrice {
+	list := []*item.MemberPrice{}
+	g.Table(item.MemberPrice{}).Select(&list, "goods_id=?", goodsId)
+	return list
+}
+
+// 保存会员价
+func (g *goodsRepo) SaveGoodsLevelPrice(v *item.MemberPrice) (int32, error) {
+	return i32(g.Table(v).Save(v))
+}
+
+// 移除会员价
+func (g *goodsRepo) RemoveGoodsLevelPrice(id int32) error {
+	g.Table(item.MemberPrice{}).DeleteByPk(id)
+	return nil
+}
+
+// 保存快照
+func (g *goodsRepo) SaveSnapshot(v *item.Snapshot) (int64, error) {
+	return g.Table(v).Save(v)
+}
+
+// 根据指定商品快照
+func (g *goodsRepo) GetSnapshots(skuIdArr []int64) []item.Snapshot {
+	list := []item.Snapshot{}
+	for _, id := range skuIdArr {
+		if e := g.GetLatestSnapshot(id); e != nil {
+			list = append(list, *e)
+		}
+	}
+	return list
+}
+
+// 获取最新的商品快照
+func (g *goodsRepo) GetLatestSnapshot(itemId int64) *item.Snapshot {
+	e := &item.Snapshot{}
+	if g.Table(e).Get(itemId, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取指定的商品销售快照
+func (g *goodsRepo) GetSalesSnapshot(id int64) *item.TradeSnapshot {
+	e := &item.TradeSnapshot{}
+	if g.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 根据Key获取商品销售快照
+func (g *goodsRepo) GetSaleSnapshotByKey(key string) *item.TradeSnapshot {
+	e := &item.TradeSnapshot{}
+	if g.Table(e).GetBy(e, "snap_key=?", key) {
+		return e
+	}
+	return nil
+}
+
+// 获取最新的商品销售快照
+func (g *goodsRepo) GetLatestSalesSnapshot(skuId int64) *item.TradeSnapshot {
+	e := &item.TradeSnapshot{}
+	if g.Table(e).GetBy(e, "sku_id=? ORDER BY id DESC", skuId) {
+		return e
+	}
+	return nil
+}
+
+// 保存商品销售快照
+func (g *goodsRepo) SaveSalesSnapshot(v *item.TradeSnapshot) (int64, error) {
+	return g.Table(v).Save(v)
+}
+
+// Get ItemSku
+func (g *goodsRepo) GetItemSku(primary interface{}) *item.Sku {
+	e := &item.Sku{}
+	if g.Table(e).Get(primary, e) {
+		return e
+	}
+	return nil
+}
+
+// Select ItemSku
+func (g *goodsRepo) SelectItemSku(where string, v ...interface{}) []*item.Sku {
+	list := []*item.Sku{}
+	g.Table(item.Sku{}).Select(&list, where, v...)
+	return list
+}
+
+// Save ItemSku
+func (g *goodsRepo) SaveItemSku(v *item.Sku) (int, error) {
+	return i(g.Table(v).Save(v))
+}
+
+// Delete ItemSku
+func (g *goodsRepo) DeleteItemSku(primary interface{}) error {
+	g.Table(item.Sku{}).DeleteByPk(primary)
+	return nil
+}
+
+// Batch Delete ItemSku
+func (g *goodsRepo) BatchDeleteItemSku(where string, v ...interface{}) (int64, error) {
+	return int64(g.Table(item.Sku{}).Delete(where, v...)), nil
+}
+
+func containsInt32(arr []int32, v int32) bool {
+	for _, a := range arr {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
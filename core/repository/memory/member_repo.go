@@ -0,0 +1,557 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : member_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 11:10
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"fmt"
+	"github.com/jsix/gof/storage"
+	"go2o/core/domain/interface/enum"
+	"go2o/core/domain/interface/events"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/mss"
+	"go2o/core/domain/interface/order"
+	"go2o/core/domain/interface/valueobject"
+	memberImpl "go2o/core/domain/member"
+	"go2o/core/dto"
+	"sync"
+	"time"
+)
+
+var _ member.IMemberRepo = new(memberRepo)
+
+type memberRepo struct {
+	*DB
+	storage storage.Interface
+	valRepo valueobject.IValueRepo
+	mssRepo mss.IMssRepo
+	mux     sync.Mutex
+	manager member.IMemberManager
+}
+
+func NewMemberRepo(d *DB, sto storage.Interface, mssRepo mss.IMssRepo,
+	valRepo valueobject.IValueRepo) member.IMemberRepo {
+	return &memberRepo{
+		DB:      d,
+		storage: sto,
+		mssRepo: mssRepo,
+		valRepo: valRepo,
+	}
+}
+
+// 获取管理服务
+func (m *memberRepo) GetManager() member.IMemberManager {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if m.manager == nil {
+		m.manager = memberImpl.NewMemberManager(m, m.valRepo)
+	}
+	return m.manager
+}
+
+// 获取资料或初始化
+func (m *memberRepo) GetProfile(memberId int64) *member.Profile {
+	e := &member.Profile{}
+	if !m.Table(e).Get(memberId, e) {
+		e.MemberId = memberId
+		m.Table(e).Save(e)
+	}
+	return e
+}
+
+// 保存资料
+func (m *memberRepo) SaveProfile(v *member.Profile) error {
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 获取会员等级
+func (m *memberRepo) GetMemberLevels_New() []*member.Level {
+	list := []*member.Level{}
+	m.Table(member.Level{}).Select(&list, "ORDER BY id")
+	return list
+}
+
+// 获取等级对应的会员数
+func (m *memberRepo) GetMemberNumByLevel_New(id int32) int {
+	return m.Table(member.Member{}).Count("level=?", id)
+}
+
+// 删除会员等级
+func (m *memberRepo) DeleteMemberLevel_New(id int32) error {
+	m.Table(member.Level{}).DeleteByPk(id)
+	return nil
+}
+
+// 保存会员等级
+func (m *memberRepo) SaveMemberLevel_New(v *member.Level) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 根据用户名获取会员
+func (m *memberRepo) GetMemberByUsr(usr string) *member.Member {
+	e := &member.Member{}
+	if m.Table(e).GetBy(e, "usr=?", usr) {
+		return e
+	}
+	return nil
+}
+
+// 根据手机号码获取会员
+func (m *memberRepo) GetMemberValueByPhone(phone string) *member.Member {
+	if id := m.GetMemberIdByPhone(phone); id > 0 {
+		e := &member.Member{}
+		if m.Table(e).Get(id, e) {
+			return e
+		}
+	}
+	return nil
+}
+
+// 获取会员
+func (m *memberRepo) GetMember(memberId int64) member.IMember {
+	e := &member.Member{}
+	if m.Table(e).Get(memberId, e) {
+		return m.CreateMember(e)
+	}
+	return nil
+}
+
+// 创建会员
+func (m *memberRepo) CreateMember(v *member.Member) member.IMember {
+	return memberImpl.NewMember(m.GetManager(), v, m, m.mssRepo, m.valRepo)
+}
+
+// 删除会员
+func (m *memberRepo) DeleteMember(memberId int64) error {
+	m.Table(member.Member{}).DeleteByPk(memberId)
+	m.Table(member.Profile{}).DeleteByPk(memberId)
+	m.Table(member.Account{}).DeleteByPk(memberId)
+	m.Table(member.BankInfo{}).DeleteByPk(memberId)
+	m.Table(member.Relation{}).DeleteByPk(memberId)
+	return nil
+}
+
+// 创建会员,仅作为某些操作使用,不保存
+func (m *memberRepo) CreateMemberById(memberId int64) member.IMember {
+	return m.CreateMember(&member.Member{Id: memberId})
+}
+
+// 保存会员
+func (m *memberRepo) SaveMember(v *member.Member) (int64, error) {
+	create := v.Id <= 0
+	id, err := m.Table(v).Save(v)
+	if err == nil {
+		if create {
+			m.initMember(v)
+		}
+		m.Publish(&events.MemberChanged{MemberId: v.Id, Create: create})
+	}
+	return id, err
+}
+
+func (m *memberRepo) initMember(v *member.Member) {
+	m.Table(member.Account{}).Save(&member.Account{
+		MemberId:   v.Id,
+		UpdateTime: v.RegTime,
+	})
+	m.Table(member.BankInfo{}).Save(&member.BankInfo{
+		MemberId: v.Id,
+		State:    1,
+	})
+	m.Table(member.Relation{}).Save(&member.Relation{
+		MemberId: v.Id,
+	})
+}
+
+// 获取会员最后更新时间
+func (m *memberRepo) GetMemberLatestUpdateTime(id int64) int64 {
+	e := &member.Member{}
+	if m.Table(e).Get(id, e) {
+		return e.UpdateTime
+	}
+	return 0
+}
+
+// 根据邀请码获取会员编号
+func (m *memberRepo) GetMemberIdByInvitationCode(code string) int64 {
+	e := &member.Member{}
+	if m.Table(e).GetBy(e, "invitation_code=?", code) {
+		return e.Id
+	}
+	return 0
+}
+
+// 根据手机号获取会员编号
+func (m *memberRepo) GetMemberIdByPhone(phone string) int64 {
+	e := &member.Profile{}
+	if m.Table(e).GetBy(e, "phone=?", phone) {
+		return e.MemberId
+	}
+	return 0
+}
+
+// 根据邮箱地址获取会员编号
+func (m *memberRepo) GetMemberIdByEmail(email string) int64 {
+	e := &member.Profile{}
+	if m.Table(e).GetBy(e, "email=?", email) {
+		return e.MemberId
+	}
+	return 0
+}
+
+// 根据用户名获取会员编号
+func (m *memberRepo) GetMemberIdByUser(user string) int64 {
+	if e := m.GetMemberByUsr(user); e != nil {
+		return e.Id
+	}
+	return 0
+}
+
+// 用户名是否存在
+func (m *memberRepo) CheckUsrExist(usr string, memberId int64) bool {
+	return m.Table(member.Member{}).Count("usr=? AND id<>?", usr, memberId) != 0
+}
+
+// 手机号码是否使用
+func (m *memberRepo) CheckPhoneBind(phone string, memberId int64) bool {
+	return m.Table(member.Profile{}).Count("phone=? AND member_id<>?", phone, memberId) != 0
+}
+
+// 保存绑定
+func (m *memberRepo) SaveRelation(v *member.Relation) error {
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 获取账户
+func (m *memberRepo) GetAccount(memberId int64) *member.Account {
+	e := &member.Account{}
+	if m.Table(e).Get(memberId, e) {
+		return e
+	}
+	return nil
+}
+
+// 保存账户，传入会员编号。账户已被修改时返回冲突错误
+func (m *memberRepo) SaveAccount(v *member.Account) (int64, error) {
+	_, err := m.Table(v).Save(v)
+	return v.MemberId, err
+}
+
+// 获取银行信息
+func (m *memberRepo) GetBankInfo(memberId int64) *member.BankInfo {
+	e := &member.BankInfo{}
+	m.Table(e).Get(memberId, e)
+	return e
+}
+
+// 保存银行信息
+func (m *memberRepo) SaveBankInfo(v *member.BankInfo) error {
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 保存积分记录
+func (m *memberRepo) SaveIntegralLog(v *member.IntegralLog) error {
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 保存余额日志
+func (m *memberRepo) SaveBalanceLog(v *member.BalanceLog) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 保存钱包账户日志
+func (m *memberRepo) SavePresentLog(v *member.WalletLog) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 获取钱包账户日志
+func (m *memberRepo) GetWalletLog(id int32) *member.WalletLog {
+	e := &member.WalletLog{}
+	if m.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取会员提现次数键
+func (m *memberRepo) getMemberTakeOutTimesKey(memberId int64) string {
+	return fmt.Sprintf("sys:go2o:rep:mm:take-out-times:%d", memberId)
+}
+
+// 增加会员当天提现次数
+func (m *memberRepo) AddTodayTakeOutTimes(memberId int64) error {
+	times := m.GetTodayTakeOutTimes(memberId)
+	// 保存到当天结束
+	t := time.Now()
+	d := (24-t.Hour())*3600 + (60-t.Minute())*60 + (60 - t.Second())
+	return m.storage.SetExpire(m.getMemberTakeOutTimesKey(memberId), times+1, int64(d))
+}
+
+// 获取会员每日提现次数
+func (m *memberRepo) GetTodayTakeOutTimes(memberId int64) int {
+	times, _ := m.storage.GetInt(m.getMemberTakeOutTimesKey(memberId))
+	return times
+}
+
+// 获取会员关联
+func (m *memberRepo) GetRelation(memberId int64) *member.Relation {
+	e := &member.Relation{}
+	if m.Table(e).Get(memberId, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取积分对应的等级
+func (m *memberRepo) GetLevelValueByExp(mchId int32, exp int64) int {
+	e := &merchant.MemberLevel{}
+	if m.Table(e).GetBy(e, "merchant_id=? AND require_exp<=? AND enabled=1 ORDER BY require_exp DESC",
+		mchId, exp) {
+		return int(e.Value)
+	}
+	return 0
+}
+
+// 获取会员升级记录
+func (m *memberRepo) GetLevelUpLog(id int32) *member.LevelUpLog {
+	e := &member.LevelUpLog{}
+	if m.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 保存会员升级记录
+func (m *memberRepo) SaveLevelUpLog(v *member.LevelUpLog) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 获取会员最近的等级变更记录
+func (m *memberRepo) GetLatestLevelUpLog(memberId int64) *member.LevelUpLog {
+	e := &member.LevelUpLog{}
+	if m.Table(e).GetBy(e, "member_id=? AND reviewed IN(?,?) ORDER BY id DESC",
+		memberId, enum.ReviewPass, enum.ReviewConfirm) {
+		return e
+	}
+	return nil
+}
+
+// 获取等级规则
+func (m *memberRepo) GetLevelRule(levelId int32) *member.LevelRule {
+	e := &member.LevelRule{}
+	if m.Table(e).Get(levelId, e) {
+		return e
+	}
+	return nil
+}
+
+// 保存等级规则
+func (m *memberRepo) SaveLevelRule(v *member.LevelRule) error {
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 获取等级权益
+func (m *memberRepo) GetLevelBenefit(levelId int32) *member.LevelBenefit {
+	e := &member.LevelBenefit{}
+	if m.Table(e).Get(levelId, e) {
+		return e
+	}
+	return nil
+}
+
+// 保存等级权益
+func (m *memberRepo) SaveLevelBenefit(v *member.LevelBenefit) error {
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 获取会员在时间段内已完成订单的统计数据
+func (m *memberRepo) GetLevelStat(memberId int64, begin, end int64) *member.LevelStat {
+	list := []*order.NormalSubOrder{}
+	m.Table(order.NormalSubOrder{}).Select(&list,
+		"buyer_id=? AND state=? AND update_time>=? AND update_time<=?",
+		memberId, order.StatCompleted, begin, end)
+	e := &member.LevelStat{Orders: int32(len(list))}
+	for _, v := range list {
+		e.Amount += v.FinalAmount
+	}
+	return e
+}
+
+// 保存地址
+func (m *memberRepo) SaveDeliver(v *member.Address) (int64, error) {
+	return m.Table(v).Save(v)
+}
+
+// 获取全部配送地址
+func (m *memberRepo) GetDeliverAddress(memberId int64) []*member.Address {
+	list := []*member.Address{}
+	m.Table(member.Address{}).Select(&list, "member_id=?", memberId)
+	return list
+}
+
+// 获取配送地址
+func (m *memberRepo) GetSingleDeliverAddress(memberId, addressId int64) *member.Address {
+	e := &member.Address{}
+	if m.Table(e).Get(addressId, e) && e.MemberId == memberId {
+		return e
+	}
+	return nil
+}
+
+// 删除配送地址
+func (m *memberRepo) DeleteAddress(memberId, addressId int64) error {
+	m.Table(member.Address{}).Delete("member_id=? AND id=?", memberId, addressId)
+	return nil
+}
+
+// 邀请
+func (m *memberRepo) GetMyInvitationMembers(memberId int64, begin, end int) (
+	total int, rows []*dto.InvitationMember) {
+	rels := []*member.Relation{}
+	m.Table(member.Relation{}).Select(&rels, "inviter_id=?", memberId)
+	arr := []*dto.InvitationMember{}
+	for _, r := range rels {
+		v := &member.Member{}
+		if !m.Table(v).Get(r.MemberId, v) {
+			continue
+		}
+		p := m.GetProfile(v.Id)
+		arr = append(arr, &dto.InvitationMember{
+			MemberId: int32(v.Id),
+			User:     v.Usr,
+			Level:    v.Level,
+			Avatar:   p.Avatar,
+			NickName: p.Name,
+			Phone:    p.Phone,
+			Im:       p.Im,
+		})
+	}
+	// 与数据库仓储一致,按等级倒序
+	for i := 1; i < len(arr); i++ {
+		for j := i; j > 0 && arr[j].Level > arr[j-1].Level; j-- {
+			arr[j], arr[j-1] = arr[j-1], arr[j]
+		}
+	}
+	total = len(arr)
+	if begin >= total {
+		return total, []*dto.InvitationMember{}
+	}
+	if end > total {
+		end = total
+	}
+	return total, arr[begin:end]
+}
+
+// 获取下级会员数量
+func (m *memberRepo) GetSubInvitationNum(memberId int64, memberIdArr []int32) map[int32]int {
+	mp := make(map[int32]int, len(memberIdArr))
+	for _, id := range memberIdArr {
+		mp[id] = m.Table(member.Relation{}).Count("inviter_id=?", id)
+	}
+	return mp
+}
+
+// 获取推荐我的人
+func (m *memberRepo) GetInvitationMeMember(memberId int64) *member.Member {
+	if r := m.GetRelation(memberId); r != nil && r.InviterId > 0 {
+		e := &member.Member{}
+		if m.Table(e).Get(r.InviterId, e) {
+			return e
+		}
+	}
+	return nil
+}
+
+// 根据编号获取余额变动信息
+func (m *memberRepo) GetBalanceInfo(id int32) *member.BalanceInfo {
+	e := &member.BalanceInfo{}
+	if m.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 根据号码获取余额变动信息
+func (m *memberRepo) GetBalanceInfoByNo(tradeNo string) *member.BalanceInfo {
+	e := &member.BalanceInfo{}
+	if m.Table(e).GetBy(e, "trade_no=?", tradeNo) {
+		return e
+	}
+	return nil
+}
+
+// 保存余额变动信息
+func (m *memberRepo) SaveBalanceInfo(v *member.BalanceInfo) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 保存理财账户信息
+func (m *memberRepo) SaveGrowAccount(memberId int64, balance, totalAmount,
+	growEarnings, totalGrowEarnings float32, updateTime int64) error {
+	e := m.GetAccount(memberId)
+	if e == nil {
+		return member.ErrNoSuchMember
+	}
+	e.GrowBalance = balance
+	e.GrowAmount = totalAmount
+	e.GrowEarnings = growEarnings
+	e.GrowTotalEarnings = totalGrowEarnings
+	e.UpdateTime = updateTime
+	_, err := m.SaveAccount(e)
+	return err
+}
+
+// 收藏,typeId 为类型编号, referId为关联的ID
+func (m *memberRepo) Favorite(memberId int64, favType int, referId int32) error {
+	v := &member.Favorite{
+		MemberId:   memberId,
+		FavType:    favType,
+		ReferId:    referId,
+		UpdateTime: time.Now().Unix(),
+	}
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 是否已收藏
+func (m *memberRepo) Favored(memberId int64, favType int, referId int32) bool {
+	return m.Table(member.Favorite{}).Count("member_id=? AND fav_type=? AND refer_id=?",
+		memberId, favType, referId) > 0
+}
+
+// 取消收藏
+func (m *memberRepo) CancelFavorite(memberId int64, favType int, referId int32) error {
+	m.Table(member.Favorite{}).Delete("member_id=? AND fav_type=? AND refer_id=?",
+		memberId, favType, referId)
+	return nil
+}
+
+// 获取会员分页的优惠券列表,内存仓储不支持联表的查询条件,返回空列表
+func (m *memberRepo) GetMemberPagedCoupon(memberId int64, start, end int,
+	where string) (total int, rows []*dto.SimpleCoupon) {
+	return 0, []*dto.SimpleCoupon{}
+}
+
+// Select MmBuyerGroup
+func (m *memberRepo) SelectMmBuyerGroup(where string, v ...interface{}) []*member.BuyerGroup {
+	list := []*member.BuyerGroup{}
+	m.Table(member.BuyerGroup{}).Select(&list, where, v...)
+	return list
+}
+
+// Save MmBuyerGroup
+func (m *memberRepo) SaveMmBuyerGroup(v *member.BuyerGroup) (int, error) {
+	return i(m.Table(v).Save(v))
+}
@@ -0,0 +1,56 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : member_test.go
+ * author : jarryliu
+ * date : 2026-10-21 10:20
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/member"
+	"testing"
+	"time"
+)
+
+// 测试统计周期结束后未达到等级规则时自动降级
+func TestLevelDowngrade(t *testing.T) {
+	r := NewRepos()
+	lg := r.MemberRepo.GetManager().LevelManager()
+	arr := lg.GetLevelSet()
+	if len(arr) < 2 {
+		t.Fatal("未初始化会员等级")
+	}
+	// 全部等级均设置了无法达到的规则
+	for _, lv := range arr[:2] {
+		err := lg.SaveLevelRule(&member.LevelRule{
+			LevelId:       lv.ID,
+			RequireAmount: 1000,
+			RequireOrders: 1,
+			PeriodType:    member.LevelPeriodRolling,
+			PeriodMonths:  1,
+			AutoDowngrade: 1,
+			Enabled:       1,
+		})
+		if err != nil {
+			t.Fatal("保存等级规则失败:", err)
+		}
+	}
+	m := createBuyer(t, r)
+	v := m.GetValue()
+	v.Level = arr[1].ID
+	v.Exp = arr[1].RequireExp
+	v.RegTime = time.Now().AddDate(0, -3, 0).Unix()
+	if _, err := r.MemberRepo.SaveMember(&v); err != nil {
+		t.Fatal("保存会员失败:", err)
+	}
+	m = r.MemberRepo.GetMember(v.Id)
+	changed, err := m.ReEvaluateLevel()
+	if err != nil {
+		t.Fatal("评定等级失败:", err)
+	}
+	if lv := r.MemberRepo.GetMember(v.Id).GetValue().Level; !changed || lv != arr[0].ID {
+		t.Fatalf("会员应降级为最低等级,当前等级:%d", lv)
+	}
+}
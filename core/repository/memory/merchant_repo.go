@@ -0,0 +1,551 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : merchant_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 12:10
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"fmt"
+	"github.com/jsix/gof/storage"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/merchant/shop"
+	"go2o/core/domain/interface/merchant/user"
+	"go2o/core/domain/interface/merchant/wholesaler"
+	"go2o/core/domain/interface/valueobject"
+	merchantImpl "go2o/core/domain/merchant"
+	"go2o/core/infrastructure/domain"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ merchant.IMerchantRepo = new(merchantRepo)
+
+type merchantRepo struct {
+	*DB
+	storage    storage.Interface
+	manager    merchant.IMerchantManager
+	wsRepo     wholesaler.IWholesaleRepo
+	itemRepo   item.IGoodsItemRepo
+	userRepo   user.IUserRepo
+	shopRepo   shop.IShopRepo
+	valRepo    valueobject.IValueRepo
+	memberRepo member.IMemberRepo
+	mux        sync.RWMutex
+	// 商户的键值,键为: 类别:商户编号:键
+	kv map[string]string
+}
+
+func NewMerchantRepo(d *DB, storage storage.Interface,
+	wsRepo wholesaler.IWholesaleRepo, itemRepo item.IGoodsItemRepo,
+	shopRepo shop.IShopRepo, userRepo user.IUserRepo, memberRepo member.IMemberRepo,
+	valRepo valueobject.IValueRepo) merchant.IMerchantRepo {
+	return &merchantRepo{
+		DB:         d,
+		storage:    storage,
+		wsRepo:     wsRepo,
+		itemRepo:   itemRepo,
+		userRepo:   userRepo,
+		shopRepo:   shopRepo,
+		valRepo:    valRepo,
+		memberRepo: memberRepo,
+		kv:         map[string]string{},
+	}
+}
+
+// 获取商户管理器
+func (m *merchantRepo) GetManager() merchant.IMerchantManager {
+	if m.manager == nil {
+		m.manager = merchantImpl.NewMerchantManager(m, m.valRepo)
+	}
+	return m.manager
+}
+
+// 创建商户
+func (m *merchantRepo) CreateMerchant(v *merchant.Merchant) merchant.IMerchant {
+	return merchantImpl.NewMerchant(v, m, m.wsRepo, m.itemRepo,
+		m.shopRepo, m.userRepo, m.memberRepo, m.valRepo)
+}
+
+// 创建会员申请商户密钥
+func (m *merchantRepo) CreateSignUpToken(memberId int64) string {
+	mKey := fmt.Sprintf("go2o:rep:mch:signup:mm-%d", memberId)
+	if token, err := m.storage.GetString(mKey); err == nil {
+		return token
+	}
+	for {
+		token := domain.NewSecret(0)[8:14]
+		key := "go2o:rep:mch:signup:tk-" + token
+		if _, err := m.storage.GetInt(key); err != nil {
+			seconds := int64(time.Hour * 12)
+			m.storage.SetExpire(key, memberId, seconds)
+			m.storage.SetExpire(mKey, token, seconds)
+			return token
+		}
+	}
+}
+
+// 根据商户申请密钥获取会员编号
+func (m *merchantRepo) GetMemberFromSignUpToken(token string) int64 {
+	id, err := m.storage.GetInt64("go2o:rep:mch:signup:tk-" + token)
+	if err == nil {
+		return id
+	}
+	return -1
+}
+
+// 获取商户的编号
+func (m *merchantRepo) GetMerchantsId() []int32 {
+	list := []*merchant.Merchant{}
+	m.Table(merchant.Merchant{}).Select(&list, "")
+	arr := make([]int32, len(list))
+	for i, v := range list {
+		arr[i] = v.ID
+	}
+	return arr
+}
+
+// 获取商户
+func (m *merchantRepo) GetMerchant(id int32) merchant.IMerchant {
+	e := &merchant.Merchant{}
+	if m.Table(e).Get(id, e) {
+		return m.CreateMerchant(e)
+	}
+	return nil
+}
+
+// 获取合作商主要的域名主机,内存仓储不保存站点配置
+func (m *merchantRepo) GetMerchantMajorHost(mchId int32) string {
+	return ""
+}
+
+// 保存
+func (m *merchantRepo) SaveMerchant(v *merchant.Merchant) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 获取账户,不存在时初始化
+func (m *merchantRepo) GetAccount(mchId int32) *merchant.Account {
+	e := &merchant.Account{}
+	if !m.Table(e).Get(mchId, e) {
+		e.MchId = mchId
+		e.UpdateTime = time.Now().Unix()
+		m.Table(e).Save(e)
+	}
+	return e
+}
+
+// 保存账户
+func (m *merchantRepo) UpdateAccount(v *merchant.Account) error {
+	if v.MchId > 0 {
+		_, err := m.Table(v).Save(v)
+		return err
+	}
+	return nil
+}
+
+// 根据编号获取余额变动信息
+func (m *merchantRepo) GetBalanceLog(id int32) *merchant.BalanceLog {
+	e := &merchant.BalanceLog{}
+	if m.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 根据外部单号获取余额变动信息
+func (m *merchantRepo) GetBalanceLogByOuterNo(outerNo string) *merchant.BalanceLog {
+	e := &merchant.BalanceLog{}
+	if m.Table(e).GetBy(e, "outer_no=?", outerNo) {
+		return e
+	}
+	return nil
+}
+
+// 保存余额变动信息
+func (m *merchantRepo) SaveBalanceLog(v *merchant.BalanceLog) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 获取商户申请信息
+func (m *merchantRepo) GetSignUpInfo(id int32) *merchant.MchSignUp {
+	e := &merchant.MchSignUp{}
+	if m.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取会员申请的商户信息
+func (m *merchantRepo) GetSignUpInfoByMemberId(memberId int64) *merchant.MchSignUp {
+	e := &merchant.MchSignUp{}
+	if m.Table(e).GetBy(e, "member_id=?", memberId) {
+		return e
+	}
+	return nil
+}
+
+// 保存商户申请信息
+func (m *merchantRepo) SaveSignUpInfo(v *merchant.MchSignUp) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 删除会员的商户申请信息
+func (m *merchantRepo) DeleteSignUpInfo(memberId int64) error {
+	m.Table(merchant.MchSignUp{}).Delete("member_id=?", memberId)
+	return nil
+}
+
+// 获取会员关联的商户
+func (m *merchantRepo) GetMerchantByMemberId(memberId int64) merchant.IMerchant {
+	e := &merchant.Merchant{}
+	if m.Table(e).GetBy(e, "member_id=?", memberId) {
+		return m.CreateMerchant(e)
+	}
+	return nil
+}
+
+// 获取销售配置
+func (m *merchantRepo) GetMerchantSaleConf(mchId int32) *merchant.SaleConf {
+	e := &merchant.SaleConf{}
+	if m.Table(e).Get(mchId, e) {
+		return e
+	}
+	return nil
+}
+
+// 保存销售配置
+func (m *merchantRepo) SaveMerchantSaleConf(v *merchant.SaleConf) error {
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 保存API信息
+func (m *merchantRepo) SaveApiInfo(v *merchant.ApiInfo) error {
+	_, err := m.Table(v).Save(v)
+	return err
+}
+
+// 获取API信息
+func (m *merchantRepo) GetApiInfo(mchId int32) *merchant.ApiInfo {
+	e := &merchant.ApiInfo{}
+	if m.Table(e).Get(mchId, e) {
+		return e
+	}
+	return nil
+}
+
+// 根据API编号获取商户编号
+func (m *merchantRepo) GetMerchantIdByApiId(apiId string) int32 {
+	e := &merchant.ApiInfo{}
+	if m.Table(e).GetBy(e, "api_id=?", apiId) {
+		return e.MerchantId
+	}
+	return 0
+}
+
+func (m *merchantRepo) kvKey(mchId int32, indent string, k string) string {
+	return fmt.Sprintf("%s:%d:%s", indent, mchId, k)
+}
+
+// 获取键值
+func (m *merchantRepo) GetKeyValue(mchId int32, indent string, k string) string {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	return m.kv[m.kvKey(mchId, indent, k)]
+}
+
+// 设置键值
+func (m *merchantRepo) SaveKeyValue(mchId int32, indent string, k, v string, updateTime int64) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.kv[m.kvKey(mchId, indent, k)] = v
+	return nil
+}
+
+// 获取多个键值
+func (m *merchantRepo) GetKeyMap(mchId int32, indent string, k []string) map[string]string {
+	mp := make(map[string]string)
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	for _, key := range k {
+		if v, ok := m.kv[m.kvKey(mchId, indent, key)]; ok {
+			mp[key] = v
+		}
+	}
+	return mp
+}
+
+// 检查是否包含值的键数量,keyStr为键模糊匹配
+func (m *merchantRepo) CheckKvContainValue(mchId int32, indent string, value string, keyStr string) int {
+	i := 0
+	for _, v := range m.GetKeyMapByChar(mchId, indent, keyStr) {
+		if v == value {
+			i++
+		}
+	}
+	return i
+}
+
+// 根据关键字获取字典
+func (m *merchantRepo) GetKeyMapByChar(mchId int32, indent string, keyword string) map[string]string {
+	mp := make(map[string]string)
+	prefix := m.kvKey(mchId, indent, "")
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	for k, v := range m.kv {
+		if strings.HasPrefix(k, prefix) && strings.Contains(k[len(prefix):], keyword) {
+			mp[k[len(prefix):]] = v
+		}
+	}
+	return mp
+}
+
+// 获取等级
+func (m *merchantRepo) GetLevel(mchId, levelValue int32) *merchant.MemberLevel {
+	e := &merchant.MemberLevel{}
+	if m.Table(e).GetBy(e, "merchant_id=? AND value=?", mchId, levelValue) {
+		return e
+	}
+	return nil
+}
+
+// 获取下一个等级
+func (m *merchantRepo) GetNextLevel(mchId, levelVal int32) *merchant.MemberLevel {
+	e := &merchant.MemberLevel{}
+	if m.Table(e).GetBy(e, "merchant_id=? AND value>? ORDER BY value", mchId, levelVal) {
+		return e
+	}
+	return nil
+}
+
+// 获取会员等级
+func (m *merchantRepo) GetMemberLevels(mchId int32) []*merchant.MemberLevel {
+	list := []*merchant.MemberLevel{}
+	m.Table(merchant.MemberLevel{}).Select(&list, "merchant_id=?", mchId)
+	return list
+}
+
+// 删除会员等级
+func (m *merchantRepo) DeleteMemberLevel(mchId, id int32) error {
+	m.Table(merchant.MemberLevel{}).Delete("id=? AND merchant_id=?", id, mchId)
+	return nil
+}
+
+// 保存等级
+func (m *merchantRepo) SaveMemberLevel(mchId int32, v *merchant.MemberLevel) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 获取商户的佣金规则
+func (m *merchantRepo) GetCommissionRules(mchId int32) []*merchant.CommissionRule {
+	list := []*merchant.CommissionRule{}
+	m.Table(merchant.CommissionRule{}).Select(&list, "mch_id=? ORDER BY item_id DESC", mchId)
+	return list
+}
+
+// 获取佣金规则
+func (m *merchantRepo) GetCommissionRule(mchId int32, id int32) *merchant.CommissionRule {
+	e := &merchant.CommissionRule{}
+	if m.Table(e).GetBy(e, "id=? AND mch_id=?", id, mchId) {
+		return e
+	}
+	return nil
+}
+
+// 保存佣金规则
+func (m *merchantRepo) SaveCommissionRule(v *merchant.CommissionRule) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 删除佣金规则
+func (m *merchantRepo) DeleteCommissionRule(mchId int32, id int32) error {
+	m.Table(merchant.CommissionRule{}).Delete("id=? AND mch_id=?", id, mchId)
+	return nil
+}
+
+// 获取佣金
+func (m *merchantRepo) GetCommission(id int64) *merchant.Commission {
+	e := &merchant.Commission{}
+	if m.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取订单的佣金,snapshotId为0时返回订单的全部佣金
+func (m *merchantRepo) GetCommissions(orderId int64, snapshotId int64) []*merchant.Commission {
+	list := []*merchant.Commission{}
+	if snapshotId > 0 {
+		m.Table(merchant.Commission{}).Select(&list, "order_id=? AND snap_id=?", orderId, snapshotId)
+	} else {
+		m.Table(merchant.Commission{}).Select(&list, "order_id=?", orderId)
+	}
+	return list
+}
+
+// 保存佣金
+func (m *merchantRepo) SaveCommission(v *merchant.Commission) (int64, error) {
+	return m.Table(v).Save(v)
+}
+
+// 获取推荐人的佣金报表,mchId为0时统计全部商户
+func (m *merchantRepo) GetCommissionReport(mchId int32, memberId int64,
+	begin int64, end int64) *merchant.CommissionReport {
+	where := "member_id=? AND create_time>=? AND create_time<=?"
+	args := []interface{}{memberId, begin, end}
+	if mchId > 0 {
+		where += " AND mch_id=?"
+		args = append(args, mchId)
+	}
+	list := []*merchant.Commission{}
+	m.Table(merchant.Commission{}).Select(&list, where, args...)
+	e := &merchant.CommissionReport{MemberId: memberId}
+	orders := map[int64]bool{}
+	for _, v := range list {
+		orders[v.OrderId] = true
+		switch v.State {
+		case merchant.CommissionFrozen:
+			e.FrozenAmount += v.Amount - v.ReverseAmount
+		case merchant.CommissionReleased:
+			e.ReleasedAmount += v.Amount - v.ReverseAmount
+		}
+		e.ReversedAmount += v.ReverseAmount
+	}
+	e.Orders = int32(len(orders))
+	return e
+}
+
+// 获取商户的通知地址
+func (m *merchantRepo) GetWebhooks(mchId int32) []*merchant.Webhook {
+	list := []*merchant.Webhook{}
+	m.Table(merchant.Webhook{}).Select(&list, "mch_id=? ORDER BY id", mchId)
+	return list
+}
+
+// 保存通知地址
+func (m *merchantRepo) SaveWebhook(v *merchant.Webhook) (int32, error) {
+	return i32(m.Table(v).Save(v))
+}
+
+// 删除通知地址
+func (m *merchantRepo) DeleteWebhook(mchId int32, id int32) error {
+	m.Table(merchant.Webhook{}).Delete("id=? AND mch_id=?", id, mchId)
+	return nil
+}
+
+// 获取通知消息
+func (m *merchantRepo) GetWebhookMessage(id int64) *merchant.WebhookMessage {
+	e := &merchant.WebhookMessage{}
+	if m.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 保存通知消息
+func (m *merchantRepo) SaveWebhookMessage(v *merchant.WebhookMessage) (int64, error) {
+	return m.Table(v).Save(v)
+}
+
+// 保存通知发送日志
+func (m *merchantRepo) SaveWebhookLog(v *merchant.WebhookLog) (int64, error) {
+	return m.Table(v).Save(v)
+}
+
+// 获取通知消息的发送日志
+func (m *merchantRepo) GetWebhookLogs(msgId int64) []*merchant.WebhookLog {
+	list := []*merchant.WebhookLog{}
+	m.Table(merchant.WebhookLog{}).Select(&list, "msg_id=? ORDER BY id", msgId)
+	return list
+}
+
+// 获取接口某日某类别的调用统计
+func (m *merchantRepo) GetApiUsage(mchId int32, date int, class string) *merchant.ApiUsage {
+	e := &merchant.ApiUsage{}
+	if m.Table(e).GetBy(e, "mch_id=? AND stat_date=? AND api_class=?", mchId, date, class) {
+		return e
+	}
+	return nil
+}
+
+// 保存接口调用统计
+func (m *merchantRepo) SaveApiUsage(v *merchant.ApiUsage) (int64, error) {
+	return m.Table(v).Save(v)
+}
+
+// 获取接口调用统计
+func (m *merchantRepo) GetApiUsages(mchId int32, begin int, end int) []*merchant.ApiUsage {
+	list := []*merchant.ApiUsage{}
+	m.Table(merchant.ApiUsage{}).Select(&list,
+		"mch_id=? AND stat_date>=? AND stat_date<=? ORDER BY stat_date", mchId, begin, end)
+	return list
+}
+
+// Get MchEnterpriseInfo
+func (m *merchantRepo) GetMchEnterpriseInfo(mchId int32) *merchant.EnterpriseInfo {
+	e := &merchant.EnterpriseInfo{}
+	if m.Table(e).GetBy(e, "mch_id=?", mchId) {
+		return e
+	}
+	return nil
+}
+
+// Save MchEnterpriseInfo
+func (m *merchantRepo) SaveMchEnterpriseInfo(v *merchant.EnterpriseInfo) (int, error) {
+	return i(m.Table(v).Save(v))
+}
+
+// Get MchBuyerGroup
+func (m *merchantRepo) GetMchBuyerGroupByGroupId(mchId, groupId int32) *merchant.MchBuyerGroup {
+	e := &merchant.MchBuyerGroup{}
+	if m.Table(e).GetBy(e, "mch_id=? AND group_id=?", mchId, groupId) {
+		return e
+	}
+	return nil
+}
+
+// Select MchBuyerGroup
+func (m *merchantRepo) SelectMchBuyerGroup(mchId int32) []*merchant.MchBuyerGroup {
+	list := []*merchant.MchBuyerGroup{}
+	m.Table(merchant.MchBuyerGroup{}).Select(&list, "mch_id=?", mchId)
+	return list
+}
+
+// Save MchBuyerGroup
+func (m *merchantRepo) SaveMchBuyerGroup(v *merchant.MchBuyerGroup) (int, error) {
+	return i(m.Table(v).Save(v))
+}
+
+// 获取订单类型的时效设置
+func (m *merchantRepo) GetMchOrderTimeout(mchId int32, orderType int32) *merchant.OrderTimeout {
+	e := &merchant.OrderTimeout{}
+	if m.Table(e).GetBy(e, "mch_id=? AND order_type=?", mchId, orderType) {
+		return e
+	}
+	return nil
+}
+
+// 获取商户按订单类型的时效设置
+func (m *merchantRepo) SelectMchOrderTimeout(mchId int32) []*merchant.OrderTimeout {
+	list := []*merchant.OrderTimeout{}
+	m.Table(merchant.OrderTimeout{}).Select(&list, "mch_id=? ORDER BY order_type ASC", mchId)
+	return list
+}
+
+// 保存订单类型的时效设置
+func (m *merchantRepo) SaveMchOrderTimeout(v *merchant.OrderTimeout) (int, error) {
+	return i(m.Table(v).Save(v))
+}
+
+// 删除订单类型的时效设置
+func (m *merchantRepo) DeleteMchOrderTimeout(mchId int32, orderType int32) error {
+	m.Table(merchant.OrderTimeout{}).Delete("mch_id=? AND order_type=?", mchId, orderType)
+	return nil
+}
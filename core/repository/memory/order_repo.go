@@ -0,0 +1,328 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : order_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 13:30
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/cart"
+	"go2o/core/domain/interface/delivery"
+	"go2o/core/domain/interface/events"
+	"go2o/core/domain/interface/express"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/order"
+	"go2o/core/domain/interface/payment"
+	"go2o/core/domain/interface/product"
+	"go2o/core/domain/interface/promotion"
+	"go2o/core/domain/interface/shipment"
+	"go2o/core/domain/interface/valueobject"
+	orderImpl "go2o/core/domain/order"
+	"go2o/core/dto"
+	"go2o/core/infrastructure/domain"
+)
+
+var _ order.IOrderRepo = new(OrderRepo)
+
+type OrderRepo struct {
+	*DB
+	productRepo product.IProductRepo
+	goodsRepo   item.IGoodsItemRepo
+	promRepo    promotion.IPromotionRepo
+	memberRepo  member.IMemberRepo
+	mchRepo     merchant.IMerchantRepo
+	deliverRepo delivery.IDeliveryRepo
+	cartRepo    cart.ICartRepo
+	valRepo     valueobject.IValueRepo
+	payRepo     payment.IPaymentRepo
+	expressRepo express.IExpressRepo
+	shipRepo    shipment.IShipmentRepo
+	manager     order.IOrderManager
+}
+
+func NewOrderRepo(d *DB, mchRepo merchant.IMerchantRepo, payRepo payment.IPaymentRepo,
+	proRepo product.IProductRepo, cartRepo cart.ICartRepo, goodsRepo item.IGoodsItemRepo,
+	promRepo promotion.IPromotionRepo, memRepo member.IMemberRepo,
+	deliverRepo delivery.IDeliveryRepo, expressRepo express.IExpressRepo,
+	shipRepo shipment.IShipmentRepo, valRepo valueobject.IValueRepo) *OrderRepo {
+	return &OrderRepo{
+		DB:          d,
+		productRepo: proRepo,
+		goodsRepo:   goodsRepo,
+		promRepo:    promRepo,
+		payRepo:     payRepo,
+		memberRepo:  memRepo,
+		mchRepo:     mchRepo,
+		cartRepo:    cartRepo,
+		deliverRepo: deliverRepo,
+		valRepo:     valRepo,
+		expressRepo: expressRepo,
+		shipRepo:    shipRepo,
+	}
+}
+
+// 设置支付仓储,支付仓储依赖订单仓储,需在创建后设置
+func (o *OrderRepo) SetPaymentRepo(payRepo payment.IPaymentRepo) {
+	o.payRepo = payRepo
+}
+
+// 获取订单服务
+func (o *OrderRepo) Manager() order.IOrderManager {
+	if o.manager == nil {
+		o.manager = orderImpl.NewOrderManager(o.cartRepo, o.mchRepo,
+			o, o.payRepo, o.productRepo, o.goodsRepo, o.promRepo,
+			o.memberRepo, o.deliverRepo, o.expressRepo, o.shipRepo,
+			o.valRepo)
+	}
+	return o.manager
+}
+
+// 生成订单
+func (o *OrderRepo) CreateOrder(val *order.Order) order.IOrder {
+	return orderImpl.FactoryNew(val, o.Manager(), o, o.mchRepo, o.goodsRepo,
+		o.productRepo, o.promRepo, o.memberRepo, o.expressRepo,
+		o.shipRepo, o.payRepo, o.valRepo)
+}
+
+// 生成空白订单,并保存返回对象
+func (o *OrderRepo) CreateNormalSubOrder(v *order.NormalSubOrder) order.ISubOrder {
+	return orderImpl.NewSubNormalOrder(v, o.Manager(), o, o.memberRepo,
+		o.goodsRepo, o.shipRepo, o.productRepo,
+		o.valRepo, o.mchRepo)
+}
+
+// 获取可用的订单号
+func (o *OrderRepo) GetFreeOrderNo(vendorId int32) string {
+	for {
+		orderNo := domain.NewOrderNo(int(vendorId), "")
+		if o.Table(order.Order{}).Count("order_no=?", orderNo) == 0 {
+			return orderNo
+		}
+	}
+}
+
+// 获取订单编号
+func (o *OrderRepo) GetOrderId(orderNo string, subOrder bool) int64 {
+	if subOrder {
+		e := &order.NormalSubOrder{}
+		if o.Table(e).GetBy(e, "order_no=?", orderNo) {
+			return e.ID
+		}
+		return 0
+	}
+	e := &order.Order{}
+	if o.Table(e).GetBy(e, "order_no=?", orderNo) {
+		return e.ID
+	}
+	return 0
+}
+
+// Get OrderList
+func (o *OrderRepo) GetOrder(where string, arg ...interface{}) *order.Order {
+	e := &order.Order{}
+	if o.Table(e).GetBy(e, where, arg...) {
+		return e
+	}
+	return nil
+}
+
+// Save OrderList,零售订单或已拆单的订单以外,状态改变时记录订单变更事件
+func (o *OrderRepo) SaveOrder(v *order.Order) (int, error) {
+	changed := v.OrderType != int32(order.TRetail) &&
+		v.State != int32(order.StatBreak)
+	if changed && v.ID > 0 {
+		if origin := o.GetOrder("id=?", v.ID); origin != nil {
+			changed = origin.State != v.State
+		}
+	}
+	id, err := i(o.Table(v).Save(v))
+	if err == nil && changed {
+		o.Publish(&events.OrderChanged{OrderNo: v.OrderNo, Sub: false})
+	}
+	return id, err
+}
+
+// 保存订单优惠券绑定
+func (o *OrderRepo) SaveOrderCouponBind(v *order.OrderCoupon) error {
+	_, err := o.Table(v).Save(v)
+	return err
+}
+
+// 获取订单的促销绑定
+func (o *OrderRepo) GetOrderPromotionBinds(orderNo string) []*order.OrderPromotionBind {
+	list := []*order.OrderPromotionBind{}
+	o.Table(order.OrderPromotionBind{}).Select(&list, "order_no=?", orderNo)
+	return list
+}
+
+// 保存订单的促销绑定
+func (o *OrderRepo) SavePromotionBindForOrder(v *order.OrderPromotionBind) (int32, error) {
+	return i32(o.Table(v).Save(v))
+}
+
+// 根据编号获取订单
+func (o *OrderRepo) GetNormalOrderById(orderId int64) *order.NormalOrder {
+	e := &order.NormalOrder{}
+	if o.Table(e).GetBy(e, "order_id=?", orderId) {
+		return e
+	}
+	return nil
+}
+
+// 保存订单
+func (o *OrderRepo) SaveNormalOrder(v *order.NormalOrder) (int, error) {
+	return i(o.Table(v).Save(v))
+}
+
+// 获取订单的所有子订单
+func (o *OrderRepo) GetNormalSubOrders(orderId int64) []*order.NormalSubOrder {
+	list := []*order.NormalSubOrder{}
+	o.Table(order.NormalSubOrder{}).Select(&list, "order_id=?", orderId)
+	return list
+}
+
+// 保存订单日志
+func (o *OrderRepo) SaveNormalSubOrderLog(v *order.OrderLog) error {
+	_, err := o.Table(v).Save(v)
+	return err
+}
+
+// 获取子订单
+func (o *OrderRepo) GetSubOrder(id int64) *order.NormalSubOrder {
+	e := &order.NormalSubOrder{}
+	if o.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 保存子订单,状态改变或延长收货时记录事件。订单已被修改时返回冲突错误
+func (o *OrderRepo) SaveSubOrder(v *order.NormalSubOrder) (int, error) {
+	changed, extended := true, false
+	if v.ID > 0 {
+		if origin := o.GetSubOrder(v.ID); origin != nil {
+			changed = origin.State != v.State
+			extended = origin.ReceiveExtend == 0 && v.ReceiveExtend > 0
+		}
+	}
+	id, err := i(o.Table(v).Save(v))
+	if err == nil && changed {
+		o.Publish(&events.OrderChanged{OrderNo: v.OrderNo, Sub: true})
+	} else if err == nil && extended {
+		o.Publish(&events.OrderReceiveExtended{OrderNo: v.OrderNo,
+			Sub: true, Hours: v.ReceiveExtend})
+	}
+	return id, err
+}
+
+// 保存子订单的商品项,并返回编号和错误
+func (o *OrderRepo) SaveOrderItem(subOrderId int64, v *order.SubOrderItem) (int32, error) {
+	v.OrderId = subOrderId
+	return i32(o.Table(v).Save(v))
+}
+
+// 获取订单项
+func (o *OrderRepo) GetSubOrderItems(orderId int64) []*order.SubOrderItem {
+	list := []*order.SubOrderItem{}
+	o.Table(order.SubOrderItem{}).Select(&list, "order_id=?", orderId)
+	return list
+}
+
+// 获取订单的操作记录
+func (o *OrderRepo) GetSubOrderLogs(orderId int64) []*order.OrderLog {
+	list := []*order.OrderLog{}
+	o.Table(order.OrderLog{}).Select(&list, "order_id=?", orderId)
+	return list
+}
+
+// 根据商品快照获取订单项
+func (o *OrderRepo) GetOrderItemBySnapshotId(orderId int64, snapshotId int32) *order.SubOrderItem {
+	e := &order.SubOrderItem{}
+	if o.Table(e).GetBy(e, "order_id=? AND snap_id=?", orderId, snapshotId) {
+		return e
+	}
+	return nil
+}
+
+// 根据商品快照获取订单项数据传输对象
+func (o *OrderRepo) GetOrderItemDtoBySnapshotId(orderId int64, snapshotId int32) *dto.OrderItem {
+	it := o.GetOrderItemBySnapshotId(orderId, snapshotId)
+	if it == nil {
+		return nil
+	}
+	sn := &item.TradeSnapshot{}
+	if !o.Table(sn).Get(it.SnapshotId, sn) {
+		return nil
+	}
+	e := &dto.OrderItem{
+		Id:             int(it.ID),
+		OrderId:        it.OrderId,
+		SnapshotId:     int(it.SnapshotId),
+		SkuId:          int(sn.SkuId),
+		GoodsTitle:     sn.GoodsTitle,
+		Image:          sn.Image,
+		Price:          sn.Price,
+		Quantity:       int(it.Quantity),
+		ReturnQuantity: int(it.ReturnQuantity),
+		Amount:         it.Amount,
+		FinalAmount:    it.FinalAmount,
+		IsShipped:      int(it.IsShipped),
+	}
+	e.FinalPrice = e.FinalAmount / float32(e.Quantity)
+	return e
+}
+
+// Get WholesaleOrder
+func (o *OrderRepo) GetWholesaleOrder(where string, v ...interface{}) *order.WholesaleOrder {
+	e := &order.WholesaleOrder{}
+	if o.Table(e).GetBy(e, where, v...) {
+		return e
+	}
+	return nil
+}
+
+// Save WholesaleOrder,延长收货时记录事件
+func (o *OrderRepo) SaveWholesaleOrder(v *order.WholesaleOrder) (int, error) {
+	extended := false
+	if v.ID > 0 && v.ReceiveExtend > 0 {
+		origin := o.GetWholesaleOrder("id=?", v.ID)
+		extended = origin != nil && origin.ReceiveExtend == 0
+	}
+	id, err := i(o.Table(v).Save(v))
+	if err == nil && extended {
+		o.Publish(&events.OrderReceiveExtended{OrderNo: v.OrderNo,
+			Sub: false, Hours: v.ReceiveExtend})
+	}
+	return id, err
+}
+
+// Save WholesaleItem
+func (o *OrderRepo) SaveWholesaleItem(v *order.WholesaleItem) (int, error) {
+	return i(o.Table(v).Save(v))
+}
+
+// Select WholesaleItem
+func (o *OrderRepo) SelectWholesaleItem(where string, v ...interface{}) []*order.WholesaleItem {
+	list := []*order.WholesaleItem{}
+	o.Table(order.WholesaleItem{}).Select(&list, where, v...)
+	return list
+}
+
+// Get OrderTradeOrder
+func (o *OrderRepo) GetTradeOrder(where string, v ...interface{}) *order.TradeOrder {
+	e := &order.TradeOrder{}
+	if o.Table(e).GetBy(e, where, v...) {
+		return e
+	}
+	return nil
+}
+
+// Save OrderTradeOrder
+func (o *OrderRepo) SaveTradeOrder(v *order.TradeOrder) (int, error) {
+	return i(o.Table(v).Save(v))
+}
@@ -0,0 +1,243 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : order_test.go
+ * author : jarryliu
+ * date : 2026-10-20 14:40
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/cart"
+	"go2o/core/domain/interface/enum"
+	"go2o/core/domain/interface/events"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/order"
+	"go2o/core/domain/interface/payment"
+	"testing"
+)
+
+// 创建测试会员,并设置收货地址及钱包余额
+func createBuyer(t *testing.T, r *Repos) member.IMember {
+	m := r.MemberRepo.CreateMember(&member.Member{
+		Usr: "buyer001",
+		Pwd: "123456",
+	})
+	memberId, err := m.Save()
+	if err != nil {
+		t.Fatal("创建会员失败:", err)
+	}
+	m = r.MemberRepo.GetMember(memberId)
+	addr := m.Profile().CreateDeliver(&member.Address{
+		MemberId:  memberId,
+		RealName:  "测试用户",
+		Phone:     "13800138000",
+		Province:  440000,
+		City:      440600,
+		District:  440605,
+		Address:   "测试街道一号",
+		IsDefault: 1,
+	})
+	if _, err = addr.Save(); err != nil {
+		t.Fatal("保存收货地址失败:", err)
+	}
+	err = m.GetAccount().Charge(member.AccountWallet, member.ChargeBySystem,
+		"测试充值", "", 1000, member.DefaultRelateUser)
+	if err != nil {
+		t.Fatal("充值失败:", err)
+	}
+	return m
+}
+
+// 创建已上架的测试商品
+func createItem(t *testing.T, r *Repos) item.IGoodsItem {
+	it := r.ItemRepo.CreateItem(&item.GoodsItem{
+		VendorId:    1,
+		ShopId:      1,
+		Title:       "测试商品",
+		Code:        "T0001",
+		Cost:        8,
+		Price:       10,
+		RetailPrice: 12,
+		StockNum:    10,
+		ShelveState: item.ShelvesOn,
+		ReviewState: enum.ReviewPass,
+	})
+	itemId, err := it.Save()
+	if err != nil {
+		t.Fatal("创建商品失败:", err)
+	}
+	return r.ItemRepo.GetItem(itemId)
+}
+
+// 测试订单从提交到收货的流程
+func TestOrderLifecycle(t *testing.T) {
+	r := NewRepos()
+	buyer := createBuyer(t, r)
+	buyerId := buyer.GetAggregateRootId()
+	it := createItem(t, r)
+
+	c := r.CartRepo.GetMyCart(buyerId, cart.KRetail)
+	if err := c.Put(it.GetAggregateRootId(), 0, 2); err != nil {
+		t.Fatal("加入购物车失败:", err)
+	}
+	if _, err := c.Save(); err != nil {
+		t.Fatal("保存购物车失败:", err)
+	}
+
+	addressId := buyer.Profile().GetDefaultAddress().GetDomainId()
+	manager := r.OrderRepo.Manager()
+	o, err := manager.SubmitOrder(c, addressId, "", false)
+	if err != nil {
+		t.Fatal("提交订单失败:", err)
+	}
+	if stock := r.ItemRepo.GetItem(it.GetAggregateRootId()).GetValue().StockNum; stock != 8 {
+		t.Fatalf("库存未扣减,当前库存:%d", stock)
+	}
+
+	no := o.(order.INormalOrder)
+	py := no.GetPaymentOrder()
+	if err = py.PaymentByWallet("支付订单"); err != nil {
+		t.Fatal("钱包支付失败:", err)
+	}
+	if s := py.GetValue().State; s != payment.StateFinishPayment {
+		t.Fatalf("支付单未完成支付,状态:%d", s)
+	}
+
+	o = manager.GetOrderById(o.GetAggregateRootId())
+	subs := o.(order.INormalOrder).GetSubOrders()
+	if len(subs) != 1 {
+		t.Fatalf("子订单数量不正确:%d", len(subs))
+	}
+	sub := subs[0]
+	if s := sub.GetValue().State; s != order.StatAwaitingConfirm {
+		t.Fatalf("订单未完成支付,状态:%s", order.OrderState(s).String())
+	}
+	if err = sub.Confirm(); err != nil {
+		t.Fatal("确认订单失败:", err)
+	}
+	if err = sub.PickUp(); err != nil {
+		t.Fatal("备货失败:", err)
+	}
+	spId := r.ExpressRepo.GetExpressProviders()[0].Id
+	if err = sub.Ship(spId, "100000001"); err != nil {
+		t.Fatal("发货失败:", err)
+	}
+	if err = sub.BuyerReceived(); err != nil {
+		t.Fatal("确认收货失败:", err)
+	}
+
+	sv := r.OrderRepo.GetSubOrder(sub.GetDomainId())
+	if sv.State != order.StatCompleted {
+		t.Fatalf("订单未完成,状态:%s", order.OrderState(sv.State).String())
+	}
+	if n := len(r.OrderRepo.GetSubOrderLogs(sv.ID)); n == 0 {
+		t.Fatal("未记录订单日志")
+	}
+	if !hasEvent(r.DB, func(e interface{}) bool {
+		pe, ok := e.(*events.PaymentFinished)
+		return ok && pe.PaymentOrderId == py.GetAggregateRootId()
+	}) {
+		t.Fatal("未记录支付完成事件")
+	}
+	if !hasEvent(r.DB, func(e interface{}) bool {
+		oe, ok := e.(*events.OrderChanged)
+		return ok && oe.Sub && oe.OrderNo == sv.OrderNo
+	}) {
+		t.Fatal("未记录订单变更事件")
+	}
+}
+
+// 测试按订单类型的时效设置及买家延长收货
+func TestOrderReceiveExtend(t *testing.T) {
+	r := NewRepos()
+	mchId, err := r.MerchantRepo.SaveMerchant(&merchant.Merchant{
+		Usr:  "mch001",
+		Name: "测试商户",
+	})
+	if err != nil {
+		t.Fatal("创建商户失败:", err)
+	}
+	conf := r.MerchantRepo.GetMerchant(mchId).ConfManager()
+	err = conf.SaveOrderTimeout(&merchant.OrderTimeout{
+		OrderType:         int32(order.TRetail),
+		ReceiveHour:       48,
+		ReceiveExtendHour: 24,
+	})
+	if err != nil {
+		t.Fatal("保存订单时效失败:", err)
+	}
+	ot := conf.GetOrderTimeout(int32(order.TRetail))
+	if ot.ReceiveHour != 48 || ot.ReceiveExtendHour != 24 {
+		t.Fatalf("订单时效设置不正确:%#v", ot)
+	}
+	if ot.TimeoutMinute != conf.GetSaleConf().OrderTimeOutMinute {
+		t.Fatal("未设置的时效应使用销售设置")
+	}
+	if ot.ConfirmMinute != 0 {
+		t.Fatal("自动确认时间为0时应立即确认")
+	}
+	conf.SaveOrderTimeout(&merchant.OrderTimeout{
+		OrderType:     int32(order.TWholesale),
+		ConfirmMinute: merchant.ConfirmMinuteUnset,
+	})
+	ot = conf.GetOrderTimeout(int32(order.TWholesale))
+	if ot.ConfirmMinute != conf.GetSaleConf().OrderConfirmAfterMinute {
+		t.Fatal("未设置自动确认时间时应使用销售设置")
+	}
+
+	buyer := createBuyer(t, r)
+	buyerId := buyer.GetAggregateRootId()
+	it := createItem(t, r)
+	c := r.CartRepo.GetMyCart(buyerId, cart.KRetail)
+	c.Put(it.GetAggregateRootId(), 0, 1)
+	c.Save()
+	addressId := buyer.Profile().GetDefaultAddress().GetDomainId()
+	manager := r.OrderRepo.Manager()
+	o, err := manager.SubmitOrder(c, addressId, "", false)
+	if err != nil {
+		t.Fatal("提交订单失败:", err)
+	}
+	err = o.(order.INormalOrder).GetPaymentOrder().PaymentByWallet("支付订单")
+	if err != nil {
+		t.Fatal("钱包支付失败:", err)
+	}
+	o = manager.GetOrderById(o.GetAggregateRootId())
+	sub := o.(order.INormalOrder).GetSubOrders()[0]
+	if err = sub.ExtendReceive(); err != order.ErrOrderNotShipped {
+		t.Fatal("未发货的订单不能延长收货:", err)
+	}
+	sub.Confirm()
+	sub.PickUp()
+	if err = sub.Ship(r.ExpressRepo.GetExpressProviders()[0].Id, "100000002"); err != nil {
+		t.Fatal("发货失败:", err)
+	}
+	if err = sub.ExtendReceive(); err != nil {
+		t.Fatal("延长收货失败:", err)
+	}
+	if err = sub.ExtendReceive(); err != order.ErrReceiveExtended {
+		t.Fatal("订单只能延长一次收货:", err)
+	}
+	sv := r.OrderRepo.GetSubOrder(sub.GetDomainId())
+	if sv.ReceiveExtend != 24 {
+		t.Fatalf("延长收货时间不正确:%d", sv.ReceiveExtend)
+	}
+	if !hasEvent(r.DB, func(e interface{}) bool {
+		oe, ok := e.(*events.OrderReceiveExtended)
+		return ok && oe.Sub && oe.OrderNo == sv.OrderNo && oe.Hours == 24
+	}) {
+		t.Fatal("未记录延长收货事件")
+	}
+}
+
+func hasEvent(d *DB, match func(e interface{}) bool) bool {
+	for _, e := range d.Events() {
+		if match(e) {
+			return true
+		}
+	}
+	return false
+}
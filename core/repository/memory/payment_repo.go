@@ -0,0 +1,85 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : payment_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 13:20
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/events"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/order"
+	"go2o/core/domain/interface/payment"
+	"go2o/core/domain/interface/valueobject"
+	payImpl "go2o/core/domain/payment"
+)
+
+var _ payment.IPaymentRepo = new(paymentRepo)
+
+type paymentRepo struct {
+	*DB
+	*payImpl.PaymentRepBase
+	memberRepo member.IMemberRepo
+	valRepo    valueobject.IValueRepo
+	orderRepo  order.IOrderRepo
+}
+
+func NewPaymentRepo(d *DB, mmRepo member.IMemberRepo,
+	orderRepo order.IOrderRepo, valRepo valueobject.IValueRepo) payment.IPaymentRepo {
+	return &paymentRepo{
+		DB:         d,
+		memberRepo: mmRepo,
+		valRepo:    valRepo,
+		orderRepo:  orderRepo,
+	}
+}
+
+// 根据编号获取支付单
+func (p *paymentRepo) GetPaymentOrderById(id int32) payment.IPaymentOrder {
+	e := &payment.PaymentOrder{}
+	if id > 0 && p.Table(e).Get(id, e) {
+		return p.CreatePaymentOrder(e)
+	}
+	return nil
+}
+
+// 根据支付单号获取支付单
+func (p *paymentRepo) GetPaymentOrder(paymentNo string) payment.IPaymentOrder {
+	e := &payment.PaymentOrder{}
+	if p.Table(e).GetBy(e, "trade_no=?", paymentNo) {
+		return p.CreatePaymentOrder(e)
+	}
+	return nil
+}
+
+// 根据订单号获取支付单
+func (p *paymentRepo) GetPaymentBySalesOrderId(orderId int64) payment.IPaymentOrder {
+	e := &payment.PaymentOrder{}
+	if p.Table(e).GetBy(e, "order_id=?", orderId) {
+		return p.CreatePaymentOrder(e)
+	}
+	return nil
+}
+
+// 创建支付单
+func (p *paymentRepo) CreatePaymentOrder(o *payment.PaymentOrder) payment.IPaymentOrder {
+	return p.PaymentRepBase.CreatePaymentOrder(o, p,
+		p.memberRepo, p.orderRepo.Manager(), p.valRepo)
+}
+
+// 保存支付单,支付完成时记录支付完成事件
+func (p *paymentRepo) SavePaymentOrder(v *payment.PaymentOrder) (int32, error) {
+	stat := v.State
+	origin := &payment.PaymentOrder{}
+	if v.Id > 0 && p.Table(origin).Get(v.Id, origin) {
+		stat = origin.State
+	}
+	id, err := i32(p.Table(v).Save(v))
+	if err == nil && stat != v.State && v.State == payment.StateFinishPayment {
+		p.Publish(&events.PaymentFinished{PaymentOrderId: v.Id})
+	}
+	return id, err
+}
@@ -0,0 +1,149 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : personfinance_repo.go
+ * author : jarryliu
+ * date : 2026-10-21 16:40
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"database/sql"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/personfinance"
+	pf "go2o/core/domain/personfinance"
+	"sync"
+)
+
+var _ personfinance.IPersonFinanceRepository = new(personFinanceRepo)
+
+type personFinanceRepo struct {
+	*DB
+	accRepo     member.IMemberRepo
+	mux         sync.Mutex
+	planManager personfinance.IPlanManager
+}
+
+func NewPersonFinanceRepo(d *DB, accRepo member.IMemberRepo) personfinance.IPersonFinanceRepository {
+	return &personFinanceRepo{
+		DB:      d,
+		accRepo: accRepo,
+	}
+}
+
+// 获取个人财富聚合根
+func (p *personFinanceRepo) GetPersonFinance(personId int64) personfinance.IPersonFinance {
+	return pf.NewPersonFinance(personId, p, p.accRepo)
+}
+
+// 根据时间获取收益情况
+func (p *personFinanceRepo) GetRiseByTime(personId int64, begin,
+	end int64) []*personfinance.RiseDayInfo {
+	list := []*personfinance.RiseDayInfo{}
+	p.Table(personfinance.RiseDayInfo{}).Select(&list,
+		"person_id=? AND unix_date>=? AND unix_date<=?", personId, begin, end)
+	return list
+}
+
+// 根据人员编号获取收益
+func (p *personFinanceRepo) GetRiseValueByPersonId(id int64) (
+	*personfinance.RiseInfoValue, error) {
+	e := &personfinance.RiseInfoValue{}
+	if p.Table(e).Get(id, e) {
+		return e, nil
+	}
+	return e, sql.ErrNoRows
+}
+
+// 保存收益信息
+func (p *personFinanceRepo) SaveRiseInfo(v *personfinance.RiseInfoValue) (int, error) {
+	return i(p.Table(v).Save(v))
+}
+
+// 获取日志
+func (p *personFinanceRepo) GetRiseLog(personId int64, logId int32) *personfinance.RiseLog {
+	e := &personfinance.RiseLog{}
+	if p.Table(e).GetBy(e, "person_id=? AND id=?", personId, logId) {
+		return e
+	}
+	return nil
+}
+
+// 保存日志
+func (p *personFinanceRepo) SaveRiseLog(v *personfinance.RiseLog) (int32, error) {
+	return i32(p.Table(v).Save(v))
+}
+
+// 获取日志
+func (p *personFinanceRepo) GetRiseLogs(personId int64, date int64, riseType int) []*personfinance.RiseLog {
+	list := []*personfinance.RiseLog{}
+	p.Table(personfinance.RiseLog{}).Select(&list,
+		"person_id=? AND unix_date=? AND type=?", personId, date, riseType)
+	return list
+}
+
+// 获取指定时间后已确认的日志
+func (p *personFinanceRepo) GetRiseLogsAfter(personId int64, riseType int,
+	begin int64) []*personfinance.RiseLog {
+	list := []*personfinance.RiseLog{}
+	p.Table(personfinance.RiseLog{}).Select(&list,
+		"person_id=? AND type=? AND state=? AND log_time>=?",
+		personId, riseType, personfinance.RiseStateOk, begin)
+	return list
+}
+
+// 保存每日收益
+func (p *personFinanceRepo) SaveRiseDayInfo(v *personfinance.RiseDayInfo) (int32, error) {
+	return i32(p.Table(v).Save(v))
+}
+
+// 获取理财计划管理器
+func (p *personFinanceRepo) GetPlanManager() personfinance.IPlanManager {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.planManager == nil {
+		p.planManager = pf.NewPlanManager(p)
+	}
+	return p.planManager
+}
+
+// 获取全部理财计划
+func (p *personFinanceRepo) GetPlans() []*personfinance.FinancePlan {
+	list := []*personfinance.FinancePlan{}
+	p.Table(personfinance.FinancePlan{}).Select(&list, "1=1 ORDER BY id")
+	return list
+}
+
+// 获取理财计划
+func (p *personFinanceRepo) GetPlan(planId int32) *personfinance.FinancePlan {
+	e := &personfinance.FinancePlan{}
+	if p.Table(e).Get(planId, e) {
+		return e
+	}
+	return nil
+}
+
+// 保存理财计划
+func (p *personFinanceRepo) SavePlan(v *personfinance.FinancePlan) (int32, error) {
+	return i32(p.Table(v).Save(v))
+}
+
+// 获取理财计划的利率
+func (p *personFinanceRepo) GetPlanRates(planId int32) []*personfinance.PlanRate {
+	list := []*personfinance.PlanRate{}
+	p.Table(personfinance.PlanRate{}).Select(&list,
+		"plan_id=? ORDER BY effective_date", planId)
+	return list
+}
+
+// 保存理财计划利率
+func (p *personFinanceRepo) SavePlanRate(v *personfinance.PlanRate) (int32, error) {
+	return i32(p.Table(v).Save(v))
+}
+
+// 删除理财计划利率
+func (p *personFinanceRepo) DeletePlanRate(planId int32, id int32) error {
+	p.Table(personfinance.PlanRate{}).Delete("id=? AND plan_id=?", id, planId)
+	return nil
+}
@@ -0,0 +1,142 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : product_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 12:50
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/pro_model"
+	"go2o/core/domain/interface/product"
+	"go2o/core/domain/interface/valueobject"
+	proImpl "go2o/core/domain/product"
+)
+
+var _ product.IProductRepo = new(productRepo)
+
+type productRepo struct {
+	*DB
+	pmRepo    promodel.IProModelRepo
+	valueRepo valueobject.IValueRepo
+}
+
+func NewProductRepo(d *DB, pmRepo promodel.IProModelRepo,
+	valRepo valueobject.IValueRepo) product.IProductRepo {
+	return &productRepo{
+		DB:        d,
+		pmRepo:    pmRepo,
+		valueRepo: valRepo,
+	}
+}
+
+// 创建产品
+func (p *productRepo) CreateProduct(v *product.Product) product.IProduct {
+	return proImpl.NewProductImpl(v, p, p.pmRepo, p.valueRepo)
+}
+
+// 根据产品编号获取货品
+func (p *productRepo) GetProduct(id int64) product.IProduct {
+	if v := p.GetProductValue(id); v != nil {
+		return p.CreateProduct(v)
+	}
+	return nil
+}
+
+// Get Product
+func (p *productRepo) GetProductValue(itemId int64) *product.Product {
+	e := &product.Product{}
+	if p.Table(e).Get(itemId, e) {
+		return e
+	}
+	return nil
+}
+
+// 根据编号获取产品
+func (p *productRepo) GetProductsById(ids ...int32) ([]*product.Product, error) {
+	list := []*product.Product{}
+	for _, id := range ids {
+		if e := p.GetProductValue(int64(id)); e != nil {
+			list = append(list, e)
+		}
+	}
+	return list, nil
+}
+
+// Save Product
+func (p *productRepo) SaveProduct(v *product.Product) (int, error) {
+	return i(p.Table(v).Save(v))
+}
+
+// 获取分页的产品
+func (p *productRepo) GetPagedOnShelvesProduct(supplierId int32, catIds []int32,
+	start, end int) (total int, goods []*product.Product) {
+	list := []*product.Product{}
+	p.Table(product.Product{}).Select(&list, "supplier_id=?", supplierId)
+	arr := []*product.Product{}
+	for _, v := range list {
+		if containsInt32(catIds, v.CatId) {
+			arr = append(arr, v)
+		}
+	}
+	total = len(arr)
+	if start >= total {
+		return total, []*product.Product{}
+	}
+	if end > total {
+		end = total
+	}
+	return total, arr[start:end]
+}
+
+// 获取货品销售总数
+func (p *productRepo) GetProductSaleNum(productId int64) int {
+	list := []*item.GoodsItem{}
+	p.Table(item.GoodsItem{}).Select(&list, "product_id=?", productId)
+	num := 0
+	for _, v := range list {
+		num += int(v.SaleNum)
+	}
+	return num
+}
+
+// Delete Product
+func (p *productRepo) DeleteProduct(productId int64) error {
+	p.Table(product.Product{}).DeleteByPk(productId)
+	return nil
+}
+
+// Get ProAttrInfo
+func (p *productRepo) GetAttr(primary interface{}) *product.Attr {
+	e := &product.Attr{}
+	if p.Table(e).Get(primary, e) {
+		return e
+	}
+	return nil
+}
+
+// Select ProAttrInfo
+func (p *productRepo) SelectAttr(where string, v ...interface{}) []*product.Attr {
+	list := []*product.Attr{}
+	p.Table(product.Attr{}).Select(&list, where, v...)
+	return list
+}
+
+// Save ProAttrInfo
+func (p *productRepo) SaveAttr(v *product.Attr) (int, error) {
+	return i(p.Table(v).Save(v))
+}
+
+// Delete ProAttrInfo
+func (p *productRepo) DeleteAttr(primary interface{}) error {
+	p.Table(product.Attr{}).DeleteByPk(primary)
+	return nil
+}
+
+// Batch Delete ProAttrInfo
+func (p *productRepo) BatchDeleteAttr(where string, v ...interface{}) (int64, error) {
+	return int64(p.Table(product.Attr{}).Delete(where, v...)), nil
+}
@@ -0,0 +1,211 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : promotion_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 10:10
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"database/sql"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/promotion"
+	promImpl "go2o/core/domain/promotion"
+	"time"
+)
+
+var _ promotion.IPromotionRepo = new(promotionRepo)
+
+type promotionRepo struct {
+	*DB
+	memberRepo member.IMemberRepo
+	goodsRepo  item.IGoodsItemRepo
+}
+
+func NewPromotionRepo(d *DB, goodsRepo item.IGoodsItemRepo,
+	memberRepo member.IMemberRepo) promotion.IPromotionRepo {
+	return &promotionRepo{
+		DB:         d,
+		memberRepo: memberRepo,
+		goodsRepo:  goodsRepo,
+	}
+}
+
+// 获取促销
+func (p *promotionRepo) GetValuePromotion(id int32) *promotion.PromotionInfo {
+	e := &promotion.PromotionInfo{}
+	if p.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取促销
+func (p *promotionRepo) GetPromotion(id int32) promotion.IPromotion {
+	if v := p.GetValuePromotion(id); v != nil {
+		return p.CreatePromotion(v)
+	}
+	return nil
+}
+
+// 获取促销
+func (p *promotionRepo) CreatePromotion(v *promotion.PromotionInfo) promotion.IPromotion {
+	return promImpl.FactoryPromotion(p, p.goodsRepo, p.memberRepo, v)
+}
+
+// 保存促销
+func (p *promotionRepo) SaveValuePromotion(v *promotion.PromotionInfo) (int32, error) {
+	return i32(p.Table(v).Save(v))
+}
+
+// 删除促销
+func (p *promotionRepo) DeletePromotion(id int32) error {
+	p.Table(promotion.PromotionInfo{}).DeleteByPk(id)
+	return nil
+}
+
+// 保存返现促销
+func (p *promotionRepo) SaveValueCashBack(v *promotion.ValueCashBack, create bool) (int32, error) {
+	return i32(p.Table(v).Save(v))
+}
+
+// 获取返现促销
+func (p *promotionRepo) GetValueCashBack(id int32) *promotion.ValueCashBack {
+	e := &promotion.ValueCashBack{}
+	if p.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 删除返现促销
+func (p *promotionRepo) DeleteValueCashBack(id int32) error {
+	p.Table(promotion.ValueCashBack{}).DeleteByPk(id)
+	return nil
+}
+
+// 获取商品的促销编号
+func (p *promotionRepo) GetGoodsPromotionId(goodsId int64, promFlag int) int {
+	e := &promotion.PromotionInfo{}
+	if p.Table(e).GetBy(e, "goods_id=? AND type_flag=? AND enabled=1", goodsId, promFlag) {
+		return int(e.Id)
+	}
+	return 0
+}
+
+// 获取商品的促销
+func (p *promotionRepo) GetPromotionOfGoods(goodsId int64) []*promotion.PromotionInfo {
+	arr := []*promotion.PromotionInfo{}
+	p.Table(promotion.PromotionInfo{}).Select(&arr, "goods_id=? AND enabled=1", goodsId)
+	return arr
+}
+
+// 获取商户订单可用的促销
+func (p *promotionRepo) GetPromotionOfMerchantOrder(mchId int32) []*promotion.PromotionInfo {
+	arr := []*promotion.PromotionInfo{}
+	p.Table(promotion.PromotionInfo{}).Select(&arr, "mch_id=? AND goods_id=0 AND enabled=1", mchId)
+	return arr
+}
+
+func (p *promotionRepo) GetValueCoupon(id int32) *promotion.ValueCoupon {
+	e := &promotion.ValueCoupon{}
+	if p.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+func (p *promotionRepo) SaveValueCoupon(v *promotion.ValueCoupon, isCreate bool) (int32, error) {
+	return i32(p.Table(v).Save(v))
+}
+
+// 删除优惠券
+func (p *promotionRepo) DeleteValueCoupon(id int32) error {
+	p.Table(promotion.ValueCoupon{}).DeleteByPk(id)
+	return nil
+}
+
+func (p *promotionRepo) GetCouponTake(couponId, takeId int32) *promotion.ValueCouponTake {
+	e := &promotion.ValueCouponTake{}
+	if p.Table(e).Get(takeId, e) && e.CouponId == couponId {
+		return e
+	}
+	return nil
+}
+
+func (p *promotionRepo) SaveCouponTake(v *promotion.ValueCouponTake) error {
+	_, err := p.Table(v).Save(v)
+	return err
+}
+
+func (p *promotionRepo) GetCouponTakes(couponId int32) []promotion.ValueCouponTake {
+	arr := []promotion.ValueCouponTake{}
+	p.Table(promotion.ValueCouponTake{}).Select(&arr, "coupon_id=?", couponId)
+	return arr
+}
+
+func (p *promotionRepo) GetCouponBind(couponId, bindId int32) *promotion.ValueCouponBind {
+	e := &promotion.ValueCouponBind{}
+	if p.Table(e).Get(bindId, e) && e.CouponId == couponId {
+		return e
+	}
+	return nil
+}
+
+func (p *promotionRepo) GetCouponBinds(couponId int32) []promotion.ValueCouponBind {
+	arr := []promotion.ValueCouponBind{}
+	p.Table(promotion.ValueCouponBind{}).Select(&arr, "coupon_id=?", couponId)
+	return arr
+}
+
+func (p *promotionRepo) SaveCouponBind(v *promotion.ValueCouponBind) error {
+	_, err := p.Table(v).Save(v)
+	return err
+}
+
+// 获取会员的优惠券绑定
+func (p *promotionRepo) GetCouponBindByMemberId(couponId int32, memberId int64) (
+	*promotion.ValueCouponBind, error) {
+	e := &promotion.ValueCouponBind{}
+	if p.Table(e).GetBy(e, "is_used=0 AND coupon_id=? AND member_id=?", couponId, memberId) {
+		return e, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+// 获取会员的优惠券占用
+func (p *promotionRepo) GetCouponTakeByMemberId(couponId int32, memberId int64) (
+	*promotion.ValueCouponTake, error) {
+	e := &promotion.ValueCouponTake{}
+	if p.Table(e).GetBy(e, "is_apply=0 AND extra_time>? AND coupon_id=? AND member_id=?",
+		time.Now().Unix(), couponId, memberId) {
+		return e, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+// 根据优惠券代码获取优惠券
+func (p *promotionRepo) GetValueCouponByCode(mchId int32, couponCode string) *promotion.ValueCoupon {
+	arr := []*promotion.ValueCoupon{}
+	p.Table(promotion.ValueCoupon{}).Select(&arr, "code=?", couponCode)
+	for _, v := range arr {
+		if pv := p.GetValuePromotion(v.Id); pv != nil && pv.MerchantId == mchId {
+			return v
+		}
+	}
+	return nil
+}
+
+// 根据代码获取优惠券
+func (p *promotionRepo) GetCouponByCode(mchId int32, code string) promotion.IPromotion {
+	v := p.GetValueCouponByCode(mchId, code)
+	if v != nil {
+		prom := p.CreatePromotion(p.GetValuePromotion(v.Id))
+		prom.(promotion.ICouponPromotion).SetDetailsValue(v)
+		return prom
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : repos.go
+ * author : jarryliu
+ * date : 2026-10-20 14:10
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"github.com/jsix/gof/storage"
+	"go2o/core/domain/interface/cart"
+	"go2o/core/domain/interface/delivery"
+	"go2o/core/domain/interface/express"
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/merchant/shop"
+	"go2o/core/domain/interface/merchant/user"
+	"go2o/core/domain/interface/merchant/wholesaler"
+	"go2o/core/domain/interface/order"
+	"go2o/core/domain/interface/payment"
+	"go2o/core/domain/interface/personfinance"
+	"go2o/core/domain/interface/product"
+	"go2o/core/domain/interface/promotion"
+	"go2o/core/domain/interface/shipment"
+	"go2o/core/domain/interface/valueobject"
+)
+
+// 内存仓储集合,按依赖顺序创建订单流程所需的仓储
+type Repos struct {
+	DB            *DB
+	Storage       storage.Interface
+	ValueRepo     valueobject.IValueRepo
+	ExpressRepo   express.IExpressRepo
+	ShipmentRepo  shipment.IShipmentRepo
+	MemberRepo    member.IMemberRepo
+	ProductRepo   product.IProductRepo
+	CategoryRepo  product.ICategoryRepo
+	ItemRepo      item.IGoodsItemRepo
+	PromotionRepo promotion.IPromotionRepo
+	ShopRepo      shop.IShopRepo
+	WholesaleRepo wholesaler.IWholesaleRepo
+	UserRepo      user.IUserRepo
+	MerchantRepo  merchant.IMerchantRepo
+	CartRepo      cart.ICartRepo
+	DeliveryRepo  delivery.IDeliveryRepo
+	OrderRepo     order.IOrderRepo
+	PaymentRepo   payment.IPaymentRepo
+	FinanceRepo   personfinance.IPersonFinanceRepository
+}
+
+// 创建内存仓储集合,站内信、产品模型及批发商品仓储不可用
+func NewRepos() *Repos {
+	r := &Repos{DB: NewDB(), Storage: NewStorage()}
+	d := r.DB
+	r.ValueRepo = NewValueRepo(d)
+	r.ExpressRepo = NewExpressRepo(d, r.ValueRepo)
+	r.ShipmentRepo = NewShipmentRepo(d, r.ExpressRepo)
+	r.MemberRepo = NewMemberRepo(d, r.Storage, nil, r.ValueRepo)
+	r.ProductRepo = NewProductRepo(d, nil, r.ValueRepo)
+	r.CategoryRepo = NewCategoryRepo(d, r.ValueRepo)
+	r.ItemRepo = NewGoodsItemRepo(d, r.CategoryRepo, r.ProductRepo,
+		nil, nil, r.ExpressRepo, r.ValueRepo)
+	r.PromotionRepo = NewPromotionRepo(d, r.ItemRepo, r.MemberRepo)
+	r.ShopRepo = NewShopRepo(d, r.ValueRepo)
+	r.WholesaleRepo = NewWholesaleRepo(d)
+	r.UserRepo = NewUserRepo(d)
+	r.MerchantRepo = NewMerchantRepo(d, r.Storage, r.WholesaleRepo,
+		r.ItemRepo, r.ShopRepo, r.UserRepo, r.MemberRepo, r.ValueRepo)
+	r.CartRepo = NewCartRepo(d, r.MemberRepo, r.MerchantRepo, r.ItemRepo)
+	r.DeliveryRepo = NewDeliverRepo(d)
+	orderRepo := NewOrderRepo(d, r.MerchantRepo, nil, r.ProductRepo,
+		r.CartRepo, r.ItemRepo, r.PromotionRepo, r.MemberRepo,
+		r.DeliveryRepo, r.ExpressRepo, r.ShipmentRepo, r.ValueRepo)
+	r.PaymentRepo = NewPaymentRepo(d, r.MemberRepo, orderRepo, r.ValueRepo)
+	orderRepo.SetPaymentRepo(r.PaymentRepo)
+	r.OrderRepo = orderRepo
+	r.FinanceRepo = NewPersonFinanceRepo(d, r.MemberRepo)
+	return r
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : shipment_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 13:05
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/express"
+	"go2o/core/domain/interface/shipment"
+	shipImpl "go2o/core/domain/shipment"
+)
+
+var _ shipment.IShipmentRepo = new(shipmentRepo)
+
+type shipmentRepo struct {
+	*DB
+	expRepo express.IExpressRepo
+}
+
+func NewShipmentRepo(d *DB, expRepo express.IExpressRepo) shipment.IShipmentRepo {
+	return &shipmentRepo{
+		DB:      d,
+		expRepo: expRepo,
+	}
+}
+
+// 创建发货单
+func (s *shipmentRepo) CreateShipmentOrder(o *shipment.ShipmentOrder) shipment.IShipmentOrder {
+	return shipImpl.NewShipmentOrder(o, s, s.expRepo)
+}
+
+// 获取发货单
+func (s *shipmentRepo) GetShipmentOrder(id int64) shipment.IShipmentOrder {
+	e := &shipment.ShipmentOrder{}
+	if s.Table(e).Get(id, e) {
+		return s.CreateShipmentOrder(e)
+	}
+	return nil
+}
+
+// 获取订单对应的发货单
+func (s *shipmentRepo) GetShipOrders(orderId int64, sub bool) []shipment.IShipmentOrder {
+	list := []*shipment.ShipmentOrder{}
+	if sub {
+		s.Table(shipment.ShipmentOrder{}).Select(&list, "sub_orderid=?", orderId)
+	} else {
+		s.Table(shipment.ShipmentOrder{}).Select(&list, "order_id=?", orderId)
+	}
+	orders := make([]shipment.IShipmentOrder, len(list))
+	for i, v := range list {
+		orders[i] = s.CreateShipmentOrder(v)
+	}
+	return orders
+}
+
+// 保存发货单
+func (s *shipmentRepo) SaveShipmentOrder(o *shipment.ShipmentOrder) (int, error) {
+	return i(s.Table(o).Save(o))
+}
+
+// 保存发货商品项
+func (s *shipmentRepo) SaveShipmentItem(v *shipment.Item) (int, error) {
+	return i(s.Table(v).Save(v))
+}
+
+// 删除发货单
+func (s *shipmentRepo) DeleteShipmentOrder(id int64) error {
+	s.Table(shipment.ShipmentOrder{}).DeleteByPk(id)
+	return nil
+}
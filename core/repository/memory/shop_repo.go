@@ -0,0 +1,112 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : shop_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 12:30
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/merchant/shop"
+	"go2o/core/domain/interface/valueobject"
+	shopImpl "go2o/core/domain/merchant/shop"
+)
+
+var _ shop.IShopRepo = new(shopRepo)
+
+type shopRepo struct {
+	*DB
+	valueRepo valueobject.IValueRepo
+}
+
+func NewShopRepo(d *DB, valueRepo valueobject.IValueRepo) shop.IShopRepo {
+	return &shopRepo{
+		DB:        d,
+		valueRepo: valueRepo,
+	}
+}
+
+// 获取商店
+func (s *shopRepo) GetShop(shopId int32) shop.IShop {
+	v := s.GetValueShop(shopId)
+	return shopImpl.NewShop(v, s, s.valueRepo)
+}
+
+// 保存商店
+func (s *shopRepo) SaveShop(v *shop.Shop) (int32, error) {
+	return i32(s.Table(v).Save(v))
+}
+
+// 获取商店数量
+func (s *shopRepo) ShopCount(vendorId int32, shopType int32) int {
+	return s.Table(shop.Shop{}).Count("vendor_id=? AND shop_type=?", vendorId, shopType)
+}
+
+// 商店别名是否存在
+func (s *shopRepo) ShopAliasExists(alias string, shopId int32) bool {
+	return s.Table(shop.OnlineShop{}).Count("alias=? AND shop_id<>?", alias, shopId) > 0
+}
+
+// 获取商店
+func (s *shopRepo) GetValueShop(shopId int32) *shop.Shop {
+	e := &shop.Shop{}
+	if s.Table(e).Get(shopId, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取线上商店
+func (s *shopRepo) GetOnlineShop(shopId int32) *shop.OnlineShop {
+	e := &shop.OnlineShop{}
+	if s.Table(e).Get(shopId, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取线下商店
+func (s *shopRepo) GetOfflineShop(shopId int32) *shop.OfflineShop {
+	e := &shop.OfflineShop{}
+	if s.Table(e).Get(shopId, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取商户的商店
+func (s *shopRepo) GetShopsOfMerchant(mchId int32) []shop.Shop {
+	list := []shop.Shop{}
+	s.Table(shop.Shop{}).Select(&list, "vendor_id=?", mchId)
+	return list
+}
+
+// 删除线上商店
+func (s *shopRepo) DeleteOnlineShop(mchId, shopId int32) error {
+	if s.Table(shop.Shop{}).Delete("vendor_id=? AND id=?", mchId, shopId) > 0 {
+		s.Table(shop.OnlineShop{}).DeleteByPk(shopId)
+	}
+	return nil
+}
+
+// 删除线下门店
+func (s *shopRepo) DeleteOfflineShop(mchId, shopId int32) error {
+	if s.Table(shop.Shop{}).Delete("vendor_id=? AND id=?", mchId, shopId) > 0 {
+		s.Table(shop.OfflineShop{}).DeleteByPk(shopId)
+	}
+	return nil
+}
+
+// 保存线上商店
+func (s *shopRepo) SaveOnlineShop(v *shop.OnlineShop, create bool) error {
+	_, err := s.Table(v).Save(v)
+	return err
+}
+
+// 保存线下商店
+func (s *shopRepo) SaveOfflineShop(v *shop.OfflineShop, create bool) error {
+	_, err := s.Table(v).Save(v)
+	return err
+}
@@ -0,0 +1,163 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : storage.go
+ * author : jarryliu
+ * date : 2026-10-20 09:40
+ * description : 内存存储,值使用gob编码保存,取出的值为副本
+ * history :
+ */
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"github.com/jsix/gof/storage"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ storage.Interface = new(memoryStorage)
+
+var ErrNoSuchKey = errors.New("no such key")
+
+type storageItem struct {
+	data    []byte
+	expires int64
+}
+
+type memoryStorage struct {
+	mux  sync.RWMutex
+	data map[string]*storageItem
+}
+
+// 创建内存存储
+func NewStorage() storage.Interface {
+	return &memoryStorage{data: map[string]*storageItem{}}
+}
+
+func (m *memoryStorage) Driver() string {
+	return "memory"
+}
+
+func (m *memoryStorage) Source() interface{} {
+	return m.data
+}
+
+func (m *memoryStorage) Exists(key string) bool {
+	_, ok := m.get(key)
+	return ok
+}
+
+func (m *memoryStorage) Set(key string, v interface{}) error {
+	return m.SetExpire(key, v, 0)
+}
+
+func (m *memoryStorage) SetExpire(key string, v interface{}, seconds int64) error {
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	it := &storageItem{data: buf.Bytes()}
+	if seconds > 0 {
+		it.expires = time.Now().Unix() + seconds
+	}
+	m.mux.Lock()
+	m.data[key] = it
+	m.mux.Unlock()
+	return nil
+}
+
+func (m *memoryStorage) get(key string) ([]byte, bool) {
+	m.mux.RLock()
+	it, ok := m.data[key]
+	m.mux.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	if it.expires > 0 && it.expires < time.Now().Unix() {
+		m.Del(key)
+		return nil, false
+	}
+	return it.data, true
+}
+
+func (m *memoryStorage) Get(key string, dst interface{}) error {
+	data, ok := m.get(key)
+	if !ok {
+		return ErrNoSuchKey
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dst)
+}
+
+func (m *memoryStorage) GetBool(key string) (b bool, err error) {
+	err = m.Get(key, &b)
+	return b, err
+}
+
+func (m *memoryStorage) GetInt(key string) (i int, err error) {
+	err = m.Get(key, &i)
+	return i, err
+}
+
+func (m *memoryStorage) GetInt64(key string) (i int64, err error) {
+	err = m.Get(key, &i)
+	return i, err
+}
+
+func (m *memoryStorage) GetString(key string) (s string, err error) {
+	err = m.Get(key, &s)
+	return s, err
+}
+
+func (m *memoryStorage) GetFloat64(key string) (f float64, err error) {
+	err = m.Get(key, &f)
+	return f, err
+}
+
+func (m *memoryStorage) GetBytes(key string) (b []byte, err error) {
+	err = m.Get(key, &b)
+	return b, err
+}
+
+func (m *memoryStorage) GetRaw(key string) (interface{}, error) {
+	data, ok := m.get(key)
+	if !ok {
+		return nil, ErrNoSuchKey
+	}
+	return data, nil
+}
+
+func (m *memoryStorage) Del(key string) {
+	m.mux.Lock()
+	delete(m.data, key)
+	m.mux.Unlock()
+}
+
+func (m *memoryStorage) DelWith(prefix string) (int, error) {
+	prefix = strings.TrimSuffix(prefix, "*")
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	n := 0
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.data, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryStorage) RWJson(key string, dst interface{}, src func() interface{}, second int64) error {
+	var data []byte
+	if m.Get(key, &data) != nil {
+		var err error
+		if data, err = json.Marshal(src()); err != nil {
+			return err
+		}
+		m.SetExpire(key, data, second)
+	}
+	return json.Unmarshal(data, dst)
+}
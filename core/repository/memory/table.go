@@ -0,0 +1,456 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : table.go
+ * author : jarryliu
+ * date : 2026-10-20 09:10
+ * description : 内存表,按实体的db标签保存实体的副本,
+ *   支持简单的查询条件,如: "member_id=? AND state<>?"
+ * history :
+ */
+package memory
+
+import (
+	"fmt"
+	"go2o/core/infrastructure/domain"
+	"go2o/core/infrastructure/tx"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 内存表
+type Table struct {
+	mux  sync.RWMutex
+	typ  reflect.Type
+	pk   int
+	auto bool
+	// 版本列,无版本列时为-1
+	version int
+	cols    map[string]int
+	// 非数据列的字段,保存时不保存
+	skip []int
+	rows map[string]reflect.Value
+	keys []string
+	seq  int64
+}
+
+// 创建内存表,entity为实体或实体指针。无主键的实体只能新增,如:日志
+func NewTable(entity interface{}) *Table {
+	t := indirect(reflect.TypeOf(entity))
+	tb := &Table{
+		typ:     t,
+		pk:      -1,
+		version: -1,
+		cols:    map[string]int{},
+		rows:    map[string]reflect.Value{},
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		col := f.Tag.Get("db")
+		if col == "" || col == "-" {
+			tb.skip = append(tb.skip, i)
+			continue
+		}
+		tb.cols[col] = i
+		if col == tx.VersionColumn {
+			tb.version = i
+		}
+		if isTrue(f.Tag.Get("pk")) {
+			tb.pk = i
+			tb.auto = isTrue(f.Tag.Get("auto"))
+		}
+	}
+	return tb
+}
+
+// 保存实体,自增主键为零值时新增并回写主键,返回主键。
+// 实体包含版本列时,版本不一致返回冲突错误,与数据库仓储一致
+func (t *Table) Save(entity interface{}) (int64, error) {
+	v := t.valueOf(entity)
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if t.pk == -1 {
+		t.seq++
+		k := "#" + strconv.FormatInt(t.seq, 10)
+		t.keys = append(t.keys, k)
+		t.rows[k] = t.copyOf(v)
+		return 0, nil
+	}
+	pkv := v.Field(t.pk)
+	if t.auto && isZero(pkv) {
+		t.seq++
+		setInt(pkv, t.seq)
+	} else if n := intOf(pkv); n > t.seq {
+		t.seq = n
+	}
+	k := keyOf(pkv)
+	old, ok := t.rows[k]
+	if !ok {
+		t.keys = append(t.keys, k)
+	} else if t.version != -1 {
+		ver := v.Field(t.version)
+		if intOf(old.Field(t.version)) != intOf(ver) {
+			return 0, &domain.ConflictError{Table: t.typ.Name(),
+				Id: pkv.Interface(), Version: intOf(ver)}
+		}
+		setInt(ver, intOf(ver)+1)
+	}
+	t.rows[k] = t.copyOf(v)
+	return intOf(pkv), nil
+}
+
+// 复制保存的实体,非数据列不保存
+func (t *Table) copyOf(v reflect.Value) reflect.Value {
+	r := clone(v)
+	for _, i := range t.skip {
+		r.Field(i).Set(reflect.Zero(r.Field(i).Type()))
+	}
+	return r
+}
+
+// 根据主键获取实体,并复制到dst
+func (t *Table) Get(pk interface{}, dst interface{}) bool {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	r, ok := t.rows[keyOf(reflect.ValueOf(pk))]
+	if ok {
+		t.valueOf(dst).Set(r)
+	}
+	return ok
+}
+
+// 获取第一个符合条件的实体,并复制到dst
+func (t *Table) GetBy(dst interface{}, where string, args ...interface{}) bool {
+	arr := t.find(where, args, 1)
+	if len(arr) > 0 {
+		t.valueOf(dst).Set(arr[0])
+		return true
+	}
+	return false
+}
+
+// 查询符合条件的实体,dst为实体或实体指针的切片的指针
+func (t *Table) Select(dst interface{}, where string, args ...interface{}) {
+	sv := reflect.ValueOf(dst).Elem()
+	isPtr := sv.Type().Elem().Kind() == reflect.Ptr
+	for _, r := range t.find(where, args, -1) {
+		if isPtr {
+			p := reflect.New(t.typ)
+			p.Elem().Set(r)
+			sv.Set(reflect.Append(sv, p))
+		} else {
+			sv.Set(reflect.Append(sv, r))
+		}
+	}
+}
+
+// 统计符合条件的实体数量
+func (t *Table) Count(where string, args ...interface{}) int {
+	return len(t.find(where, args, -1))
+}
+
+// 根据主键删除实体
+func (t *Table) DeleteByPk(pk interface{}) bool {
+	return t.remove(keyOf(reflect.ValueOf(pk)))
+}
+
+// 删除符合条件的实体,返回删除的数量
+func (t *Table) Delete(where string, args ...interface{}) int {
+	arr := t.find(where, args, -1)
+	for _, r := range arr {
+		t.remove(keyOf(r.Field(t.pk)))
+	}
+	return len(arr)
+}
+
+func (t *Table) remove(k string) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if _, ok := t.rows[k]; !ok {
+		return false
+	}
+	delete(t.rows, k)
+	for i, v := range t.keys {
+		if v == k {
+			t.keys = append(t.keys[:i], t.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// 按保存的顺序查询,limit小于0时不限制数量
+func (t *Table) find(where string, args []interface{}, limit int) []reflect.Value {
+	where, order := t.parseOrder(where)
+	conds := t.parse(where, args)
+	t.mux.RLock()
+	var arr []reflect.Value
+	for _, k := range t.keys {
+		r := t.rows[k]
+		if match(r, conds) {
+			arr = append(arr, clone(r))
+		}
+	}
+	t.mux.RUnlock()
+	if order != nil {
+		sort.Stable(&byField{arr: arr, order: order})
+	}
+	if limit > 0 && len(arr) > limit {
+		arr = arr[:limit]
+	}
+	return arr
+}
+
+func (t *Table) valueOf(entity interface{}) reflect.Value {
+	v := reflect.ValueOf(entity)
+	for v.Kind() == reflect.Ptr {
+		if v.Elem().Kind() != reflect.Ptr {
+			break
+		}
+		if v.Elem().IsNil() {
+			v.Elem().Set(reflect.New(t.typ))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Ptr || v.Elem().Type() != t.typ {
+		panic(fmt.Sprintf("memory: entity must be a pointer to %s", t.typ.Name()))
+	}
+	return v.Elem()
+}
+
+// 查询条件
+type cond struct {
+	field int
+	op    string
+	value interface{}
+	in    []interface{}
+}
+
+var (
+	condRegex = regexp.MustCompile(`(?i)^([\w.]+)\s*(=|<>|!=|>=|<=|>|<|\s+in\s*)\s*(.+)$`)
+	andRegex  = regexp.MustCompile(`(?i)\s+and\s+`)
+)
+
+// 排序
+type sortOrder struct {
+	field int
+	desc  bool
+}
+
+// 解析排序,仅按第一个排序列排序
+func (t *Table) parseOrder(where string) (string, *sortOrder) {
+	i := strings.Index(strings.ToUpper(where), "ORDER BY ")
+	if i == -1 {
+		return where, nil
+	}
+	s := where[i+len("ORDER BY "):]
+	if j := strings.Index(s, ","); j != -1 {
+		s = s[:j]
+	}
+	arr := strings.Fields(s)
+	col := arr[0]
+	if j := strings.LastIndex(col, "."); j != -1 {
+		col = col[j+1:]
+	}
+	fi, ok := t.cols[col]
+	if !ok {
+		panic(fmt.Sprintf("memory: no such column %s in %s", col, t.typ.Name()))
+	}
+	return where[:i], &sortOrder{
+		field: fi,
+		desc:  len(arr) > 1 && strings.ToUpper(arr[1]) == "DESC",
+	}
+}
+
+// 解析查询条件,仅支持以AND连接的比较和IN,不支持的条件直接panic
+func (t *Table) parse(where string, args []interface{}) []*cond {
+	where = strings.TrimSpace(where)
+	if where == "" || where == "1=1" {
+		return nil
+	}
+	var conds []*cond
+	argIndex := 0
+	for _, p := range andRegex.Split(where, -1) {
+		p = strings.Trim(strings.TrimSpace(p), "()")
+		m := condRegex.FindStringSubmatch(strings.TrimSpace(p))
+		if m == nil {
+			panic(fmt.Sprintf("memory: unsupported condition %q", p))
+		}
+		col := m[1]
+		if i := strings.LastIndex(col, "."); i != -1 {
+			col = col[i+1:]
+		}
+		fi, ok := t.cols[col]
+		if !ok {
+			panic(fmt.Sprintf("memory: no such column %s in %s", col, t.typ.Name()))
+		}
+		c := &cond{field: fi, op: strings.ToUpper(strings.TrimSpace(m[2]))}
+		if c.op == "!=" {
+			c.op = "<>"
+		}
+		expr := strings.Trim(strings.TrimSpace(m[3]), "()")
+		if c.op == "IN" {
+			for _, s := range strings.Split(expr, ",") {
+				if s = strings.TrimSpace(s); s == "?" {
+					c.in = append(c.in, args[argIndex])
+					argIndex++
+				} else {
+					c.in = append(c.in, literal(s))
+				}
+			}
+		} else if expr == "?" {
+			c.value = args[argIndex]
+			argIndex++
+		} else {
+			c.value = literal(expr)
+		}
+		conds = append(conds, c)
+	}
+	return conds
+}
+
+// 解析字面量
+func literal(s string) interface{} {
+	if strings.HasPrefix(s, "'") {
+		return strings.Trim(s, "'")
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	panic(fmt.Sprintf("memory: unsupported literal %s", s))
+}
+
+func match(r reflect.Value, conds []*cond) bool {
+	for _, c := range conds {
+		fv := r.Field(c.field).Interface()
+		if c.op == "IN" {
+			in := false
+			for _, v := range c.in {
+				if compare(fv, v) == 0 {
+					in = true
+					break
+				}
+			}
+			if !in {
+				return false
+			}
+			continue
+		}
+		n := compare(fv, c.value)
+		ok := false
+		switch c.op {
+		case "=":
+			ok = n == 0
+		case "<>":
+			ok = n != 0
+		case ">":
+			ok = n > 0
+		case ">=":
+			ok = n >= 0
+		case "<":
+			ok = n < 0
+		case "<=":
+			ok = n <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// 比较两个值,数值按数值比较,其他按字符串比较
+func compare(a, b interface{}) int {
+	fa, ok1 := toFloat(a)
+	fb, ok2 := toFloat(b)
+	if ok1 && ok2 {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// 复制实体
+func clone(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+func isTrue(s string) bool {
+	return s == "yes" || s == "true"
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isZero(v reflect.Value) bool {
+	return v.Interface() == reflect.Zero(v.Type()).Interface()
+}
+
+func keyOf(v reflect.Value) string {
+	if f, ok := toFloat(v.Interface()); ok {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func intOf(v reflect.Value) int64 {
+	f, _ := toFloat(v.Interface())
+	return int64(f)
+}
+
+func setInt(v reflect.Value, n int64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(n))
+	}
+}
+
+type byField struct {
+	arr   []reflect.Value
+	order *sortOrder
+}
+
+func (b *byField) Len() int      { return len(b.arr) }
+func (b *byField) Swap(i, j int) { b.arr[i], b.arr[j] = b.arr[j], b.arr[i] }
+func (b *byField) Less(i, j int) bool {
+	n := compare(b.arr[i].Field(b.order.field).Interface(),
+		b.arr[j].Field(b.order.field).Interface())
+	if b.order.desc {
+		return n > 0
+	}
+	return n < 0
+}
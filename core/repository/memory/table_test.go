@@ -0,0 +1,76 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : table_test.go
+ * author : jarryliu
+ * date : 2026-10-20 14:40
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/infrastructure/domain"
+	"testing"
+)
+
+type testEntity struct {
+	Id      int64  `db:"id" pk:"yes" auto:"yes"`
+	Name    string `db:"name"`
+	State   int32  `db:"state"`
+	Version int64  `db:"version"`
+	Items   []int  `db:"-"`
+}
+
+func TestTableSaveAndSelect(t *testing.T) {
+	tb := NewTable(testEntity{})
+	for i, name := range []string{"a", "b", "c"} {
+		e := &testEntity{Name: name, State: int32(i), Items: []int{i}}
+		id, err := tb.Save(e)
+		if err != nil || id != int64(i+1) || e.Id != id {
+			t.Fatalf("保存失败: id=%d err=%v", id, err)
+		}
+	}
+	e := &testEntity{}
+	if !tb.Get(2, e) || e.Name != "b" || e.Items != nil {
+		t.Fatalf("获取实体不正确: %#v", e)
+	}
+	list := []*testEntity{}
+	tb.Select(&list, "state>=? AND name<>'c' ORDER BY id DESC", 0)
+	if len(list) != 2 || list[0].Name != "b" {
+		t.Fatalf("查询结果不正确: %d", len(list))
+	}
+	if n := tb.Count("name IN('a','c')"); n != 2 {
+		t.Fatalf("统计数量不正确: %d", n)
+	}
+	if n := tb.Delete("state=?", 0); n != 1 || tb.Get(1, e) {
+		t.Fatal("删除失败")
+	}
+}
+
+func TestTableVersionConflict(t *testing.T) {
+	tb := NewTable(testEntity{})
+	e := &testEntity{Name: "a"}
+	tb.Save(e)
+	stale := &testEntity{}
+	tb.Get(e.Id, stale)
+	e.State = 1
+	if _, err := tb.Save(e); err != nil || e.Version != 1 {
+		t.Fatalf("更新失败: version=%d err=%v", e.Version, err)
+	}
+	stale.State = 2
+	if _, err := tb.Save(stale); !domain.IsConflict(err) {
+		t.Fatal("未检测到版本冲突:", err)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	s := NewStorage()
+	s.Set("go2o:test:1", 10)
+	if i, err := s.GetInt("go2o:test:1"); err != nil || i != 10 {
+		t.Fatalf("读取失败: %d %v", i, err)
+	}
+	s.Set("go2o:test:2", "str")
+	if n, _ := s.DelWith("go2o:test:"); n != 2 || s.Exists("go2o:test:1") {
+		t.Fatal("按前缀删除失败")
+	}
+}
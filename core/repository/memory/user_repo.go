@@ -0,0 +1,54 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : user_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 12:40
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/merchant/user"
+)
+
+var _ user.IUserRepo = new(userRepo)
+
+type userRepo struct {
+	*DB
+}
+
+func NewUserRepo(d *DB) user.IUserRepo {
+	return &userRepo{DB: d}
+}
+
+// 保存角色
+func (u *userRepo) SaveRole(v *user.RoleValue) (int32, error) {
+	return i32(u.Table(v).Save(v))
+}
+
+// 保存人员
+func (u *userRepo) SavePerson(v *user.PersonValue) (int32, error) {
+	return i32(u.Table(v).Save(v))
+}
+
+// 保存凭据
+func (u *userRepo) SaveCredential(v *user.CredentialValue) (int32, error) {
+	return i32(u.Table(v).Save(v))
+}
+
+// 获取人员
+func (u *userRepo) GetPersonValue(id int32) *user.PersonValue {
+	e := &user.PersonValue{}
+	if u.Table(e).Get(id, e) {
+		return e
+	}
+	return nil
+}
+
+// 获取配送人员
+func (u *userRepo) GetDeliveryStaffPersons(mchId int32) []*user.PersonValue {
+	list := []*user.PersonValue{}
+	u.Table(user.PersonValue{}).Select(&list, "")
+	return list
+}
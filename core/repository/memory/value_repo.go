@@ -0,0 +1,307 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : value_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 10:50
+ * description : 配置保存在内存中,默认值与数据库仓储一致
+ * history :
+ */
+package memory
+
+import (
+	"errors"
+	"github.com/jsix/gof/util"
+	"go2o/core/domain/interface/valueobject"
+	"go2o/core/repository"
+	"strings"
+	"sync"
+)
+
+var _ valueobject.IValueRepo = new(valueRepo)
+
+type valueRepo struct {
+	*DB
+	mux         sync.RWMutex
+	kv          map[string]string
+	wxConf      valueobject.WxApiConfig
+	rpConf      valueobject.RegisterPerm
+	numConf     valueobject.GlobNumberConf
+	pmConf      valueobject.PlatformConf
+	registry    valueobject.Registry
+	tplConf     valueobject.TemplateConf
+	moAppConf   valueobject.MoAppConf
+	mchSaleConf valueobject.GlobMchSaleConf
+	smsConf     valueobject.SmsApiSet
+}
+
+func NewValueRepo(d *DB) valueobject.IValueRepo {
+	r := repository.DefaultRegistry
+	r.RegistryData = map[string]string{}
+	for k, v := range repository.DefaultRegistry.RegistryData {
+		r.RegistryData[k] = v
+	}
+	return &valueRepo{
+		DB:          d,
+		kv:          map[string]string{},
+		rpConf:      repository.DefaultRegisterPerm,
+		numConf:     repository.DefaultGlobNumberConf,
+		pmConf:      repository.DefaultPlatformConf,
+		registry:    r,
+		tplConf:     repository.DefaultTemplateConf,
+		moAppConf:   repository.DefaultMoAppConf,
+		mchSaleConf: repository.DefaultGlobMchSaleConf,
+		smsConf: valueobject.SmsApiSet{
+			valueobject.SmsHttp:   {Default: true},
+			valueobject.SmsAli:    {},
+			valueobject.Sms253Com: {},
+		},
+	}
+}
+
+// 根据键获取值
+func (v *valueRepo) GetValue(key string) string {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+	return v.kv[key]
+}
+
+// 根据前缀获取值
+func (v *valueRepo) GetValues(prefix string) map[string]string {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+	mp := map[string]string{}
+	for k, s := range v.kv {
+		if strings.HasPrefix(k, prefix) {
+			mp[k] = s
+		}
+	}
+	return mp
+}
+
+// 设置键值
+func (v *valueRepo) SetValue(key string, value interface{}) error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	v.kv[key] = util.Str(value)
+	return nil
+}
+
+// 删除值
+func (v *valueRepo) DeleteValue(key string) error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	delete(v.kv, key)
+	return nil
+}
+
+// 获取微信接口配置
+func (v *valueRepo) GetWxApiConfig() valueobject.WxApiConfig {
+	return v.wxConf
+}
+
+// 保存微信接口配置
+func (v *valueRepo) SaveWxApiConfig(c *valueobject.WxApiConfig) error {
+	if c == nil {
+		return errors.New("nil value")
+	}
+	v.wxConf = *c
+	return nil
+}
+
+// 获取注册权限
+func (v *valueRepo) GetRegisterPerm() valueobject.RegisterPerm {
+	return v.rpConf
+}
+
+// 保存注册权限
+func (v *valueRepo) SaveRegisterPerm(c *valueobject.RegisterPerm) error {
+	if c != nil {
+		// 如果要验证手机，则必须开启填写手机
+		if c.MustBindPhone {
+			c.NeedPhone = true
+		}
+		v.rpConf = *c
+	}
+	return nil
+}
+
+// 获取全局系统销售设置
+func (v *valueRepo) GetGlobNumberConf() valueobject.GlobNumberConf {
+	return v.numConf
+}
+
+// 保存全局系统销售设置
+func (v *valueRepo) SaveGlobNumberConf(c *valueobject.GlobNumberConf) error {
+	if c != nil {
+		v.numConf = *c
+	}
+	return nil
+}
+
+// 获取平台设置
+func (v *valueRepo) GetPlatformConf() valueobject.PlatformConf {
+	return v.pmConf
+}
+
+// 保存平台设置
+func (v *valueRepo) SavePlatformConf(c *valueobject.PlatformConf) error {
+	if c != nil {
+		v.pmConf = *c
+	}
+	return nil
+}
+
+// 获取数据存储
+func (v *valueRepo) GetRegistry() valueobject.Registry {
+	return v.registry
+}
+
+// 保存数据存储
+func (v *valueRepo) SaveRegistry(r *valueobject.Registry) error {
+	if r != nil {
+		v.registry = *r
+	}
+	return nil
+}
+
+// 根据键获取数据值
+func (v *valueRepo) GetsRegistry(keys []string) []string {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+	arr := make([]string, len(keys))
+	for i, k := range keys {
+		if s, ok := v.registry.RegistryData[k]; ok {
+			arr[i] = s
+		} else {
+			arr[i] = "no value in registry"
+		}
+	}
+	return arr
+}
+
+// 根据键获取数据值
+func (v *valueRepo) GetsRegistryMap(keys []string) map[string]string {
+	arr := v.GetsRegistry(keys)
+	mp := make(map[string]string, len(keys))
+	for i, k := range keys {
+		mp[k] = arr[i]
+	}
+	return mp
+}
+
+// 保存数据值
+func (v *valueRepo) SavesRegistry(values map[string]string) error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	for k, s := range values {
+		v.registry.RegistryData[k] = s
+	}
+	return nil
+}
+
+// 获取模板配置
+func (v *valueRepo) GetTemplateConf() valueobject.TemplateConf {
+	return v.tplConf
+}
+
+// 保存模板配置
+func (v *valueRepo) SaveTemplateConf(c *valueobject.TemplateConf) error {
+	if c != nil {
+		v.tplConf = *c
+	}
+	return nil
+}
+
+// 获取移动应用设置
+func (v *valueRepo) GetMoAppConf() valueobject.MoAppConf {
+	return v.moAppConf
+}
+
+// 保存移动应用设置
+func (v *valueRepo) SaveMoAppConf(c *valueobject.MoAppConf) error {
+	if c != nil {
+		v.moAppConf = *c
+	}
+	return nil
+}
+
+// 获取全局商户销售设置
+func (v *valueRepo) GetGlobMchSaleConf() valueobject.GlobMchSaleConf {
+	return v.mchSaleConf
+}
+
+// 保存全局商户销售设置
+func (v *valueRepo) SaveGlobMchSaleConf(c *valueobject.GlobMchSaleConf) error {
+	if c != nil {
+		v.mchSaleConf = *c
+	}
+	return nil
+}
+
+// 获取短信设置
+func (v *valueRepo) GetSmsApiSet() valueobject.SmsApiSet {
+	return v.smsConf
+}
+
+// 保存短信API
+func (v *valueRepo) SaveSmsApiPerm(provider int, s *valueobject.SmsApiPerm) error {
+	if _, ok := v.smsConf[provider]; !ok {
+		return errors.New("系统不支持的短信接口")
+	}
+	if s.Default {
+		for _, p := range v.smsConf {
+			p.Default = false
+		}
+	}
+	v.smsConf[provider] = s
+	return nil
+}
+
+// 获取默认的短信API
+func (v *valueRepo) GetDefaultSmsApiPerm() (int, *valueobject.SmsApiPerm) {
+	for i, p := range v.smsConf {
+		if p.Default {
+			return i, p
+		}
+	}
+	panic(errors.New("至少为系统设置一个短信接口"))
+}
+
+// 获取下级区域
+func (v *valueRepo) GetChildAreas(id int32) []*valueobject.Area {
+	list := []*valueobject.Area{}
+	v.Table(valueobject.Area{}).Select(&list, "code <> 0 AND parent=?", id)
+	return list
+}
+
+// 获取区域名称
+func (v *valueRepo) GetAreaName(id int32) string {
+	e := &valueobject.Area{}
+	if id > 0 && v.Table(e).GetBy(e, "code=?", id) {
+		if e.Name == "市辖区" || e.Name == "市辖县" || e.Name == "县" {
+			return ""
+		}
+		return strings.TrimSpace(e.Name)
+	}
+	return ""
+}
+
+// 获取地区名称
+func (v *valueRepo) GetAreaNames(id []int32) []string {
+	arr := make([]string, len(id))
+	for i, c := range id {
+		arr[i] = v.GetAreaName(c)
+	}
+	return arr
+}
+
+// 获取省市区字符串
+func (v *valueRepo) GetAreaString(province, city, district int32) string {
+	names := v.GetAreaNames([]int32{province, city, district})
+	return strings.Join(names, " ")
+}
+
+// 获取省市区字符串
+func (v *valueRepo) AreaString(province, city, district int32, detail string) string {
+	return strings.Join(v.GetAreaNames([]int32{province, city, district}), "") + detail
+}
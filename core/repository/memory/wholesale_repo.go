@@ -0,0 +1,81 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : wholesale_repo.go
+ * author : jarryliu
+ * date : 2026-10-20 12:35
+ * description :
+ * history :
+ */
+package memory
+
+import (
+	"go2o/core/domain/interface/item"
+	"go2o/core/domain/interface/merchant/wholesaler"
+)
+
+var _ wholesaler.IWholesaleRepo = new(wholesaleRepo)
+
+type wholesaleRepo struct {
+	*DB
+}
+
+func NewWholesaleRepo(d *DB) wholesaler.IWholesaleRepo {
+	return &wholesaleRepo{DB: d}
+}
+
+// Get WsWholesaler
+func (w *wholesaleRepo) GetWsWholesaler(primary interface{}) *wholesaler.WsWholesaler {
+	e := &wholesaler.WsWholesaler{}
+	if w.Table(e).Get(primary, e) {
+		return e
+	}
+	return nil
+}
+
+// Save WsWholesaler
+func (w *wholesaleRepo) SaveWsWholesaler(v *wholesaler.WsWholesaler, create bool) (int, error) {
+	return i(w.Table(v).Save(v))
+}
+
+// 同步商品,删除已不存在的批发商品
+func (w *wholesaleRepo) SyncItems(vendorId int32, shelve, review int32) (add int, del int) {
+	list := []*item.WsItem{}
+	w.Table(item.WsItem{}).Select(&list, "vendor_id=?", vendorId)
+	for _, v := range list {
+		if w.Table(item.GoodsItem{}).Count("id=? AND vendor_id=?", v.ItemId, vendorId) == 0 {
+			w.Table(item.WsItem{}).DeleteByPk(v.ID)
+			del++
+		}
+	}
+	return add, del
+}
+
+// 获取待同步商品
+func (w *wholesaleRepo) GetAwaitSyncItems(vendorId int32) []int {
+	list := []*item.GoodsItem{}
+	w.Table(item.GoodsItem{}).Select(&list, "vendor_id=?", vendorId)
+	arr := []int{}
+	for _, v := range list {
+		if w.Table(item.WsItem{}).Count("vendor_id=? AND item_id=?", vendorId, v.ID) == 0 {
+			arr = append(arr, int(v.ID))
+		}
+	}
+	return arr
+}
+
+// Select WsRebateRate
+func (w *wholesaleRepo) SelectWsRebateRate(where string, v ...interface{}) []*wholesaler.WsRebateRate {
+	list := []*wholesaler.WsRebateRate{}
+	w.Table(wholesaler.WsRebateRate{}).Select(&list, where, v...)
+	return list
+}
+
+// Save WsRebateRate
+func (w *wholesaleRepo) SaveWsRebateRate(v *wholesaler.WsRebateRate) (int, error) {
+	return i(w.Table(v).Save(v))
+}
+
+// Batch Delete WsRebateRate
+func (w *wholesaleRepo) BatchDeleteWsRebateRate(where string, v ...interface{}) (int64, error) {
+	return int64(w.Table(wholesaler.WsRebateRate{}).Delete(where, v...)), nil
+}
@@ -24,7 +24,9 @@ import (
 	"go2o/core/domain/interface/mss"
 	"go2o/core/domain/interface/valueobject"
 	merchantImpl "go2o/core/domain/merchant"
+	"go2o/core/infrastructure/cache"
 	"go2o/core/infrastructure/domain"
+	"go2o/core/infrastructure/uow"
 	"log"
 	"strings"
 	"sync"
@@ -37,6 +39,7 @@ type merchantRepo struct {
 	db.Connector
 	_orm        orm.Orm
 	storage     storage.Interface
+	mchCache    *cache.Cache
 	manager     merchant.IMerchantManager
 	_wsRepo     wholesaler.IWholesaleRepo
 	_itemRepo   item.IGoodsItemRepo
@@ -46,16 +49,19 @@ type merchantRepo struct {
 	_valRepo    valueobject.IValueRepo
 	_memberRepo member.IMemberRepo
 	mux         *sync.RWMutex
+	// 事务提交后执行,未绑定工作单元时为nil
+	afterCommit func(func())
 }
 
 func NewMerchantRepo(c db.Connector, storage storage.Interface,
 	wsRepo wholesaler.IWholesaleRepo, itemRepo item.IGoodsItemRepo,
 	shopRepo shop.IShopRepo, userRepo user.IUserRepo, memberRepo member.IMemberRepo, mssRepo mss.IMssRepo,
 	valRepo valueobject.IValueRepo) merchant.IMerchantRepo {
-	return &merchantRepo{
+	m := &merchantRepo{
 		Connector:   c,
 		_orm:        c.GetOrm(),
 		storage:     storage,
+		mchCache:    cache.New("mch", storage, DefaultCacheSeconds, cache.DefaultLocalSize),
 		_wsRepo:     wsRepo,
 		_itemRepo:   itemRepo,
 		_userRepo:   userRepo,
@@ -65,6 +71,30 @@ func NewMerchantRepo(c db.Connector, storage storage.Interface,
 		_memberRepo: memberRepo,
 		mux:         &sync.RWMutex{},
 	}
+	// 商户变更后失效商户及商户相关的数据
+	m.mchCache.Watch(merchant.Merchant{}, func(v interface{}) []string {
+		id := v.(*merchant.Merchant).ID
+		return []string{fmt.Sprint(id), fmt.Sprintf("%d:*", id)}
+	})
+	// 密钥轮换或撤销后立即失效
+	m.mchCache.Watch(merchant.ApiInfo{}, func(v interface{}) []string {
+		return []string{fmt.Sprintf("api:%d", v.(*merchant.ApiInfo).MerchantId)}
+	})
+	return m
+}
+
+// 绑定到工作单元,账户及余额变动在事务中保存
+func (m *merchantRepo) BindUnitOfWork(u *uow.UnitOfWork) interface{} {
+	c := *m
+	u.Bound(m, &c)
+	c.Connector = u.Connector()
+	c._orm = c.Connector.GetOrm()
+	c.storage = u.Storage(m.storage)
+	c.mchCache = m.mchCache.Bind(u.AfterCommit)
+	c.afterCommit = u.AfterCommit
+	c.manager = nil
+	c._memberRepo = uow.Bind(u, m._memberRepo).(member.IMemberRepo)
+	return &c
 }
 
 // 获取商户管理器
@@ -109,27 +139,13 @@ func (m *merchantRepo) CreateMerchant(v *merchant.Merchant) merchant.IMerchant {
 		m._shopRepo, m._userRepo, m._memberRepo, m._valRepo)
 }
 
-func (m *merchantRepo) cleanCache(mchId int32) {
-	key := m.getMchCacheKey(mchId)
-	m.storage.Del(key)
-	PrefixDel(m.storage, key+":*")
-}
-
-func (m *merchantRepo) getMchCacheKey(mchId int32) string {
-	return fmt.Sprintf("go2o:rep:mch:%d", mchId)
-}
-
 func (m *merchantRepo) GetMerchant(id int32) merchant.IMerchant {
 	e := merchant.Merchant{}
-	key := m.getMchCacheKey(id)
-	err := m.storage.Get(key, &e)
+	err := m.mchCache.Get(fmt.Sprint(id), &e, func() error {
+		return m.Connector.GetOrm().Get(id, &e)
+	})
 	if err != nil {
-		// 获取并缓存到列表中
-		err = m.Connector.GetOrm().Get(id, &e)
-		if err != nil {
-			return nil
-		}
-		m.storage.Set(key, e)
+		return nil
 	}
 	return m.CreateMerchant(&e)
 }
@@ -150,6 +166,67 @@ func (m *merchantRepo) GetAccount(mchId int32) *merchant.Account {
 	return nil
 }
 
+// 根据编号获取余额变动信息
+func (m *merchantRepo) GetBalanceLog(id int32) *merchant.BalanceLog {
+	e := merchant.BalanceLog{}
+	if m.Connector.GetOrm().Get(id, &e) == nil {
+		return &e
+	}
+	return nil
+}
+
+// 根据外部单号获取余额变动信息
+func (m *merchantRepo) GetBalanceLogByOuterNo(outerNo string) *merchant.BalanceLog {
+	e := merchant.BalanceLog{}
+	if m.Connector.GetOrm().GetBy(&e, "outer_no=?", outerNo) == nil {
+		return &e
+	}
+	return nil
+}
+
+// 保存余额变动信息
+func (m *merchantRepo) SaveBalanceLog(v *merchant.BalanceLog) (int32, error) {
+	return orm.I32(orm.Save(m.Connector.GetOrm(), v, int(v.Id)))
+}
+
+// 获取商户申请信息
+func (m *merchantRepo) GetSignUpInfo(id int32) *merchant.MchSignUp {
+	e := merchant.MchSignUp{}
+	if m.Connector.GetOrm().Get(id, &e) == nil {
+		return &e
+	}
+	return nil
+}
+
+// 获取会员申请的商户信息
+func (m *merchantRepo) GetSignUpInfoByMemberId(memberId int64) *merchant.MchSignUp {
+	e := merchant.MchSignUp{}
+	if m.Connector.GetOrm().GetBy(&e, "member_id=?", memberId) == nil {
+		return &e
+	}
+	return nil
+}
+
+// 保存商户申请信息
+func (m *merchantRepo) SaveSignUpInfo(v *merchant.MchSignUp) (int32, error) {
+	return orm.I32(orm.Save(m.Connector.GetOrm(), v, int(v.Id)))
+}
+
+// 删除会员的商户申请信息
+func (m *merchantRepo) DeleteSignUpInfo(memberId int64) error {
+	_, err := m.Connector.GetOrm().Delete(merchant.MchSignUp{}, "member_id=?", memberId)
+	return err
+}
+
+// 获取会员关联的商户
+func (m *merchantRepo) GetMerchantByMemberId(memberId int64) merchant.IMerchant {
+	e := merchant.Merchant{}
+	if m.Connector.GetOrm().GetBy(&e, "member_id=?", memberId) == nil {
+		return m.CreateMerchant(&e)
+	}
+	return nil
+}
+
 // 获取合作商主要的域名主机
 func (m *merchantRepo) GetMerchantMajorHost(mchId int32) string {
 	//todo:
@@ -161,11 +238,8 @@ func (m *merchantRepo) GetMerchantMajorHost(mchId int32) string {
 
 // 保存
 func (m *merchantRepo) SaveMerchant(v *merchant.Merchant) (int32, error) {
-	id, err := orm.I32(orm.Save(m.GetOrm(), v, int(v.ID)))
-	if err == nil {
-		m.cleanCache(id)
-	}
-	return id, err
+	id, err := saveEntity(m.Connector, v, m.afterCommit)
+	return int32(id), err
 }
 
 // 获取商户的编号
@@ -208,13 +282,16 @@ func (m *merchantRepo) SaveMerchantSaleConf(v *merchant.SaleConf) error {
 // 保存API信息
 func (m *merchantRepo) SaveApiInfo(v *merchant.ApiInfo) error {
 	_, err := orm.Save(m.GetOrm(), v, int(v.MerchantId))
-	return err
+	return entityChanged(v, err, m.afterCommit)
 }
 
 // 获取API信息
 func (m *merchantRepo) GetApiInfo(mchId int32) *merchant.ApiInfo {
 	var d *merchant.ApiInfo = new(merchant.ApiInfo)
-	if err := m.GetOrm().Get(mchId, d); err == nil {
+	err := m.mchCache.Get(fmt.Sprintf("api:%d", mchId), d, func() error {
+		return m.GetOrm().Get(mchId, d)
+	})
+	if err == nil {
 		return d
 	}
 	return nil
@@ -406,3 +483,217 @@ func (m *merchantRepo) SaveMchBuyerGroup(v *merchant.MchBuyerGroup) (int, error)
 	}
 	return id, err
 }
+
+// 获取订单类型的时效设置
+func (m *merchantRepo) GetMchOrderTimeout(mchId int32, orderType int32) *merchant.OrderTimeout {
+	e := merchant.OrderTimeout{}
+	err := m._orm.GetBy(&e, "mch_id=? AND order_type=?", mchId, orderType)
+	if err == nil {
+		return &e
+	}
+	if err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:MchOrderTimeout")
+	}
+	return nil
+}
+
+// 获取商户按订单类型的时效设置
+func (m *merchantRepo) SelectMchOrderTimeout(mchId int32) []*merchant.OrderTimeout {
+	list := []*merchant.OrderTimeout{}
+	err := m._orm.Select(&list, "mch_id=? ORDER BY order_type ASC", mchId)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:MchOrderTimeout")
+	}
+	return list
+}
+
+// 保存订单类型的时效设置
+func (m *merchantRepo) SaveMchOrderTimeout(v *merchant.OrderTimeout) (int, error) {
+	id, err := orm.Save(m._orm, v, int(v.ID))
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:MchOrderTimeout")
+	}
+	return id, err
+}
+
+// 删除订单类型的时效设置
+func (m *merchantRepo) DeleteMchOrderTimeout(mchId int32, orderType int32) error {
+	_, err := m._orm.Delete(merchant.OrderTimeout{},
+		"mch_id=? AND order_type=?", mchId, orderType)
+	return err
+}
+
+// 获取商户的佣金规则
+func (m *merchantRepo) GetCommissionRules(mchId int32) []*merchant.CommissionRule {
+	list := []*merchant.CommissionRule{}
+	err := m._orm.Select(&list, "mch_id=? ORDER BY item_id DESC,cat_id DESC", mchId)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:CommissionRule")
+	}
+	return list
+}
+
+// 获取佣金规则
+func (m *merchantRepo) GetCommissionRule(mchId int32, id int32) *merchant.CommissionRule {
+	e := merchant.CommissionRule{}
+	err := m._orm.GetBy(&e, "id=? AND mch_id=?", id, mchId)
+	if err == nil {
+		return &e
+	}
+	if err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:CommissionRule")
+	}
+	return nil
+}
+
+// 保存佣金规则
+func (m *merchantRepo) SaveCommissionRule(v *merchant.CommissionRule) (int32, error) {
+	return orm.I32(orm.Save(m._orm, v, int(v.Id)))
+}
+
+// 删除佣金规则
+func (m *merchantRepo) DeleteCommissionRule(mchId int32, id int32) error {
+	_, err := m._orm.Delete(&merchant.CommissionRule{},
+		"id=? AND mch_id=?", id, mchId)
+	return err
+}
+
+// 获取佣金
+func (m *merchantRepo) GetCommission(id int64) *merchant.Commission {
+	e := merchant.Commission{}
+	err := m._orm.Get(id, &e)
+	if err == nil {
+		return &e
+	}
+	if err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:Commission")
+	}
+	return nil
+}
+
+// 获取订单的佣金,snapshotId为0时返回订单的全部佣金
+func (m *merchantRepo) GetCommissions(orderId int64, snapshotId int64) []*merchant.Commission {
+	list := []*merchant.Commission{}
+	var err error
+	if snapshotId > 0 {
+		err = m._orm.Select(&list, "order_id=? AND snap_id=?", orderId, snapshotId)
+	} else {
+		err = m._orm.Select(&list, "order_id=?", orderId)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:Commission")
+	}
+	return list
+}
+
+// 保存佣金
+func (m *merchantRepo) SaveCommission(v *merchant.Commission) (int64, error) {
+	return orm.I64(orm.Save(m._orm, v, int(v.Id)))
+}
+
+// 获取商户的通知地址
+func (m *merchantRepo) GetWebhooks(mchId int32) []*merchant.Webhook {
+	list := []*merchant.Webhook{}
+	err := m._orm.Select(&list, "mch_id=? ORDER BY id", mchId)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:Webhook")
+	}
+	return list
+}
+
+// 保存通知地址
+func (m *merchantRepo) SaveWebhook(v *merchant.Webhook) (int32, error) {
+	return orm.I32(orm.Save(m._orm, v, int(v.Id)))
+}
+
+// 删除通知地址
+func (m *merchantRepo) DeleteWebhook(mchId int32, id int32) error {
+	_, err := m._orm.Delete(&merchant.Webhook{},
+		"id=? AND mch_id=?", id, mchId)
+	return err
+}
+
+// 获取通知消息
+func (m *merchantRepo) GetWebhookMessage(id int64) *merchant.WebhookMessage {
+	e := merchant.WebhookMessage{}
+	err := m._orm.Get(id, &e)
+	if err == nil {
+		return &e
+	}
+	if err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:WebhookMessage")
+	}
+	return nil
+}
+
+// 保存通知消息
+func (m *merchantRepo) SaveWebhookMessage(v *merchant.WebhookMessage) (int64, error) {
+	return orm.I64(orm.Save(m._orm, v, int(v.Id)))
+}
+
+// 保存通知发送日志
+func (m *merchantRepo) SaveWebhookLog(v *merchant.WebhookLog) (int64, error) {
+	return orm.I64(orm.Save(m._orm, v, int(v.Id)))
+}
+
+// 获取通知消息的发送日志
+func (m *merchantRepo) GetWebhookLogs(msgId int64) []*merchant.WebhookLog {
+	list := []*merchant.WebhookLog{}
+	err := m._orm.Select(&list, "msg_id=? ORDER BY id", msgId)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:WebhookLog")
+	}
+	return list
+}
+
+// 获取接口某日某类别的调用统计
+func (m *merchantRepo) GetApiUsage(mchId int32, date int, class string) *merchant.ApiUsage {
+	e := merchant.ApiUsage{}
+	err := m._orm.GetBy(&e, "mch_id=? AND stat_date=? AND api_class=?",
+		mchId, date, class)
+	if err == nil {
+		return &e
+	}
+	if err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:ApiUsage")
+	}
+	return nil
+}
+
+// 保存接口调用统计
+func (m *merchantRepo) SaveApiUsage(v *merchant.ApiUsage) (int64, error) {
+	return orm.I64(orm.Save(m._orm, v, int(v.Id)))
+}
+
+// 获取接口调用统计
+func (m *merchantRepo) GetApiUsages(mchId int32, begin int, end int) []*merchant.ApiUsage {
+	list := []*merchant.ApiUsage{}
+	err := m._orm.Select(&list, "mch_id=? AND stat_date BETWEEN ? AND ? ORDER BY stat_date,api_class",
+		mchId, begin, end)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:ApiUsage")
+	}
+	return list
+}
+
+// 获取推荐人的佣金报表,mchId为0时统计全部商户
+func (m *merchantRepo) GetCommissionReport(mchId int32, memberId int64,
+	begin int64, end int64) *merchant.CommissionReport {
+	e := &merchant.CommissionReport{MemberId: memberId}
+	where := "member_id=? AND create_time BETWEEN ? AND ?"
+	args := []interface{}{merchant.CommissionFrozen, merchant.CommissionReleased,
+		memberId, begin, end}
+	if mchId > 0 {
+		where += " AND mch_id=?"
+		args = append(args, mchId)
+	}
+	m.Connector.QueryRow(`SELECT COUNT(DISTINCT order_id),
+		IFNULL(SUM(CASE WHEN state=? THEN amount-reverse_amount ELSE 0 END),0),
+		IFNULL(SUM(CASE WHEN state=? THEN amount-reverse_amount ELSE 0 END),0),
+		IFNULL(SUM(reverse_amount),0) FROM mch_commission WHERE `+where,
+		func(r *sql.Row) error {
+			return r.Scan(&e.Orders, &e.FrozenAmount, &e.ReleasedAmount,
+				&e.ReversedAmount)
+		}, args...)
+	return e
+}
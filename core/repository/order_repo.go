@@ -15,9 +15,9 @@ import (
 	"github.com/jsix/gof/db"
 	"github.com/jsix/gof/db/orm"
 	"github.com/jsix/gof/storage"
-	"go2o/core"
 	"go2o/core/domain/interface/cart"
 	"go2o/core/domain/interface/delivery"
+	"go2o/core/domain/interface/events"
 	"go2o/core/domain/interface/express"
 	"go2o/core/domain/interface/item"
 	"go2o/core/domain/interface/member"
@@ -31,7 +31,9 @@ import (
 	orderImpl "go2o/core/domain/order"
 	"go2o/core/dto"
 	"go2o/core/infrastructure/domain"
-	"go2o/core/variable"
+	"go2o/core/infrastructure/eventbus"
+	"go2o/core/infrastructure/tx"
+	"go2o/core/infrastructure/uow"
 	"log"
 )
 
@@ -85,6 +87,23 @@ func (o *OrderRepImpl) SetPaymentRepo(payRepo payment.IPaymentRepo) {
 	o._payRepo = payRepo
 }
 
+// 绑定到工作单元,依赖的仓储一并绑定
+func (o *OrderRepImpl) BindUnitOfWork(u *uow.UnitOfWork) interface{} {
+	c := *o
+	u.Bound(o, &c)
+	c.Connector = u.Connector()
+	c._orm = c.Connector.GetOrm()
+	c.Storage = u.Storage(o.Storage)
+	c._manager = nil
+	c._goodsRepo = uow.Bind(u, o._goodsRepo).(item.IGoodsItemRepo)
+	c._promRepo = uow.Bind(u, o._promRepo).(promotion.IPromotionRepo)
+	c._memberRepo = uow.Bind(u, o._memberRepo).(member.IMemberRepo)
+	c._mchRepo = uow.Bind(u, o._mchRepo).(merchant.IMerchantRepo)
+	c._cartRepo = uow.Bind(u, o._cartRepo).(cart.ICartRepo)
+	c._payRepo = uow.Bind(u, o._payRepo).(payment.IPaymentRepo)
+	return &c
+}
+
 func (o *OrderRepImpl) Manager() order.IOrderManager {
 	if o._productRepo == nil {
 		panic("saleRepo uninitialize!")
@@ -292,18 +311,19 @@ func (o *OrderRepImpl) GetOrder(where string, arg ...interface{}) *order.Order {
 	return nil
 }
 
-// 加入到订单通知队列,如果为子订单,则带上sub
-func (o *OrderRepImpl) pushOrderQueue(orderNo string, sub bool) {
-	rc := core.GetRedisConn()
-	if sub {
-		content := fmt.Sprintf("sub!%s", orderNo)
-		rc.Do("RPUSH", variable.KvOrderBusinessQueue, content)
-	} else {
-		rc.Do("RPUSH", variable.KvOrderBusinessQueue, orderNo)
+// 订单变更事件
+func (o *OrderRepImpl) orderChanged(orderNo string, sub bool) func() []eventbus.Event {
+	return func() []eventbus.Event {
+		return []eventbus.Event{&events.OrderChanged{OrderNo: orderNo, Sub: sub}}
 	}
-	rc.Close()
+}
 
-	//log.Println("----- order notify ! orderNo:", orderNo, " sub:", sub)
+// 订单延长收货事件
+func (o *OrderRepImpl) receiveExtended(orderNo string, sub bool, hours int32) func() []eventbus.Event {
+	return func() []eventbus.Event {
+		return []eventbus.Event{&events.OrderReceiveExtended{
+			OrderNo: orderNo, Sub: sub, Hours: hours}}
+	}
 }
 
 // Save OrderList
@@ -330,22 +350,36 @@ func (o *OrderRepImpl) SaveOrder(v *order.Order) (int, error) {
 		origin := o.GetOrder("id=?", v.ID)
 		statusIsChanged = origin.State != v.State
 	}
-	// log.Println("--- save order:", v.ID, "; state:",
-	// v.State, ";", statusIsChanged)
-	id, err := o.saveOrder(v)
-	if err == nil {
-		v.ID = int64(id)
-		//如果业务状态已经发生改变,则提交到队列
-		if statusIsChanged {
-			o.pushOrderQueue(v.OrderNo, false)
-		}
+	if !statusIsChanged {
+		return o.saveOrder(v)
 	}
-	return id, err
+	//如果业务状态已经发生改变,则在同一事务中记录事件
+	id, err := saveWithEvents(o.Connector, v,
+		o.orderChanged(v.OrderNo, false))
+	if err != nil {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:OrderList")
+	}
+	return int(id), err
 }
 
-func (o *OrderRepImpl) saveSubOrder(v *order.NormalSubOrder) (int, error) {
-	id, err := orm.Save(o._orm, v, int(v.ID))
-	if err != nil && err != sql.ErrNoRows {
+func (o *OrderRepImpl) saveSubOrder(v *order.NormalSubOrder,
+	evt func() []eventbus.Event) (int, error) {
+	var id int
+	var err error
+	if evt != nil {
+		//如果业务状态已经发生改变,则在同一事务中记录事件
+		var id64 int64
+		id64, err = saveWithEvents(o.Connector, v, evt)
+		id = int(id64)
+	} else {
+		var id64 int64
+		id64, err = tx.SaveBy(o.Connector, v)
+		id = int(id64)
+	}
+	if domain.IsConflict(err) {
+		// 清除缓存,重试时重新加载订单
+		o.Storage.Del(o.getOrderCk(v.ID, true))
+	} else if err != nil && err != sql.ErrNoRows {
 		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:SaleSubOrder")
 	}
 	if err == nil {
@@ -362,21 +396,21 @@ func (o *OrderRepImpl) saveSubOrder(v *order.NormalSubOrder) (int, error) {
 func (o *OrderRepImpl) SaveSubOrder(v *order.NormalSubOrder) (int, error) {
 	// 判断业务状态是否改变
 	statusIsChanged := true
+	extended := false
 	if v.ID <= 0 {
 		statusIsChanged = true
 	} else {
 		origin := o.GetSubOrder(v.ID)
 		statusIsChanged = origin.State != v.State
+		extended = origin.ReceiveExtend == 0 && v.ReceiveExtend > 0
 	}
-	id, err := o.saveSubOrder(v)
-	if err == nil {
-		v.ID = int64(id)
-		//如果业务状态已经发生改变,则提交到队列
-		if statusIsChanged {
-			o.pushOrderQueue(v.OrderNo, true)
-		}
+	if statusIsChanged {
+		return o.saveSubOrder(v, o.orderChanged(v.OrderNo, true))
 	}
-	return id, err
+	if extended {
+		return o.saveSubOrder(v, o.receiveExtended(v.OrderNo, true, v.ReceiveExtend))
+	}
+	return o.saveSubOrder(v, nil)
 }
 
 // Get WholesaleOrder
@@ -394,6 +428,18 @@ func (o *OrderRepImpl) GetWholesaleOrder(where string, v ...interface{}) *order.
 
 // Save WholesaleOrder
 func (o *OrderRepImpl) SaveWholesaleOrder(v *order.WholesaleOrder) (int, error) {
+	// 延长收货时,在同一事务中记录事件
+	if v.ID > 0 && v.ReceiveExtend > 0 {
+		origin := o.GetWholesaleOrder("id=?", v.ID)
+		if origin != nil && origin.ReceiveExtend == 0 {
+			id, err := saveWithEvents(o.Connector, v,
+				o.receiveExtended(v.OrderNo, false, v.ReceiveExtend))
+			if err != nil {
+				log.Println("[ Orm][ Error]:", err.Error(), "; Entity:WholesaleOrder")
+			}
+			return int(id), err
+		}
+	}
 	id, err := orm.Save(o._orm, v, int(v.ID))
 	if err != nil && err != sql.ErrNoRows {
 		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:WholesaleOrder")
@@ -14,13 +14,14 @@ import (
 	"github.com/jsix/gof/db/orm"
 	"github.com/jsix/gof/storage"
 	"github.com/jsix/gof/util"
-	"go2o/core"
+	"go2o/core/domain/interface/events"
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/order"
 	"go2o/core/domain/interface/payment"
 	"go2o/core/domain/interface/valueobject"
 	payImpl "go2o/core/domain/payment"
-	"go2o/core/variable"
+	"go2o/core/infrastructure/eventbus"
+	"go2o/core/infrastructure/uow"
 )
 
 var _ payment.IPaymentRepo = new(paymentRepo)
@@ -45,6 +46,17 @@ func NewPaymentRepo(sto storage.Interface, conn db.Connector, mmRepo member.IMem
 	}
 }
 
+// 绑定到工作单元
+func (p *paymentRepo) BindUnitOfWork(u *uow.UnitOfWork) interface{} {
+	c := *p
+	u.Bound(p, &c)
+	c.Connector = u.Connector()
+	c.Storage = u.Storage(p.Storage)
+	c._memberRepo = uow.Bind(u, p._memberRepo).(member.IMemberRepo)
+	c._orderRepo = uow.Bind(u, p._orderRepo).(order.IOrderRepo)
+	return &c
+}
+
 // 根据订单号获取支付单
 func (p *paymentRepo) GetPaymentBySalesOrderId(orderId int64) payment.IPaymentOrder {
 	e := &payment.PaymentOrder{}
@@ -104,26 +116,25 @@ func (p *paymentRepo) SavePaymentOrder(v *payment.PaymentOrder) (int32, error) {
 	if v.Id > 0 {
 		stat = p.GetPaymentOrderById(v.Id).GetValue().State
 	}
-	id, err := orm.I32(orm.Save(p.GetOrm(), v, int(v.Id)))
+	var id int32
+	var err error
+	// 已经更改过状态,且为已成功,则在同一事务中记录事件
+	if stat != v.State && v.State == payment.StateFinishPayment {
+		var id64 int64
+		id64, err = saveWithEvents(p.Connector, v, func() []eventbus.Event {
+			return []eventbus.Event{&events.PaymentFinished{PaymentOrderId: v.Id}}
+		})
+		id = int32(id64)
+	} else {
+		id, err = orm.I32(orm.Save(p.GetOrm(), v, int(v.Id)))
+	}
 	if err == nil {
 		v.Id = id
 		// 缓存订单
 		p.Storage.SetExpire(p.getPaymentOrderCk(id), *v, DefaultCacheSeconds)
 		// 缓存订单号与订单的关系
 		p.Storage.SetExpire(p.getPaymentOrderCkByNo(v.TradeNo), v.Id, DefaultCacheSeconds*10)
-		// 已经更改过状态,且为已成功,则推送到队列中
-		if stat != v.State && v.State == payment.StateFinishPayment {
-			p.notifyPaymentFinish(v.Id)
-		}
 	}
 	return id, err
 }
 
-// 通知支付单完成
-func (p *paymentRepo) notifyPaymentFinish(paymentOrderId int32) error {
-	rc := core.GetRedisConn()
-	defer rc.Close()
-	_, err := rc.Do("RPUSH", variable.KvPaymentOrderFinishQueue, paymentOrderId)
-	//log.Println("--  推送支付单成功", paymentOrderId)
-	return err
-}
@@ -9,19 +9,22 @@
 package repository
 
 import (
+	"database/sql"
 	"github.com/jsix/gof/db"
 	"github.com/jsix/gof/db/orm"
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/personfinance"
 	pf "go2o/core/domain/personfinance"
+	"log"
 )
 
 var _ personfinance.IPersonFinanceRepository = new(personFinanceRepository)
 
 type personFinanceRepository struct {
-	_db      db.Connector
-	_orm     orm.Orm
-	_accRepo member.IMemberRepo
+	_db          db.Connector
+	_orm         orm.Orm
+	_accRepo     member.IMemberRepo
+	_planManager personfinance.IPlanManager
 }
 
 func NewPersonFinanceRepository(conn db.Connector, mRepo member.IMemberRepo) personfinance.IPersonFinanceRepository {
@@ -81,7 +84,73 @@ func (p *personFinanceRepository) GetRiseLogs(personId int64, date int64, riseTy
 	return list
 }
 
+// 获取指定时间后已确认的日志
+func (p *personFinanceRepository) GetRiseLogsAfter(personId int64, riseType int,
+	begin int64) []*personfinance.RiseLog {
+	list := []*personfinance.RiseLog{}
+	p._orm.Select(&list, "person_id=? AND type=? AND state=? AND log_time>=?",
+		personId, riseType, personfinance.RiseStateOk, begin)
+	return list
+}
+
 // 保存每日收益
 func (p *personFinanceRepository) SaveRiseDayInfo(v *personfinance.RiseDayInfo) (int32, error) {
 	return orm.I32(orm.Save(p._db.GetOrm(), v, int(v.Id)))
 }
+
+// 获取理财计划管理器
+func (p *personFinanceRepository) GetPlanManager() personfinance.IPlanManager {
+	if p._planManager == nil {
+		p._planManager = pf.NewPlanManager(p)
+	}
+	return p._planManager
+}
+
+// 获取全部理财计划
+func (p *personFinanceRepository) GetPlans() []*personfinance.FinancePlan {
+	list := []*personfinance.FinancePlan{}
+	err := p._orm.Select(&list, "1=1 ORDER BY id")
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:FinancePlan")
+	}
+	return list
+}
+
+// 获取理财计划
+func (p *personFinanceRepository) GetPlan(planId int32) *personfinance.FinancePlan {
+	e := personfinance.FinancePlan{}
+	err := p._orm.Get(planId, &e)
+	if err == nil {
+		return &e
+	}
+	if err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:FinancePlan")
+	}
+	return nil
+}
+
+// 保存理财计划
+func (p *personFinanceRepository) SavePlan(v *personfinance.FinancePlan) (int32, error) {
+	return orm.I32(orm.Save(p._orm, v, int(v.Id)))
+}
+
+// 获取理财计划的利率
+func (p *personFinanceRepository) GetPlanRates(planId int32) []*personfinance.PlanRate {
+	list := []*personfinance.PlanRate{}
+	err := p._orm.Select(&list, "plan_id=? ORDER BY effective_date", planId)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("[ Orm][ Error]:", err.Error(), "; Entity:PlanRate")
+	}
+	return list
+}
+
+// 保存理财计划利率
+func (p *personFinanceRepository) SavePlanRate(v *personfinance.PlanRate) (int32, error) {
+	return orm.I32(orm.Save(p._orm, v, int(v.Id)))
+}
+
+// 删除理财计划利率
+func (p *personFinanceRepository) DeletePlanRate(planId int32, id int32) error {
+	_, err := p._orm.Delete(&personfinance.PlanRate{}, "id=? AND plan_id=?", id, planId)
+	return err
+}
@@ -19,6 +19,7 @@ import (
 	"go2o/core/domain/interface/promotion"
 	promImpl "go2o/core/domain/promotion"
 	"go2o/core/infrastructure/log"
+	"go2o/core/infrastructure/uow"
 	"time"
 )
 
@@ -39,6 +40,16 @@ func NewPromotionRepo(c db.Connector, goodsRepo item.IGoodsItemRepo,
 	}
 }
 
+// 绑定到工作单元
+func (p *promotionRepo) BindUnitOfWork(u *uow.UnitOfWork) interface{} {
+	c := *p
+	u.Bound(p, &c)
+	c.Connector = u.Connector()
+	c._memberRepo = uow.Bind(u, p._memberRepo).(member.IMemberRepo)
+	c._goodsRepo = uow.Bind(u, p._goodsRepo).(item.IGoodsItemRepo)
+	return &c
+}
+
 // 获取促销
 func (this *promotionRepo) GetValuePromotion(id int32) *promotion.PromotionInfo {
 	var e promotion.PromotionInfo
@@ -9,9 +9,15 @@
 package repository
 
 import (
+	"database/sql"
+	"github.com/jsix/gof/db"
 	"github.com/jsix/gof/log"
 	"github.com/jsix/gof/storage"
+	"go2o/core/infrastructure/cache"
 	"go2o/core/infrastructure/domain"
+	"go2o/core/infrastructure/eventbus"
+	"go2o/core/infrastructure/outbox"
+	"go2o/core/infrastructure/tx"
 	"sync"
 )
 
@@ -38,3 +44,33 @@ func PrefixDel(sto storage.Interface, prefix string) error {
 	}
 	return err
 }
+
+// 在同一事务中保存实体并记录领域事件,events在保存后调用,可使用新增的编号
+func saveWithEvents(conn db.Connector, v interface{},
+	events func() []eventbus.Event) (int64, error) {
+	var id int64
+	err := tx.Do(conn, func(t *sql.Tx) error {
+		var err error
+		id, err = tx.Save(t, v)
+		if err == nil && events != nil {
+			err = outbox.Append(t, events()...)
+		}
+		return err
+	})
+	return id, err
+}
+
+// 保存实体,保存后使关注该实体的缓存失效。after用于工作单元中提交后再次失效
+func saveEntity(conn db.Connector, v interface{}, after func(func())) (int64, error) {
+	id, err := tx.SaveBy(conn, v)
+	return id, entityChanged(v, err, after)
+}
+
+// 实体已保存或删除,使关注该实体的缓存失效。
+// 冲突时缓存的数据可能已过期,同样失效
+func entityChanged(v interface{}, err error, after func(func())) error {
+	if err == nil || domain.IsConflict(err) {
+		cache.Changed(v, after)
+	}
+	return err
+}
@@ -11,7 +11,6 @@ package repository
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"github.com/jsix/gof/db"
 	"github.com/jsix/gof/db/orm"
@@ -20,6 +19,7 @@ import (
 	"go2o/core/domain/interface/merchant/shop"
 	"go2o/core/domain/interface/valueobject"
 	shopImpl "go2o/core/domain/merchant/shop"
+	"go2o/core/infrastructure/cache"
 	"log"
 )
 
@@ -29,6 +29,7 @@ type shopRepo struct {
 	db.Connector
 	valueRepo valueobject.IValueRepo
 	storage   storage.Interface
+	shopCache *cache.Cache
 }
 
 func (s *shopRepo) ShopCount(vendorId int32, shopType int32) int {
@@ -40,11 +41,19 @@ func (s *shopRepo) ShopCount(vendorId int32, shopType int32) int {
 
 func NewShopRepo(c db.Connector, storage storage.Interface,
 	valueRepo valueobject.IValueRepo) shop.IShopRepo {
-	return &shopRepo{
+	s := &shopRepo{
 		Connector: c,
 		valueRepo: valueRepo,
 		storage:   storage,
+		shopCache: cache.New("shop", storage, DefaultCacheSeconds, cache.DefaultLocalSize),
 	}
+	// 商店变更后失效商店、商店相关的数据及商户的商店列表
+	s.shopCache.Watch(shop.Shop{}, func(v interface{}) []string {
+		e := v.(*shop.Shop)
+		return []string{fmt.Sprint(e.Id), fmt.Sprintf("%d:*", e.Id),
+			fmt.Sprintf("mch%d:*", e.VendorId)}
+	})
+	return s
 }
 
 // 获取商店
@@ -104,7 +113,7 @@ func (s *shopRepo) SaveOfflineShop(v *shop.OfflineShop, create bool) error {
 // 保存API信息
 func (s *shopRepo) SaveApiInfo(v *merchant.ApiInfo) error {
 	_, err := orm.Save(s.GetOrm(), v, int(v.MerchantId))
-	return err
+	return entityChanged(v, err, nil)
 }
 
 // 获取API信息
@@ -117,16 +126,15 @@ func (s *shopRepo) GetApiInfo(mchId int32) *merchant.ApiInfo {
 }
 
 func (s *shopRepo) SaveShop(v *shop.Shop) (int32, error) {
-	id, err := orm.I32(orm.Save(s.GetOrm(), v, int(v.Id)))
-	if err == nil {
-		s.delCache(v.VendorId)
-	}
-	return id, err
+	id, err := saveEntity(s.Connector, v, nil)
+	return int32(id), err
 }
 
 func (s *shopRepo) GetValueShop(shopId int32) *shop.Shop {
 	v := &shop.Shop{}
-	err := s.Connector.GetOrm().Get(shopId, v)
+	err := s.shopCache.Get(fmt.Sprint(shopId), v, func() error {
+		return s.Connector.GetOrm().Get(shopId, v)
+	})
 	if err == nil {
 		return v
 	}
@@ -136,31 +144,15 @@ func (s *shopRepo) GetValueShop(shopId int32) *shop.Shop {
 	return nil
 }
 
-func (s *shopRepo) delCache(mchId int32) {
-	PrefixDel(s.storage, fmt.Sprintf("go2o:rep:shop:%d:*", mchId))
-}
-
-func (s *shopRepo) getShopCacheKey(mchId int32) string {
-	return fmt.Sprintf("go2o:rep:shop:%d:shops", mchId)
-}
-
 func (s *shopRepo) GetShopsOfMerchant(mchId int32) []shop.Shop {
 	shops := []shop.Shop{}
-	key := s.getShopCacheKey(mchId)
-	jsonStr, err := s.storage.GetString(key)
-	if err == nil {
-		err = json.Unmarshal([]byte(jsonStr), &shops)
-	}
-	if err != nil {
-		err = s.Connector.GetOrm().SelectByQuery(&shops,
+	err := s.shopCache.Get(fmt.Sprintf("mch%d:shops", mchId), &shops, func() error {
+		return s.Connector.GetOrm().SelectByQuery(&shops,
 			"SELECT * FROM mch_shop WHERE vendor_id=?", mchId)
-		if err != nil {
-			handleError(err)
-			return nil
-		} else {
-			b, _ := json.Marshal(shops)
-			s.storage.Set(key, string(b))
-		}
+	})
+	if err != nil {
+		handleError(err)
+		return nil
 	}
 	return shops
 }
@@ -168,8 +160,7 @@ func (s *shopRepo) GetShopsOfMerchant(mchId int32) []shop.Shop {
 func (s *shopRepo) deleteShop(mchId, shopId int32) error {
 	_, err := s.Connector.GetOrm().Delete(shop.Shop{},
 		"vendor_id=? AND id=?", mchId, shopId)
-	s.delCache(mchId)
-	return err
+	return entityChanged(&shop.Shop{Id: shopId, VendorId: mchId}, err, nil)
 }
 
 // 删除线上商店
@@ -177,7 +168,6 @@ func (s *shopRepo) DeleteOnlineShop(mchId, shopId int32) error {
 	err := s.deleteShop(mchId, shopId)
 	if err == nil {
 		err = s.Connector.GetOrm().DeleteByPk(shop.OnlineShop{}, shopId)
-		s.delCache(mchId)
 	}
 	return err
 }
@@ -187,7 +177,6 @@ func (s *shopRepo) DeleteOfflineShop(mchId, shopId int32) error {
 	err := s.deleteShop(mchId, shopId)
 	if err == nil {
 		err = s.Connector.GetOrm().DeleteByPk(shop.OfflineShop{}, shopId)
-		s.delCache(mchId)
 	}
 	return err
 }
@@ -114,7 +114,7 @@ var (
 	}
 
 	// 默认注册权限设置
-	defaultRegisterPerm = valueobject.RegisterPerm{
+	DefaultRegisterPerm = valueobject.RegisterPerm{
 		RegisterMode:        member.RegisterModeNormal,
 		NeedPhone:           false,
 		MustBindPhone:       false,
@@ -181,6 +181,18 @@ var (
 		OrderConfirmAfterMinute: 10,
 		// 订单超时自动收货
 		OrderTimeOutReceiveHour: 168, //7天
+		// 买家延长收货的小时数
+		OrderReceiveExtendHour: 72, //3天
+		// 分销佣金冻结天数
+		CommissionFreezeDays: 7,
+		// 售后商户处理时效
+		AsVendorHour: 72,
+		// 售后买家退货时效
+		AsReturnShipHour: 168,
+		// 售后商户收货时效
+		AsReceiveHour: 240,
+		// 售后拒绝后自动申请调解时效
+		AsDeclinedHour: 72,
 	}
 
 	// 默认短信接口设置
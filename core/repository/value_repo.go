@@ -240,7 +240,7 @@ func (vp *valueRepo) SaveWxApiConfig(v *valueobject.WxApiConfig) error {
 func (vp *valueRepo) GetRegisterPerm() valueobject.RegisterPerm {
 	vp.checkReload()
 	if vp._rpConf == nil {
-		v := defaultRegisterPerm
+		v := DefaultRegisterPerm
 		vp._rpConf = &v
 		vp._rpGob.Unmarshal(vp._rpConf)
 	}
@@ -0,0 +1,50 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : client.go
+ * author : jarryliu
+ * date : 2026-10-19 11:20
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// 客户端令牌
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t *tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authKey: bearerType + t.token}, nil
+}
+
+func (t *tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
+
+// 连接gRPC服务,certFile为服务端证书,为空时不使用TLS
+func Dial(addr string, certFile string, token string) (*grpc.ClientConn, error) {
+	var opts []grpc.DialOption
+	if certFile != "" {
+		cred, err := credentials.NewClientTLSFromFile(certFile, "")
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(cred))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(&tokenCredentials{
+			token:  token,
+			secure: certFile != "",
+		}))
+	}
+	return grpc.Dial(addr, opts...)
+}
@@ -0,0 +1,100 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : convert.go
+ * author : jarryliu
+ * date : 2026-10-19 09:20
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"go2o/core/infrastructure/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"reflect"
+)
+
+// 按字段名称复制结构,用于thrift与protobuf对象互转。
+// thrift生成的字段可能带有"_"后缀(如:Result_),复制时忽略后缀
+func copyStruct(dst, src interface{}) {
+	copyValue(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem())
+}
+
+func copyValue(dst, src reflect.Value) {
+	st := src.Type()
+	for i, n := 0, st.NumField(); i < n; i++ {
+		f := st.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		df := dst.FieldByName(name)
+		if !df.IsValid() && name[len(name)-1] == '_' {
+			df = dst.FieldByName(name[:len(name)-1])
+		}
+		if !df.IsValid() {
+			df = dst.FieldByName(name + "_")
+		}
+		if df.IsValid() && df.CanSet() {
+			assignValue(df, src.Field(i))
+		}
+	}
+}
+
+func assignValue(dst, src reflect.Value) {
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if src.Kind() == reflect.Ptr && !src.IsNil() &&
+			dst.Type().Elem().Kind() == reflect.Struct {
+			v := reflect.New(dst.Type().Elem())
+			copyValue(v.Elem(), src.Elem())
+			dst.Set(v)
+		}
+	case reflect.Slice:
+		if src.Kind() == reflect.Slice && !src.IsNil() {
+			arr := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+			for i := 0; i < src.Len(); i++ {
+				assignValue(arr.Index(i), src.Index(i))
+			}
+			dst.Set(arr)
+		}
+	default:
+		if src.Type().ConvertibleTo(dst.Type()) {
+			dst.Set(src.Convert(dst.Type()))
+		}
+	}
+}
+
+// 转换服务返回的对象,对象为空时返回NotFound
+func result(dst, src interface{}, err error) error {
+	if err != nil {
+		return toStatus(err)
+	}
+	if src == nil || reflect.ValueOf(src).IsNil() {
+		return status.Error(codes.NotFound, "not found")
+	}
+	copyStruct(dst, src)
+	return nil
+}
+
+// 将服务的错误转换为gRPC状态
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*domain.DomainError); ok {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	// 并发修改冲突,客户端可重试
+	if domain.IsConflict(err) {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	log.Println("[ Grpc][ Error]:", err.Error())
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,55 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : convert_test.go
+ * author : jarryliu
+ * date : 2026-10-19 11:40
+ * description :
+ * history :
+ */
+package grpc
+
+import "testing"
+
+type thriftItem struct {
+	ID   int64
+	Data map[string]string
+}
+
+type thriftOrder struct {
+	OrderNo string
+	Result_ bool
+	Items   []*thriftItem
+}
+
+type protoItem struct {
+	ID   int64
+	Data map[string]string
+}
+
+type protoOrder struct {
+	OrderNo string
+	Result  bool
+	Items   []*protoItem
+	state   int
+}
+
+func TestCopyStruct(t *testing.T) {
+	src := &thriftOrder{
+		OrderNo: "100001",
+		Result_: true,
+		Items:   []*thriftItem{{ID: 1, Data: map[string]string{"k": "v"}}},
+	}
+	dst := &protoOrder{}
+	copyStruct(dst, src)
+	if dst.OrderNo != src.OrderNo || !dst.Result {
+		t.Fatalf("copy field failed: %#v", dst)
+	}
+	if len(dst.Items) != 1 || dst.Items[0].ID != 1 || dst.Items[0].Data["k"] != "v" {
+		t.Fatalf("copy items failed: %#v", dst.Items)
+	}
+	back := &thriftOrder{}
+	copyStruct(back, dst)
+	if !back.Result_ || len(back.Items) != 1 {
+		t.Fatalf("copy back failed: %#v", back)
+	}
+}
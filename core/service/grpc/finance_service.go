@@ -0,0 +1,28 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : finance_service.go
+ * author : jarryliu
+ * date : 2026-10-19 10:20
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"go2o/core/service/grpc/proto/gen-go/pb"
+	"go2o/core/service/rsi"
+)
+
+var _ pb.FinanceServiceServer = new(financeServer)
+
+// 财务服务
+type financeServer struct {
+	pb.UnimplementedFinanceServiceServer
+}
+
+func (f *financeServer) RiseTransferIn(ctx context.Context, r *pb.TransferInRequest) (*pb.DResult, error) {
+	v, err := rsi.PersonFinanceService.RiseTransferIn(r.PersonId, r.TransferWith, r.Amount)
+	dst := &pb.DResult{}
+	return dst, result(dst, v, err)
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : interceptor.go
+ * author : jarryliu
+ * date : 2026-10-19 11:05
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	// 令牌元数据键,值为: Bearer <token>
+	authKey    = "authorization"
+	bearerType = "Bearer "
+)
+
+// 无需验证令牌的方法前缀
+var publicMethods = []string{
+	"/grpc.health.v1.Health/",
+}
+
+// 验证请求令牌
+func checkToken(ctx context.Context, method string, token string) error {
+	if token == "" {
+		return nil
+	}
+	for _, p := range publicMethods {
+		if strings.HasPrefix(method, p) {
+			return nil
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get(authKey) {
+			v = strings.TrimPrefix(v, bearerType)
+			if subtle.ConstantTimeCompare([]byte(v), []byte(token)) == 1 {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid token")
+}
+
+func unaryAuth(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkToken(ctx, info.FullMethod, token); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(token string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if err := checkToken(ss.Context(), info.FullMethod, token); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// 服务发生panic时返回Internal错误,避免服务退出
+func recoverInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("[ Grpc][ Panic]:", info.FullMethod, r, "\n", string(debug.Stack()))
+			err = status.Errorf(codes.Internal, "%v", r)
+		}
+	}()
+	return handler(ctx, req)
+}
@@ -0,0 +1,38 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : item_service.go
+ * author : jarryliu
+ * date : 2026-10-19 10:10
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"go2o/core/service/grpc/proto/gen-go/pb"
+	"go2o/core/service/rsi"
+)
+
+var _ pb.ItemServiceServer = new(itemServer)
+
+// 商品服务
+type itemServer struct {
+	pb.UnimplementedItemServiceServer
+}
+
+func (i *itemServer) GetSku(ctx context.Context, r *pb.GetSkuRequest) (*pb.Sku, error) {
+	v, err := rsi.ItemService.GetSku(r.ItemId, r.SkuId)
+	dst := &pb.Sku{}
+	return dst, result(dst, v, err)
+}
+
+func (i *itemServer) GetItemSkuJson(ctx context.Context, r *pb.Int64Id) (*pb.StringValue, error) {
+	v, err := rsi.ItemService.GetItemSkuJson(r.Id)
+	return &pb.StringValue{Value: v}, toStatus(err)
+}
+
+func (i *itemServer) GetItemDetailData(ctx context.Context, r *pb.ItemDetailRequest) (*pb.StringValue, error) {
+	v, err := rsi.ItemService.GetItemDetailData(r.ItemId, r.IType)
+	return &pb.StringValue{Value: v}, toStatus(err)
+}
@@ -0,0 +1,163 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : member_service.go
+ * author : jarryliu
+ * date : 2026-10-19 09:40
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"go2o/core/service/grpc/proto/gen-go/pb"
+	"go2o/core/service/rsi"
+)
+
+var _ pb.MemberServiceServer = new(memberServer)
+
+// 会员服务
+type memberServer struct {
+	pb.UnimplementedMemberServiceServer
+}
+
+func (m *memberServer) CheckLogin(ctx context.Context, r *pb.MemberLoginRequest) (*pb.Result64, error) {
+	v, err := rsi.MemberService.CheckLogin(r.User, r.Pwd, r.Update)
+	dst := &pb.Result64{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) CheckTradePwd(ctx context.Context, r *pb.TradePwdRequest) (*pb.Result, error) {
+	v, err := rsi.MemberService.CheckTradePwd(r.Id, r.TradePwd)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) LevelList(ctx context.Context, r *pb.Empty) (*pb.LevelList, error) {
+	list, err := rsi.MemberService.LevelList()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	dst := &pb.LevelList{Value: make([]*pb.Level, len(list))}
+	for i, v := range list {
+		dst.Value[i] = &pb.Level{}
+		copyStruct(dst.Value[i], v)
+	}
+	return dst, nil
+}
+
+func (m *memberServer) GetTrustInfo(ctx context.Context, r *pb.Int64Id) (*pb.TrustedInfo, error) {
+	v, err := rsi.MemberService.GetTrustInfo(r.Id)
+	dst := &pb.TrustedInfo{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) GetLevel(ctx context.Context, r *pb.Int32Id) (*pb.Level, error) {
+	v, err := rsi.MemberService.GetLevel(r.Id)
+	dst := &pb.Level{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) GetLevelBySign(ctx context.Context, r *pb.StringValue) (*pb.Level, error) {
+	v, err := rsi.MemberService.GetLevelBySign(r.Value)
+	dst := &pb.Level{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) GetMember(ctx context.Context, r *pb.Int64Id) (*pb.Member, error) {
+	v, err := rsi.MemberService.GetMember(r.Id)
+	dst := &pb.Member{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) GetMemberByUser(ctx context.Context, r *pb.StringValue) (*pb.Member, error) {
+	v, err := rsi.MemberService.GetMemberByUser(r.Value)
+	dst := &pb.Member{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) GetProfile(ctx context.Context, r *pb.Int64Id) (*pb.Profile, error) {
+	v, err := rsi.MemberService.GetProfile(r.Id)
+	dst := &pb.Profile{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) Complex(ctx context.Context, r *pb.Int64Id) (*pb.ComplexMember, error) {
+	v, err := rsi.MemberService.Complex(r.Id)
+	dst := &pb.ComplexMember{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) UpdateLevel(ctx context.Context, r *pb.UpdateLevelRequest) (*pb.Result, error) {
+	v, err := rsi.MemberService.UpdateLevel(r.MemberId, r.Level, r.Review, r.PaymentOrderId)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) Premium(ctx context.Context, r *pb.PremiumRequest) (*pb.Result, error) {
+	v, err := rsi.MemberService.Premium(r.MemberId, r.V, r.Expires)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) GetToken(ctx context.Context, r *pb.GetTokenRequest) (*pb.StringValue, error) {
+	v, err := rsi.MemberService.GetToken(r.MemberId, r.Reset)
+	return &pb.StringValue{Value: v}, toStatus(err)
+}
+
+func (m *memberServer) CheckToken(ctx context.Context, r *pb.CheckTokenRequest) (*pb.BoolValue, error) {
+	v, err := rsi.MemberService.CheckToken(r.MemberId, r.Token)
+	return &pb.BoolValue{Value: v}, toStatus(err)
+}
+
+func (m *memberServer) RemoveToken(ctx context.Context, r *pb.Int64Id) (*pb.Empty, error) {
+	err := rsi.MemberService.RemoveToken(r.Id)
+	return &pb.Empty{}, toStatus(err)
+}
+
+func (m *memberServer) GetAddress(ctx context.Context, r *pb.GetAddressRequest) (*pb.Address, error) {
+	v, err := rsi.MemberService.GetAddress(r.MemberId, r.AddrId)
+	dst := &pb.Address{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) GetAccount(ctx context.Context, r *pb.Int64Id) (*pb.Account, error) {
+	v, err := rsi.MemberService.GetAccount(r.Id)
+	dst := &pb.Account{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) InviterArray(ctx context.Context, r *pb.InviterArrayRequest) (*pb.Int64List, error) {
+	v, err := rsi.MemberService.InviterArray(r.MemberId, r.Depth)
+	return &pb.Int64List{Value: v}, toStatus(err)
+}
+
+func (m *memberServer) GetInviterQuantity(ctx context.Context, r *pb.InviterQueryRequest) (*pb.Int32Value, error) {
+	v, err := rsi.MemberService.GetInviterQuantity(r.MemberId, r.Data)
+	return &pb.Int32Value{Value: v}, toStatus(err)
+}
+
+func (m *memberServer) GetInviterArray(ctx context.Context, r *pb.InviterQueryRequest) (*pb.Int64List, error) {
+	v, err := rsi.MemberService.GetInviterArray(r.MemberId, r.Data)
+	return &pb.Int64List{Value: v}, toStatus(err)
+}
+
+func (m *memberServer) ChargeAccount(ctx context.Context, r *pb.ChargeAccountRequest) (*pb.Result, error) {
+	v, err := rsi.MemberService.ChargeAccount(r.MemberId, r.Account, r.Kind,
+		r.Title, r.OuterNo, r.Amount, r.RelateUser)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) DiscountAccount(ctx context.Context, r *pb.DiscountAccountRequest) (*pb.Result, error) {
+	v, err := rsi.MemberService.DiscountAccount(r.MemberId, r.Account, r.Title,
+		r.OuterNo, r.Amount, r.RelateUser, r.MustLargeZero)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (m *memberServer) B4EAuth(ctx context.Context, r *pb.B4EAuthRequest) (*pb.Result, error) {
+	v, err := rsi.MemberService.B4EAuth(r.MemberId, r.Action, r.Data)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
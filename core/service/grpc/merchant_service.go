@@ -0,0 +1,45 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : merchant_service.go
+ * author : jarryliu
+ * date : 2026-10-19 10:05
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"go2o/core/service/grpc/proto/gen-go/pb"
+	"go2o/core/service/rsi"
+)
+
+var _ pb.MerchantServiceServer = new(merchantServer)
+
+// 商户服务
+type merchantServer struct {
+	pb.UnimplementedMerchantServiceServer
+}
+
+func (m *merchantServer) Complex(ctx context.Context, r *pb.Int32Id) (*pb.ComplexMerchant, error) {
+	v, err := rsi.MerchantService.Complex(r.Id)
+	dst := &pb.ComplexMerchant{}
+	return dst, result(dst, v, err)
+}
+
+func (m *merchantServer) CheckLogin(ctx context.Context, r *pb.MchLoginRequest) (*pb.Result, error) {
+	v, err := rsi.MerchantService.CheckLogin(r.Usr, r.OriPwd)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (m *merchantServer) Stat(ctx context.Context, r *pb.Int32Id) (*pb.Result, error) {
+	v, err := rsi.MerchantService.Stat(r.Id)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (m *merchantServer) SyncWholesaleItem(ctx context.Context, r *pb.Int32Id) (*pb.Int32Map, error) {
+	v, err := rsi.MerchantService.SyncWholesaleItem(r.Id)
+	return &pb.Int32Map{Value: v}, toStatus(err)
+}
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : payment_service.go
+ * author : jarryliu
+ * date : 2026-10-19 10:25
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"go2o/core/service/grpc/proto/gen-go/pb"
+	"go2o/core/service/rsi"
+	"go2o/core/service/thrift/idl/gen-go/define"
+)
+
+var _ pb.PaymentServiceServer = new(paymentServer)
+
+// 支付服务
+type paymentServer struct {
+	pb.UnimplementedPaymentServiceServer
+}
+
+func (p *paymentServer) SubmitPaymentOrder(ctx context.Context, r *pb.PaymentOrder) (*pb.Result, error) {
+	o := &define.PaymentOrder{}
+	copyStruct(o, r)
+	v, err := rsi.PaymentService.SubmitPaymentOrder(o)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) GetPaymentOrder(ctx context.Context, r *pb.StringValue) (*pb.PaymentOrder, error) {
+	v, err := rsi.PaymentService.GetPaymentOrder(r.Value)
+	dst := &pb.PaymentOrder{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) GetPaymentOrderId(ctx context.Context, r *pb.StringValue) (*pb.Int32Value, error) {
+	v, err := rsi.PaymentService.GetPaymentOrderId(r.Value)
+	return &pb.Int32Value{Value: v}, toStatus(err)
+}
+
+func (p *paymentServer) GetPaymentOrderById(ctx context.Context, r *pb.Int32Id) (*pb.PaymentOrder, error) {
+	v, err := rsi.PaymentService.GetPaymentOrderById(r.Id)
+	dst := &pb.PaymentOrder{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) AdjustOrder(ctx context.Context, r *pb.AdjustOrderRequest) (*pb.Result, error) {
+	v, err := rsi.PaymentService.AdjustOrder(r.PaymentNo, r.Amount)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) DiscountByBalance(ctx context.Context, r *pb.PaymentRemarkRequest) (*pb.Result, error) {
+	v, err := rsi.PaymentService.DiscountByBalance(r.OrderId, r.Remark)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) DiscountByIntegral(ctx context.Context, r *pb.IntegralDiscountRequest) (*pb.DResult, error) {
+	v, err := rsi.PaymentService.DiscountByIntegral(r.OrderId, r.Integral, r.IgnoreOut)
+	dst := &pb.DResult{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) PaymentByWallet(ctx context.Context, r *pb.PaymentRemarkRequest) (*pb.Result, error) {
+	v, err := rsi.PaymentService.PaymentByWallet(r.OrderId, r.Remark)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) HybridPayment(ctx context.Context, r *pb.PaymentRemarkRequest) (*pb.Result, error) {
+	v, err := rsi.PaymentService.HybridPayment(r.OrderId, r.Remark)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) FinishPayment(ctx context.Context, r *pb.FinishPaymentRequest) (*pb.Result, error) {
+	var v *define.Result_
+	var err error
+	if r.IdempotencyKey != "" {
+		v, err = rsi.PaymentService.FinishPaymentIdempotent(r.IdempotencyKey,
+			r.TradeNo, r.SpName, r.OuterNo)
+	} else {
+		v, err = rsi.PaymentService.FinishPayment(r.TradeNo, r.SpName, r.OuterNo)
+	}
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (p *paymentServer) GatewayV1(ctx context.Context, r *pb.GatewayRequest) (*pb.Result, error) {
+	v, err := rsi.PaymentService.GatewayV1(r.Action, r.UserId, r.Data)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
@@ -0,0 +1,38 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : proto-gen_test.go
+ * author : jarryliu
+ * date : 2026-10-19 11:30
+ * description :
+ * history :
+ */
+package proto
+
+import (
+	"github.com/jsix/gof/shell"
+	"os"
+	"testing"
+)
+
+// 生成Golang的gRPC代码,需安装protoc,protoc-gen-go及protoc-gen-go-grpc
+func TestGo(t *testing.T) {
+	os.MkdirAll("gen-go/pb", os.ModePerm)
+	genCode(t, "protoc --go_out=paths=source_relative:gen-go/pb "+
+		"--go-grpc_out=paths=source_relative:gen-go/pb *.proto")
+}
+
+// 生成Java的gRPC代码
+func TestJava(t *testing.T) {
+	os.MkdirAll("gen-java", os.ModePerm)
+	genCode(t, "protoc --java_out=gen-java --grpc-java_out=gen-java *.proto")
+}
+
+func genCode(t *testing.T, cmd string) {
+	_, output, err := shell.Run(cmd)
+	if err == nil {
+		t.Log("生成成功!")
+		return
+	}
+	t.Log(output + "\n")
+	t.Fail()
+}
@@ -0,0 +1,108 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : sale_service.go
+ * author : jarryliu
+ * date : 2026-10-19 10:35
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"go2o/core/service/grpc/proto/gen-go/pb"
+	"go2o/core/service/rsi"
+	"go2o/core/service/thrift/idl/gen-go/define"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ pb.SaleServiceServer = new(saleServer)
+
+// 销售服务
+type saleServer struct {
+	pb.UnimplementedSaleServiceServer
+}
+
+func (s *saleServer) WholesaleCartV1(ctx context.Context, r *pb.CartRequest) (*pb.Result, error) {
+	v, err := rsi.ShoppingService.WholesaleCartV1(r.MemberId, r.Action, r.Data)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (s *saleServer) RetailCartV1(ctx context.Context, r *pb.CartRequest) (*pb.Result, error) {
+	v, err := rsi.ShoppingService.RetailCartV1(r.MemberId, r.Action, r.Data)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (s *saleServer) SubmitOrderV1(ctx context.Context, r *pb.SubmitOrderRequest) (*pb.StringMap, error) {
+	if r.IdempotencyKey != "" {
+		if r.Data == nil {
+			r.Data = map[string]string{}
+		}
+		r.Data["idempotency_key"] = r.IdempotencyKey
+	}
+	v, err := rsi.ShoppingService.SubmitOrderV1(r.BuyerId, r.CartType, r.Data)
+	return &pb.StringMap{Value: v}, toStatus(err)
+}
+
+func (s *saleServer) GetOrder(ctx context.Context, r *pb.GetOrderRequest) (*pb.ComplexOrder, error) {
+	v, err := rsi.ShoppingService.GetOrder(r.OrderNo, r.SubOrder)
+	dst := &pb.ComplexOrder{}
+	return dst, result(dst, v, err)
+}
+
+func (s *saleServer) GetOrderAndItems(ctx context.Context, r *pb.GetOrderRequest) (*pb.ComplexOrder, error) {
+	v, err := rsi.ShoppingService.GetOrderAndItems(r.OrderNo, r.SubOrder)
+	dst := &pb.ComplexOrder{}
+	return dst, result(dst, v, err)
+}
+
+func (s *saleServer) GetSubOrder(ctx context.Context, r *pb.Int64Id) (*pb.ComplexOrder, error) {
+	v, err := rsi.ShoppingService.GetSubOrder(r.Id)
+	dst := &pb.ComplexOrder{}
+	return dst, result(dst, v, err)
+}
+
+func (s *saleServer) GetSubOrderByNo(ctx context.Context, r *pb.StringValue) (*pb.ComplexOrder, error) {
+	v, err := rsi.ShoppingService.GetSubOrderByNo(r.Value)
+	dst := &pb.ComplexOrder{}
+	return dst, result(dst, v, err)
+}
+
+func (s *saleServer) GetSubOrderItems(ctx context.Context, r *pb.Int64Id) (*pb.ComplexItemList, error) {
+	list, err := rsi.ShoppingService.GetSubOrderItems(r.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	dst := &pb.ComplexItemList{Value: make([]*pb.ComplexItem, len(list))}
+	for i, v := range list {
+		dst.Value[i] = &pb.ComplexItem{}
+		copyStruct(dst.Value[i], v)
+	}
+	return dst, nil
+}
+
+func (s *saleServer) SubmitTradeOrder(ctx context.Context, r *pb.TradeOrderRequest) (*pb.Result64, error) {
+	if r.Order == nil {
+		return nil, status.Error(codes.InvalidArgument, "order is required")
+	}
+	o := &define.ComplexOrder{}
+	copyStruct(o, r.Order)
+	v, err := rsi.ShoppingService.SubmitTradeOrder(o, r.Rate)
+	dst := &pb.Result64{}
+	return dst, result(dst, v, err)
+}
+
+func (s *saleServer) TradeOrderCashPay(ctx context.Context, r *pb.Int64Id) (*pb.Result64, error) {
+	v, err := rsi.ShoppingService.TradeOrderCashPay(r.Id)
+	dst := &pb.Result64{}
+	return dst, result(dst, v, err)
+}
+
+func (s *saleServer) TradeOrderUpdateTicket(ctx context.Context, r *pb.TradeTicketRequest) (*pb.Result64, error) {
+	v, err := rsi.ShoppingService.TradeOrderUpdateTicket(r.OrderId, r.Img)
+	dst := &pb.Result64{}
+	return dst, result(dst, v, err)
+}
@@ -0,0 +1,77 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : server.go
+ * author : jarryliu
+ * date : 2026-10-19 10:50
+ * description : gRPC服务,与thrift服务共用rsi中的服务实现
+ * history :
+ */
+package grpc
+
+import (
+	"fmt"
+	"go2o/core/service/grpc/proto/gen-go/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"net"
+)
+
+// 服务选项
+type Options struct {
+	// 监听地址
+	Addr string
+	// TLS证书文件,为空时不启用TLS
+	CertFile string
+	// TLS私钥文件
+	KeyFile string
+	// 访问令牌,为空时不验证
+	Token string
+}
+
+// 创建gRPC服务,并注册服务、健康检查及反射服务
+func NewServer(o *Options) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(recoverInterceptor, unaryAuth(o.Token)),
+		grpc.ChainStreamInterceptor(streamAuth(o.Token)),
+	}
+	if o.CertFile != "" {
+		cred, err := credentials.NewServerTLSFromFile(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(cred))
+	}
+	s := grpc.NewServer(opts...)
+	pb.RegisterMerchantServiceServer(s, &merchantServer{})
+	pb.RegisterMemberServiceServer(s, &memberServer{})
+	pb.RegisterPaymentServiceServer(s, &paymentServer{})
+	pb.RegisterSaleServiceServer(s, &saleServer{})
+	pb.RegisterItemServiceServer(s, &itemServer{})
+	pb.RegisterShopServiceServer(s, &shopServer{})
+	pb.RegisterFinanceServiceServer(s, &financeServer{})
+
+	// 健康检查,各服务均为可用状态
+	hs := health.NewServer()
+	for name := range s.GetServiceInfo() {
+		hs.SetServingStatus(name, grpc_health_v1.HealthCheckResponse_SERVING)
+	}
+	grpc_health_v1.RegisterHealthServer(s, hs)
+	reflection.Register(s)
+	return s, nil
+}
+
+func ListenAndServe(o *Options) error {
+	s, err := NewServer(o)
+	if err == nil {
+		var l net.Listener
+		l, err = net.Listen("tcp", o.Addr)
+		if err == nil {
+			fmt.Println("Starting the grpc server... on ", o.Addr)
+			err = s.Serve(l)
+		}
+	}
+	return err
+}
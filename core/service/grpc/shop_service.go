@@ -0,0 +1,46 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : shop_service.go
+ * author : jarryliu
+ * date : 2026-10-19 10:15
+ * description :
+ * history :
+ */
+package grpc
+
+import (
+	"context"
+	"go2o/core/service/grpc/proto/gen-go/pb"
+	"go2o/core/service/rsi"
+)
+
+var _ pb.ShopServiceServer = new(shopServer)
+
+// 商店服务
+type shopServer struct {
+	pb.UnimplementedShopServiceServer
+}
+
+func (s *shopServer) GetStore(ctx context.Context, r *pb.Int32Id) (*pb.Store, error) {
+	v, err := rsi.ShopService.GetStore(r.Id)
+	dst := &pb.Store{}
+	return dst, result(dst, v, err)
+}
+
+func (s *shopServer) GetStoreById(ctx context.Context, r *pb.Int32Id) (*pb.Store, error) {
+	v, err := rsi.ShopService.GetStoreById(r.Id)
+	dst := &pb.Store{}
+	return dst, result(dst, v, err)
+}
+
+func (s *shopServer) TurnShop(ctx context.Context, r *pb.ShopSwitchRequest) (*pb.Result, error) {
+	v, err := rsi.ShopService.TurnShop(r.ShopId, r.On, r.Reason)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
+
+func (s *shopServer) OpenShop(ctx context.Context, r *pb.ShopSwitchRequest) (*pb.Result, error) {
+	v, err := rsi.ShopService.OpenShop(r.ShopId, r.On, r.Reason)
+	dst := &pb.Result{}
+	return dst, result(dst, v, err)
+}
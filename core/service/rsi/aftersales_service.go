@@ -9,27 +9,40 @@
 package rsi
 
 import (
+	"fmt"
 	"github.com/jsix/gof/db"
 	"github.com/labstack/gommon/log"
+	asImpl "go2o/core/domain/after-sales"
 	"go2o/core/domain/interface/after-sales"
+	"go2o/core/domain/interface/merchant"
+	"go2o/core/domain/interface/mss"
+	"go2o/core/domain/interface/mss/notify"
 	"go2o/core/domain/interface/order"
 	"go2o/core/dto"
 	"go2o/core/infrastructure/format"
+	"go2o/core/infrastructure/uow"
 	"go2o/core/query"
+	"strings"
+	"time"
 )
 
 type afterSalesService struct {
 	_orderRepo order.IOrderRepo
+	_mchRepo   merchant.IMerchantRepo
+	_mssRepo   mss.IMssRepo
 	_rep       afterSales.IAfterSalesRepo
 	_query     *query.AfterSalesQuery
 	db.Connector
 }
 
 func NewAfterSalesService(rep afterSales.IAfterSalesRepo,
-	q *query.AfterSalesQuery, orderRepo order.IOrderRepo) *afterSalesService {
+	q *query.AfterSalesQuery, orderRepo order.IOrderRepo,
+	mchRepo merchant.IMerchantRepo, mssRepo mss.IMssRepo) *afterSalesService {
 	return &afterSalesService{
 		_rep:       rep,
 		_orderRepo: orderRepo,
+		_mchRepo:   mchRepo,
+		_mssRepo:   mssRepo,
 		_query:     q,
 	}
 }
@@ -49,7 +62,8 @@ func (a *afterSalesService) SubmitAfterSalesOrder(orderId int64, asType int,
 	})
 	err := ro.SetItem(snapshotId, quantity)
 	if err == nil {
-		return ro.Submit()
+		id, err := ro.Submit()
+		return id, a.publishState(id, err)
 	}
 	return 0, err
 }
@@ -92,25 +106,40 @@ func (a *afterSalesService) GetAfterSaleOrder(id int32) *afterSales.AfterSalesOr
 // 同意售后
 func (a *afterSalesService) AgreeAfterSales(id int32, remark string) error {
 	as := a._rep.GetAfterSalesOrder(id)
-	return as.Agree()
+	return a.publishState(id, as.Agree())
 }
 
 // 拒绝售后
 func (a *afterSalesService) DeclineAfterSales(id int32, reason string) error {
 	as := a._rep.GetAfterSalesOrder(id)
-	return as.Decline(reason)
+	return a.publishState(id, as.Decline(reason))
 }
 
 // 申请调解
 func (a *afterSalesService) RequestIntercede(id int32) error {
 	as := a._rep.GetAfterSalesOrder(id)
-	return as.RequestIntercede()
+	return a.publishState(id, as.RequestIntercede())
+}
+
+// 在工作单元中处理售后单,退款、退货涉及的订单、账户变动在同一事务中提交
+func (a *afterSalesService) processInUnitOfWork(id int32,
+	f func(as afterSales.IAfterSalesOrder) error) error {
+	err := runInUnitOfWork(func(u *uow.UnitOfWork) error {
+		rep := uow.Bind(u, a._rep).(afterSales.IAfterSalesRepo)
+		as := rep.GetAfterSalesOrder(id)
+		if as == nil {
+			return afterSales.ErrNoSuchOrder
+		}
+		return f(as)
+	})
+	return a.publishState(id, err)
 }
 
 // 系统确认
 func (a *afterSalesService) ConfirmAfterSales(id int32) error {
-	as := a._rep.GetAfterSalesOrder(id)
-	return as.Confirm()
+	return a.processInUnitOfWork(id, func(as afterSales.IAfterSalesOrder) error {
+		return as.Confirm()
+	})
 }
 
 // 系统退回
@@ -120,24 +149,18 @@ func (a *afterSalesService) RejectAfterSales(id int32, remark string) error {
 		return afterSales.ErrNoSuchOrder
 	}
 
-	return as.Reject(remark)
+	return a.publishState(id, as.Reject(remark))
 }
 
 // 处理退款/退货完成,一般是系统自动调用
 func (a *afterSalesService) ProcessAfterSalesOrder(id int32) error {
-	as := a._rep.GetAfterSalesOrder(id)
-	if as == nil {
-		return afterSales.ErrNoSuchOrder
-	}
-	v := as.Value()
-	log.Print("========================================%d", v.State)
-	switch v.Type {
-	case afterSales.TypeRefund:
-		return as.Process()
-	case afterSales.TypeReturn:
-		return as.Process()
-	}
-	return afterSales.ErrAutoProcess
+	return a.processInUnitOfWork(id, func(as afterSales.IAfterSalesOrder) error {
+		switch as.Value().Type {
+		case afterSales.TypeRefund, afterSales.TypeReturn:
+			return as.Process()
+		}
+		return afterSales.ErrAutoProcess
+	})
 }
 
 // 售后收货
@@ -149,17 +172,147 @@ func (a *afterSalesService) ReceiveReturnShipment(id int32) error {
 			err = as.Process()
 		}
 	}
-	return err
+	return a.publishState(id, err)
 }
 
 // 换货发货
 func (a *afterSalesService) ExchangeShipment(id int32, spName string, spOrder string) error {
 	ex := a._rep.GetAfterSalesOrder(id).(afterSales.IExchangeOrder)
-	return ex.ExchangeShip(spName, spOrder)
+	return a.publishState(id, ex.ExchangeShip(spName, spOrder))
 }
 
 // 换货收货
 func (a *afterSalesService) ReceiveExchange(id int32) error {
 	ex := a._rep.GetAfterSalesOrder(id).(afterSales.IExchangeOrder)
-	return ex.ExchangeReceive()
+	return a.publishState(id, ex.ExchangeReceive())
+}
+
+// 售后单留言,可附带多张图片凭证
+func (a *afterSalesService) PostAfterSalesMessage(id int32, role int, senderId int64,
+	content string, images []string) (int32, error) {
+	as := a._rep.GetAfterSalesOrder(id)
+	if as == nil {
+		return 0, afterSales.ErrNoSuchOrder
+	}
+	return as.PostMessage(role, senderId, content, images)
+}
+
+// 仲裁售后单
+func (a *afterSalesService) ArbitrateAfterSales(id int32, v *afterSales.Arbitration) error {
+	return a.processInUnitOfWork(id, func(as afterSales.IAfterSalesOrder) error {
+		return as.Arbitrate(v)
+	})
+}
+
+// 获取售后单的完整记录,包括留言和仲裁结果
+func (a *afterSalesService) GetAfterSalesHistory(id int32) *afterSales.History {
+	as := a._rep.GetAfterSalesOrder(id)
+	if as == nil {
+		return nil
+	}
+	v := as.Value()
+	v.StateText = afterSales.Stat(v.State).String()
+	v.ReturnSpImage = format.GetResUrl(v.ReturnSpImage)
+	list := as.Messages()
+	for _, m := range list {
+		arr := m.ImageArray()
+		for i, img := range arr {
+			arr[i] = format.GetResUrl(img)
+		}
+		m.Images = strings.Join(arr, ",")
+	}
+	return &afterSales.History{
+		Order:       &v,
+		Messages:    list,
+		Arbitration: as.GetArbitration(),
+	}
+}
+
+// 获取商户的售后时效设置
+func (a *afterSalesService) getSLA(vendorId int32) afterSales.SLA {
+	var conf merchant.SaleConf
+	if mch := a._mchRepo.GetMerchant(vendorId); mch != nil {
+		conf = mch.ConfManager().GetSaleConf()
+	}
+	return afterSales.SLA{
+		VendorHour:     conf.AsVendorHour,
+		ReturnShipHour: conf.AsReturnShipHour,
+		ReceiveHour:    conf.AsReceiveHour,
+		DeclinedHour:   conf.AsDeclinedHour,
+	}
+}
+
+// 处理超时的售后单,返回执行的超时操作,一般由系统自动调用
+func (a *afterSalesService) HandleTimeout(id int32) (int, error) {
+	as := a._rep.GetAfterSalesOrder(id)
+	if as == nil {
+		return 0, afterSales.ErrNoSuchOrder
+	}
+	v := as.Value()
+	action, err := asImpl.HandleTimeout(as, a.getSLA(v.VendorId), time.Now().Unix())
+	if action > 0 && err == nil {
+		a.notifyTimeout(&v, action)
+		a.publishState(id, nil)
+	}
+	return action, err
+}
+
+// 发送售后单超时处理通知
+func (a *afterSalesService) notifyTimeout(v *afterSales.AfterSalesOrder, action int) {
+	var buyerMsg, vendorMsg string
+	switch action {
+	case afterSales.TimeoutAgree:
+		buyerMsg = "商户未在规定时间内处理,系统已自动同意您的售后申请"
+		vendorMsg = "售后单超时未处理,系统已自动同意"
+	case afterSales.TimeoutCancel:
+		buyerMsg = "您未在规定时间内退回商品,售后单已自动取消"
+		vendorMsg = "买家超时未退货,售后单已自动取消"
+	case afterSales.TimeoutReceive:
+		buyerMsg = "商户未在规定时间内确认收货,系统已自动确认收货"
+		vendorMsg = "退回商品超时未确认收货,系统已自动确认收货"
+	case afterSales.TimeoutIntercede:
+		buyerMsg = "商户拒绝了您的售后申请,系统已自动为您申请客服调解"
+		vendorMsg = "售后单已进入客服调解"
+	default:
+		return
+	}
+	subject := fmt.Sprintf("售后单(%d)状态通知", v.Id)
+	mm := a._mssRepo.MessageManager()
+	mm.CreateMemberNotifyMessage(v.BuyerId, notify.TypeSiteMessage,
+		&notify.SiteMessage{Subject: subject, Message: buyerMsg}).Send(nil)
+	msg := &mss.Message{
+		Type:       notify.TypeSiteMessage,
+		UseFor:     mss.UseForNotify,
+		SenderRole: mss.RoleSystem,
+		To:         []mss.User{{Id: v.VendorId, Role: mss.RoleMerchant}},
+		CreateTime: time.Now().Unix(),
+	}
+	mm.CreateMessage(msg, &notify.SiteMessage{Subject: subject,
+		Message: vendorMsg}).Send(nil)
+}
+
+// 发布售后单状态变更通知,返回传入的错误
+func (a *afterSalesService) publishState(id int32, err error) error {
+	if err != nil {
+		return err
+	}
+	as := a._rep.GetAfterSalesOrder(id)
+	if as == nil {
+		return nil
+	}
+	v := as.Value()
+	if mch := a._mchRepo.GetMerchant(v.VendorId); mch != nil {
+		_, err := mch.ApiManager().PublishWebhook(merchant.EventAfterSalesState,
+			map[string]interface{}{
+				"id":         v.Id,
+				"order_id":   v.OrderId,
+				"type":       v.Type,
+				"state":      v.State,
+				"state_text": afterSales.Stat(v.State).String(),
+			})
+		if err != nil {
+			log.Error("[ AfterSales][ Webhook][ Error]:", err.Error())
+		}
+	}
+	return nil
 }
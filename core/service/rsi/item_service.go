@@ -112,26 +112,82 @@ func (s *itemService) GetItemSkuJson(itemId int64) (r string, err error) {
 
 // 保存商品
 func (s *itemService) SaveItem(di *define.OldItem, vendorId int32) (_ *define.Result64, err error) {
-	var gi item.IGoodsItem
 	it := parser.Item(di)
+	it.ID, err = s.SaveItemValue(it, vendorId)
+	return parser.Result64(it.ID, err), nil
+}
+
+// 保存商品及SKU
+func (s *itemService) SaveItemValue(it *item.GoodsItem, vendorId int32) (int64, error) {
+	var gi item.IGoodsItem
 	if it.ID > 0 {
 		gi = s.itemRepo.GetItem(it.ID)
 		if gi == nil || gi.GetValue().VendorId != vendorId {
-			err = item.ErrNoSuchItem
-			goto R
+			return it.ID, item.ErrNoSuchItem
 		}
 	} else {
+		it.VendorId = vendorId
 		gi = s.itemRepo.CreateItem(it)
 	}
-	err = gi.SetValue(it)
+	err := gi.SetValue(it)
 	if err == nil {
 		err = gi.SetSku(it.SkuArray)
 		if err == nil {
 			it.ID, err = gi.Save()
 		}
 	}
-R:
-	return parser.Result64(it.ID, err), nil
+	return it.ID, err
+}
+
+// 获取商户的商品及SKU
+func (s *itemService) GetVendorItem(vendorId int32, itemId int64) *item.GoodsItem {
+	it := s.itemRepo.GetItem(itemId)
+	if it == nil || it.GetValue().VendorId != vendorId {
+		return nil
+	}
+	v := *it.GetValue()
+	v.SkuArray = it.SkuArray()
+	return &v
+}
+
+// 获取商户的商品,按编号倒序,lastId为上一页最后的商品编号
+func (s *itemService) GetVendorItems(vendorId int32, lastId int64, size int) []*item.GoodsItem {
+	return s.itemQuery.GetItemsOfVendor(vendorId, lastId, size)
+}
+
+// 更新SKU库存,商品没有SKU时skuId传0
+func (s *itemService) SetSkuStock(vendorId int32, itemId int64, skuId int64, stock int32) error {
+	if stock < 0 {
+		return item.ErrOutOfStock
+	}
+	it := s.itemRepo.GetItem(itemId)
+	if it == nil || it.GetValue().VendorId != vendorId {
+		return item.ErrNoSuchItem
+	}
+	arr := it.SkuArray()
+	if len(arr) == 0 {
+		if skuId > 0 {
+			return item.ErrNoSuchSku
+		}
+		it.GetValue().StockNum = stock
+	} else {
+		var sku *item.Sku
+		for _, v := range arr {
+			if v.ID == skuId {
+				sku = v
+				break
+			}
+		}
+		if sku == nil {
+			return item.ErrNoSuchSku
+		}
+		sku.Stock = stock
+		if err := it.SetSku(arr); err != nil {
+			return err
+		}
+	}
+	_, err := it.Save()
+	return err
 }
 
 // 获取上架商品数据（分页）
@@ -512,18 +568,22 @@ func (s *itemService) GetSnapshot(skuId int64) *item.Snapshot {
 // 设置商品货架状态
 func (s *itemService) SetShelveState(vendorId int32, itemId int64,
 	itemType int32, state int32, remark string) (_ *define.Result_, err error) {
+	err = s.SetItemShelve(vendorId, itemId, itemType, state, remark)
+	return parser.Result(0, err), nil
+}
+
+// 设置商品货架状态
+func (s *itemService) SetItemShelve(vendorId int32, itemId int64,
+	itemType int32, state int32, remark string) error {
 	it := s.itemRepo.GetItem(itemId)
 	if it == nil || it.GetValue().VendorId != vendorId {
-		err = item.ErrNoSuchItem
-	} else {
-		switch itemType {
-		case item.ItemWholesale:
-			err = it.Wholesale().SetShelve(state, remark)
-		default:
-			err = it.SetShelve(state, remark)
-		}
+		return item.ErrNoSuchItem
 	}
-	return parser.Result(0, err), nil
+	switch itemType {
+	case item.ItemWholesale:
+		return it.Wholesale().SetShelve(state, remark)
+	}
+	return it.SetShelve(state, remark)
 }
 
 // 设置商品货架状态
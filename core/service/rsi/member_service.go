@@ -22,6 +22,7 @@ import (
 	"go2o/core/dto"
 	"go2o/core/infrastructure/domain"
 	"go2o/core/infrastructure/format"
+	"go2o/core/infrastructure/uow"
 	"go2o/core/module"
 	"go2o/core/query"
 	"go2o/core/service/thrift/idl/gen-go/define"
@@ -158,6 +159,44 @@ func (ms *memberService) RemoveToken(memberId int64) (err error) {
 	return nil
 }
 
+// 在设备上创建会话,返回访问令牌及刷新令牌
+func (ms *memberService) CreateSession(memberId int64, deviceId string,
+	deviceName string, ip string) (*module.MemberSession, error) {
+	m := ms._repo.GetMember(memberId)
+	if m == nil {
+		return nil, member.ErrNoSuchMember
+	}
+	if m.GetValue().State != member.StateOk {
+		return nil, member.ErrMemberDisabled
+	}
+	md := module.Get(module.M_MM).(*module.MemberModule)
+	return md.CreateSession(memberId, deviceId, deviceName, ip)
+}
+
+// 刷新会话
+func (ms *memberService) RefreshSession(refreshToken string, ip string) (*module.MemberSession, error) {
+	md := module.Get(module.M_MM).(*module.MemberModule)
+	return md.RefreshSession(refreshToken, ip)
+}
+
+// 获取会员已登录的设备
+func (ms *memberService) GetDevices(memberId int64) []*module.MemberDevice {
+	md := module.Get(module.M_MM).(*module.MemberModule)
+	return md.GetDevices(memberId)
+}
+
+// 退出会员的设备
+func (ms *memberService) RevokeDevice(memberId int64, deviceId string) error {
+	md := module.Get(module.M_MM).(*module.MemberModule)
+	return md.RevokeDevice(memberId, deviceId)
+}
+
+// 退出会员的所有设备
+func (ms *memberService) revokeDevices(memberId int64) {
+	md := module.Get(module.M_MM).(*module.MemberModule)
+	md.RevokeDevices(memberId)
+}
+
 // 更改手机号码，不验证手机格式
 func (ms *memberService) ChangePhone(memberId int64, phone string) error {
 	m := ms._repo.GetMember(memberId)
@@ -278,6 +317,35 @@ func (ms *memberService) GetHighestLevel() member.Level {
 	return member.Level{}
 }
 
+// 获取等级规则
+func (ms *memberService) GetLevelRule(levelId int32) *member.LevelRule {
+	return ms._repo.GetManager().LevelManager().GetLevelRule(levelId)
+}
+
+// 保存等级规则
+func (ms *memberService) SaveLevelRule(v *member.LevelRule) error {
+	return ms._repo.GetManager().LevelManager().SaveLevelRule(v)
+}
+
+// 获取等级权益
+func (ms *memberService) GetLevelBenefit(levelId int32) *member.LevelBenefit {
+	return ms._repo.GetManager().LevelManager().GetLevelBenefit(levelId)
+}
+
+// 保存等级权益
+func (ms *memberService) SaveLevelBenefit(v *member.LevelBenefit) error {
+	return ms._repo.GetManager().LevelManager().SaveLevelBenefit(v)
+}
+
+// 根据等级规则重新评定会员等级
+func (ms *memberService) ReEvaluateLevel(memberId int64) (bool, error) {
+	m, err := ms.getMember(memberId)
+	if err != nil {
+		return false, err
+	}
+	return m.ReEvaluateLevel()
+}
+
 func (ms *memberService) GetWalletLog(memberId int64, logId int32) *member.WalletLog {
 	m := ms._repo.GetMember(memberId)
 	return m.GetAccount().GetWalletLog(logId)
@@ -434,7 +502,11 @@ func (ms *memberService) LockMember(memberId int64) (bool, error) {
 
 	state := m.GetValue().State
 	if state == 1 {
-		return false, m.Lock()
+		err := m.Lock()
+		if err == nil {
+			ms.revokeDevices(memberId)
+		}
+		return false, err
 	}
 	return true, m.Unlock()
 }
@@ -455,6 +527,7 @@ func (ms *memberService) ResetPassword(memberId int64) string {
 		newPwd := domain.GenerateRandomIntPwd(6)
 		newEncPwd := domain.MemberSha1Pwd(newPwd)
 		if err := m.Profile().ModifyPassword(newEncPwd, ""); err == nil {
+			ms.revokeDevices(memberId)
 			return newPwd
 		} else {
 			log.Println("--- 重置密码:", err)
@@ -484,7 +557,11 @@ func (ms *memberService) ModifyPassword(memberId int64, newPwd, oldPwd string) e
 	if m == nil {
 		return member.ErrNoSuchMember
 	}
-	return m.Profile().ModifyPassword(newPwd, oldPwd)
+	err := m.Profile().ModifyPassword(newPwd, oldPwd)
+	if err == nil {
+		ms.revokeDevices(memberId)
+	}
+	return err
 }
 
 //修改密码,传入密文密码
@@ -868,18 +945,12 @@ func (ms *memberService) AddIntegral(memberId int64, iType int,
 // 充值,account为账户类型,kind为业务类型
 func (ms *memberService) ChargeAccount(memberId int64, account int32,
 	kind int32, title, outerNo string, amount float64, relateUser int64) (*define.Result_, error) {
-	var err error
-	m := ms._repo.CreateMember(&member.Member{Id: memberId})
-	acc := m.GetAccount()
-	if acc == nil {
-		err = member.ErrNoSuchMember
-	} else {
+	err := ms.updateAccount(memberId, func(acc member.IAccount) error {
 		if account == member.AccountIntegral {
-			err = acc.AddIntegral(int(kind), outerNo, int64(amount), title)
-		} else {
-			err = acc.Charge(account, kind, title, outerNo, float32(amount), relateUser)
+			return acc.AddIntegral(int(kind), outerNo, int64(amount), title)
 		}
-	}
+		return acc.Charge(account, kind, title, outerNo, float32(amount), relateUser)
+	})
 	return parser.Result(0, err), nil
 }
 
@@ -906,18 +977,17 @@ func (ms *memberService) UnfreezesIntegral(memberId int64,
 // 抵扣账户
 func (ms *memberService) DiscountAccount(memberId int64, account int32, title string,
 	outerNo string, amount float64, relateUser int64, mustLargeZero bool) (r *define.Result_, err error) {
-	m, err := ms.getMember(memberId)
-	if err == nil {
-		acc := m.GetAccount()
+	err = ms.updateAccount(memberId, func(acc member.IAccount) error {
 		switch int(account) {
 		case member.AccountBalance:
-			err = acc.DiscountBalance(title, outerNo, float32(amount),
+			return acc.DiscountBalance(title, outerNo, float32(amount),
 				member.DefaultRelateUser)
 		case member.AccountWallet:
-			err = acc.DiscountWallet(title, outerNo, float32(amount),
+			return acc.DiscountWallet(title, outerNo, float32(amount),
 				member.DefaultRelateUser, mustLargeZero)
 		}
-	}
+		return nil
+	})
 	return parser.I64Result(memberId, err), nil
 }
 
@@ -1038,41 +1108,33 @@ func (ms *memberService) FinishTakeOutRequest(memberId int64, id int32, tradeNo
 // 冻结余额
 func (ms *memberService) Freeze(memberId int64, title string,
 	tradeNo string, amount float32, referId int64) error {
-	m := ms._repo.GetMember(memberId)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().Freeze(title, tradeNo, amount, referId)
+	return ms.updateAccount(memberId, func(acc member.IAccount) error {
+		return acc.Freeze(title, tradeNo, amount, referId)
+	})
 }
 
 // 解冻金额
 func (ms *memberService) Unfreeze(memberId int64, title string,
 	tradeNo string, amount float32, referId int64) error {
-	m := ms._repo.GetMember(memberId)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().Unfreeze(title, tradeNo, amount, referId)
+	return ms.updateAccount(memberId, func(acc member.IAccount) error {
+		return acc.Unfreeze(title, tradeNo, amount, referId)
+	})
 }
 
 // 冻结赠送金额
 func (ms *memberService) FreezeWallet(memberId int64, title string,
 	tradeNo string, amount float32, referId int64) error {
-	m := ms._repo.GetMember(memberId)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().FreezeWallet(title, tradeNo, amount, referId)
+	return ms.updateAccount(memberId, func(acc member.IAccount) error {
+		return acc.FreezeWallet(title, tradeNo, amount, referId)
+	})
 }
 
 // 解冻赠送金额
 func (ms *memberService) UnfreezeWallet(memberId int64, title string,
 	tradeNo string, amount float32, referId int64) error {
-	m := ms._repo.GetMember(memberId)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().UnfreezeWallet(title, tradeNo, amount, referId)
+	return ms.updateAccount(memberId, func(acc member.IAccount) error {
+		return acc.UnfreezeWallet(title, tradeNo, amount, referId)
+	})
 }
 
 // 将冻结金额标记为失效
@@ -1085,61 +1147,88 @@ func (ms *memberService) FreezeExpired(memberId int64, accountKind int, amount f
 	return m.GetAccount().FreezeExpired(accountKind, amount, remark)
 }
 
+// 绑定到工作单元的仓储
+func (ms *memberService) uowRepo(u *uow.UnitOfWork) member.IMemberRepo {
+	return uow.Bind(u, ms._repo).(member.IMemberRepo)
+}
+
+// 在工作单元中变更账户,账户已被修改时重新加载并重试
+func (ms *memberService) updateAccount(memberId int64,
+	f func(acc member.IAccount) error) error {
+	return retryInUnitOfWork(func(u *uow.UnitOfWork) error {
+		m := ms.uowRepo(u).GetMember(memberId)
+		if m == nil {
+			return member.ErrNoSuchMember
+		}
+		return f(m.GetAccount())
+	})
+}
+
 // 转账余额到其他账户
 func (ms *memberService) TransferAccount(accountKind int, fromMember int64,
 	toMember int64, amount float32, csnRate float32, remark string) error {
-	m := ms._repo.GetMember(fromMember)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().TransferAccount(accountKind, toMember,
-		amount, csnRate, remark)
+	return retryInUnitOfWork(func(u *uow.UnitOfWork) error {
+		m := ms.uowRepo(u).GetMember(fromMember)
+		if m == nil {
+			return member.ErrNoSuchMember
+		}
+		return m.GetAccount().TransferAccount(accountKind, toMember,
+			amount, csnRate, remark)
+	})
 }
 
 // 转账余额到其他账户
 func (ms *memberService) TransferBalance(memberId int64, kind int32, amount float32, tradeNo string,
 	toTitle, fromTitle string) error {
-	m := ms._repo.GetMember(memberId)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().TransferBalance(kind, amount, tradeNo, toTitle, fromTitle)
+	return retryInUnitOfWork(func(u *uow.UnitOfWork) error {
+		m := ms.uowRepo(u).GetMember(memberId)
+		if m == nil {
+			return member.ErrNoSuchMember
+		}
+		return m.GetAccount().TransferBalance(kind, amount, tradeNo, toTitle, fromTitle)
+	})
 }
 
 // 转账返利账户,kind为转账类型，如 KindBalanceTransfer等
 // commission手续费
 func (ms *memberService) TransferWallet(memberId int64, kind int32, amount float32, commission float32,
 	tradeNo string, toTitle string, fromTitle string) error {
-	m := ms._repo.GetMember(memberId)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().TransferWallet(kind, amount, commission,
-		tradeNo, toTitle, fromTitle)
+	return retryInUnitOfWork(func(u *uow.UnitOfWork) error {
+		m := ms.uowRepo(u).GetMember(memberId)
+		if m == nil {
+			return member.ErrNoSuchMember
+		}
+		return m.GetAccount().TransferWallet(kind, amount, commission,
+			tradeNo, toTitle, fromTitle)
+	})
 }
 
 // 转账活动账户,kind为转账类型，如 KindBalanceTransfer等
 // commission手续费
 func (ms *memberService) TransferFlow(memberId int64, kind int32, amount float32,
 	commission float32, tradeNo string, toTitle string, fromTitle string) error {
-	m := ms._repo.GetMember(memberId)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().TransferFlow(kind, amount, commission, tradeNo,
-		toTitle, fromTitle)
+	return retryInUnitOfWork(func(u *uow.UnitOfWork) error {
+		m := ms.uowRepo(u).GetMember(memberId)
+		if m == nil {
+			return member.ErrNoSuchMember
+		}
+		return m.GetAccount().TransferFlow(kind, amount, commission, tradeNo,
+			toTitle, fromTitle)
+	})
 }
 
 // 将活动金转给其他人
 func (ms *memberService) TransferFlowTo(memberId int64, toMemberId int64, kind int32,
 	amount float32, commission float32, tradeNo string, toTitle string,
 	fromTitle string) error {
-	m := ms._repo.GetMember(memberId)
-	if m == nil {
-		return member.ErrNoSuchMember
-	}
-	return m.GetAccount().TransferFlowTo(toMemberId, kind, amount,
-		commission, tradeNo, toTitle, fromTitle)
+	return retryInUnitOfWork(func(u *uow.UnitOfWork) error {
+		m := ms.uowRepo(u).GetMember(memberId)
+		if m == nil {
+			return member.ErrNoSuchMember
+		}
+		return m.GetAccount().TransferFlowTo(toMemberId, kind, amount,
+			commission, tradeNo, toTitle, fromTitle)
+	})
 }
 
 // 根据用户或手机筛选会员
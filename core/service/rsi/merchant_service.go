@@ -321,6 +321,43 @@ func (m *merchantService) GetSaleConf(mchId int32) *merchant.SaleConf {
 	return nil
 }
 
+// 获取订单类型的时效,商户不存在时返回nil
+func (m *merchantService) GetOrderTimeout(mchId int32, orderType int32) *merchant.OrderTimeout {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch != nil {
+		v := mch.ConfManager().GetOrderTimeout(orderType)
+		return &v
+	}
+	return nil
+}
+
+// 获取商户按订单类型的时效设置
+func (m *merchantService) SelectOrderTimeout(mchId int32) []*merchant.OrderTimeout {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch != nil {
+		return mch.ConfManager().SelectOrderTimeout()
+	}
+	return []*merchant.OrderTimeout{}
+}
+
+// 保存订单类型的时效设置
+func (m *merchantService) SaveOrderTimeout(mchId int32, v *merchant.OrderTimeout) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch != nil {
+		return mch.ConfManager().SaveOrderTimeout(v)
+	}
+	return merchant.ErrNoSuchMerchant
+}
+
+// 删除订单类型的时效设置
+func (m *merchantService) DeleteOrderTimeout(mchId int32, orderType int32) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch != nil {
+		return mch.ConfManager().DeleteOrderTimeout(orderType)
+	}
+	return merchant.ErrNoSuchMerchant
+}
+
 func (m *merchantService) GetShopsOfMerchant(mchId int32) []*shop.Shop {
 	mch := m._mchRepo.GetMerchant(mchId)
 	shops := mch.ShopManager().GetShops()
@@ -357,6 +394,9 @@ func (m *merchantService) SaveApiInfo(mchId int32, d *merchant.ApiInfo) error {
 // 获取API接口
 func (m *merchantService) GetApiInfo(mchId int32) *merchant.ApiInfo {
 	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return nil
+	}
 	v := mch.ApiManager().GetApiInfo()
 	return &v
 }
@@ -370,11 +410,152 @@ func (m *merchantService) ApiPerm(mchId int32, enabled bool) error {
 	return mch.ApiManager().DisableApiPerm()
 }
 
+// 轮换接口密钥,返回新密钥
+func (m *merchantService) RotateApiSecret(mchId int32) (string, error) {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return "", merchant.ErrNoSuchMerchant
+	}
+	return mch.ApiManager().RotateSecret()
+}
+
+// 撤销轮换前的接口密钥
+func (m *merchantService) RevokeOldApiSecret(mchId int32) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return merchant.ErrNoSuchMerchant
+	}
+	return mch.ApiManager().RevokeOldSecret()
+}
+
+// 获取通知地址
+func (m *merchantService) GetWebhooks(mchId int32) []*merchant.Webhook {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return []*merchant.Webhook{}
+	}
+	return mch.ApiManager().GetWebhooks()
+}
+
+// 保存通知地址
+func (m *merchantService) SaveWebhook(mchId int32, v *merchant.Webhook) (int32, error) {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return 0, merchant.ErrNoSuchMerchant
+	}
+	return mch.ApiManager().SaveWebhook(v)
+}
+
+// 删除通知地址
+func (m *merchantService) DeleteWebhook(mchId int32, id int32) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return merchant.ErrNoSuchMerchant
+	}
+	return mch.ApiManager().DeleteWebhook(id)
+}
+
+// 发布商户事件通知
+func (m *merchantService) PublishWebhook(mchId int32, event string, data interface{}) (int, error) {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return 0, merchant.ErrNoSuchMerchant
+	}
+	return mch.ApiManager().PublishWebhook(event, data)
+}
+
+// 发送通知消息,一般由系统自动调用
+func (m *merchantService) DeliverWebhook(mchId int32, msgId int64) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return merchant.ErrNoSuchMerchant
+	}
+	return mch.ApiManager().DeliverWebhook(msgId)
+}
+
+// 重新发送通知消息
+func (m *merchantService) RedeliverWebhook(mchId int32, msgId int64) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return merchant.ErrNoSuchMerchant
+	}
+	return mch.ApiManager().RedeliverWebhook(msgId)
+}
+
+// 获取通知消息的发送日志
+func (m *merchantService) GetWebhookLogs(mchId int32, msgId int64) []*merchant.WebhookLog {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return []*merchant.WebhookLog{}
+	}
+	return mch.ApiManager().GetWebhookLogs(msgId)
+}
+
+// 保存接口每日调用统计
+func (m *merchantService) SaveApiUsage(mchId int32, v *merchant.ApiUsage) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return merchant.ErrNoSuchMerchant
+	}
+	return mch.ApiManager().SaveApiUsage(v)
+}
+
+// 获取接口调用统计,日期格式如:20261019
+func (m *merchantService) GetApiUsages(mchId int32, begin int, end int) []*merchant.ApiUsage {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return []*merchant.ApiUsage{}
+	}
+	return mch.ApiManager().GetApiUsages(begin, end)
+}
+
 // 根据API ID获取MerchantId
 func (m *merchantService) GetMerchantIdByApiId(apiId string) int32 {
 	return m._mchRepo.GetMerchantIdByApiId(apiId)
 }
 
+// 获取分销佣金规则
+func (m *merchantService) GetCommissionRules(mchId int32) []*merchant.CommissionRule {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch != nil {
+		return mch.DistributionManager().GetRules()
+	}
+	return []*merchant.CommissionRule{}
+}
+
+// 保存分销佣金规则
+func (m *merchantService) SaveCommissionRule(mchId int32, v *merchant.CommissionRule) (int32, error) {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return 0, merchant.ErrNoSuchMerchant
+	}
+	return mch.DistributionManager().SaveRule(v)
+}
+
+// 删除分销佣金规则
+func (m *merchantService) DeleteCommissionRule(mchId int32, id int32) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return merchant.ErrNoSuchMerchant
+	}
+	return mch.DistributionManager().DeleteRule(id)
+}
+
+// 发放售后期结束的佣金
+func (m *merchantService) ReleaseCommission(mchId int32, id int64) error {
+	mch := m._mchRepo.GetMerchant(mchId)
+	if mch == nil {
+		return merchant.ErrNoSuchMerchant
+	}
+	return mch.DistributionManager().Release(id)
+}
+
+// 获取推荐人的佣金报表,mchId为0时统计全部商户
+func (m *merchantService) GetCommissionReport(mchId int32, memberId int64,
+	begin int64, end int64) *merchant.CommissionReport {
+	return m._mchRepo.GetCommissionReport(mchId, memberId, begin, end)
+}
+
 // 获取所有会员等级
 func (m *merchantService) GetMemberLevels(mchId int32) []*merchant.MemberLevel {
 	mch := m._mchRepo.GetMerchant(mchId)
@@ -9,18 +9,23 @@
 package rsi
 
 import (
+	"github.com/jsix/gof/storage"
 	"go2o/core/domain/interface/mss"
 	"go2o/core/domain/interface/mss/notify"
 	"go2o/core/dto"
+	"go2o/core/infrastructure/push"
+	"log"
 )
 
 type mssService struct {
-	_rep mss.IMssRepo
+	_rep     mss.IMssRepo
+	_storage storage.Interface
 }
 
-func NewMssService(rep mss.IMssRepo) *mssService {
+func NewMssService(rep mss.IMssRepo, sto storage.Interface) *mssService {
 	return &mssService{
-		_rep: rep,
+		_rep:     rep,
+		_storage: sto,
 	}
 }
 
@@ -102,11 +107,17 @@ func (ms *mssService) SendSiteNotifyMessage(senderId int32, toRole int,
 	} else {
 		v.ToRole = toRole
 	}
-	var err error
 	m := ms._rep.MessageManager().CreateMessage(v, msg)
-	if _, err = m.Save(); err == nil {
+	id, err := m.Save()
+	if err == nil {
 		err = m.Send(nil)
 	}
+	if err == nil {
+		if toRole <= 0 {
+			toRole = push.RoleAll
+		}
+		ms.pushSiteMessage(id, toRole, 0, msg)
+	}
 	return err
 }
 
@@ -134,14 +145,48 @@ func (ms *mssService) SendSiteMessageToUser(senderId int32, toRole int, toUser i
 		Readonly: 1,
 	}
 
-	var err error
 	m := ms._rep.MessageManager().CreateMessage(v, msg)
-	if _, err = m.Save(); err == nil {
+	id, err := m.Save()
+	if err == nil {
 		err = m.Send(nil)
 	}
+	// 推送给在线的用户
+	if err == nil {
+		ms.pushSiteMessage(id, toRole, toUser, msg)
+	}
 	return err
 }
 
+// 推送站内信,toUser为0时广播给角色的所有用户
+func (ms *mssService) pushSiteMessage(id int32, toRole int, toUser int64, msg *notify.SiteMessage) {
+	data := map[string]interface{}{
+		"id":      id,
+		"subject": msg.Subject,
+		"message": msg.Message,
+	}
+	e := &push.Event{Type: push.EventMessage, Data: data}
+	conn := ms._storage.(storage.IRedisStorage).GetConn()
+	defer conn.Close()
+	var err error
+	switch {
+	case toUser <= 0:
+		e.Role = toRole
+	case toRole == mss.RoleMember:
+		e.MemberId = toUser
+		_, err = push.Publish(conn, toUser, push.TopicMessage, data)
+	case toRole == mss.RoleMerchant:
+		e.MchId = int32(toUser)
+	default:
+		return
+	}
+	if err == nil {
+		err = push.PublishEvent(conn, e)
+	}
+	if err != nil {
+		log.Println("[ Push][ Message][ Error]:", toUser, err.Error())
+	}
+}
+
 // 获取站内信
 func (m *mssService) GetSiteMessage(id, toUserId int32, toRole int) *dto.SiteMessage {
 	msg := m._rep.MessageManager().GetMessage(id)
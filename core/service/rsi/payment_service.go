@@ -11,9 +11,18 @@ package rsi
 import (
 	"go2o/core/domain/interface/order"
 	"go2o/core/domain/interface/payment"
+	"go2o/core/infrastructure/uow"
 	"go2o/core/module"
 	"go2o/core/service/thrift/idl/gen-go/define"
 	"go2o/core/service/thrift/parser"
+	"time"
+)
+
+const (
+	// 完成支付的幂等范围
+	idemFinishPayment = "payment_finish"
+	// 网关会在数日内重复通知,幂等键保留7天
+	paymentIdemWindow = 7 * 24 * time.Hour
 )
 
 type paymentService struct {
@@ -130,15 +139,29 @@ func (p *paymentService) HybridPayment(orderId int32, remark string) (r *define.
 	return parser.Result(0, err), nil
 }
 
-// 完成支付单支付，并传入支付方式及外部订单号
+// 完成支付单支付，并传入支付方式及外部订单号。
+// 以支付方式、支付单号及外部订单号作为幂等键,网关重复通知时返回首次的结果
 func (p *paymentService) FinishPayment(tradeNo string, spName string,
 	outerNo string) (r *define.Result_, err error) {
-	o := p._rep.GetPaymentOrder(tradeNo)
-	if o == nil {
-		err = payment.ErrNoSuchPaymentOrder
-	} else {
-		err = o.PaymentFinish(spName, outerNo)
+	key := ""
+	if outerNo != "" {
+		key = spName + ":" + tradeNo + ":" + outerNo
 	}
+	return p.FinishPaymentIdempotent(key, tradeNo, spName, outerNo)
+}
+
+// 使用幂等键完成支付单支付,窗口期内相同的键不再重复结算
+func (p *paymentService) FinishPaymentIdempotent(idempotencyKey string, tradeNo string,
+	spName string, outerNo string) (r *define.Result_, err error) {
+	// 支付单与订单状态在同一事务中提交
+	err = runIdempotent(idemFinishPayment, idempotencyKey, paymentIdemWindow, nil,
+		func(u *uow.UnitOfWork) error {
+			o := uow.Bind(u, p._rep).(payment.IPaymentRepo).GetPaymentOrder(tradeNo)
+			if o == nil {
+				return payment.ErrNoSuchPaymentOrder
+			}
+			return o.PaymentFinish(spName, outerNo)
+		})
 	return parser.Result(0, err), nil
 }
 
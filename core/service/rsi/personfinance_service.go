@@ -40,8 +40,8 @@ func (p *personFinanceService) GetRiseInfo(personId int64) (
 	return pf.GetRiseInfo().Value()
 }
 
-// 开通增利服务
-func (p *personFinanceService) OpenRiseService(personId int64) error {
+// 开通增利服务,planId为选择的理财计划
+func (p *personFinanceService) OpenRiseService(personId int64, planId int32) error {
 	m := p._accRepo.GetMember(personId)
 	if m == nil {
 		return member.ErrNoSuchMember
@@ -50,7 +50,7 @@ func (p *personFinanceService) OpenRiseService(personId int64) error {
 		return errors.New("会员等级不够,请升级后再开通理财账户！")
 	}
 	pf := p._rep.GetPersonFinance(personId)
-	return pf.CreateRiseInfo()
+	return pf.CreateRiseInfo(planId)
 }
 
 // 提交转入/转出日志
@@ -88,13 +88,16 @@ func (p *personFinanceService) RiseTransferOut(personId int64,
 		return member.ErrNoSuchMember
 	}
 	acc := m.GetAccount()
+	// 转出时扣除锁定期内转出的违约金,银行转出按转出日志的金额打款
+	var penalty float32
 
 	if transferWith == personfinance.TransferOutWithBalance {
 		//转入余额
-		if err = r.TransferOut(amount, transferWith, personfinance.RiseStateOk); err == nil {
+		if penalty, err = r.TransferOut(amount, transferWith,
+			personfinance.RiseStateOk); err == nil {
 			err = acc.Charge(member.AccountBalance,
 				member.KindBalanceSystemCharge, variable.AliasGrowthAccount+"转出",
-				domain.NewTradeNo(10000), amount, member.DefaultRelateUser)
+				domain.NewTradeNo(10000), amount-penalty, member.DefaultRelateUser)
 			if err != nil {
 				log.Println("[ TransferOut][ Error]:", err.Error())
 			}
@@ -105,10 +108,11 @@ func (p *personFinanceService) RiseTransferOut(personId int64,
 
 	if transferWith == personfinance.TransferFromWithWallet {
 		//转入钱包
-		if err = r.TransferOut(amount, transferWith, personfinance.RiseStateOk); err == nil {
+		if penalty, err = r.TransferOut(amount, transferWith,
+			personfinance.RiseStateOk); err == nil {
 			err = acc.Charge(member.AccountWallet,
 				member.KindWalletAdd, variable.AliasGrowthAccount+"转出",
-				domain.NewTradeNo(10000), amount, member.DefaultRelateUser)
+				domain.NewTradeNo(10000), amount-penalty, member.DefaultRelateUser)
 			if err != nil {
 				log.Println("[ TransferOut][ Error]:", err.Error())
 			}
@@ -121,7 +125,7 @@ func (p *personFinanceService) RiseTransferOut(personId int64,
 		if b := m.Profile().GetBank(); !b.Right() || !b.Locked() {
 			return member.ErrNoSuchBankInfo
 		}
-		if err = r.TransferOut(amount, transferWith,
+		if _, err = r.TransferOut(amount, transferWith,
 			personfinance.RiseStateOk); err == nil {
 			err = pf.SyncToAccount()
 		}
@@ -131,13 +135,44 @@ func (p *personFinanceService) RiseTransferOut(personId int64,
 	return errors.New("暂时无法提供服务")
 }
 
-// 结算收益(按日期每天结息)
+// 结算收益(按日期每天结息),按会员理财计划在结算日的利率计算
 func (p *personFinanceService) RiseSettleByDay(personId int64,
-	settleUnix int64, dayRatio float32) (err error) {
+	settleUnix int64) (err error) {
 	pf := p._rep.GetPersonFinance(personId)
 	r := pf.GetRiseInfo()
+	dayRatio := r.GetDayRatio(settleUnix)
 	if err = r.RiseSettleByDay(settleUnix, dayRatio); err != nil {
 		return err
 	}
 	return pf.SyncToAccount() //同步到会员账户
 }
+
+// 获取全部理财计划
+func (p *personFinanceService) GetPlans() []*personfinance.FinancePlan {
+	return p._rep.GetPlanManager().GetAllPlans()
+}
+
+// 获取理财计划
+func (p *personFinanceService) GetPlan(planId int32) *personfinance.FinancePlan {
+	return p._rep.GetPlanManager().GetPlan(planId)
+}
+
+// 保存理财计划
+func (p *personFinanceService) SavePlan(v *personfinance.FinancePlan) (int32, error) {
+	return p._rep.GetPlanManager().SavePlan(v)
+}
+
+// 获取理财计划的利率表
+func (p *personFinanceService) GetPlanRates(planId int32) []*personfinance.PlanRate {
+	return p._rep.GetPlanManager().GetRates(planId)
+}
+
+// 保存理财计划利率
+func (p *personFinanceService) SavePlanRate(v *personfinance.PlanRate) (int32, error) {
+	return p._rep.GetPlanManager().SaveRate(v)
+}
+
+// 删除理财计划利率
+func (p *personFinanceService) DeletePlanRate(planId int32, id int32) error {
+	return p._rep.GetPlanManager().DeleteRate(planId, id)
+}
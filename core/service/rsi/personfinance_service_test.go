@@ -0,0 +1,141 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : personfinance_service_test.go
+ * author : jarryliu
+ * date : 2026-10-21 17:10
+ * description :
+ * history :
+ */
+package rsi
+
+import (
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/personfinance"
+	"go2o/core/infrastructure/tool"
+	"go2o/core/repository/memory"
+	"testing"
+	"time"
+)
+
+// 创建理财账户,ins为已确认转入的金额及转入时间
+func createRiseInfo(t *testing.T, r *memory.Repos, ins map[time.Time]float32) int64 {
+	m := r.MemberRepo.CreateMember(&member.Member{
+		Usr: "finance001",
+		Pwd: "123456",
+	})
+	memberId, err := m.Save()
+	if err != nil {
+		t.Fatal("创建会员失败:", err)
+	}
+	err = r.MemberRepo.SaveBankInfo(&member.BankInfo{
+		MemberId:    memberId,
+		BankName:    "测试银行",
+		Account:     "6222000000000000",
+		AccountName: "测试用户",
+		IsLocked:    member.BankLocked,
+	})
+	if err != nil {
+		t.Fatal("保存银行信息失败:", err)
+	}
+	planId, err := r.FinanceRepo.GetPlanManager().SavePlan(&personfinance.FinancePlan{
+		Name:           "锁定30天",
+		LockDays:       30,
+		MinTransferIn:  1,
+		MinTransferOut: 1,
+		PenaltyRate:    0.1,
+		Enabled:        1,
+	})
+	if err != nil {
+		t.Fatal("保存理财计划失败:", err)
+	}
+	v := &personfinance.RiseInfoValue{PersonId: memberId, PlanId: planId}
+	for dt, amount := range ins {
+		v.Balance += amount
+		v.SettlementAmount += amount
+		v.TotalAmount += amount
+		_, err = r.FinanceRepo.SaveRiseLog(&personfinance.RiseLog{
+			PersonId:   memberId,
+			Title:      "[转入]测试转入",
+			Amount:     amount,
+			Type:       personfinance.RiseTypeTransferIn,
+			State:      personfinance.RiseStateOk,
+			UnixDate:   tool.GetStartDate(dt).Unix(),
+			LogTime:    dt.Unix(),
+			UpdateTime: dt.Unix(),
+		})
+		if err != nil {
+			t.Fatal("保存转入日志失败:", err)
+		}
+	}
+	if _, err = r.FinanceRepo.SaveRiseInfo(v); err != nil {
+		t.Fatal("保存理财账户失败:", err)
+	}
+	return memberId
+}
+
+// 获取今日的日志金额
+func riseLogAmount(r *memory.Repos, personId int64, riseType int) float32 {
+	var amount float32
+	for _, v := range r.FinanceRepo.GetRiseLogs(personId,
+		tool.GetStartDate(time.Now()).Unix(), riseType) {
+		amount += v.Amount
+	}
+	return amount
+}
+
+// 测试锁定期内外转出到余额、钱包及银行的违约金,
+// 锁定期按每笔转入分别计算,仅锁定期内的部分收取违约金
+func TestRiseTransferOutPenalty(t *testing.T) {
+	now := time.Now()
+	expired := now.AddDate(0, 0, -31)
+	cases := []struct {
+		name    string
+		ins     map[time.Time]float32
+		amount  float32
+		penalty float32
+	}{
+		{"锁定期内", map[time.Time]float32{now: 100}, 100, 10},
+		{"锁定期外", map[time.Time]float32{expired: 100}, 100, 0},
+		{"部分锁定", map[time.Time]float32{expired: 100, now: 100}, 150, 5},
+	}
+	ways := []personfinance.TransferWith{
+		personfinance.TransferOutWithBalance,
+		personfinance.TransferOutWithWallet,
+		personfinance.TransferOutWithBank,
+	}
+	for _, c := range cases {
+		for _, w := range ways {
+			r := memory.NewRepos()
+			personId := createRiseInfo(t, r, c.ins)
+			s := NewPersonFinanceService(r.FinanceRepo, r.MemberRepo)
+			if err := s.RiseTransferOut(personId, w, c.amount); err != nil {
+				t.Fatalf("%s转出到%s失败:%s", c.name,
+					personfinance.TransferOutWithText(w), err)
+			}
+			net := c.amount - c.penalty
+			if v := riseLogAmount(r, personId, personfinance.RiseTypeTransferOut); v != net {
+				t.Errorf("%s转出到%s,转出日志金额为%.2f,应为%.2f", c.name,
+					personfinance.TransferOutWithText(w), v, net)
+			}
+			if v := riseLogAmount(r, personId, personfinance.RiseTypePenalty); v != c.penalty {
+				t.Errorf("%s转出到%s,违约金为%.2f,应为%.2f", c.name,
+					personfinance.TransferOutWithText(w), v, c.penalty)
+			}
+			// 转出到余额或钱包时入账扣除违约金后的金额,
+			// 银行转出按转出日志的金额打款,不入账户
+			acc := r.MemberRepo.GetAccount(personId)
+			balance, wallet := acc.Balance, acc.WalletBalance
+			switch w {
+			case personfinance.TransferOutWithBalance:
+				balance -= net
+			case personfinance.TransferOutWithWallet:
+				wallet -= net
+			}
+			if balance != 0 || wallet != 0 {
+				t.Errorf("%s转出到%s,余额为%.2f,钱包为%.2f,应入账%.2f", c.name,
+					personfinance.TransferOutWithText(w), acc.Balance,
+					acc.WalletBalance, net)
+			}
+		}
+	}
+}
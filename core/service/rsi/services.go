@@ -18,7 +18,10 @@ import (
 	"go2o/app"
 	"go2o/core/dao"
 	"go2o/core/factory"
+	"go2o/core/infrastructure/cache"
 	"go2o/core/infrastructure/domain"
+	"go2o/core/infrastructure/idempotent"
+	"go2o/core/infrastructure/uow"
 	"go2o/core/query"
 	"go2o/core/variable"
 	"strconv"
@@ -63,6 +66,9 @@ var (
 	PortalService *portalService
 
 	CommonDao *dao.CommonDao
+
+	// 工作单元使用的数据库连接
+	uowConn db.Connector
 )
 
 // 处理错误
@@ -74,6 +80,28 @@ func handleError(err error) error {
 	//return err
 }
 
+// 在工作单元中执行,多个仓储的写入在同一事务中提交,出错时回滚
+func runInUnitOfWork(f func(u *uow.UnitOfWork) error) error {
+	return uow.Run(uowConn, f)
+}
+
+// 以幂等键在工作单元中执行,窗口期内相同的键返回首次执行的结果,
+// 发生并发修改冲突时重新执行
+func runIdempotent(scope, key string, window time.Duration, result interface{},
+	f func(u *uow.UnitOfWork) error) error {
+	return domain.RetryOnConflict(domain.DefaultConflictRetries, func() error {
+		return idempotent.Run(uowConn, scope, key, window, result, f)
+	})
+}
+
+// 在工作单元中执行,发生并发修改冲突时回滚并重新执行。
+// 仅用于重新加载聚合后可重复执行的操作
+func retryInUnitOfWork(f func(u *uow.UnitOfWork) error) error {
+	return domain.RetryOnConflict(domain.DefaultConflictRetries, func() error {
+		return runInUnitOfWork(f)
+	})
+}
+
 func Init(ctx gof.App, appFlag int) {
 	Context := ctx
 	db := Context.Db()
@@ -89,9 +117,11 @@ func Init(ctx gof.App, appFlag int) {
 }
 
 func initService(ctx gof.App, db db.Connector, orm orm.Orm, sto storage.Interface) {
-
+	uowConn = db
 	rds := sto.(storage.IRedisStorage)
 	factory.Repo.Init(db, sto)
+	// 接收缓存失效广播
+	go cache.Listen(sto)
 
 	proMRepo := factory.Repo.GetIProModelRepo()
 	valueRepo := factory.Repo.GetValueRepo()
@@ -132,13 +162,14 @@ func initService(ctx gof.App, db db.Connector, orm orm.Orm, sto storage.Interfac
 	PromService = NewPromotionService(promRepo)
 	ShoppingService = NewShoppingService(orderRepo, cartRepo,
 		productRepo, itemRepo, mchRepo, orderQuery)
-	AfterSalesService = NewAfterSalesService(asRepo, afterSalesQuery, orderRepo)
+	AfterSalesService = NewAfterSalesService(asRepo, afterSalesQuery,
+		orderRepo, mchRepo, mssRepo)
 	MerchantService = NewMerchantService(mchRepo, memberRepo, mchQuery, orderQuery)
 	ShopService = NewShopService(shopRepo, mchRepo, shopQuery)
 	MemberService = NewMemberService(MerchantService, memberRepo, memberQue, orderQuery, valueRepo)
 	ItemService = NewSaleService(rds, catRepo, itemRepo, goodsQuery, tagSaleRepo, proMRepo, mchRepo, valueRepo)
 	PaymentService = NewPaymentService(paymentRepo, orderRepo)
-	MssService = NewMssService(mssRepo)
+	MssService = NewMssService(mssRepo, sto)
 	ExpressService = NewExpressService(expressRepo)
 	ShipmentService = NewShipmentService(shipRepo, deliveryRepo)
 	ContentService = NewContentService(contentRepo, contentQue)
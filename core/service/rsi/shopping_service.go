@@ -12,6 +12,7 @@ package rsi
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/jsix/gof/util"
 	"go2o/core/domain/interface/cart"
 	proItem "go2o/core/domain/interface/item"
@@ -21,6 +22,8 @@ import (
 	"go2o/core/domain/interface/product"
 	orderImpl "go2o/core/domain/order"
 	"go2o/core/dto"
+	"go2o/core/infrastructure/idempotent"
+	"go2o/core/infrastructure/uow"
 	"go2o/core/query"
 	"go2o/core/service/thrift/idl/gen-go/define"
 	"go2o/core/service/thrift/parser"
@@ -30,6 +33,12 @@ import (
 
 var _ define.SaleService = new(shoppingService)
 
+// 幂等键的业务范围
+const (
+	idemSubmitOrder    = "order_submit"
+	idemWholesaleOrder = "wholesale_order_submit"
+)
+
 type shoppingService struct {
 	_repo       order.IOrderRepo
 	_itemRepo   product.IProductRepo
@@ -274,12 +283,23 @@ func (s *shoppingService) RetailCartV1(memberId int64, action string, data map[s
 	}, nil
 }
 
-// 提交订单
+// 提交订单,可通过idempotency_key传入幂等键,重复提交时返回首次提交的结果
 func (s *shoppingService) SubmitOrderV1(buyerId int64, cartType int32,
 	data map[string]string) (map[string]string, error) {
-	c := s._cartRepo.GetMyCart(buyerId, cart.KWholesale)
-	iData := orderImpl.NewPostedData(data)
-	rd, err := s._repo.Manager().SubmitWholesaleOrder(c, iData)
+	var rd map[string]string
+	key := data["idempotency_key"]
+	if key != "" {
+		key = fmt.Sprintf("%d:%s", buyerId, key)
+	}
+	err := runIdempotent(idemWholesaleOrder, key, idempotent.DefaultWindow, &rd,
+		func(u *uow.UnitOfWork) error {
+			repo := uow.Bind(u, s._repo).(order.IOrderRepo)
+			c := uow.Bind(u, s._cartRepo).(cart.ICartRepo).GetMyCart(buyerId, cart.KWholesale)
+			iData := orderImpl.NewPostedData(data)
+			var err error
+			rd, err = repo.Manager().SubmitWholesaleOrder(c, iData)
+			return err
+		})
 	if err != nil {
 		return map[string]string{
 			"error": err.Error(),
@@ -290,14 +310,19 @@ func (s *shoppingService) SubmitOrderV1(buyerId int64, cartType int32,
 
 //  获取购物车
 func (s *shoppingService) getShoppingCart(buyerId int64, code string) cart.ICart {
+	return s.loadShoppingCart(s._cartRepo, buyerId, code)
+}
+
+// 从仓储中获取购物车
+func (s *shoppingService) loadShoppingCart(repo cart.ICartRepo, buyerId int64, code string) cart.ICart {
 	var c cart.ICart
 	var cc cart.ICart
 	if len(code) > 0 {
-		cc = s._cartRepo.GetShoppingCartByKey(code)
+		cc = repo.GetShoppingCartByKey(code)
 	}
 	// 如果传入会员编号，则合并购物车
 	if buyerId > 0 {
-		c = s._cartRepo.GetMyCart(buyerId, cart.KRetail)
+		c = repo.GetMyCart(buyerId, cart.KRetail)
 		if cc != nil {
 			rc := c.(cart.IRetailCart)
 			rc.Combine(cc)
@@ -310,7 +335,7 @@ func (s *shoppingService) getShoppingCart(buyerId int64, code string) cart.ICart
 		return cc
 	}
 	// 不存在，则新建购物车
-	c = s._cartRepo.NewRetailCart(code)
+	c = repo.NewRetailCart(code)
 	//_, err := c.Save()
 	//domain.HandleError(err, "service")
 	return c
@@ -497,16 +522,35 @@ func (s *shoppingService) PrepareOrderWithCoupon(buyerId int64, cartCode string,
 	return data, err
 }
 
-func (s *shoppingService) SubmitOrder_V1(buyerId int64, cartCode string,
-	addressId int64, subject string, couponCode string, balanceDiscount bool) (
-	orderNo string, paymentTradeNo string, err error) {
-	c := s.getShoppingCart(buyerId, cartCode)
-	od, err := s._manager.SubmitOrder(c, addressId, couponCode, balanceDiscount)
+// 提交订单,idempotencyKey不为空时,窗口期内重复提交返回首次提交的订单号及支付单号
+func (s *shoppingService) SubmitOrder_V1(idempotencyKey string, buyerId int64,
+	cartCode string, addressId int64, subject string, couponCode string,
+	balanceDiscount bool) (orderNo string, paymentTradeNo string, err error) {
+	var rd struct {
+		OrderNo string
+		TradeNo string
+	}
+	key := idempotencyKey
+	if key != "" {
+		key = fmt.Sprintf("%d:%s", buyerId, key)
+	}
+	// 订单、子订单、支付单、优惠券及库存在同一事务中提交
+	err = runIdempotent(idemSubmitOrder, key, idempotent.DefaultWindow, &rd,
+		func(u *uow.UnitOfWork) error {
+			repo := uow.Bind(u, s._repo).(order.IOrderRepo)
+			c := s.loadShoppingCart(uow.Bind(u, s._cartRepo).(cart.ICartRepo),
+				buyerId, cartCode)
+			od, err := repo.Manager().SubmitOrder(c, addressId, couponCode, balanceDiscount)
+			if err == nil {
+				py := od.(order.INormalOrder).GetPaymentOrder()
+				rd.OrderNo, rd.TradeNo = od.OrderNo(), py.GetTradeNo()
+			}
+			return err
+		})
 	if err != nil {
 		return "", "", err
 	}
-	py := od.(order.INormalOrder).GetPaymentOrder()
-	return od.OrderNo(), py.GetTradeNo(), err
+	return rd.OrderNo, rd.TradeNo, err
 }
 
 // 根据编号获取订单
@@ -657,34 +701,58 @@ func (s *shoppingService) TradeOrderUpdateTicket(orderId int64, img string) (r *
 	return parser.Result64(o.GetAggregateRootId(), err), nil
 }
 
+// 在工作单元中变更订单状态,订单已被修改时重新加载并重试
+func (s *shoppingService) changeOrderState(orderNo string, sub bool,
+	f func(c order.IUnifiedOrderAdapter) error) error {
+	return retryInUnitOfWork(func(u *uow.UnitOfWork) error {
+		repo := uow.Bind(u, s._repo).(order.IOrderRepo)
+		return f(repo.Manager().Unified(orderNo, sub))
+	})
+}
+
 // 取消订单
 func (s *shoppingService) CancelOrder(orderNo string, sub bool, reason string) error {
-	c := s._manager.Unified(orderNo, sub)
-	return c.Cancel(reason)
+	return s.changeOrderState(orderNo, sub, func(c order.IUnifiedOrderAdapter) error {
+		return c.Cancel(reason)
+	})
 }
 
 // 确定订单
 func (s *shoppingService) ConfirmOrder(orderNo string, sub bool) error {
-	c := s._manager.Unified(orderNo, sub)
-	return c.Confirm()
+	return s.changeOrderState(orderNo, sub, func(c order.IUnifiedOrderAdapter) error {
+		return c.Confirm()
+	})
 }
 
 // 备货完成
 func (s *shoppingService) PickUp(orderNo string, sub bool) error {
-	c := s._manager.Unified(orderNo, sub)
-	return c.PickUp()
+	return s.changeOrderState(orderNo, sub, func(c order.IUnifiedOrderAdapter) error {
+		return c.PickUp()
+	})
 }
 
 // 订单发货,并记录配送服务商编号及单号
 func (s *shoppingService) Ship(orderNo string, sub bool, spId int32, spOrder string) error {
-	c := s._manager.Unified(orderNo, sub)
-	return c.Ship(spId, spOrder)
+	return s.changeOrderState(orderNo, sub, func(c order.IUnifiedOrderAdapter) error {
+		return c.Ship(spId, spOrder)
+	})
 }
 
 // 消费者收货
 func (s *shoppingService) BuyerReceived(orderNo string, sub bool) error {
-	c := s._manager.Unified(orderNo, sub)
-	return c.BuyerReceived()
+	return s.changeOrderState(orderNo, sub, func(c order.IUnifiedOrderAdapter) error {
+		return c.BuyerReceived()
+	})
+}
+
+// 买家延长收货时间,每个订单只能延长一次
+func (s *shoppingService) ExtendReceive(buyerId int64, orderNo string, sub bool) error {
+	return s.changeOrderState(orderNo, sub, func(c order.IUnifiedOrderAdapter) error {
+		if o := c.Complex(); o == nil || o.BuyerId != buyerId {
+			return order.ErrNoSuchOrder
+		}
+		return c.ExtendReceive()
+	})
 }
 
 // 根据商品快照获取订单项
@@ -0,0 +1,121 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : limit.go
+ * author : jarryliu
+ * date : 2026-10-19 12:05
+ * description : 服务调用限流
+ * history :
+ */
+package thrift
+
+import (
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"go2o/core"
+	"go2o/core/infrastructure/ratelimit"
+	"log"
+	"strings"
+)
+
+// 每个调用方对每个服务每分钟的调用限额,0为不限制。多个实例共享计数
+var RateLimit = 0
+
+var _ thrift.TProcessor = new(limitProcessor)
+
+// 限流处理器,超出限额的调用返回应用异常
+type limitProcessor struct {
+	processor thrift.TProcessor
+}
+
+func newLimitProcessor(p thrift.TProcessor) thrift.TProcessor {
+	return &limitProcessor{processor: p}
+}
+
+func (l *limitProcessor) Process(in, out thrift.TProtocol) (bool, thrift.TException) {
+	name, typeId, seqId, err := in.ReadMessageBegin()
+	if err != nil {
+		return false, err
+	}
+	if !l.allow(callerOf(in), name) {
+		// 读取失败时无法继续处理该连接的后续消息
+		if err = in.Skip(thrift.STRUCT); err != nil {
+			return false, err
+		}
+		if err = in.ReadMessageEnd(); err != nil {
+			return false, err
+		}
+		e := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION,
+			"rate limit exceeded: "+name)
+		if err = out.WriteMessageBegin(name, thrift.EXCEPTION, seqId); err == nil {
+			if err = e.Write(out); err == nil {
+				if err = out.WriteMessageEnd(); err == nil {
+					err = out.Flush()
+				}
+			}
+		}
+		return err == nil, err
+	}
+	return l.processor.Process(&storedMessageProtocol{
+		TProtocol: in,
+		name:      name,
+		typeId:    typeId,
+		seqId:     seqId,
+	}, out)
+}
+
+// 按调用方及服务名称取出令牌
+func (l *limitProcessor) allow(caller, name string) bool {
+	if RateLimit <= 0 {
+		return true
+	}
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	r, err := ratelimit.Take(conn, rateKey(caller, name), RateLimit)
+	if err != nil {
+		log.Println("[ Go2o][ Thrift][ Limit]: take token failed:", err.Error())
+	}
+	return r.Allowed
+}
+
+// 限流的键,每个调用方的每个服务使用单独的令牌桶,
+// 如:go2o:thrift:rate:10.0.0.1:merchant
+func rateKey(caller, name string) string {
+	service := name
+	if i := strings.Index(name, thrift.MULTIPLEXED_SEPARATOR); i != -1 {
+		service = name[:i]
+	}
+	if caller == "" {
+		caller = "unknown"
+	}
+	return "go2o:thrift:rate:" + caller + ":" + service
+}
+
+// 可获取调用方的协议
+type callerProtocol interface {
+	Caller() string
+}
+
+// 获取调用方标识,未知时返回空
+func callerOf(p thrift.TProtocol) string {
+	for {
+		switch v := p.(type) {
+		case callerProtocol:
+			return v.Caller()
+		case *storedMessageProtocol:
+			p = v.TProtocol
+		default:
+			return ""
+		}
+	}
+}
+
+// 已读取消息头的协议,再次读取时返回已读取的消息头
+type storedMessageProtocol struct {
+	thrift.TProtocol
+	name   string
+	typeId thrift.TMessageType
+	seqId  int32
+}
+
+func (s *storedMessageProtocol) ReadMessageBegin() (string, thrift.TMessageType, int32, error) {
+	return s.name, s.typeId, s.seqId, nil
+}
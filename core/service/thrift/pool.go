@@ -0,0 +1,298 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : pool.go
+ * author : jarryliu
+ * date : 2026-10-19 13:10
+ * description : 限制并发连接数的服务,支持平滑关闭
+ * history :
+ */
+package thrift
+
+import (
+	"crypto/tls"
+	"errors"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrShutdownTimeout = errors.New("shutdown timeout, connections forced to close")
+
+var _ thrift.TServer = new(PoolServer)
+
+// 连接池服务,同时处理的连接数不超过workers,
+// 超出时新连接在监听队列中等待
+type PoolServer struct {
+	processor        thrift.TProcessor
+	serverTransport  thrift.TServerTransport
+	transportFactory thrift.TTransportFactory
+	protocolFactory  thrift.TProtocolFactory
+	workers          chan struct{}
+	quit             chan struct{}
+	wg               sync.WaitGroup
+	mux              sync.Mutex
+	conns            map[*serverConn]struct{}
+	stopped          int32
+}
+
+func NewPoolServer(processor thrift.TProcessor, serverTransport thrift.TServerTransport,
+	transportFactory thrift.TTransportFactory, protocolFactory thrift.TProtocolFactory,
+	workers int) *PoolServer {
+	if workers <= 0 {
+		workers = 100
+	}
+	return &PoolServer{
+		processor:        processor,
+		serverTransport:  serverTransport,
+		transportFactory: transportFactory,
+		protocolFactory:  protocolFactory,
+		workers:          make(chan struct{}, workers),
+		quit:             make(chan struct{}),
+		conns:            make(map[*serverConn]struct{}),
+	}
+}
+
+func (p *PoolServer) ProcessorFactory() thrift.TProcessorFactory {
+	return thrift.NewTProcessorFactory(p.processor)
+}
+
+func (p *PoolServer) ServerTransport() thrift.TServerTransport {
+	return p.serverTransport
+}
+
+func (p *PoolServer) InputTransportFactory() thrift.TTransportFactory {
+	return p.transportFactory
+}
+
+func (p *PoolServer) OutputTransportFactory() thrift.TTransportFactory {
+	return p.transportFactory
+}
+
+func (p *PoolServer) InputProtocolFactory() thrift.TProtocolFactory {
+	return p.protocolFactory
+}
+
+func (p *PoolServer) OutputProtocolFactory() thrift.TProtocolFactory {
+	return p.protocolFactory
+}
+
+// 接收并处理连接,直到服务停止
+func (p *PoolServer) Serve() error {
+	if err := p.serverTransport.Listen(); err != nil {
+		return err
+	}
+	for {
+		client, err := p.serverTransport.Accept()
+		if p.isStopped() {
+			if client != nil {
+				client.Close()
+			}
+			return nil
+		}
+		if err != nil {
+			log.Println("[ Go2o][ Thrift]: accept error:", err.Error())
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		// 等待空闲的工作者
+		select {
+		case p.workers <- struct{}{}:
+		case <-p.quit:
+			client.Close()
+			return nil
+		}
+		// 与停止服务互斥,避免停止后仍添加连接
+		c := &serverConn{trans: client}
+		p.mux.Lock()
+		if p.isStopped() {
+			p.mux.Unlock()
+			<-p.workers
+			client.Close()
+			return nil
+		}
+		p.conns[c] = struct{}{}
+		p.wg.Add(1)
+		p.mux.Unlock()
+		go func() {
+			defer func() {
+				p.untrack(c)
+				<-p.workers
+				p.wg.Done()
+			}()
+			p.processRequests(c)
+		}()
+	}
+}
+
+// 停止服务:不再接收连接,关闭空闲连接,并等待正在处理的调用完成;
+// 超过timeout后强制关闭所有连接
+func (p *PoolServer) Stop() error {
+	return p.StopTimeout(30 * time.Second)
+}
+
+func (p *PoolServer) StopTimeout(timeout time.Duration) error {
+	p.mux.Lock()
+	if p.isStopped() {
+		p.mux.Unlock()
+		return nil
+	}
+	atomic.StoreInt32(&p.stopped, 1)
+	p.mux.Unlock()
+	close(p.quit)
+	p.serverTransport.Interrupt()
+	p.closeConns(false)
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		p.closeConns(true)
+		return ErrShutdownTimeout
+	}
+}
+
+func (p *PoolServer) isStopped() bool {
+	return atomic.LoadInt32(&p.stopped) == 1
+}
+
+// 关闭连接,force为false时仅关闭空闲连接
+func (p *PoolServer) closeConns(force bool) {
+	p.mux.Lock()
+	for c := range p.conns {
+		if force || !c.isBusy() {
+			c.close()
+		}
+	}
+	p.mux.Unlock()
+}
+
+func (p *PoolServer) untrack(c *serverConn) {
+	p.mux.Lock()
+	delete(p.conns, c)
+	p.mux.Unlock()
+}
+
+func (p *PoolServer) processRequests(c *serverConn) {
+	c.conn = rawConn(c.trans)
+	c.caller = clientId(c.conn)
+	trans := p.transportFactory.GetTransport(c.trans)
+	defer trans.Close()
+	in := &connProtocol{
+		TProtocol: p.protocolFactory.GetProtocol(trans),
+		conn:      c,
+	}
+	out := p.protocolFactory.GetProtocol(trans)
+	for {
+		ok, err := p.processor.Process(in, out)
+		c.setBusy(false)
+		if err, ok := err.(thrift.TTransportException); ok && err.TypeId() == thrift.END_OF_FILE {
+			return
+		}
+		if err != nil {
+			if !p.isStopped() {
+				log.Println("[ Go2o][ Thrift]: process error:", err.Error())
+			}
+			return
+		}
+		// 服务停止时,完成当前调用后关闭连接
+		if !ok || p.isStopped() {
+			return
+		}
+	}
+}
+
+// 服务端连接,读取到消息头后标记为处理中
+type serverConn struct {
+	trans thrift.TTransport
+	// 底层连接,可在其他协程中安全关闭
+	conn net.Conn
+	// 调用方标识
+	caller string
+	busy   int32
+}
+
+// 获取传输层的底层连接
+func rawConn(t thrift.TTransport) net.Conn {
+	switch s := t.(type) {
+	case *thrift.TSocket:
+		return s.Conn()
+	case *thrift.TSSLSocket:
+		return s.Conn()
+	}
+	return nil
+}
+
+// 获取客户端标识,使用TLS客户端证书时为证书名称,否则为客户端IP
+func clientId(conn net.Conn) string {
+	if conn == nil {
+		return ""
+	}
+	if tc, ok := conn.(*tls.Conn); ok {
+		// 握手需在读取证书前完成,限制握手时间
+		tc.SetDeadline(time.Now().Add(10 * time.Second))
+		err := tc.Handshake()
+		tc.SetDeadline(time.Time{})
+		if err == nil {
+			if certs := tc.ConnectionState().PeerCertificates; len(certs) > 0 {
+				return certs[0].Subject.CommonName
+			}
+		}
+	}
+	addr := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// 关闭连接,正在读写的协程将返回错误。
+// 优先关闭底层连接,避免与读写协程竞争传输层的状态
+func (s *serverConn) close() {
+	if s.conn != nil {
+		s.conn.Close()
+	} else {
+		s.trans.Close()
+	}
+}
+
+func (s *serverConn) setBusy(b bool) {
+	if b {
+		atomic.StoreInt32(&s.busy, 1)
+	} else {
+		atomic.StoreInt32(&s.busy, 0)
+	}
+}
+
+func (s *serverConn) isBusy() bool {
+	return atomic.LoadInt32(&s.busy) == 1
+}
+
+type connProtocol struct {
+	thrift.TProtocol
+	conn *serverConn
+}
+
+// 调用方标识
+func (c *connProtocol) Caller() string {
+	return c.conn.caller
+}
+
+// 中断连接,用于调用超时
+func (c *connProtocol) Abort() {
+	c.conn.close()
+}
+
+func (c *connProtocol) ReadMessageBegin() (string, thrift.TMessageType, int32, error) {
+	name, typeId, seqId, err := c.TProtocol.ReadMessageBegin()
+	if err == nil {
+		c.conn.setBusy(true)
+	}
+	return name, typeId, seqId, err
+}
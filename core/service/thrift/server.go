@@ -10,44 +10,115 @@ package thrift
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"git.apache.org/thrift.git/lib/go/thrift"
 	"go2o/core/service/rsi"
 	"go2o/core/service/thrift/idl/gen-go/define"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
-func ListenAndServe(addr string, secure bool) error {
-	var err error
-	transportFactory := thrift.NewTFramedTransportFactory(thrift.NewTTransportFactory())
-	protocolFactory := thrift.NewTCompactProtocolFactory()
-	var transport thrift.TServerTransport
-	if secure {
-		cfg := new(tls.Config)
-		if cert, err := tls.LoadX509KeyPair("server.crt", "server.key"); err == nil {
-			cfg.Certificates = append(cfg.Certificates, cert)
-		} else {
-			return err
+// 服务选项
+type Options struct {
+	// 监听地址
+	Addr string
+	// TLS证书文件,为空时不启用TLS
+	CertFile string
+	// TLS私钥文件
+	KeyFile string
+	// 客户端CA证书文件,设置后要求客户端提供有效证书
+	ClientCAFile string
+	// 最大并发连接数
+	Workers int
+	// 连接读写超时,空闲超过该时间的连接将被关闭
+	ClientTimeout time.Duration
+	// 单次调用超时,0为不限制
+	CallTimeout time.Duration
+	// 关闭服务时等待调用完成的时间
+	ShutdownTimeout time.Duration
+}
+
+// 默认选项
+func DefaultOptions(addr string) *Options {
+	return &Options{
+		Addr:            addr,
+		Workers:         100,
+		ClientTimeout:   5 * time.Minute,
+		CallTimeout:     30 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+// 创建服务端传输,设置了证书时使用TLS
+func newServerTransport(o *Options) (thrift.TServerTransport, error) {
+	if o.CertFile == "" {
+		return thrift.NewTServerSocketTimeout(o.Addr, o.ClientTimeout)
+	}
+	cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if o.ClientCAFile != "" {
+		data, err := ioutil.ReadFile(o.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("no certificate found in " + o.ClientCAFile)
 		}
-		transport, err = thrift.NewTSSLServerSocket(addr, cfg)
-	} else {
-		transport, err = thrift.NewTServerSocket(addr)
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
 	}
+	return thrift.NewTSSLServerSocketTimeout(o.Addr, cfg, o.ClientTimeout)
+}
 
-	transport, err = thrift.NewTServerSocket(addr)
-	if err == nil {
-		processor := thrift.NewTMultiplexedProcessor()
-		processor.RegisterProcessor("merchant", define.NewMerchantServiceProcessor(rsi.MerchantService))
-		processor.RegisterProcessor("member", define.NewMemberServiceProcessor(rsi.MemberService))
-		processor.RegisterProcessor("foundation", define.NewFoundationServiceProcessor(rsi.FoundationService))
-		processor.RegisterProcessor("payment", define.NewPaymentServiceProcessor(rsi.PaymentService))
-		processor.RegisterProcessor("sale", define.NewSaleServiceProcessor(rsi.ShoppingService))
-		processor.RegisterProcessor("item", define.NewItemServiceProcessor(rsi.ItemService))
-		processor.RegisterProcessor("shop", define.NewShopServiceProcessor(rsi.ShopService))
-		processor.RegisterProcessor("finance", define.NewFinanceServiceProcessor(rsi.PersonFinanceService))
-		server := thrift.NewTSimpleServer4(processor, transport,
-			transportFactory, protocolFactory)
-		fmt.Println("Starting the thrift server... on ", addr)
-		err = server.Serve()
+// 创建服务
+func NewServer(o *Options) (*PoolServer, error) {
+	transport, err := newServerTransport(o)
+	if err != nil {
+		return nil, err
 	}
-	return err
+	transportFactory := thrift.NewTFramedTransportFactory(thrift.NewTTransportFactory())
+	protocolFactory := thrift.NewTCompactProtocolFactory()
+	processor := thrift.NewTMultiplexedProcessor()
+	processor.RegisterProcessor("merchant", define.NewMerchantServiceProcessor(rsi.MerchantService))
+	processor.RegisterProcessor("member", define.NewMemberServiceProcessor(rsi.MemberService))
+	processor.RegisterProcessor("foundation", define.NewFoundationServiceProcessor(rsi.FoundationService))
+	processor.RegisterProcessor("payment", define.NewPaymentServiceProcessor(rsi.PaymentService))
+	processor.RegisterProcessor("sale", define.NewSaleServiceProcessor(rsi.ShoppingService))
+	processor.RegisterProcessor("item", define.NewItemServiceProcessor(rsi.ItemService))
+	processor.RegisterProcessor("shop", define.NewShopServiceProcessor(rsi.ShopService))
+	processor.RegisterProcessor("finance", define.NewFinanceServiceProcessor(rsi.PersonFinanceService))
+	p := newCallProcessor(newLimitProcessor(processor), o.CallTimeout)
+	return NewPoolServer(p, transport, transportFactory, protocolFactory, o.Workers), nil
+}
+
+// 启动服务,收到退出信号后停止接收连接,并等待调用完成
+func ListenAndServe(o *Options) error {
+	s, err := NewServer(o)
+	if err != nil {
+		return err
+	}
+	go func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		sig := <-ch
+		log.Println("[ Go2o][ Thrift]: received", sig.String(), ", shutting down...")
+		if err := s.StopTimeout(o.ShutdownTimeout); err != nil {
+			log.Println("[ Go2o][ Thrift]: shutdown:", err.Error())
+		}
+	}()
+	fmt.Println("Starting the thrift server... on ", o.Addr)
+	return s.Serve()
 }
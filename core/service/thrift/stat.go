@@ -0,0 +1,172 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : stat.go
+ * author : jarryliu
+ * date : 2026-10-19 13:40
+ * description : 调用统计及超时
+ * history :
+ */
+package thrift
+
+import (
+	"encoding/json"
+	"fmt"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 方法调用统计
+type CallStat struct {
+	// 处理器(服务)名称
+	Processor string `json:"processor"`
+	// 方法名称
+	Method string `json:"method"`
+	// 调用次数
+	Calls int64 `json:"calls"`
+	// 错误次数
+	Errors int64 `json:"errors"`
+	// 超时次数
+	Timeouts int64 `json:"timeouts"`
+	// 总耗时(毫秒)
+	TotalMs int64 `json:"total_ms"`
+	// 最大耗时(毫秒)
+	MaxMs int64 `json:"max_ms"`
+}
+
+var (
+	statMux sync.Mutex
+	stats   = map[string]*CallStat{}
+)
+
+func recordCall(name string, d time.Duration, err error, timeout bool) {
+	statMux.Lock()
+	s, ok := stats[name]
+	if !ok {
+		s = &CallStat{Method: name}
+		if i := strings.Index(name, thrift.MULTIPLEXED_SEPARATOR); i != -1 {
+			s.Processor = name[:i]
+			s.Method = name[i+len(thrift.MULTIPLEXED_SEPARATOR):]
+		}
+		stats[name] = s
+	}
+	ms := int64(d / time.Millisecond)
+	s.Calls++
+	s.TotalMs += ms
+	if ms > s.MaxMs {
+		s.MaxMs = ms
+	}
+	if timeout {
+		s.Timeouts++
+	} else if err != nil {
+		s.Errors++
+	}
+	statMux.Unlock()
+}
+
+type callStatList []*CallStat
+
+func (c callStatList) Len() int      { return len(c) }
+func (c callStatList) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c callStatList) Less(i, j int) bool {
+	if c[i].Processor == c[j].Processor {
+		return c[i].Method < c[j].Method
+	}
+	return c[i].Processor < c[j].Processor
+}
+
+// 获取各方法的调用统计
+func Stats() []*CallStat {
+	statMux.Lock()
+	list := make(callStatList, 0, len(stats))
+	for _, v := range stats {
+		cp := *v
+		list = append(list, &cp)
+	}
+	statMux.Unlock()
+	sort.Sort(list)
+	return list
+}
+
+// 以JSON输出调用统计
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Stats())
+}
+
+var _ thrift.TProcessor = new(callProcessor)
+
+// 记录调用统计,并限制调用时间
+type callProcessor struct {
+	processor thrift.TProcessor
+	timeout   time.Duration
+}
+
+func newCallProcessor(p thrift.TProcessor, timeout time.Duration) thrift.TProcessor {
+	return &callProcessor{processor: p, timeout: timeout}
+}
+
+// 可中断连接的协议
+type abortProtocol interface {
+	Abort()
+}
+
+type processResult struct {
+	ok  bool
+	err thrift.TException
+}
+
+func (c *callProcessor) Process(in, out thrift.TProtocol) (bool, thrift.TException) {
+	name, typeId, seqId, err := in.ReadMessageBegin()
+	if err != nil {
+		return false, err
+	}
+	conn := in
+	in = &storedMessageProtocol{TProtocol: in, name: name, typeId: typeId, seqId: seqId}
+	start := time.Now()
+	if c.timeout <= 0 {
+		r := c.process(name, in, out)
+		recordCall(name, time.Since(start), r.err, false)
+		return r.ok, r.err
+	}
+	ch := make(chan processResult, 1)
+	go func() {
+		ch <- c.process(name, in, out)
+	}()
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-ch:
+		recordCall(name, time.Since(start), r.err, false)
+		return r.ok, r.err
+	case <-timer.C:
+		// 超时后中断连接,客户端将收到连接错误
+		recordCall(name, time.Since(start), nil, true)
+		log.Println("[ Go2o][ Thrift]: call timeout:", name)
+		if a, ok := conn.(abortProtocol); ok {
+			a.Abort()
+		}
+		// 等待调用结束后再返回,避免调用仍在读写时释放工作者及传输层
+		<-ch
+		return false, thrift.NewTTransportException(thrift.TIMED_OUT,
+			fmt.Sprintf("call %s timeout after %s", name, c.timeout))
+	}
+}
+
+// 处理调用,发生panic时返回应用异常
+func (c *callProcessor) process(name string, in, out thrift.TProtocol) (r processResult) {
+	defer func() {
+		if e := recover(); e != nil {
+			log.Println("[ Go2o][ Thrift][ Panic]:", name, e, "\n", string(debug.Stack()))
+			r = processResult{false, thrift.NewTApplicationException(
+				thrift.INTERNAL_ERROR, fmt.Sprintf("%v", e))}
+		}
+	}()
+	ok, err := c.processor.Process(in, out)
+	return processResult{ok, err}
+}
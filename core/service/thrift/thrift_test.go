@@ -10,11 +10,14 @@ package thrift
 
 import (
 	"errors"
+	"git.apache.org/thrift.git/lib/go/thrift"
 	"github.com/jsix/gof/log"
 	"go2o/core/infrastructure/domain"
 	"go2o/core/service/thrift/idl/gen-go/define"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestLogin(t *testing.T) {
@@ -59,3 +62,79 @@ func TestSSORegister(t *testing.T) {
 		t.Log("连接失败：", err.Error())
 	}
 }
+
+// 测试调用统计
+func TestCallStats(t *testing.T) {
+	recordCall("member:GetMember", 20*time.Millisecond, nil, false)
+	recordCall("member:GetMember", 50*time.Millisecond, errors.New("error"), false)
+	recordCall("member:GetMember", time.Second, nil, true)
+	for _, v := range Stats() {
+		if v.Processor == "member" && v.Method == "GetMember" {
+			if v.Calls != 3 || v.Errors != 1 || v.Timeouts != 1 || v.MaxMs != 1000 {
+				t.Errorf("统计错误:%#v", v)
+			}
+			return
+		}
+	}
+	t.Error("未找到统计")
+}
+
+// 测试按调用方及服务限流
+func TestRateKey(t *testing.T) {
+	k1 := rateKey("10.0.0.1", "member:GetMember")
+	if k1 != rateKey("10.0.0.1", "member:CheckLogin") {
+		t.Error("同一调用方的同一服务应使用相同的令牌桶")
+	}
+	if k1 == rateKey("10.0.0.2", "member:GetMember") {
+		t.Error("不同调用方应使用不同的令牌桶")
+	}
+	if k1 == rateKey("10.0.0.1", "merchant:GetMerchant") {
+		t.Error("不同服务应使用不同的令牌桶")
+	}
+	in := &storedMessageProtocol{TProtocol: &connProtocol{
+		conn: &serverConn{caller: "erp"}}}
+	if c := callerOf(in); c != "erp" {
+		t.Errorf("调用方不正确:%s", c)
+	}
+}
+
+// 只返回消息头的协议,记录是否被中断
+type timeoutProtocol struct {
+	thrift.TProtocol
+	aborted int32
+}
+
+func (p *timeoutProtocol) ReadMessageBegin() (string, thrift.TMessageType, int32, error) {
+	return "member:GetMember", thrift.CALL, 1, nil
+}
+
+func (p *timeoutProtocol) Abort() {
+	atomic.StoreInt32(&p.aborted, 1)
+}
+
+// 执行时间超过超时时间的处理器
+type slowProcessor struct {
+	done int32
+}
+
+func (s *slowProcessor) Process(in, out thrift.TProtocol) (bool, thrift.TException) {
+	time.Sleep(100 * time.Millisecond)
+	atomic.StoreInt32(&s.done, 1)
+	return true, nil
+}
+
+// 测试调用超时时中断连接,并等待调用结束后返回
+func TestCallTimeout(t *testing.T) {
+	sp := &slowProcessor{}
+	in := &timeoutProtocol{}
+	_, err := newCallProcessor(sp, 10*time.Millisecond).Process(in, in)
+	if e, ok := err.(thrift.TTransportException); !ok || e.TypeId() != thrift.TIMED_OUT {
+		t.Errorf("应返回超时错误:%v", err)
+	}
+	if atomic.LoadInt32(&in.aborted) != 1 {
+		t.Error("超时后未中断连接")
+	}
+	if atomic.LoadInt32(&sp.done) != 1 {
+		t.Error("调用未结束时已返回")
+	}
+}
@@ -200,3 +200,56 @@ func TestOrderExchange(t *testing.T) {
 	log.Println("售后单状态为:", ro.Value().State, ro.Value().State == afterSales.StatCompleted)
 	log.Printf("%#v", ro.Value().Data)
 }
+
+// 测试售后单调解及仲裁
+func TestAfterSalesArbitrate(t *testing.T) {
+	subOrderNo := "100000160304"
+	orderRepo := ti.OrderRepo
+	rep := ti.AfterSalesRepo
+	orderId := orderRepo.GetOrderId(subOrderNo, true)
+	od := orderRepo.Manager().GetSubOrder(orderId)
+	ro := rep.CreateAfterSalesOrder(&afterSales.AfterSalesOrder{
+		OrderId: od.GetDomainId(),
+		Type:    afterSales.TypeRefund,
+		Reason:  "商品与描述不符,申请退款",
+	})
+	item := od.Items()[0]
+	err := ro.SetItem(item.SnapshotId, item.Quantity)
+	if err == nil {
+		_, err = ro.Submit()
+	}
+	if err == nil {
+		err = ro.Decline("商品没有问题")
+	}
+	if err == nil {
+		err = ro.RequestIntercede()
+	}
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	v := ro.Value()
+	_, err = ro.PostMessage(afterSales.RoleBuyer, v.BuyerId, "商品有破损",
+		[]string{"as/1.jpg", "as/2.jpg"})
+	if err != nil {
+		t.Error("买家留言:", err.Error())
+	}
+	_, err = ro.PostMessage(afterSales.RoleVendor, v.BuyerId, "冒充商户", nil)
+	if err != afterSales.ErrMessageRole {
+		t.Error("留言未校验角色")
+	}
+	err = ro.Arbitrate(&afterSales.Arbitration{
+		ArbiterId:   1,
+		ArbiterName: "客服",
+		Outcome:     afterSales.ArbitrateForBuyer,
+		Remark:      "凭证有效,支持退款",
+	})
+	if err != nil {
+		t.Error("仲裁:", err.Error())
+		t.FailNow()
+	}
+	if ro.GetArbitration() == nil || len(ro.Messages()) != 1 {
+		t.Error("仲裁记录或留言未保存")
+	}
+	log.Println("售后单状态:", afterSales.Stat(ro.Value().State).String())
+}
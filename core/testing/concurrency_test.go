@@ -0,0 +1,125 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : concurrency_test.go
+ * author : jarryliu
+ * date : 2026-10-19 23:50
+ * description : 乐观并发控制,重现并发保存时的更新丢失
+ * history :
+ */
+package testing
+
+import (
+	"go2o/core/domain/interface/member"
+	"go2o/core/infrastructure/domain"
+	"go2o/core/infrastructure/uow"
+	"go2o/core/testing/ti"
+	"sync"
+	"testing"
+)
+
+// 测试账户被其他操作修改后,使用旧数据保存返回冲突
+func TestAccountLostUpdate(t *testing.T) {
+	var memberId int64 = 1
+	repo := ti.MemberRepo
+	a1 := repo.GetAccount(memberId)
+	a2 := repo.GetAccount(memberId)
+	a1.Balance += 1
+	if _, err := repo.SaveAccount(a1); err != nil {
+		t.Fatal(err)
+	}
+	a2.Balance += 2
+	if _, err := repo.SaveAccount(a2); !domain.IsConflict(err) {
+		t.Fatal("expect conflict error, got", err)
+	}
+	// 还原余额
+	a1.Balance -= 1
+	if _, err := repo.SaveAccount(a1); err != nil {
+		t.Error(err)
+	}
+}
+
+// 测试商品库存被其他操作修改后,使用旧数据保存返回冲突
+func TestItemStockLostUpdate(t *testing.T) {
+	var itemId int64 = 1
+	repo := ti.ItemRepo
+	v1 := repo.GetValueGoodsById(itemId)
+	v2 := repo.GetValueGoodsById(itemId)
+	if v1 == nil {
+		t.Skip("no such item")
+	}
+	v1.StockNum -= 1
+	if _, err := repo.SaveValueGoods(v1); err != nil {
+		t.Fatal(err)
+	}
+	v2.StockNum -= 1
+	if _, err := repo.SaveValueGoods(v2); !domain.IsConflict(err) {
+		t.Fatal("expect conflict error, got", err)
+	}
+	v1.StockNum += 1
+	if _, err := repo.SaveValueGoods(v1); err != nil {
+		t.Error(err)
+	}
+}
+
+// 测试子订单状态被其他操作修改后,使用旧数据保存返回冲突
+func TestSubOrderLostUpdate(t *testing.T) {
+	var orderId int64 = 1
+	repo := ti.OrderRepo
+	o1 := repo.GetSubOrder(orderId)
+	o2 := repo.GetSubOrder(orderId)
+	if o1 == nil {
+		t.Skip("no such order")
+	}
+	// 测试结束后还原备注
+	remark := o1.Remark
+	defer func() {
+		if o := repo.GetSubOrder(orderId); o != nil && o.Remark != remark {
+			o.Remark = remark
+			if _, err := repo.SaveSubOrder(o); err != nil {
+				t.Error("restore remark:", err)
+			}
+		}
+	}()
+	o1.Remark = "并发测试"
+	if _, err := repo.SaveSubOrder(o1); err != nil {
+		t.Fatal(err)
+	}
+	o2.IsSuspend = 1 - o2.IsSuspend
+	if _, err := repo.SaveSubOrder(o2); !domain.IsConflict(err) {
+		t.Fatal("expect conflict error, got", err)
+	}
+}
+
+// 测试并发充值时,冲突重试后不丢失更新
+func TestAccountConcurrentCharge(t *testing.T) {
+	var memberId int64 = 1
+	const n = 5
+	before := ti.MemberRepo.GetAccount(memberId).Balance
+	wg := sync.WaitGroup{}
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- domain.RetryOnConflict(n, func() error {
+				return uow.Run(ti.GetApp().Db(), func(u *uow.UnitOfWork) error {
+					repo := uow.Bind(u, ti.MemberRepo).(member.IMemberRepo)
+					return repo.GetMember(memberId).GetAccount().Charge(
+						member.AccountBalance, member.KindBalanceCharge,
+						"并发测试", "", 1, 0)
+				})
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	after := ti.MemberRepo.GetAccount(memberId).Balance
+	if after-before != n {
+		t.Errorf("lost update: balance %.2f -> %.2f, expect +%d", before, after, n)
+	}
+}
@@ -0,0 +1,117 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : eventbus_test.go
+ * author : jarryliu
+ * date : 2026-10-19 19:40
+ * description :
+ * history :
+ */
+package testing
+
+import (
+	"github.com/garyburd/redigo/redis"
+	"go2o/core"
+	"go2o/core/domain/interface/events"
+	"go2o/core/infrastructure/eventbus"
+	"go2o/core/testing/ti"
+	"testing"
+	"time"
+)
+
+// 测试发布并消费事件,处理失败的事件重新投递
+func TestEventBusRedeliver(t *testing.T) {
+	ti.GetApp()
+	topic := "test_" + time.Now().Format("150405")
+	e := &testEvent{Id: topic}
+	var times int
+	done := make(chan bool)
+	o := eventbus.DefaultOptions("tester")
+	o.Block = time.Second
+	o.MinIdle = time.Second
+	c := eventbus.NewConsumer(topic, "test", func(m *eventbus.Message) error {
+		times++
+		if times == 1 {
+			panic("first delivery failed")
+		}
+		close(done)
+		return nil
+	}, o)
+	go c.Run()
+	time.Sleep(time.Second)
+	if err := eventbus.Publish(e); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Error("event not redelivered")
+	}
+	c.Stop()
+	if times != 2 {
+		t.Error("deliveries:", times)
+	}
+}
+
+type testEvent struct {
+	Id string
+}
+
+func (t *testEvent) Topic() string { return t.Id }
+func (t *testEvent) Type() string  { return "test" }
+func (t *testEvent) Key() string   { return t.Id }
+
+var _ eventbus.Event = new(events.OrderChanged)
+
+// 测试超过投递次数的事件已从流中删除时,转入仅记录原事件编号的死信并确认
+func TestEventBusDeadMissing(t *testing.T) {
+	ti.GetApp()
+	topic := "test_dead_" + time.Now().Format("150405")
+	key := "go2o:stream:" + topic
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	defer conn.Do("DEL", key, "go2o:stream:dead:test", "go2o:stream:error:test")
+	handled := make(chan string, 1)
+	o := eventbus.DefaultOptions("tester")
+	o.Block = time.Second
+	o.MinIdle = time.Second
+	o.MaxDeliveries = 1
+	c := eventbus.NewConsumer(topic, "test", func(m *eventbus.Message) error {
+		select {
+		case handled <- m.Id:
+		default:
+		}
+		panic("handle failed")
+	}, o)
+	go c.Run()
+	time.Sleep(time.Second)
+	if err := eventbus.Publish(&testEvent{Id: topic}); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	select {
+	case id = <-handled:
+	case <-time.After(10 * time.Second):
+		t.Fatal("event not delivered")
+	}
+	conn.Do("XDEL", key, id)
+	// 等待超时未确认的事件转入死信
+	time.Sleep(3 * time.Second)
+	c.Stop()
+	arr, err := redis.Values(conn.Do("XPENDING", key, "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := redis.Int(arr[0], nil); n != 0 {
+		t.Error("event should be acknowledged, pending:", n)
+	}
+	list, err := eventbus.ListDead("test", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].OriginId != id || !list[0].Missing() {
+		t.Fatalf("dead letter should record origin id %s only: %#v", id, list)
+	}
+	if err = eventbus.Replay("test", list[0].Id); err != eventbus.ErrEventMissing {
+		t.Error("missing event should not be replayed:", err)
+	}
+}
@@ -0,0 +1,79 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : idempotent_test.go
+ * author : jarryliu
+ * date : 2026-10-20 01:00
+ * description :
+ * history :
+ */
+package testing
+
+import (
+	"errors"
+	"fmt"
+	"go2o/core/infrastructure/idempotent"
+	"go2o/core/infrastructure/uow"
+	"go2o/core/testing/ti"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 测试相同的幂等键只执行一次,并返回首次的结果
+func TestIdempotentRun(t *testing.T) {
+	conn := ti.GetApp().Db()
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	var mux sync.Mutex
+	times := 0
+	wg := sync.WaitGroup{}
+	results := make([]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := idempotent.Run(conn, "test", key, time.Minute, &results[i],
+				func(u *uow.UnitOfWork) error {
+					mux.Lock()
+					times++
+					mux.Unlock()
+					results[i] = fmt.Sprintf("result-%d", i)
+					return nil
+				})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if times != 1 {
+		t.Errorf("executed %d times, expect 1", times)
+	}
+	for _, r := range results {
+		if r != results[0] || r == "" {
+			t.Errorf("results not equal: %v", results)
+			break
+		}
+	}
+}
+
+// 测试执行出错时不保存幂等键,可使用相同的键重试
+func TestIdempotentRetryAfterError(t *testing.T) {
+	conn := ti.GetApp().Db()
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	var r int
+	err := idempotent.Run(conn, "test", key, time.Minute, &r,
+		func(u *uow.UnitOfWork) error {
+			return errors.New("failed")
+		})
+	if err == nil {
+		t.Fatal("expect error")
+	}
+	err = idempotent.Run(conn, "test", key, time.Minute, &r,
+		func(u *uow.UnitOfWork) error {
+			r = 1
+			return nil
+		})
+	if err != nil || r != 1 {
+		t.Error("retry failed:", err, r)
+	}
+}
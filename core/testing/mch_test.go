@@ -2,9 +2,14 @@ package testing
 
 import (
 	"errors"
+	"go2o/core/domain/interface/merchant"
 	"go2o/core/domain/interface/merchant/wholesaler"
+	"go2o/core/infrastructure/domain"
 	"go2o/core/testing/ti"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 )
 
 // 测试商家分组设置
@@ -92,3 +97,100 @@ func TestGroupRebateRate(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// 测试保存分销佣金规则
+func TestSaveCommissionRule(t *testing.T) {
+	dm := ti.MchRepo.GetMerchant(1).DistributionManager()
+	_, err := dm.SaveRule(&merchant.CommissionRule{
+		Rates:   "0.6,0.5",
+		Enabled: 1,
+	})
+	if err != merchant.ErrCommissionRates {
+		t.Error("各级比例之和超过1时应返回错误")
+		t.FailNow()
+	}
+	id, err := dm.SaveRule(&merchant.CommissionRule{
+		Rates:   "0.1,0.05,0.02",
+		Enabled: 1,
+	})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	r := dm.MatchRule(1, 1)
+	if r == nil || r.Id != id {
+		t.Error("未匹配到默认佣金规则")
+	}
+	dm.DeleteRule(id)
+}
+
+// 测试接口密钥轮换及签名
+func TestApiSecretRotate(t *testing.T) {
+	am := ti.MchRepo.GetMerchant(1).ApiManager()
+	old := am.GetApiInfo().ApiSecret
+	secret, err := am.RotateSecret()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	form := url.Values{"item_id": []string{"1"}, "merchant_id": []string{"1"}}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	signOld := domain.HmacApiSign(old, "GET", "/mch/item/get", form, nil, ts, "abcdefgh")
+	signNew := domain.HmacApiSign(secret, "GET", "/mch/item/get", form, nil, ts, "abcdefgh")
+	secrets := am.GetApiInfo().Secrets()
+	if !domain.CheckHmacApiSign(secrets, signOld, "GET", "/mch/item/get", form, nil, ts, "abcdefgh") ||
+		!domain.CheckHmacApiSign(secrets, signNew, "GET", "/mch/item/get", form, nil, ts, "abcdefgh") {
+		t.Error("轮换期间新旧密钥应同时有效")
+	}
+	if err = am.RevokeOldSecret(); err != nil {
+		t.Error(err)
+	}
+	secrets = am.GetApiInfo().Secrets()
+	if domain.CheckHmacApiSign(secrets, signOld, "GET", "/mch/item/get", form, nil, ts, "abcdefgh") {
+		t.Error("撤销后旧密钥仍然有效")
+	}
+	// 仓储缓存的接口信息应同时失效
+	secrets = ti.MchRepo.GetApiInfo(1).Secrets()
+	if domain.CheckHmacApiSign(secrets, signOld, "GET", "/mch/item/get", form, nil, ts, "abcdefgh") {
+		t.Error("撤销后缓存的旧密钥仍然有效")
+	}
+}
+
+// 测试JSON请求内容参与签名
+func TestApiSignBody(t *testing.T) {
+	form := url.Values{"merchant_id": []string{"1"}}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	body := []byte(`{"id":1,"title":"商品"}`)
+	sign := domain.HmacApiSign("secret", "POST", "/mch/item/save", form, body, ts, "abcdefgh")
+	secrets := []string{"secret"}
+	if !domain.CheckHmacApiSign(secrets, sign, "POST", "/mch/item/save", form, body, ts, "abcdefgh") {
+		t.Error("请求内容签名验证失败")
+	}
+	body = []byte(`{"id":1,"title":"修改"}`)
+	if domain.CheckHmacApiSign(secrets, sign, "POST", "/mch/item/save", form, body, ts, "abcdefgh") {
+		t.Error("请求内容被修改后签名仍然有效")
+	}
+	if domain.CheckHmacApiSign(secrets, sign, "POST", "/mch/item/save", form, nil, ts, "abcdefgh") {
+		t.Error("未传入请求内容时签名仍然有效")
+	}
+}
+
+// 测试保存接口调用统计,同一日期及类别的统计被覆盖
+func TestSaveApiUsage(t *testing.T) {
+	am := ti.MchRepo.GetMerchant(1).ApiManager()
+	for _, n := range []int64{10, 20} {
+		err := am.SaveApiUsage(&merchant.ApiUsage{
+			StatDate: 20261019,
+			ApiClass: merchant.ApiClassRead,
+			Requests: n,
+		})
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+	}
+	list := am.GetApiUsages(20261019, 20261019)
+	if len(list) != 1 || list[0].Requests != 20 {
+		t.Error("统计未被覆盖:", len(list))
+	}
+}
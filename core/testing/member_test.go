@@ -3,7 +3,9 @@ package testing
 import (
 	"go2o/core/domain/interface/member"
 	"go2o/core/infrastructure/domain"
+	"go2o/core/module"
 	"go2o/core/testing/ti"
+	"sync"
 	"testing"
 	"time"
 )
@@ -60,3 +62,136 @@ func TestModifyPwd(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+// 测试根据等级规则评定会员等级
+func TestReEvaluateLevel(t *testing.T) {
+	repo := ti.MemberRepo
+	lg := repo.GetManager().LevelManager()
+	lv := lg.GetHighestLevel()
+	err := lg.SaveLevelRule(&member.LevelRule{
+		LevelId:       lv.ID,
+		RequireAmount: 1000,
+		RequireOrders: 5,
+		RequireExp:    lv.RequireExp,
+		PeriodType:    member.LevelPeriodRolling,
+		PeriodMonths:  12,
+		AutoDowngrade: 1,
+		Enabled:       1,
+	})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	m := repo.GetMember(1)
+	b, err := m.ReEvaluateLevel()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	t.Log("等级是否变更:", b, "; 当前等级:", m.GetValue().Level)
+}
+
+// 测试会员访问令牌签发及验证
+func TestMemberJwt(t *testing.T) {
+	secret := []byte("go2o-test-secret")
+	unix := time.Now().Unix()
+	token, err := domain.SignMemberJwt(secret, &domain.MemberClaims{
+		MemberId:  1,
+		DeviceId:  "iphone",
+		IssuedAt:  unix,
+		ExpiresAt: unix + 900,
+	})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	c, err := domain.ParseMemberJwt(secret, token, unix)
+	if err != nil || c.MemberId != 1 || c.DeviceId != "iphone" {
+		t.Error("验证访问令牌失败:", err)
+	}
+	if _, err = domain.ParseMemberJwt([]byte("other"), token, unix); err != domain.ErrJwtSign {
+		t.Error("密钥不同时应验证失败:", err)
+	}
+	if _, err = domain.ParseMemberJwt(secret, token, unix+901); err != domain.ErrJwtExpired {
+		t.Error("访问令牌应已过期:", err)
+	}
+}
+
+func newMemberModule() *module.MemberModule {
+	m := &module.MemberModule{}
+	m.SetApp(ti.GetApp())
+	m.Init()
+	return m
+}
+
+// 测试刷新令牌轮换,旧令牌重复使用时退出设备
+func TestRefreshSessionRotate(t *testing.T) {
+	m := newMemberModule()
+	ss, err := m.CreateSession(1, "test-rotate", "测试设备", "127.0.0.1")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	defer m.RevokeDevice(1, ss.DeviceId)
+	ss2, err := m.RefreshSession(ss.RefreshToken, "")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if ss2.RefreshToken == ss.RefreshToken {
+		t.Error("刷新令牌未轮换")
+	}
+	if _, err = m.RefreshSession(ss.RefreshToken, ""); err != module.ErrRefreshToken {
+		t.Error("旧刷新令牌仍可使用:", err)
+	}
+	// 重复使用后设备已退出,新令牌同样失效
+	if _, err = m.RefreshSession(ss2.RefreshToken, ""); err != module.ErrRefreshToken {
+		t.Error("令牌重复使用后设备未退出:", err)
+	}
+}
+
+// 测试退出设备后刷新令牌失效
+func TestRefreshSessionRevoked(t *testing.T) {
+	m := newMemberModule()
+	ss, err := m.CreateSession(1, "test-revoke", "测试设备", "127.0.0.1")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if err = m.RevokeDevice(1, ss.DeviceId); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if _, err = m.RefreshSession(ss.RefreshToken, ""); err != module.ErrRefreshToken {
+		t.Error("已退出设备的刷新令牌仍可使用:", err)
+	}
+}
+
+// 测试并发使用同一刷新令牌,只有一次成功
+func TestRefreshSessionConcurrent(t *testing.T) {
+	m := newMemberModule()
+	ss, err := m.CreateSession(1, "test-concurrent", "测试设备", "127.0.0.1")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	defer m.RevokeDevice(1, ss.DeviceId)
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	success := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.RefreshSession(ss.RefreshToken, ""); err == nil {
+				mux.Lock()
+				success++
+				mux.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if success != 1 {
+		t.Errorf("刷新成功%d次,应只成功1次", success)
+	}
+}
@@ -0,0 +1,64 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : migrate_test.go
+ * author : jarryliu
+ * date : 2026-10-20 17:10
+ * description :
+ * history :
+ */
+package testing
+
+import (
+	"go2o/core/infrastructure/migrate"
+	_ "go2o/core/migration"
+	"go2o/core/testing/ti"
+	"testing"
+)
+
+// 测试拆分脚本,忽略引号及注释中的分号
+func TestMigrateSplit(t *testing.T) {
+	list := migrate.Split(`/* 1; */
+CREATE TABLE a (name varchar(20) comment 'a;b');
+-- 2;
+ALTER TABLE a ADD COLUMN c int(11); # 3;
+/* end */`)
+	if len(list) != 2 {
+		t.Fatalf("expect 2 statements, got %d: %#v", len(list), list)
+	}
+}
+
+// 测试已注册的迁移版本连续,且均可拆分为语句
+func TestMigrations(t *testing.T) {
+	for i, m := range migrate.Migrations() {
+		if m.Version != i+1 {
+			t.Fatalf("migration version %d is not continuous", m.Version)
+		}
+		if m.Version > 1 && len(migrate.Split(m.Up)) == 0 {
+			t.Errorf("migration %d_%s has no statement", m.Version, m.Name)
+		}
+	}
+}
+
+// 测试数据库结构是否为最新版本
+func TestMigrateStatus(t *testing.T) {
+	app := ti.GetApp()
+	list, err := migrate.Statuses(app.Db())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(migrate.Migrations()) {
+		t.Fatalf("expect %d statuses, got %d", len(migrate.Migrations()), len(list))
+	}
+	for _, s := range list {
+		t.Logf("%04d_%s applied:%v modified:%v", s.Version, s.Name, s.Applied, s.Modified)
+		if !s.Applied {
+			t.Errorf("migration %d_%s not applied", s.Version, s.Name)
+		}
+		if s.Modified {
+			t.Errorf("migration %d_%s modified after applied", s.Version, s.Name)
+		}
+	}
+	if err = migrate.Check(app.Db()); err != nil {
+		t.Error(err)
+	}
+}
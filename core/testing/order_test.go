@@ -12,12 +12,14 @@ import (
 	"fmt"
 	"github.com/jsix/gof/storage"
 	"go2o/core/domain/interface/cart"
+	"go2o/core/domain/interface/events"
 	"go2o/core/domain/interface/order"
 	"go2o/core/domain/interface/payment"
 	oi "go2o/core/domain/order"
+	"go2o/core/infrastructure/eventbus"
 	"go2o/core/repository"
+	"go2o/core/service/rsi"
 	"go2o/core/testing/ti"
-	"go2o/core/variable"
 	"log"
 	"strconv"
 	"strings"
@@ -206,6 +208,38 @@ func TestSubmitNormalOrder(t *testing.T) {
 	t.Log("提交成功，订单号：", o.OrderNo())
 }
 
+// 测试使用相同的幂等键重复提交订单,返回首次提交的订单号及支付单号
+func TestSubmitOrderIdempotent(t *testing.T) {
+	var buyerId int64 = 1
+	rsi.Init(ti.GetApp(), 0)
+	c := ti.CartRepo.GetMyCart(buyerId, cart.KRetail)
+	joinItemsToCart(c, t)
+	if _, err := c.Save(); err != nil {
+		t.Error("保存购物车失败:", err.Error())
+		t.FailNow()
+	}
+	buyer := ti.MemberRepo.GetMember(buyerId)
+	addressId := buyer.Profile().GetDefaultAddress().GetDomainId()
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	orderNo, tradeNo, err := rsi.ShoppingService.SubmitOrder_V1(key, buyerId,
+		"", addressId, "", "", false)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	// 首次提交后购物车已清空,重复提交应直接返回首次的结果
+	orderNo2, tradeNo2, err := rsi.ShoppingService.SubmitOrder_V1(key, buyerId,
+		"", addressId, "", "", false)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if orderNo2 != orderNo || tradeNo2 != tradeNo {
+		t.Errorf("repeated submit returns %s/%s, expect %s/%s",
+			orderNo2, tradeNo2, orderNo, tradeNo)
+	}
+}
+
 // 测试批发订单,并完成付款
 func TestWholesaleOrder(t *testing.T) {
 	var buyerId int64 = 1
@@ -327,5 +361,8 @@ func TestNotifyTradeOrder(t *testing.T) {
 	conn := rds.GetConn()
 	defer conn.Close()
 	orderNo := "100000582254"
-	conn.Do("RPUSH", variable.KvOrderBusinessQueue, orderNo)
+	err := eventbus.PublishConn(conn, &events.OrderChanged{OrderNo: orderNo})
+	if err != nil {
+		t.Error(err)
+	}
 }
@@ -0,0 +1,60 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : outbox_test.go
+ * author : jarryliu
+ * date : 2026-10-19 21:20
+ * description :
+ * history :
+ */
+package testing
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/jsix/gof/storage"
+	"go2o/core/domain/interface/events"
+	"go2o/core/infrastructure/outbox"
+	"go2o/core/infrastructure/tx"
+	"go2o/core/testing/ti"
+	"testing"
+)
+
+// 测试事务回滚时不记录事件
+func TestOutboxRollback(t *testing.T) {
+	app := ti.GetApp()
+	var before, after int
+	app.Db().ExecScalar("SELECT COUNT(0) FROM "+outbox.Table, &before)
+	err := tx.Do(app.Db(), func(t *sql.Tx) error {
+		if err := outbox.Append(t, &events.OrderChanged{OrderNo: "test"}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	if err == nil {
+		t.Fatal("expect error")
+	}
+	app.Db().ExecScalar("SELECT COUNT(0) FROM "+outbox.Table, &after)
+	if after != before {
+		t.Error("event recorded after rollback")
+	}
+}
+
+// 测试发布发件箱中的事件
+func TestOutboxRelay(t *testing.T) {
+	app := ti.GetApp()
+	err := tx.Do(app.Db(), func(t *sql.Tx) error {
+		return outbox.Append(t, &events.PaymentFinished{PaymentOrderId: 1})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := app.Storage().(storage.IRedisStorage).GetConn()
+	defer conn.Close()
+	n, err := outbox.Relay(app.Db(), conn, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n == 0 {
+		t.Error("no event relayed")
+	}
+}
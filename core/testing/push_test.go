@@ -0,0 +1,61 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : push_test.go
+ * author : jarryliu
+ * date : 2026-10-20 10:30
+ * description :
+ * history :
+ */
+package testing
+
+import (
+	"go2o/core"
+	"go2o/core/infrastructure/push"
+	"go2o/core/testing/ti"
+	"testing"
+	"time"
+)
+
+// 测试发布的消息投递到所有订阅者,确认前保存为未确认消息
+func TestPushSubscribe(t *testing.T) {
+	ti.GetApp()
+	memberId := time.Now().UnixNano()
+	ch1 := make(chan *push.Message, 1)
+	ch2 := make(chan *push.Message, 1)
+	for _, ch := range []chan *push.Message{ch1, ch2} {
+		conn := core.GetRedisConn()
+		defer conn.Close()
+		go func(ch chan *push.Message) {
+			push.Subscribe(conn, func(m *push.Message) {
+				if m.MemberId == memberId {
+					ch <- m
+				}
+			})
+		}(ch)
+	}
+	time.Sleep(500 * time.Millisecond)
+	conn := core.GetRedisConn()
+	defer conn.Close()
+	m, err := push.Publish(conn, memberId, push.TopicOrder, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ch := range []chan *push.Message{ch1, ch2} {
+		select {
+		case v := <-ch:
+			if v.Id != m.Id || v.Topic != push.TopicOrder {
+				t.Errorf("订阅者%d收到的消息不正确:%#v", i+1, v)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("订阅者%d未收到消息", i+1)
+		}
+	}
+	list, _ := push.Pending(conn, memberId)
+	if len(list) != 1 || list[0].Id != m.Id {
+		t.Errorf("未确认消息不正确:%d", len(list))
+	}
+	push.Ack(conn, memberId, m.Id)
+	if list, _ = push.Pending(conn, memberId); len(list) != 0 {
+		t.Error("确认后消息仍未删除")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"go2o/core/domain/interface/member"
 	"go2o/core/domain/interface/merchant"
 	"go2o/core/domain/interface/order"
+	"go2o/core/domain/interface/payment"
 	"go2o/core/domain/interface/pro_model"
 	"go2o/core/domain/interface/product"
 	"go2o/core/domain/interface/shipment"
@@ -161,6 +162,7 @@ var (
 	MchRepo        merchant.IMerchantRepo
 	CartRepo       cart.ICartRepo
 	ShipmentRepo   shipment.IShipmentRepo
+	PaymentRepo    payment.IPaymentRepo
 )
 
 func init() {
@@ -180,7 +182,7 @@ func init() {
 	itemWsRepo := repository.NewItemWholesaleRepo(db)
 	catRepo := repository.NewCategoryRepo(db, valueRepo, sto)
 	itemRepo := repository.NewGoodsItemRepo(db, catRepo, productRepo,
-		proMRepo, itemWsRepo, expressRepo, valueRepo)
+		proMRepo, itemWsRepo, expressRepo, valueRepo, sto)
 	//tagSaleRepo := repository.NewTagSaleRepo(db, valRepo)
 	promRepo := repository.NewPromotionRepo(db, itemRepo, memberRepo)
 	//afterSalesRepo := repository.NewAfterSalesRepo(db)
@@ -212,4 +214,5 @@ func init() {
 	MchRepo = mchRepo
 	CartRepo = cartRepo
 	ShipmentRepo = shipRepo
+	PaymentRepo = paymentRepo
 }
@@ -0,0 +1,130 @@
+/**
+ * Copyright 2015 @ z3q.net.
+ * name : uow_test.go
+ * author : jarryliu
+ * date : 2026-10-19 23:10
+ * description :
+ * history :
+ */
+package testing
+
+import (
+	"errors"
+	"go2o/core/domain/interface/cart"
+	"go2o/core/domain/interface/member"
+	"go2o/core/domain/interface/order"
+	"go2o/core/domain/interface/payment"
+	"go2o/core/infrastructure/uow"
+	"go2o/core/testing/ti"
+	"testing"
+)
+
+// 测试工作单元回滚后账户余额不变
+func TestUnitOfWorkRollback(t *testing.T) {
+	var memberId int64 = 1
+	before := ti.MemberRepo.GetMember(memberId).GetAccount().GetValue().Balance
+	err := uow.Run(ti.GetApp().Db(), func(u *uow.UnitOfWork) error {
+		repo := uow.Bind(u, ti.MemberRepo).(member.IMemberRepo)
+		acc := repo.GetMember(memberId).GetAccount()
+		err := acc.Charge(member.AccountBalance, member.KindBalanceCharge,
+			"工作单元测试", "", 1, 0)
+		if err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	if err == nil || err.Error() != "rollback" {
+		t.Fatal("expect rollback error, got", err)
+	}
+	after := ti.MemberRepo.GetMember(memberId).GetAccount().GetValue().Balance
+	if after != before {
+		t.Errorf("balance changed after rollback: %.2f -> %.2f", before, after)
+	}
+}
+
+// 测试提交订单回滚后订单及支付单均不存在
+func TestUnitOfWorkSubmitOrderRollback(t *testing.T) {
+	var buyerId int64 = 1
+	c := ti.CartRepo.GetMyCart(buyerId, cart.KRetail)
+	joinItemsToCart(c, t)
+	if _, err := c.Save(); err != nil {
+		t.Fatal("保存购物车失败:", err)
+	}
+	buyer := ti.MemberRepo.GetMember(buyerId)
+	addressId := buyer.Profile().GetDefaultAddress().GetDomainId()
+	var orderNo, tradeNo string
+	err := uow.Run(ti.GetApp().Db(), func(u *uow.UnitOfWork) error {
+		repo := uow.Bind(u, ti.OrderRepo).(order.IOrderRepo)
+		bc := uow.Bind(u, ti.CartRepo).(cart.ICartRepo).GetMyCart(buyerId, cart.KRetail)
+		o, err := repo.Manager().SubmitOrder(bc, addressId, "", false)
+		if err != nil {
+			return err
+		}
+		orderNo = o.OrderNo()
+		tradeNo = o.(order.INormalOrder).GetPaymentOrder().GetTradeNo()
+		return errors.New("rollback")
+	})
+	if err == nil || err.Error() != "rollback" {
+		t.Fatal("expect rollback error, got", err)
+	}
+	if ti.OrderRepo.Manager().GetOrderByNo(orderNo) != nil {
+		t.Error("order exists after rollback:", orderNo)
+	}
+	if ti.PaymentRepo.GetPaymentOrder(tradeNo) != nil {
+		t.Error("payment order exists after rollback:", tradeNo)
+	}
+	rc := ti.CartRepo.GetMyCart(buyerId, cart.KRetail).(cart.IRetailCart)
+	if len(rc.GetValue().Items) == 0 {
+		t.Error("cart cleared after rollback")
+	}
+}
+
+// 测试完成支付回滚后支付单状态不变
+func TestUnitOfWorkFinishPaymentRollback(t *testing.T) {
+	var buyerId int64 = 1
+	c := ti.CartRepo.GetMyCart(buyerId, cart.KRetail)
+	joinItemsToCart(c, t)
+	if _, err := c.Save(); err != nil {
+		t.Fatal("保存购物车失败:", err)
+	}
+	buyer := ti.MemberRepo.GetMember(buyerId)
+	addressId := buyer.Profile().GetDefaultAddress().GetDomainId()
+	o, err := ti.OrderRepo.Manager().SubmitOrder(c, addressId, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tradeNo := o.(order.INormalOrder).GetPaymentOrder().GetTradeNo()
+	before := ti.PaymentRepo.GetPaymentOrder(tradeNo).GetValue().State
+	err = uow.Run(ti.GetApp().Db(), func(u *uow.UnitOfWork) error {
+		py := uow.Bind(u, ti.PaymentRepo).(payment.IPaymentRepo).GetPaymentOrder(tradeNo)
+		if err := py.PaymentFinish("alipay", "uow-test-"+tradeNo); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	if err == nil || err.Error() != "rollback" {
+		t.Fatal("expect rollback error, got", err)
+	}
+	after := ti.PaymentRepo.GetPaymentOrder(tradeNo).GetValue().State
+	if after != before {
+		t.Errorf("payment state changed after rollback: %d -> %d", before, after)
+	}
+	if st := ti.OrderRepo.Manager().GetOrderByNo(o.OrderNo()).State(); st != o.State() {
+		t.Errorf("order state changed after rollback: %d -> %d", o.State(), st)
+	}
+}
+
+// 测试工作单元提交后执行注册的函数
+func TestUnitOfWorkAfterCommit(t *testing.T) {
+	committed := false
+	err := uow.Run(ti.GetApp().Db(), func(u *uow.UnitOfWork) error {
+		u.AfterCommit(func() { committed = true })
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !committed {
+		t.Error("after commit func not called")
+	}
+}
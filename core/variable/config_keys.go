@@ -39,6 +39,11 @@ const (
 	SmtpFrom    = "smtp_from"
 	//是否关闭系统发送邮件队列
 	SystemMailQueueOff = "sys_mail_queue_off"
+
+	// 会员访问令牌的签名密钥,多个应用需配置相同的密钥
+	JwtSecret = "jwt_secret"
+	// gRPC服务的访问令牌
+	GrpcToken = "grpc_token"
 )
 
 var (
@@ -20,6 +20,7 @@ const (
 	KvMemberUpdateQueue           = "go2o:mq:mm_update"       //新加入会员队列
 	KvOrderExpiresTime            = "go2o:order:timeout"      //订单过期时间
 	KvOrderAutoReceive            = "go2o:order:auto_receive" //订单自动收货
+	KvOrderAutoConfirm            = "go2o:order:auto_confirm" //订单自动确认
 )
 
 const (
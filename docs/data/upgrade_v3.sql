@@ -835,3 +835,221 @@ ALTER TABLE `order_wholesale_order`
 
 ALTER TABLE `sale_sub_order`
   CHANGE COLUMN `buyer_remark` `buyer_comment` VARCHAR(120) NOT NULL COMMENT '订单买家备注' AFTER `is_suspend`;
+
+/* 2026-10-18 */
+
+CREATE TABLE mm_level_rule (
+  level_id       int(11) NOT NULL comment '等级编号',
+  require_amount decimal(10, 2) NOT NULL comment '需要消费金额',
+  require_orders int(11) NOT NULL comment '需要订单数量',
+  require_exp    int(11) NOT NULL comment '需要经验值',
+  period_type    tinyint(1) NOT NULL comment '统计周期类型,1:累计 2:滚动月份 3:自然年',
+  period_months  int(4) NOT NULL comment '滚动周期月数',
+  auto_downgrade tinyint(1) NOT NULL comment '是否自动降级',
+  enabled        tinyint(1) NOT NULL comment '是否启用',
+  update_time    int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (level_id)) comment='会员等级规则';
+
+CREATE TABLE mm_level_benefit (
+  level_id          int(11) NOT NULL comment '等级编号',
+  free_shipping     tinyint(1) NOT NULL comment '是否包邮',
+  discount_rate     decimal(4, 2) NOT NULL comment '折扣率',
+  integral_multiple decimal(4, 2) NOT NULL comment '积分倍数',
+  update_time       int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (level_id)) comment='会员等级权益';
+
+ALTER TABLE `mch_sale_conf`
+  ADD COLUMN `cms_freeze_days` INT(4) NOT NULL DEFAULT 7 COMMENT '分销佣金冻结天数(售后期)';
+
+CREATE TABLE mch_commission_rule (
+  id          int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id      int(11) NOT NULL comment '商户编号',
+  cat_id      int(11) NOT NULL comment '分类编号,0表示不限分类',
+  item_id     int(11) NOT NULL comment '商品编号,0表示不限商品',
+  rates       varchar(120) NOT NULL comment '各级佣金比例,用","分隔',
+  enabled     tinyint(1) NOT NULL comment '是否启用',
+  update_time int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id)) comment='分销佣金规则';
+
+CREATE TABLE mch_commission (
+  id             int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id         int(11) NOT NULL comment '商户编号',
+  order_id       int(11) NOT NULL comment '子订单编号',
+  order_no       varchar(45) NOT NULL comment '订单号',
+  snap_id        int(11) NOT NULL comment '快照编号',
+  item_id        int(11) NOT NULL comment '商品编号',
+  buyer_id       int(11) NOT NULL comment '买家编号',
+  member_id      int(11) NOT NULL comment '推荐人编号',
+  depth          int(4) NOT NULL comment '推荐层级',
+  base_amount    decimal(10, 2) NOT NULL comment '计算佣金的商品金额',
+  rate           decimal(6, 4) NOT NULL comment '佣金比例',
+  amount         decimal(10, 2) NOT NULL comment '佣金金额',
+  reverse_amount decimal(10, 2) NOT NULL comment '已撤销金额',
+  state          tinyint(1) NOT NULL comment '状态,1:冻结 2:已发放 3:已撤销',
+  unfreeze_time  int(11) NOT NULL comment '解冻时间',
+  create_time    int(11) NOT NULL comment '创建时间',
+  update_time    int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id),
+  INDEX idx_order (order_id, snap_id),
+  INDEX idx_member (member_id),
+  INDEX idx_state (state, unfreeze_time)) comment='分销佣金';
+
+ALTER TABLE `pf_riseinfo`
+  ADD COLUMN `plan_id` INT(11) NOT NULL DEFAULT 0 COMMENT '理财计划编号' AFTER `settled_date`;
+
+CREATE TABLE pf_plan (
+  id           int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  name         varchar(45) NOT NULL comment '名称',
+  settle_t     int(4) NOT NULL comment 'T+N开始计算收益',
+  lock_days    int(4) NOT NULL comment '锁定天数,0为随存随取',
+  min_in       decimal(10, 2) NOT NULL comment '最低转入金额',
+  min_out      decimal(10, 2) NOT NULL comment '最低转出金额',
+  max_amount   decimal(10, 2) NOT NULL comment '最高持有金额,0为不限',
+  penalty_rate decimal(6, 4) NOT NULL comment '锁定期内转出的违约金比例',
+  enabled      tinyint(1) NOT NULL comment '是否启用',
+  update_time  int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id)) comment='理财计划';
+
+CREATE TABLE pf_plan_rate (
+  id             int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  plan_id        int(11) NOT NULL comment '理财计划编号',
+  annual_rate    decimal(8, 6) NOT NULL comment '年化利率',
+  effective_date int(11) NOT NULL comment '生效日期',
+  create_time    int(11) NOT NULL comment '创建时间',
+  PRIMARY KEY (id),
+  INDEX idx_plan (plan_id, effective_date)) comment='理财计划利率';
+
+ALTER TABLE `mch_sale_conf`
+  ADD COLUMN `as_vendor_hour` INT(4) NOT NULL DEFAULT 72 COMMENT '售后商户处理时效(小时)',
+  ADD COLUMN `as_return_ship_hour` INT(4) NOT NULL DEFAULT 168 COMMENT '售后买家退货时效(小时)',
+  ADD COLUMN `as_receive_hour` INT(4) NOT NULL DEFAULT 240 COMMENT '售后商户收货时效(小时)',
+  ADD COLUMN `as_declined_hour` INT(4) NOT NULL DEFAULT 72 COMMENT '售后拒绝后自动申请调解时效(小时)';
+
+ALTER TABLE `sale_after_order`
+  ADD INDEX `idx_state` (`state`);
+
+CREATE TABLE sale_after_message (
+  id          int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  as_id       int(11) NOT NULL comment '售后单编号',
+  sender_role tinyint(2) NOT NULL comment '发送人角色,1:买家 2:商户 3:平台客服',
+  sender_id   bigint(20) NOT NULL comment '发送人编号',
+  content     varchar(512) NOT NULL comment '留言内容',
+  images      varchar(1024) NOT NULL comment '图片凭证',
+  create_time int(11) NOT NULL comment '创建时间',
+  PRIMARY KEY (id),
+  INDEX idx_as (as_id)) comment='售后单留言';
+
+CREATE TABLE sale_after_arbitration (
+  id           int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  as_id        int(11) NOT NULL comment '售后单编号',
+  arbiter_id   int(11) NOT NULL comment '仲裁人员编号',
+  arbiter_name varchar(20) NOT NULL comment '仲裁人员名称',
+  outcome      tinyint(2) NOT NULL comment '仲裁结果,1:支持买家 2:支持商户',
+  amount       decimal(10, 2) NOT NULL comment '退款金额',
+  remark       varchar(512) NOT NULL comment '仲裁说明',
+  create_time  int(11) NOT NULL comment '创建时间',
+  PRIMARY KEY (id),
+  INDEX idx_as (as_id)) comment='售后单仲裁记录';
+
+ALTER TABLE `mch_api_info`
+  ADD COLUMN `api_secret2` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '轮换前的密钥' AFTER `api_secret`;
+
+CREATE TABLE mch_webhook (
+  id          int(11) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id      int(11) NOT NULL comment '商户编号',
+  event       varchar(40) NOT NULL comment '通知事件',
+  url         varchar(255) NOT NULL comment '通知地址',
+  enabled     tinyint(1) NOT NULL comment '是否启用',
+  update_time int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id),
+  INDEX idx_mch (mch_id)) comment='商户通知地址';
+
+CREATE TABLE mch_webhook_msg (
+  id          bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id      int(11) NOT NULL comment '商户编号',
+  hook_id     int(11) NOT NULL comment '通知地址编号',
+  event       varchar(40) NOT NULL comment '通知事件',
+  payload     text NOT NULL comment '通知内容(JSON)',
+  state       tinyint(2) NOT NULL comment '状态,1:待发送 2:已送达 3:失败',
+  retries     int(4) NOT NULL comment '已重试次数',
+  next_time   int(11) NOT NULL comment '下次发送时间',
+  last_error  varchar(255) NOT NULL comment '最后的错误信息',
+  create_time int(11) NOT NULL comment '创建时间',
+  update_time int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id),
+  INDEX idx_state (state, next_time)) comment='商户通知消息';
+
+CREATE TABLE mch_webhook_log (
+  id          bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  msg_id      bigint(20) NOT NULL comment '通知消息编号',
+  url         varchar(255) NOT NULL comment '通知地址',
+  status_code int(4) NOT NULL comment 'HTTP状态码',
+  response    varchar(512) NOT NULL comment '响应内容',
+  error       varchar(255) NOT NULL comment '错误信息',
+  duration    int(11) NOT NULL comment '耗时(毫秒)',
+  create_time int(11) NOT NULL comment '创建时间',
+  PRIMARY KEY (id),
+  INDEX idx_msg (msg_id)) comment='商户通知发送日志';
+
+ALTER TABLE `mch_api_info`
+  ADD COLUMN `read_rate` INT(11) NOT NULL DEFAULT 0 COMMENT '查询类接口每分钟限额,0为默认限额',
+  ADD COLUMN `write_rate` INT(11) NOT NULL DEFAULT 0 COMMENT '操作类接口每分钟限额,0为默认限额';
+
+CREATE TABLE mch_api_usage (
+  id          bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  mch_id      int(11) NOT NULL comment '商户编号',
+  stat_date   int(8) NOT NULL comment '统计日期',
+  api_class   varchar(10) NOT NULL comment '接口类别',
+  requests    bigint(20) NOT NULL comment '请求次数',
+  limited     bigint(20) NOT NULL comment '被限流的次数',
+  update_time int(11) NOT NULL comment '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE INDEX uk_usage (mch_id, stat_date, api_class)) comment='商户接口每日调用统计';
+
+CREATE TABLE sys_job_run (
+  id          bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  job_name    varchar(40) NOT NULL comment '任务名称',
+  trigger_by  varchar(10) NOT NULL comment '触发方式',
+  instance    varchar(60) NOT NULL comment '执行的实例',
+  fence       bigint(20) NOT NULL comment '锁令牌',
+  start_time  int(11) NOT NULL comment '开始时间',
+  end_time    int(11) NOT NULL comment '结束时间',
+  state       int(1) NOT NULL comment '状态:1执行中 2成功 3失败',
+  error       varchar(512) NOT NULL comment '错误信息',
+  PRIMARY KEY (id),
+  INDEX idx_job_name (job_name)) comment='计划任务执行记录';
+
+CREATE TABLE sys_event_outbox (
+  id            bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  topic         varchar(20) NOT NULL comment '主题',
+  event_type    varchar(40) NOT NULL comment '事件类型',
+  event_key     varchar(40) NOT NULL comment '事件关联的键',
+  data          text NOT NULL comment '事件数据',
+  attempts      int(11) NOT NULL comment '发布次数',
+  last_error    varchar(255) NOT NULL comment '最后一次发布的错误',
+  dispatched    tinyint(1) NOT NULL comment '是否已发布',
+  create_time   int(11) NOT NULL comment '创建时间',
+  dispatch_time int(11) NOT NULL comment '发布时间',
+  PRIMARY KEY (id),
+  INDEX idx_dispatched (dispatched, id)) comment='领域事件发件箱';
+
+/* 乐观并发控制的数据版本 */
+ALTER TABLE `mm_account`
+  ADD COLUMN `version` int(11) NOT NULL DEFAULT 0 COMMENT '数据版本';
+ALTER TABLE `item_info`
+  ADD COLUMN `version` int(11) NOT NULL DEFAULT 0 COMMENT '数据版本';
+ALTER TABLE `sale_sub_order`
+  ADD COLUMN `version` int(11) NOT NULL DEFAULT 0 COMMENT '数据版本';
+
+CREATE TABLE sys_idempotent_key (
+  id           bigint(20) NOT NULL AUTO_INCREMENT comment '编号',
+  scope        varchar(40) NOT NULL comment '业务范围',
+  idem_key     varchar(120) NOT NULL comment '幂等键',
+  result       text NOT NULL comment '执行结果',
+  create_time  int(11) NOT NULL comment '创建时间',
+  expires_time int(11) NOT NULL comment '过期时间',
+  PRIMARY KEY (id),
+  UNIQUE INDEX uk_scope_key (scope, idem_key),
+  INDEX idx_expires_time (expires_time)) comment='幂等键';
+
+/* 此后的结构变更见 core/migration,使用 go2o-migrate 执行 */
@@ -26,6 +26,12 @@ if [[ ${action} = "stop" ]];then
    killall go2o-rpc
 fi
 
+# migrate database schema
+if [[ ${action} = "migrate" ]];then
+    ./go2o-migrate -conf=app.conf up
+    exit $?
+fi
+
 # start service
 if [[ ${action} = "start" ]];then
    nohup  ./go2o-serve -conf=app.conf -d -r>logs/go2o.log 2>&1 &
@@ -24,6 +24,7 @@ Notes:
 
 go2o.sh          :     go2o major application manager
 go2o-publish.sh  :     go2o application publish tool
+go2o-migrate     :     database schema migration tool
 
 
 Email: